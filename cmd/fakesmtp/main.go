@@ -0,0 +1,29 @@
+// Минимальный SMTP-сервер для локальной проверки почтовых уведомлений:
+// принимает любые письма и печатает их в stdout.
+//
+//	go run ./cmd/fakesmtp -addr localhost:2525
+//	SMTP_HOST=localhost SMTP_PORT=2525 SMTP_FROM=tasks@example.com go run ./cmd
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier/smtptest"
+	"log"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:2525", "address to listen on")
+	flag.Parse()
+
+	server, err := smtptest.Start(*addr, func(message smtptest.Message) {
+		fmt.Printf("===== message from %s to %s =====\n%s\n", message.From, strings.Join(message.To, ", "), message.Data)
+	})
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	log.Printf("Fake SMTP server listening on %s", server.Addr)
+	select {}
+}
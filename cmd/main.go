@@ -2,15 +2,24 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/adapter"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/handler"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/router"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
 	dbpkg "github.com/SaveljevRoman/go-layout-project-2/pkg/db"
 	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
 	"log"
-	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -33,45 +42,294 @@ func main() {
 	}
 	defer database.Close()
 
+	// Хранилище файлов: S3-совместимое (AWS, MinIO), если задан BLOB_STORE=s3,
+	// иначе локальный каталог
+	var blobs blobstore.BlobStore
+	if os.Getenv("BLOB_STORE") == "s3" {
+		blobs = blobstore.NewS3Store(blobstore.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	} else {
+		localBlobs, err := blobstore.NewLocalStore("data/attachments")
+		if err != nil {
+			log.Fatalf("Failed to init blob store: %v", err)
+		}
+		blobs = localBlobs
+	}
+
 	// Инициализация хранилищ
-	taskRepo := db.NewTaskRepository()
+	taskRepo, outboxRepo, auditRepo, timeTravel, err := newTaskStore()
+	if err != nil {
+		log.Fatalf("Failed to init task store: %v", err)
+	}
+	dependencyRepo := db.NewDependencyRepository()
+	workflowRepo := db.NewWorkflowRepository()
+	projectRepo := db.NewProjectRepository()
+	userRepo := db.NewUserRepository()
+	commentRepo := db.NewCommentRepository()
+	attachmentRepo := db.NewAttachmentRepository()
+	checklistRepo := db.NewChecklistRepository()
+
+	// Напоминания и их задания хранятся на диске и переживают перезапуск:
+	// задания пересчитываются по напоминаниям при каждом изменении задачи
+	reminderRepo, err := db.NewReminderRepository("data/task_reminders.json")
+	if err != nil {
+		log.Fatalf("Failed to load reminders: %v", err)
+	}
+	reminderJobRepo, err := db.NewReminderJobRepository("data/reminders.json")
+	if err != nil {
+		log.Fatalf("Failed to load reminder jobs: %v", err)
+	}
+
+	// Ленты календаря хранятся на диске: ссылка на ленту уже добавлена
+	// в календари пользователей и должна работать после перезапуска
+	calendarFeedRepo, err := db.NewCalendarFeedRepository("data/calendar_feeds.json")
+	if err != nil {
+		log.Fatalf("Failed to load calendar feeds: %v", err)
+	}
+
+	// Подписки и доставки вебхуков хранятся на диске: событие outbox
+	// считается обработанным, когда доставки поставлены в очередь, и
+	// повторы не должны теряться при перезапуске
+	webhookRepo, err := db.NewWebhookRepository("data/webhooks.json")
+	if err != nil {
+		log.Fatalf("Failed to load webhooks: %v", err)
+	}
+	webhookDeliveryRepo, err := db.NewWebhookDeliveryRepository("data/webhook_deliveries.json")
+	if err != nil {
+		log.Fatalf("Failed to load webhook deliveries: %v", err)
+	}
+
+	// Связи с внешними системами тоже хранятся на диске: без них повторная
+	// синхронизация создала бы задачи заново
+	syncRepo, err := db.NewSyncRepository("data/sync.json")
+	if err != nil {
+		log.Fatalf("Failed to load sync state: %v", err)
+	}
+
+	// Настройки уведомлений и отметки о письмах о просрочке хранятся на
+	// диске, чтобы после перезапуска о просрочке не сообщалось повторно
+	preferencesRepo, err := db.NewNotificationPreferencesRepository("data/notifications.json")
+	if err != nil {
+		log.Fatalf("Failed to load notification preferences: %v", err)
+	}
+
+	// Прогресс импорта хранится на диске, чтобы прерванный импорт продолжился
+	importJobRepo, err := db.NewImportJobRepository("data/import_jobs.json")
+	if err != nil {
+		log.Fatalf("Failed to load import jobs: %v", err)
+	}
+
+	// Пользователь, которого AuthMiddleware подставляет для любого токена
+	// (администратор пространства по умолчанию), и коллега, которому можно
+	// назначать задачи
+	for _, user := range []*entity.User{
+		{ID: "user-123", Username: "demo", Name: "Demo User", Email: "demo@example.com", Workspaces: map[string]entity.WorkspaceRole{entity.DefaultWorkspaceID: entity.WorkspaceAdmin}},
+		{ID: "user-456", Username: "alice", Name: "Alice", Email: "alice@example.com"},
+	} {
+		if err := userRepo.Create(context.Background(), user); err != nil {
+			log.Fatalf("Failed to create default user: %v", err)
+		}
+	}
 
 	// Инициализация use cases
-	taskUseCase := usecase.NewTaskUseCase(taskRepo, appLogger)
+	taskUseCase := usecase.NewTaskUseCase(taskRepo, dependencyRepo, workflowRepo, userRepo, outboxRepo, appLogger, usecase.TaskUseCaseConfig{
+		SubtaskDeletePolicy:     usecase.DeletePolicyCascade,
+		BlockDoneByDependencies: true,
+		// Задачу нельзя завершить, пока не выполнен её чек-лист
+		DoneGuards: []string{"checklist_complete"},
+	})
+	dependencyUseCase := usecase.NewDependencyUseCase(taskUseCase, dependencyRepo, appLogger)
+	workflowUseCase := usecase.NewWorkflowUseCase(taskUseCase, workflowRepo, appLogger)
+	projectUseCase := usecase.NewProjectUseCase(taskUseCase, projectRepo, taskRepo, appLogger)
+	commentUseCase := usecase.NewCommentUseCase(taskUseCase, commentRepo, userRepo, appLogger)
+	attachmentUseCase := usecase.NewAttachmentUseCase(taskUseCase, attachmentRepo, blobs, appLogger, usecase.AttachmentConfig{
+		MaxSize: 25 << 20,
+		AllowedTypes: []string{
+			"image/", "text/", "video/",
+			"application/pdf", "application/json", "application/xml", "application/zip", "application/x-gzip",
+		},
+	})
+
+	checklistUseCase := usecase.NewChecklistUseCase(taskUseCase, checklistRepo, taskRepo, appLogger)
+	backupUseCase := usecase.NewBackupUseCase(taskUseCase, taskRepo, dependencyRepo, projectRepo, commentRepo, checklistRepo, attachmentRepo, blobs, appLogger)
+	reportUseCase := usecase.NewReportUseCase(taskUseCase, projectUseCase, appLogger)
+	importUseCase := usecase.NewImportUseCase(taskUseCase, projectUseCase, taskRepo, commentRepo, checklistRepo, importJobRepo, blobs, appLogger, usecase.ImportConfig{})
+
+	// Каналы доставки уведомлений: журнал доступен всегда, вебхук и почта — если настроены
+	notifiers := map[string]notifier.Notifier{
+		"log": notifier.NewLogNotifier(appLogger),
+	}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifiers["webhook"] = notifier.NewWebhookNotifier(url)
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		notifiers["email"] = notifier.NewEmailNotifier(notifier.SMTPConfig{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+
+	// Ссылки отписки подписываются ключом; без UNSUBSCRIBE_SECRET ключ
+	// случайный, и ссылки из уже отправленных писем перестанут работать
+	// после перезапуска
+	unsubscribeSecret := []byte(os.Getenv("UNSUBSCRIBE_SECRET"))
+	if len(unsubscribeSecret) == 0 {
+		unsubscribeSecret = make([]byte, 32)
+		if _, err := rand.Read(unsubscribeSecret); err != nil {
+			log.Fatalf("Failed to generate unsubscribe secret: %v", err)
+		}
+		log.Println("UNSUBSCRIBE_SECRET is not set, unsubscribe links will expire on restart")
+	}
+
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:8080"
+	}
+
+	emailUseCase := usecase.NewEmailNotificationUseCase(taskRepo, userRepo, preferencesRepo, notifiers["email"], appLogger, usecase.EmailNotificationConfig{
+		BaseURL: publicURL,
+		Secret:  unsubscribeSecret,
+	})
+
+	reminderUseCase := usecase.NewReminderUseCase(taskUseCase, reminderRepo, reminderJobRepo, userRepo, notifiers, appLogger, usecase.ReminderConfig{
+		Interval:        10 * time.Second,
+		DefaultChannels: []string{"log"},
+	})
+
+	calendarUseCase := usecase.NewCalendarUseCase(calendarFeedRepo, appLogger, usecase.CalendarConfig{
+		BaseURL: publicURL,
+	})
+
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo, webhookDeliveryRepo, appLogger, usecase.WebhookConfig{
+		AllowPrivateNetworks: os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true",
+	})
+
+	taskUseCase.RegisterGuard("checklist_complete", checklistUseCase.CompleteGuard)
+
+	// Связанные данные удаляются вместе с задачей при её удалении из корзины
+	taskUseCase.RegisterDeleteHook(commentUseCase.OnTaskDeleted)
+	taskUseCase.RegisterDeleteHook(attachmentUseCase.OnTaskDeleted)
+	taskUseCase.RegisterDeleteHook(checklistUseCase.OnTaskDeleted)
+
+	// Изменения задач доходят до остальных частей системы через события
+	searchUseCase := usecase.NewSearchUseCase(appLogger)
+	auditUseCase := usecase.NewAuditUseCase(taskUseCase, auditRepo, timeTravel, appLogger)
+	eventRelay := usecase.NewEventRelay(outboxRepo, appLogger, usecase.EventRelayConfig{})
+	eventRelay.Subscribe("search", searchUseCase.HandleTaskEvent)
+	eventRelay.Subscribe("reminders", reminderUseCase.HandleTaskEvent)
+	eventRelay.Subscribe("webhooks", webhookUseCase.HandleTaskEvent)
+
+	// Письма исполнителям отправляются, только если настроен SMTP
+	if _, ok := notifiers["email"]; ok {
+		eventRelay.Subscribe("email", emailUseCase.HandleTaskEvent)
+		go emailUseCase.Run(context.Background())
+	}
+
+	go eventRelay.Run(context.Background())
+
+	// Фоновый планировщик создает следующие экземпляры повторяющихся задач
+	recurrenceScheduler := usecase.NewRecurrenceScheduler(taskUseCase, projectUseCase, taskRepo, appLogger, 30*time.Second)
+	go recurrenceScheduler.Run(context.Background())
+	go reminderUseCase.Run(context.Background())
+	go webhookUseCase.Run(context.Background())
+	go importUseCase.Run(context.Background())
+
+	// Задачи удаляются из корзины окончательно по истечении TRASH_RETENTION
+	// (по умолчанию 30 дней)
+	trashRetention, _ := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	trashPurger := usecase.NewTrashPurger(taskUseCase, taskRepo, appLogger, usecase.TrashConfig{
+		Retention: trashRetention,
+	})
+	go trashPurger.Run(context.Background())
+
+	// Задачи, завершенные больше ARCHIVE_AFTER_DAYS дней назад, уходят в
+	// архив; без переменной автоматическая архивация выключена
+	archiveAfterDays, _ := strconv.Atoi(os.Getenv("ARCHIVE_AFTER_DAYS"))
+	taskArchiver := usecase.NewTaskArchiver(taskUseCase, taskRepo, appLogger, usecase.ArchiveConfig{
+		After: time.Duration(archiveAfterDays) * 24 * time.Hour,
+	})
+	go taskArchiver.Run(context.Background())
 
 	// Инициализация адаптеров
-	taskAPI := adapter.NewTaskAPI(taskUseCase)
+	taskAPI := adapter.NewTaskAPI(taskUseCase, projectUseCase, commentUseCase, syncRepo)
+
+	// Синхронизация с задачами репозитория GitHub (GITHUB_REPO=owner/repo);
+	// GITHUB_ASSIGNEES сопоставляет логины с пользователями: login=user-id,...
+	if owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPO"), "/"); ok {
+		assignees := make(map[string]string)
+		for _, pair := range strings.Split(os.Getenv("GITHUB_ASSIGNEES"), ",") {
+			if login, userID, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				assignees[login] = userID
+			}
+		}
+		taskAPI.RegisterSource(adapter.NewGitHubIssues(adapter.GitHubConfig{
+			BaseURL:   os.Getenv("GITHUB_API_URL"),
+			Token:     os.Getenv("GITHUB_TOKEN"),
+			Owner:     owner,
+			Repo:      repo,
+			Assignees: assignees,
+		}))
+	} else {
+		log.Println("GITHUB_REPO is not set, /api/sync/github will answer 404")
+	}
 
 	// Инициализация обработчиков
 	taskHandler := handler.NewTaskHandler(taskUseCase)
+	dependencyHandler := handler.NewDependencyHandler(dependencyUseCase)
+	workflowHandler := handler.NewWorkflowHandler(workflowUseCase)
+	projectHandler := handler.NewProjectHandler(projectUseCase)
+	commentHandler := handler.NewCommentHandler(commentUseCase)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentUseCase)
+	checklistHandler := handler.NewChecklistHandler(checklistUseCase)
+	reminderHandler := handler.NewReminderHandler(reminderUseCase)
+	notificationHandler := handler.NewNotificationHandler(emailUseCase)
+	webhookHandler := handler.NewWebhookHandler(webhookUseCase)
+	searchHandler := handler.NewSearchHandler(searchUseCase)
+	auditHandler := handler.NewAuditHandler(auditUseCase)
+	trashHandler := handler.NewTrashHandler(taskUseCase)
+	transferHandler := handler.NewTransferHandler(taskAPI)
+	calendarHandler := handler.NewCalendarHandler(calendarUseCase, taskAPI)
+	backupHandler := handler.NewBackupHandler(backupUseCase)
+	importHandler := handler.NewImportHandler(importUseCase)
+	reportHandler := handler.NewReportHandler(reportUseCase)
+	syncHandler := handler.NewSyncHandler(taskAPI)
 
 	// Инициализация роутера
 	r := router.NewRouter()
 
 	// Регистрация middleware
 	r.Use(router.LoggingMiddleware(appLogger))
-	r.Use(router.AuthMiddleware())
+	r.Use(router.AuthMiddleware("/unsubscribe", "/calendar.ics"))
 
 	// Регистрация маршрутов
 	r.RegisterRoutes(taskHandler)
-
-	// Используем новый метод HandleFunc для регистрации обработчика API
-	r.HandleFunc("/api/export", func(w http.ResponseWriter, req *http.Request) {
-		if req.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		data, err := taskAPI.ExportTasksToJSON(req.Context())
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Content-Disposition", "attachment; filename=tasks.json")
-		w.Write(data)
-	})
+	r.RegisterDependencyRoutes(dependencyHandler)
+	r.RegisterWorkflowRoutes(workflowHandler)
+	r.RegisterProjectRoutes(projectHandler)
+	r.RegisterCommentRoutes(commentHandler)
+	r.RegisterAttachmentRoutes(attachmentHandler)
+	r.RegisterChecklistRoutes(checklistHandler)
+	r.RegisterReminderRoutes(reminderHandler)
+	r.RegisterNotificationRoutes(notificationHandler)
+	r.RegisterWebhookRoutes(webhookHandler)
+	r.RegisterSearchRoutes(searchHandler)
+	r.RegisterAuditRoutes(auditHandler)
+	r.RegisterTrashRoutes(trashHandler)
+	r.RegisterTransferRoutes(transferHandler)
+	r.RegisterCalendarRoutes(calendarHandler)
+	r.RegisterBackupRoutes(backupHandler)
+	r.RegisterSyncRoutes(syncHandler)
+	r.RegisterImportRoutes(importHandler)
+	r.RegisterReportRoutes(reportHandler)
 
 	// Запуск сервера
 	log.Println("Starting server on :8080")
@@ -79,3 +337,23 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// newTaskStore создает хранилище задач: журнал событий на диске, если задан
+// TASK_STORE=eventsourced, иначе хранилище в памяти. Outbox и журнал
+// изменений хранятся вместе с задачами и фиксируются в их транзакциях.
+// Хранилище в памяти не помнит прошлых состояний, поэтому timeTravel для
+// него nil.
+func newTaskStore() (repository.TaskRepository, *db.OutboxRepository, *db.AuditRepository, repository.TaskTimeTravelRepository, error) {
+	if os.Getenv("TASK_STORE") != "eventsourced" {
+		store := db.NewTaskRepository()
+		return store, db.NewOutboxRepository(store), db.NewAuditRepository(store), nil, nil
+	}
+
+	snapshotEvery, _ := strconv.Atoi(os.Getenv("TASK_SNAPSHOT_EVERY"))
+	store, err := db.NewEventSourcedTaskRepository("data/tasks", snapshotEvery)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return store, db.NewOutboxRepository(store), db.NewAuditRepository(store), store, nil
+}
@@ -0,0 +1,170 @@
+// Клиент для обмена задачами с сервисом в формате todo.txt:
+// выгружает задачи в файл и загружает отредактированный файл обратно.
+//
+//	go run ./cmd/todotxt -token secret export -o todo.txt
+//	go run ./cmd/todotxt -token secret import todo.txt
+//
+// Адрес сервиса и токен можно задать переменными TASKS_URL и TASKS_TOKEN.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// importReport часть отчета об импорте, которая выводится пользователю
+type importReport struct {
+	Total    int      `json:"total"`
+	Created  int      `json:"created"`
+	Updated  int      `json:"updated"`
+	Failed   int      `json:"failed"`
+	Projects []string `json:"created_projects"`
+	Lines    []struct {
+		Line  int    `json:"line"`
+		Error string `json:"error"`
+	} `json:"lines"`
+}
+
+func main() {
+	server := flag.String("server", envOr("TASKS_URL", "http://localhost:8080"), "service address")
+	token := flag.String("token", os.Getenv("TASKS_TOKEN"), "bearer token")
+	workspace := flag.String("workspace", "", "workspace ID (default workspace if empty)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: todotxt [flags] export [-o file] | import [file]\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *token == "" {
+		log.Fatal("Token is required: use -token or TASKS_TOKEN")
+	}
+
+	client := &client{server: strings.TrimRight(*server, "/"), token: *token, workspace: *workspace}
+
+	var err error
+	switch flag.Arg(0) {
+	case "export":
+		err = client.export(flag.Args()[1:])
+	case "import":
+		err = client.importFile(flag.Args()[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type client struct {
+	server    string
+	token     string
+	workspace string
+}
+
+// export выгружает задачи в файл или в stdout
+func (c *client) export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", "", "output file (stdout if empty)")
+	flags.Parse(args)
+
+	resp, err := c.do(http.MethodGet, "/api/export?format=todotxt", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// importFile загружает файл (или stdin, если файл не указан или равен -)
+// и печатает итог и ошибки строк
+func (c *client) importFile(args []string) error {
+	in := io.Reader(os.Stdin)
+	if len(args) > 0 && args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	resp, err := c.do(http.MethodPost, "/api/import/todotxt", in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var report importReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return fmt.Errorf("invalid import report: %v", err)
+	}
+
+	fmt.Printf("%d lines: %d created, %d updated, %d failed\n", report.Total, report.Created, report.Updated, report.Failed)
+	for _, project := range report.Projects {
+		fmt.Printf("created project %s\n", project)
+	}
+	for _, line := range report.Lines {
+		if line.Error != "" {
+			fmt.Printf("line %d: %s\n", line.Line, line.Error)
+		}
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// do выполняет запрос к сервису; ответ с ошибкой возвращается как error
+func (c *client) do(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.server+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.workspace != "" {
+		req.Header.Set("X-Workspace-ID", c.workspace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return resp, nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
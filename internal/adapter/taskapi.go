@@ -2,51 +2,44 @@ package adapter
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"sync"
 )
 
 type TaskAPI struct {
-	taskUseCase *usecase.TaskUseCase
+	taskUseCase    *usecase.TaskUseCase
+	projectUseCase *usecase.ProjectUseCase
+	commentUseCase *usecase.CommentUseCase
+	syncRepo       repository.SyncRepository
+	sources        map[string]ExternalTaskSource
+	syncMutex      sync.Mutex
+	syncLocks      map[string]*sync.Mutex // ключ — источник, пространство и пользователь
 }
 
-func NewTaskAPI(taskUseCase *usecase.TaskUseCase) *TaskAPI {
+func NewTaskAPI(taskUseCase *usecase.TaskUseCase, projectUseCase *usecase.ProjectUseCase, commentUseCase *usecase.CommentUseCase, syncRepo repository.SyncRepository) *TaskAPI {
 	return &TaskAPI{
-		taskUseCase: taskUseCase,
+		taskUseCase:    taskUseCase,
+		projectUseCase: projectUseCase,
+		commentUseCase: commentUseCase,
+		syncRepo:       syncRepo,
+		sources:        make(map[string]ExternalTaskSource),
+		syncLocks:      make(map[string]*sync.Mutex),
 	}
 }
 
-// SyncWithExternalAPI Пример метода для интеграции с внешним API
-func (a *TaskAPI) SyncWithExternalAPI(ctx context.Context, externalTasks []entity.Task) error {
-	// Это просто пример адаптера, который может синхронизировать задачи с внешним API
-	userID, ok := ctx.Value("user_id").(string)
-	if !ok || userID == "" {
-		return errors.New("unauthorized")
-	}
-
-	for _, extTask := range externalTasks {
-		task := entity.Task{
-			Title:       extTask.Title,
-			Description: extTask.Description,
-			Status:      extTask.Status,
-		}
-
-		if err := a.taskUseCase.CreateTask(ctx, &task); err != nil {
-			return err
-		}
-	}
-
-	return nil
+// RegisterSource добавляет внешнюю систему, доступную для синхронизации
+func (a *TaskAPI) RegisterSource(source ExternalTaskSource) {
+	a.sources[source.Name()] = source
 }
 
-// ExportTasksToJSON Метод для экспорта задач в JSON
-func (a *TaskAPI) ExportTasksToJSON(ctx context.Context) ([]byte, error) {
-	tasks, err := a.taskUseCase.GetAllTasks(ctx)
-	if err != nil {
-		return nil, err
+// SyncSource синхронизирует задачи с зарегистрированной внешней системой
+func (a *TaskAPI) SyncSource(ctx context.Context, name string, opts SyncOptions) (*SyncReport, error) {
+	source, ok := a.sources[name]
+	if !ok {
+		return nil, errors.New("sync source not found")
 	}
 
-	return json.Marshal(tasks)
+	return a.SyncWithExternalAPI(ctx, source, opts)
 }
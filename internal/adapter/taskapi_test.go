@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"path/filepath"
+	"testing"
+)
+
+// testAPI TaskAPI на хранилищах в памяти; связи синхронизации пишутся
+// во временный каталог теста
+type testAPI struct {
+	*TaskAPI
+	tasks    *usecase.TaskUseCase
+	projects *usecase.ProjectUseCase
+	comments *usecase.CommentUseCase
+	syncRepo *db.SyncRepository
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	appLogger := logger.NewLogger()
+	taskRepo := db.NewTaskRepository()
+	users := db.NewUserRepository()
+
+	for _, user := range []*entity.User{
+		{ID: "user-123", Username: "demo", Name: "Demo User", Email: "demo@example.com"},
+		{ID: "user-456", Username: "alice", Name: "Alice", Email: "alice@example.com"},
+	} {
+		if err := users.Create(context.Background(), user); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	syncRepo, err := db.NewSyncRepository(filepath.Join(t.TempDir(), "sync.json"))
+	if err != nil {
+		t.Fatalf("create sync repository: %v", err)
+	}
+
+	tasks := usecase.NewTaskUseCase(taskRepo, db.NewDependencyRepository(), db.NewWorkflowRepository(), users, db.NewOutboxRepository(taskRepo), appLogger, usecase.TaskUseCaseConfig{})
+	projects := usecase.NewProjectUseCase(tasks, db.NewProjectRepository(), taskRepo, appLogger)
+	comments := usecase.NewCommentUseCase(tasks, db.NewCommentRepository(), users, appLogger)
+
+	return &testAPI{
+		TaskAPI:  NewTaskAPI(tasks, projects, comments, syncRepo),
+		tasks:    tasks,
+		projects: projects,
+		comments: comments,
+		syncRepo: syncRepo,
+	}
+}
+
+// userContext контекст запроса пользователя, как его готовит AuthMiddleware
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), "user_id", userID)
+}
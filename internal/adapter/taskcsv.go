@@ -0,0 +1,368 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvColumns колонки экспорта CSV; имена совпадают с полями задачи в JSON
+var csvColumns = map[string]func(task *entity.Task) string{
+	"id":              func(t *entity.Task) string { return t.ID },
+	"title":           func(t *entity.Task) string { return t.Title },
+	"description":     func(t *entity.Task) string { return t.Description },
+	"status":          func(t *entity.Task) string { return string(t.Status) },
+	"status_category": func(t *entity.Task) string { return string(t.StatusCategory) },
+	"assignee_id":     func(t *entity.Task) string { return t.AssigneeID },
+	"parent_id":       func(t *entity.Task) string { return t.ParentID },
+	"project_id":      func(t *entity.Task) string { return t.ProjectID },
+	"due_at":          func(t *entity.Task) string { return formatCSVTime(t.DueAt) },
+	"series_id":       func(t *entity.Task) string { return t.SeriesID },
+	"occurrence":      func(t *entity.Task) string { return formatCSVInt(t.Occurrence) },
+	"checklist_done":  func(t *entity.Task) string { return strconv.Itoa(t.ChecklistDone) },
+	"checklist_total": func(t *entity.Task) string { return strconv.Itoa(t.ChecklistTotal) },
+	"completed_at":    func(t *entity.Task) string { return formatCSVTime(t.CompletedAt) },
+	"archived_at":     func(t *entity.Task) string { return formatCSVTime(t.ArchivedAt) },
+	"created_at":      func(t *entity.Task) string { return t.CreatedAt.Format(time.RFC3339) },
+	"updated_at":      func(t *entity.Task) string { return t.UpdatedAt.Format(time.RFC3339) },
+}
+
+// csvFormulaPrefixes первые символы ячейки, с которых электронная таблица
+// начинает формулу. Такие ячейки экспорт предваряет апострофом, а импорт его
+// снимает; ячейки, начинающиеся с апострофа, тоже экранируются, чтобы
+// импорт не снял апостроф самого текста.
+const csvFormulaPrefixes = "=+-@\t\r'"
+
+// DefaultCSVColumns колонки экспорта, если они не выбраны явно
+var DefaultCSVColumns = []string{"id", "title", "description", "status", "assignee_id", "parent_id", "project_id", "due_at", "created_at", "updated_at"}
+
+// csvImportFields поля задачи, которые заполняются при импорте. Остальные
+// колонки (например, created_at из экспорта) пропускаются: их ведет система.
+var csvImportFields = map[string]func(task *entity.Task, value string) error{
+	"title":       func(t *entity.Task, v string) error { t.Title = v; return nil },
+	"description": func(t *entity.Task, v string) error { t.Description = v; return nil },
+	"status":      func(t *entity.Task, v string) error { t.Status = entity.TaskStatus(v); return nil },
+	"assignee_id": func(t *entity.Task, v string) error { t.AssigneeID = v; return nil },
+	"parent_id":   func(t *entity.Task, v string) error { t.ParentID = v; return nil },
+	"due_at": func(t *entity.Task, v string) error {
+		if v == "" {
+			return nil
+		}
+		dueAt, err := parseCSVTime(v)
+		if err != nil {
+			return errors.New("validation failed: invalid due_at " + strconv.Quote(v))
+		}
+		t.DueAt = &dueAt
+		return nil
+	},
+}
+
+// CSVImportOptions настройки импорта CSV
+type CSVImportOptions struct {
+	// Mapping сопоставляет заголовки файла полям задачи (title, status, ...).
+	// Заголовок без сопоставления используется как имя поля; регистр и
+	// пробелы по краям не учитываются.
+	Mapping map[string]string
+	// DryRun только проверяет строки и сообщает, какие задачи были бы созданы
+	DryRun bool
+}
+
+// CSVImportRow результат импорта одной строки; Row — номер строки данных с 1
+type CSVImportRow struct {
+	Row   int          `json:"row"`
+	Task  *entity.Task `json:"task,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// CSVImportReport отчет об импорте CSV
+type CSVImportReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Total   int            `json:"total"`
+	Created int            `json:"created"` // при DryRun — сколько было бы создано
+	Failed  int            `json:"failed"`
+	Ignored []string       `json:"ignored_columns,omitempty"`
+	Rows    []CSVImportRow `json:"rows"`
+
+	header    []string
+	errorRows [][]string
+}
+
+// ErrorRowsCSV возвращает строки с ошибками в исходном виде с колонкой
+// error, чтобы их можно было исправить и импортировать повторно. Строка,
+// которую не удалось разобрать, записывается исходным текстом в первую
+// колонку.
+func (r *CSVImportReport) ErrorRowsCSV() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.UseCRLF = true
+
+	if err := writer.Write(append(append([]string(nil), r.header...), "error")); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(r.errorRows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ExportTasksToCSV записывает задачи пользователя в CSV (RFC 4180) с
+// колонками columns; пустой список означает DefaultCSVColumns
+func (a *TaskAPI) ExportTasksToCSV(ctx context.Context, w io.Writer, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	for _, column := range columns {
+		if _, ok := csvColumns[column]; !ok {
+			return errors.New("validation failed: unknown column " + strconv.Quote(column))
+		}
+	}
+
+	tasks, err := a.taskUseCase.GetAllTasks(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, task := range tasks {
+		for i, column := range columns {
+			record[i] = escapeCSVCell(csvColumns[column](task))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportTasksFromCSV создает задачи из строк CSV. Каждая строка проверяется
+// отдельно, ошибка в строке не прерывает импорт остальных. Если в файле
+// есть колонка id, parent_id может ссылаться на задачу из предыдущей строки
+// того же файла.
+func (a *TaskAPI) ImportTasksFromCSV(ctx context.Context, r io.Reader, opts CSVImportOptions) (*CSVImportReport, error) {
+	source := &csvSource{r: r}
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("validation failed: file is empty")
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("validation failed: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	source.take(reader.InputOffset())
+
+	report := &CSVImportReport{DryRun: opts.DryRun, header: header, Rows: []CSVImportRow{}}
+
+	// Индексы колонок файла для полей задачи
+	fields := make(map[int]string)
+	idColumn := -1
+	for i, name := range header {
+		field := csvField(name, opts.Mapping)
+		switch {
+		case csvImportFields[field] != nil:
+			fields[i] = field
+		case field == "id":
+			idColumn = i
+		default:
+			report.Ignored = append(report.Ignored, name)
+		}
+	}
+
+	hasTitle := false
+	for _, field := range fields {
+		hasTitle = hasTitle || field == "title"
+	}
+	if !hasTitle {
+		return nil, errors.New("validation failed: title column is required")
+	}
+
+	// ID из файла -> ID созданной задачи; при DryRun задачи не создаются,
+	// и ссылка на строку файла считается верной
+	imported := make(map[string]string)
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// Ошибка чтения, а не разбора строки, прерывает импорт
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, err
+		}
+
+		report.Total++
+		raw := source.take(reader.InputOffset())
+
+		var task *entity.Task
+		if err == nil {
+			task, err = a.importCSVRecord(ctx, record, fields, idColumn, imported, opts.DryRun)
+		} else {
+			// Поля неразобранной строки неизвестны; исходный текст позволяет
+			// исправить её, а не набирать заново
+			record = make([]string, max(len(header), 1))
+			record[0] = strings.TrimRight(raw, "\r\n")
+		}
+
+		if err != nil {
+			report.Failed++
+			report.Rows = append(report.Rows, CSVImportRow{Row: row, Error: err.Error()})
+			report.errorRows = append(report.errorRows, append(append([]string(nil), record...), err.Error()))
+			continue
+		}
+
+		report.Created++
+		report.Rows = append(report.Rows, CSVImportRow{Row: row, Task: task})
+	}
+
+	return report, nil
+}
+
+// importCSVRecord проверяет строку и, если это не DryRun, создает задачу
+func (a *TaskAPI) importCSVRecord(ctx context.Context, record []string, fields map[int]string, idColumn int, imported map[string]string, dryRun bool) (*entity.Task, error) {
+	task := &entity.Task{}
+	for i, field := range fields {
+		if i >= len(record) {
+			continue
+		}
+		if err := csvImportFields[field](task, unescapeCSVCell(strings.TrimSpace(record[i]))); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ссылка на задачу из этого же файла
+	parentInFile := false
+	if newID, ok := imported[task.ParentID]; ok {
+		task.ParentID = newID
+		parentInFile = true
+	}
+
+	var err error
+	switch {
+	case dryRun && parentInFile:
+		// Родителя еще нет в хранилище — проверяется все, кроме него
+		parentID := task.ParentID
+		task.ParentID = ""
+		err = a.taskUseCase.ValidateNewTask(ctx, task)
+		task.ParentID = parentID
+	case dryRun:
+		err = a.taskUseCase.ValidateNewTask(ctx, task)
+	default:
+		err = a.taskUseCase.CreateTask(ctx, task)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if idColumn >= 0 && idColumn < len(record) && record[idColumn] != "" {
+		// При DryRun задача не создана, и ссылка остается ссылкой на строку
+		newID := task.ID
+		if dryRun {
+			newID = record[idColumn]
+		}
+		imported[record[idColumn]] = newID
+	}
+
+	return task, nil
+}
+
+// csvSource запоминает текст, прочитанный из файла импорта, пока его
+// записи не разобраны, чтобы вернуть строку с ошибкой в исходном виде
+type csvSource struct {
+	r    io.Reader
+	buf  []byte
+	base int64 // смещение buf[0] от начала файла
+}
+
+func (s *csvSource) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.buf = append(s.buf, p[:n]...)
+	return n, err
+}
+
+// take возвращает текст файла от прошлого вызова до смещения offset и
+// забывает его
+func (s *csvSource) take(offset int64) string {
+	n := offset - s.base
+	text := string(s.buf[:n])
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	s.base = offset
+	return text
+}
+
+// escapeCSVCell предваряет апострофом ячейку, которую электронная таблица
+// выполнила бы как формулу
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell снимает апостроф, добавленный escapeCSVCell
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// csvField возвращает поле задачи для заголовка колонки
+func csvField(name string, mapping map[string]string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for header, field := range mapping {
+		if strings.ToLower(strings.TrimSpace(header)) == name {
+			return field
+		}
+	}
+	return name
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatCSVInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// parseCSVTime разбирает время в RFC 3339 или дату в формате 2006-01-02
+func parseCSVTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
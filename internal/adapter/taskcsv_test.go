@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"strings"
+	"testing"
+)
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := userContext("user-123")
+
+	titles := []string{`=HYPERLINK("http://evil.test","Open")`, "+1 for the idea", "-10% budget", "@channel review", "'quoted' word", "Plain title"}
+	for _, title := range titles {
+		if err := api.tasks.CreateTask(ctx, &entity.Task{Title: title}); err != nil {
+			t.Fatalf("create task %q: %v", title, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := api.ExportTasksToCSV(ctx, &buf, []string{"title"}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	cells := make(map[string]bool)
+	for _, record := range records[1:] {
+		cells[record[0]] = true
+	}
+	for _, title := range titles {
+		want := "'" + title
+		if title == "Plain title" {
+			want = title
+		}
+		if !cells[want] {
+			t.Fatalf("export has no cell %q: %q", want, records)
+		}
+	}
+
+	// Импорт экспорта возвращает исходные названия
+	report, err := api.ImportTasksFromCSV(userContext("user-456"), bytes.NewReader(buf.Bytes()), CSVImportOptions{})
+	if err != nil || report.Failed != 0 || report.Created != len(titles) {
+		t.Fatalf("import = %+v, %v", report, err)
+	}
+	imported := make(map[string]bool)
+	for _, row := range report.Rows {
+		imported[row.Task.Title] = true
+	}
+	for _, title := range titles {
+		if !imported[title] {
+			t.Fatalf("title %q is not imported back", title)
+		}
+	}
+}
+
+func TestCSVErrorRowsKeepUnparsedLine(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := userContext("user-123")
+
+	input := "title,status\r\nShip release,TODO\r\nFix \"login,TODO\r\nWrite notes,UNKNOWN\r\nPlan sprint,TODO\r\n"
+	report, err := api.ImportTasksFromCSV(ctx, strings.NewReader(input), CSVImportOptions{})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Total != 4 || report.Created != 2 || report.Failed != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	data, err := report.ErrorRowsCSV()
+	if err != nil {
+		t.Fatalf("error rows: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("read error rows: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != "title,status,error" {
+		t.Fatalf("error rows = %q", records)
+	}
+	if records[1][0] != `Fix "login,TODO` || records[1][2] == "" {
+		t.Fatalf("unparsed row = %q, want the raw line and the error", records[1])
+	}
+	if records[2][0] != "Write notes" || records[2][1] != "UNKNOWN" {
+		t.Fatalf("invalid row = %q", records[2])
+	}
+}
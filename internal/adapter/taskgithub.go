@@ -0,0 +1,501 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitHubConfig настройки синхронизации с задачами (issues) репозитория GitHub
+type GitHubConfig struct {
+	// BaseURL адрес REST API; по умолчанию https://api.github.com. Указывается
+	// для GitHub Enterprise или локального сервера с записанными ответами.
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	// Name имя источника синхронизации; по умолчанию github
+	Name string
+	// Assignees сопоставляет логины GitHub с ID наших пользователей. Если
+	// оно не задано, исполнитель не синхронизируется.
+	Assignees map[string]string
+	// OpenStatus и DoneStatus статусы открытой и закрытой задачи; по
+	// умолчанию TODO и DONE
+	OpenStatus entity.TaskStatus
+	DoneStatus entity.TaskStatus
+	// MaxRateLimitWait сколько можно ждать восстановления лимита запросов;
+	// если ждать дольше, синхронизация завершается ошибкой. По умолчанию минута.
+	MaxRateLimitWait time.Duration
+}
+
+// GitHubIssues источник синхронизации с задачами репозитория GitHub.
+// Изменения читаются по полю since списка задач; ответы кэшируются по ETag,
+// поэтому повторная синхронизация без изменений не расходует лимит
+// запросов. Pull request'ы пропускаются, закрытие задачи как not planned
+// считается её удалением. Сроков в GitHub нет, они остаются только у нас.
+// Название, текст и метки обрезаются до ограничений задачи; обрезанные
+// поля не отправляются обратно, пока их не изменили у нас.
+type GitHubIssues struct {
+	config GitHubConfig
+	client *http.Client
+	logins map[string]string // ID пользователя -> логин GitHub
+
+	mutex     sync.Mutex
+	pages     map[string]githubPage // ответы по URL для запросов с If-None-Match
+	remaining int                   // остаток лимита по последнему ответу, -1 — неизвестен
+	resetAt   time.Time
+}
+
+// githubPage закэшированная страница списка задач
+type githubPage struct {
+	etag string
+	body []byte
+	next string
+}
+
+type githubIssue struct {
+	Number      int           `json:"number"`
+	Title       string        `json:"title"`
+	Body        *string       `json:"body"`
+	State       string        `json:"state"`
+	StateReason *string       `json:"state_reason"`
+	Labels      []githubLabel `json:"labels"`
+	Assignees   []githubUser  `json:"assignees"`
+	PullRequest *struct{}     `json:"pull_request"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+var githubNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// NewGitHubIssues создает источник синхронизации с репозиторием Owner/Repo
+func NewGitHubIssues(config GitHubConfig) *GitHubIssues {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.github.com"
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Name == "" {
+		config.Name = "github"
+	}
+	if config.OpenStatus == "" {
+		config.OpenStatus = entity.StatusTodo
+	}
+	if config.DoneStatus == "" {
+		config.DoneStatus = entity.StatusDone
+	}
+	if config.MaxRateLimitWait == 0 {
+		config.MaxRateLimitWait = time.Minute
+	}
+
+	logins := make(map[string]string, len(config.Assignees))
+	for login, userID := range config.Assignees {
+		logins[userID] = login
+	}
+
+	return &GitHubIssues{
+		config:    config,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		logins:    logins,
+		pages:     make(map[string]githubPage),
+		remaining: -1,
+	}
+}
+
+func (g *GitHubIssues) Name() string {
+	return g.config.Name
+}
+
+func (g *GitHubIssues) UnsupportedFields() []string {
+	if len(g.config.Assignees) == 0 {
+		return []string{"due_at", "assignee_id"}
+	}
+	return []string{"due_at"}
+}
+
+func (g *GitHubIssues) OpenClosedStatus() bool {
+	return true
+}
+
+// Changes возвращает задачи, обновленные не раньше token — момента самого
+// позднего изменения, прочитанного в прошлый раз. Граничные задачи
+// приходят повторно, но их повторное применение ничего не меняет.
+func (g *GitHubIssues) Changes(ctx context.Context, token string) ([]ExternalTask, string, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "updated")
+	query.Set("direction", "asc")
+	query.Set("per_page", "100")
+	if token != "" {
+		query.Set("since", token)
+	}
+
+	var result []ExternalTask
+	latest := token
+	pages := make(map[string]githubPage)
+
+	next := g.repoURL("/issues") + "?" + query.Encode()
+	for next != "" {
+		page, err := g.getPage(ctx, next)
+		if err != nil {
+			return nil, "", err
+		}
+		pages[next] = page
+
+		var issues []githubIssue
+		if err := json.Unmarshal(page.body, &issues); err != nil {
+			return nil, "", fmt.Errorf("github: decode issues: %v", err)
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequest != nil {
+				continue
+			}
+			result = append(result, g.toExternal(issue))
+			if updated := issue.UpdatedAt.UTC().Format(time.RFC3339); updated > latest {
+				latest = updated
+			}
+		}
+
+		next = page.next
+	}
+
+	// В кэше остаются только страницы последней выборки: с новым токеном
+	// прежние URL больше не запрашиваются
+	g.mutex.Lock()
+	g.pages = pages
+	g.mutex.Unlock()
+
+	return result, latest, nil
+}
+
+func (g *GitHubIssues) Create(ctx context.Context, task ExternalTask) (ExternalTask, error) {
+	var issue githubIssue
+	if err := g.send(ctx, http.MethodPost, g.repoURL("/issues"), g.payload(task, false), &issue); err != nil {
+		return ExternalTask{}, err
+	}
+
+	// Задача создается открытой; закрыть её можно только отдельным запросом
+	if g.closed(task) {
+		task.ID = strconv.Itoa(issue.Number)
+		return g.Update(ctx, task)
+	}
+
+	return g.toExternal(issue), nil
+}
+
+func (g *GitHubIssues) Update(ctx context.Context, task ExternalTask) (ExternalTask, error) {
+	endpoint := g.repoURL("/issues/" + url.PathEscape(task.ID))
+	payload := g.payload(task, true)
+
+	// Обрезанное при чтении поле заменило бы в GitHub полный текст, поэтому
+	// поле, совпадающее с обрезанным значением из GitHub, передается как есть
+	if capped(task) {
+		_, body, err := g.do(ctx, http.MethodGet, endpoint, nil, http.Header{})
+		if err != nil {
+			return ExternalTask{}, err
+		}
+		var current githubIssue
+		if err := json.Unmarshal(body, &current); err != nil {
+			return ExternalTask{}, fmt.Errorf("github: decode issue: %v", err)
+		}
+
+		read := g.toExternal(current)
+		if read.Title == task.Title {
+			payload["title"] = current.Title
+		}
+		if read.FullDescription != "" && read.Description == task.Description {
+			payload["body"] = read.FullDescription
+		}
+		if entity.SameTags(read.Tags, task.Tags) {
+			labels := make([]string, 0, len(current.Labels))
+			for _, label := range current.Labels {
+				labels = append(labels, label.Name)
+			}
+			payload["labels"] = labels
+		}
+	}
+
+	var issue githubIssue
+	if err := g.send(ctx, http.MethodPatch, endpoint, payload, &issue); err != nil {
+		return ExternalTask{}, err
+	}
+
+	return g.toExternal(issue), nil
+}
+
+// Delete закрывает задачу как not planned: REST API GitHub не удаляет задачи
+func (g *GitHubIssues) Delete(ctx context.Context, id string) error {
+	payload := map[string]interface{}{"state": "closed", "state_reason": "not_planned"}
+	return g.send(ctx, http.MethodPatch, g.repoURL("/issues/"+url.PathEscape(id)), payload, nil)
+}
+
+func (g *GitHubIssues) toExternal(issue githubIssue) ExternalTask {
+	external := ExternalTask{
+		ID:             strconv.Itoa(issue.Number),
+		Title:          entity.TruncateText(issue.Title, syncTitleLimit),
+		Status:         g.config.OpenStatus,
+		StatusCategory: entity.CategoryTodo,
+		UpdatedAt:      issue.UpdatedAt,
+	}
+	if issue.Body != nil {
+		external.Description = entity.TruncateText(*issue.Body, syncDescriptionLimit)
+		if external.Description != *issue.Body {
+			external.FullDescription = *issue.Body
+		}
+	}
+
+	if issue.State == "closed" {
+		external.Status = g.config.DoneStatus
+		external.StatusCategory = entity.CategoryDone
+		external.Deleted = issue.StateReason != nil && *issue.StateReason == "not_planned"
+	}
+
+	for _, assignee := range issue.Assignees {
+		if userID, ok := g.config.Assignees[assignee.Login]; ok {
+			external.AssigneeID = userID
+			break
+		}
+	}
+
+	tags := make([]string, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		tags = append(tags, label.Name)
+	}
+	external.Tags = syncTags(tags)
+
+	return external
+}
+
+// payload тело запроса создания или изменения задачи
+func (g *GitHubIssues) payload(task ExternalTask, withState bool) map[string]interface{} {
+	labels := task.Tags
+	if labels == nil {
+		labels = []string{}
+	}
+
+	payload := map[string]interface{}{
+		"title":  task.Title,
+		"body":   task.Description,
+		"labels": labels,
+	}
+
+	// Исполнителя без сопоставления не передаем, чтобы не снять
+	// назначенного в GitHub
+	if len(g.config.Assignees) > 0 {
+		if task.AssigneeID == "" {
+			payload["assignees"] = []string{}
+		} else if login, ok := g.logins[task.AssigneeID]; ok {
+			payload["assignees"] = []string{login}
+		}
+	}
+
+	if withState {
+		if g.closed(task) {
+			payload["state"] = "closed"
+			payload["state_reason"] = "completed"
+		} else {
+			payload["state"] = "open"
+		}
+	}
+
+	return payload
+}
+
+// capped сообщает, могло ли какое-то поле записи быть обрезано при чтении
+func capped(task ExternalTask) bool {
+	if strings.HasSuffix(task.Title, "…") || strings.HasSuffix(task.Description, "…") || len(task.Tags) == syncMaxTags {
+		return true
+	}
+	for _, tag := range task.Tags {
+		if strings.HasSuffix(tag, "…") {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GitHubIssues) closed(task ExternalTask) bool {
+	if task.StatusCategory != "" {
+		return task.StatusCategory == entity.CategoryDone
+	}
+	return task.Status == g.config.DoneStatus
+}
+
+func (g *GitHubIssues) repoURL(path string) string {
+	return g.config.BaseURL + "/repos/" + url.PathEscape(g.config.Owner) + "/" + url.PathEscape(g.config.Repo) + path
+}
+
+// getPage запрашивает страницу списка с If-None-Match; при ответе 304
+// возвращается закэшированная страница
+func (g *GitHubIssues) getPage(ctx context.Context, pageURL string) (githubPage, error) {
+	g.mutex.Lock()
+	cached, hasCached := g.pages[pageURL]
+	g.mutex.Unlock()
+
+	header := http.Header{}
+	if hasCached && cached.etag != "" {
+		header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, body, err := g.do(ctx, http.MethodGet, pageURL, nil, header)
+	if err != nil {
+		return githubPage{}, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached, nil
+	}
+
+	page := githubPage{etag: resp.Header.Get("ETag"), body: body}
+	if match := githubNextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+		page.next = match[1]
+	}
+	return page, nil
+}
+
+// send выполняет запрос изменения и декодирует ответ в result, если он задан
+func (g *GitHubIssues) send(ctx context.Context, method, endpoint string, payload interface{}, result interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	_, body, err := g.do(ctx, method, endpoint, data, header)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("github: decode response: %v", err)
+	}
+	return nil
+}
+
+// do выполняет запрос, соблюдая лимиты GitHub: при исчерпанном лимите
+// ждет его восстановления, если это не дольше MaxRateLimitWait, и
+// повторяет запрос после ответа 403/429 с Retry-After или нулевым остатком
+func (g *GitHubIssues) do(ctx context.Context, method, endpoint string, data []byte, header http.Header) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := g.waitRateLimit(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if g.config.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+g.config.Token)
+		}
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		limited := g.recordRateLimit(resp)
+		if limited && attempt < 3 {
+			continue
+		}
+
+		if resp.StatusCode >= 400 {
+			var message struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(body, &message)
+			return nil, nil, fmt.Errorf("github: %s %s: %s %s", method, req.URL.Path, resp.Status, message.Message)
+		}
+
+		return resp, body, nil
+	}
+}
+
+// recordRateLimit запоминает остаток лимита из заголовков ответа и
+// сообщает, отклонен ли запрос из-за лимита
+func (g *GitHubIssues) recordRateLimit(resp *http.Response) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		g.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		g.resetAt = time.Unix(reset, 0)
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	// Вторичный лимит сообщает паузу в Retry-After
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		g.remaining = 0
+		g.resetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		return true
+	}
+
+	return g.remaining == 0
+}
+
+// waitRateLimit ждет восстановления исчерпанного лимита запросов
+func (g *GitHubIssues) waitRateLimit(ctx context.Context) error {
+	g.mutex.Lock()
+	remaining, resetAt := g.remaining, g.resetAt
+	g.mutex.Unlock()
+
+	if remaining != 0 {
+		return nil
+	}
+
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > g.config.MaxRateLimitWait {
+		return errors.New("github rate limit exceeded until " + resetAt.UTC().Format(time.RFC3339))
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,472 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitHub заменитель REST API задач GitHub для репозитория owner/repo:
+// список задач с пагинацией по Link и ETag, чтение, создание и изменение
+// задачи. Перехватчик intercept может ответить на запрос вместо сервера.
+type fakeGitHub struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	issues    map[int]*githubIssue
+	pageSize  int
+	intercept func(w http.ResponseWriter, r *http.Request) bool
+	// requests принятые запросы в виде "METHOD путь?запрос статус"
+	requests []string
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+
+	f := &fakeGitHub{issues: make(map[int]*githubIssue), pageSize: 100}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// source источник синхронизации, настроенный на заменитель
+func (f *fakeGitHub) source(config GitHubConfig) *GitHubIssues {
+	config.BaseURL = f.URL
+	config.Owner, config.Repo = "owner", "repo"
+	return NewGitHubIssues(config)
+}
+
+// add добавляет задачу; номер и время изменения назначаются, если не заданы
+func (f *fakeGitHub) add(issue githubIssue) *githubIssue {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if issue.Number == 0 {
+		issue.Number = len(f.issues) + 1
+	}
+	if issue.State == "" {
+		issue.State = "open"
+	}
+	if issue.UpdatedAt.IsZero() {
+		issue.UpdatedAt = time.Date(2026, 1, 1, 0, 0, issue.Number, 0, time.UTC)
+	}
+	f.issues[issue.Number] = &issue
+	return &issue
+}
+
+func (f *fakeGitHub) issue(number int) githubIssue {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return *f.issues[number]
+}
+
+func (f *fakeGitHub) log() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	status := f.serve(w, r)
+
+	f.mutex.Lock()
+	f.requests = append(f.requests, fmt.Sprintf("%s %s %d", r.Method, r.URL.RequestURI(), status))
+	f.mutex.Unlock()
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) int {
+	if f.intercept != nil {
+		recorder := httptest.NewRecorder()
+		if f.intercept(recorder, r) {
+			for key, values := range recorder.Header() {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return recorder.Code
+		}
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/repos/owner/repo/issues")
+	if !ok {
+		http.NotFound(w, r)
+		return http.StatusNotFound
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if path == "" && r.Method == http.MethodGet {
+		return f.list(w, r)
+	}
+
+	if path == "" && r.Method == http.MethodPost {
+		issue := &githubIssue{Number: len(f.issues) + 1, State: "open"}
+		if err := f.apply(issue, r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return http.StatusUnprocessableEntity
+		}
+		f.issues[issue.Number] = issue
+		return writeJSON(w, http.StatusCreated, issue)
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(path, "/"))
+	issue, exists := f.issues[number]
+	if err != nil || !exists {
+		http.NotFound(w, r)
+		return http.StatusNotFound
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return writeJSON(w, http.StatusOK, issue)
+	case http.MethodPatch:
+		if err := f.apply(issue, r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return http.StatusUnprocessableEntity
+		}
+		return writeJSON(w, http.StatusOK, issue)
+	}
+
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	return http.StatusMethodNotAllowed
+}
+
+// list отдает задачи, измененные не раньше since, по возрастанию времени
+// изменения страницами по pageSize
+func (f *fakeGitHub) list(w http.ResponseWriter, r *http.Request) int {
+	query := r.URL.Query()
+	since, _ := time.Parse(time.RFC3339, query.Get("since"))
+
+	var issues []*githubIssue
+	for _, issue := range f.issues {
+		if !issue.UpdatedAt.Before(since) {
+			issues = append(issues, issue)
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].UpdatedAt.Before(issues[j].UpdatedAt) })
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	page = max(page, 1)
+	start := min((page-1)*f.pageSize, len(issues))
+	end := min(start+f.pageSize, len(issues))
+
+	if end < len(issues) {
+		query.Set("page", strconv.Itoa(page+1))
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?%s>; rel="next", <%s/last>; rel="last"`, f.URL, r.URL.Path, query.Encode(), f.URL))
+	}
+
+	body, _ := json.Marshal(issues[start:end])
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+	return http.StatusOK
+}
+
+// apply переносит в задачу поля запроса создания или изменения
+func (f *fakeGitHub) apply(issue *githubIssue, r *http.Request) error {
+	var payload struct {
+		Title       *string   `json:"title"`
+		Body        *string   `json:"body"`
+		Labels      *[]string `json:"labels"`
+		State       *string   `json:"state"`
+		StateReason *string   `json:"state_reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.Title != nil {
+		issue.Title = *payload.Title
+	}
+	if payload.Body != nil {
+		issue.Body = payload.Body
+	}
+	if payload.Labels != nil {
+		issue.Labels = nil
+		for _, name := range *payload.Labels {
+			issue.Labels = append(issue.Labels, githubLabel{Name: name})
+		}
+	}
+	if payload.State != nil {
+		issue.State = *payload.State
+		issue.StateReason = payload.StateReason
+	}
+	issue.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+	return status
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// countRequests считает запросы с префиксом prefix
+func countRequests(requests []string, prefix string) int {
+	n := 0
+	for _, request := range requests {
+		if strings.HasPrefix(request, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestGitHubChangesPaginatesAndSkipsPullRequests(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.pageSize = 2
+	fake.add(githubIssue{Title: "First"})
+	fake.add(githubIssue{Title: "Pull request", PullRequest: &struct{}{}})
+	fake.add(githubIssue{Title: "Second"})
+	last := fake.add(githubIssue{Title: "Third"})
+
+	changes, token, err := fake.source(GitHubConfig{}).Changes(t.Context(), "")
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+
+	var titles []string
+	for _, change := range changes {
+		titles = append(titles, change.Title)
+	}
+	if strings.Join(titles, ", ") != "First, Second, Third" {
+		t.Fatalf("titles = %v", titles)
+	}
+	if token != last.UpdatedAt.Format(time.RFC3339) {
+		t.Fatalf("token = %q, want update time of the last issue", token)
+	}
+	if pages := countRequests(fake.log(), "GET /repos/owner/repo/issues?"); pages != 2 {
+		t.Fatalf("fetched %d pages, want 2: %v", pages, fake.log())
+	}
+}
+
+func TestGitHubChangesRevalidatesWithETag(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.add(githubIssue{Title: "Cached"})
+	source := fake.source(GitHubConfig{})
+	token := "2025-12-31T00:00:00Z"
+
+	first, _, err := source.Changes(t.Context(), token)
+	if err != nil {
+		t.Fatalf("first Changes: %v", err)
+	}
+	second, _, err := source.Changes(t.Context(), token)
+	if err != nil {
+		t.Fatalf("second Changes: %v", err)
+	}
+
+	if len(first) != 1 || len(second) != 1 || second[0].Title != "Cached" {
+		t.Fatalf("changes = %+v, then %+v", first, second)
+	}
+	requests := fake.log()
+	if len(requests) != 2 || !strings.HasSuffix(requests[1], " 304") {
+		t.Fatalf("requests = %v, want the second answered with 304", requests)
+	}
+}
+
+func TestGitHubClosedNotPlannedIsDeleted(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.add(githubIssue{Title: "Done", State: "closed", StateReason: stringPtr("completed")})
+	fake.add(githubIssue{Title: "Dropped", State: "closed", StateReason: stringPtr("not_planned")})
+
+	changes, _, err := fake.source(GitHubConfig{}).Changes(t.Context(), "")
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes", len(changes))
+	}
+	if done := changes[0]; done.Deleted || done.Status != entity.StatusDone || done.StatusCategory != entity.CategoryDone {
+		t.Errorf("completed issue = %+v", done)
+	}
+	if !changes[1].Deleted {
+		t.Errorf("not planned issue is not deleted: %+v", changes[1])
+	}
+}
+
+func TestGitHubRetriesAfterRateLimitReset(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.add(githubIssue{Title: "Task"})
+
+	limited := true
+	fake.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+		if !limited {
+			return false
+		}
+		limited = false
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+		http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+		return true
+	}
+
+	changes, _, err := fake.source(GitHubConfig{}).Changes(t.Context(), "")
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes after retry", len(changes))
+	}
+	if requests := fake.log(); len(requests) != 2 || !strings.HasSuffix(requests[0], " 403") {
+		t.Fatalf("requests = %v, want 403 then retry", requests)
+	}
+}
+
+func TestGitHubFailsWhenRateLimitResetIsTooFar(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+		return true
+	}
+
+	_, _, err := fake.source(GitHubConfig{MaxRateLimitWait: time.Second}).Changes(t.Context(), "")
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded") {
+		t.Fatalf("Changes = %v, want rate limit error", err)
+	}
+	if requests := fake.log(); len(requests) != 1 {
+		t.Fatalf("requests = %v, want no retry", requests)
+	}
+}
+
+func TestGitHubHonorsRetryAfter(t *testing.T) {
+	fake := newFakeGitHub(t)
+	fake.add(githubIssue{Title: "Task"})
+
+	var limitedAt time.Time
+	fake.intercept = func(w http.ResponseWriter, r *http.Request) bool {
+		if !limitedAt.IsZero() {
+			return false
+		}
+		limitedAt = time.Now()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, `{"message":"You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+		return true
+	}
+
+	if _, _, err := fake.source(GitHubConfig{}).Changes(t.Context(), ""); err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	if waited := time.Since(limitedAt); waited < time.Second {
+		t.Fatalf("retried after %v, want at least Retry-After", waited)
+	}
+	if requests := fake.log(); len(requests) != 2 {
+		t.Fatalf("requests = %v, want 403 then retry", requests)
+	}
+}
+
+func TestGitHubCapsLongFields(t *testing.T) {
+	fake := newFakeGitHub(t)
+	body := strings.Repeat("Длинное описание. ", 200)
+	var labels []githubLabel
+	for i := 0; i < 30; i++ {
+		labels = append(labels, githubLabel{Name: fmt.Sprintf("label-%02d-%s", i, strings.Repeat("x", 60))})
+	}
+	fake.add(githubIssue{Title: strings.Repeat("title ", 40), Body: &body, Labels: labels})
+
+	changes, _, err := fake.source(GitHubConfig{}).Changes(t.Context(), "")
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+
+	task := &entity.Task{}
+	external := changes[0]
+	external.fields().Apply(task)
+	if errMsgs := task.Validate(); len(errMsgs) > 0 {
+		t.Fatalf("capped issue is not a valid task: %v", errMsgs)
+	}
+	if external.FullDescription != body {
+		t.Fatalf("full description is not kept")
+	}
+}
+
+func TestGitHubUpdateKeepsCappedFields(t *testing.T) {
+	fake := newFakeGitHub(t)
+	body := strings.Repeat("b", 3000)
+	title := strings.Repeat("t", 150)
+	fake.add(githubIssue{Title: title, Body: &body})
+	source := fake.source(GitHubConfig{})
+
+	changes, _, err := source.Changes(t.Context(), "")
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+
+	// Меняется только статус: обрезанные название и текст не должны
+	// заменить полные в GitHub
+	external := changes[0]
+	external.Status, external.StatusCategory = entity.StatusDone, entity.CategoryDone
+	if _, err := source.Update(t.Context(), external); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	issue := fake.issue(1)
+	if issue.State != "closed" || issue.Title != title || issue.Body == nil || *issue.Body != body {
+		t.Fatalf("issue after update: state %s, title %d bytes, body kept %v", issue.State, len(issue.Title), issue.Body != nil && *issue.Body == body)
+	}
+}
+
+func TestSyncKeepsFullGitHubDescriptionInComment(t *testing.T) {
+	api := newTestAPI(t)
+	fake := newFakeGitHub(t)
+	body := strings.Repeat("Подробности. ", 150)
+	fake.add(githubIssue{Title: "Imported", Body: &body})
+	ctx := userContext("user-123")
+
+	report, err := api.SyncWithExternalAPI(ctx, fake.source(GitHubConfig{}), SyncOptions{})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(report.Errors) > 0 || report.Local.Created != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	if _, err := api.syncRepo.GetCursor(ctx, "github", "user-123", entity.DefaultWorkspaceID); err != nil {
+		t.Fatalf("cursor is not saved: %v", err)
+	}
+
+	links, _ := api.syncRepo.GetLinks(ctx, "github", "user-123", entity.DefaultWorkspaceID)
+	threads, err := api.comments.GetComments(ctx, links[0].TaskID)
+	if err != nil {
+		t.Fatalf("get comments: %v", err)
+	}
+	if len(threads) != 1 || !strings.HasSuffix(threads[0].Comment.Body, body) {
+		t.Fatalf("comments = %+v, want the full description", threads)
+	}
+
+	// Повторная синхронизация не перезаписывает текст в GitHub обрезанным
+	if _, err := api.SyncWithExternalAPI(ctx, fake.source(GitHubConfig{}), SyncOptions{Full: true}); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if issue := fake.issue(1); *issue.Body != body {
+		t.Fatalf("issue body was overwritten")
+	}
+}
@@ -0,0 +1,148 @@
+package adapter
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/ical"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	icsProdID = "-//go-layout-project//Tasks//EN"
+	// icsUIDDomain делает UID задач глобально уникальными (RFC 5545, 3.8.4.7)
+	icsUIDDomain = "tasks.go-layout-project"
+	// icsRefresh как часто клиентам стоит перечитывать ленту
+	icsRefresh = "PT15M"
+	// icsEventDuration длительность события-срока, если лента включает VEVENT
+	icsEventDuration = 30 * time.Minute
+)
+
+// ICSOptions настройки выгрузки в iCalendar
+type ICSOptions struct {
+	Name string // название календаря в клиенте
+	// Events добавляет к каждой задаче событие в момент срока: не все
+	// календари показывают VTODO, а событие видно в любой сетке
+	Events bool
+}
+
+// ExportTasksToICS записывает задачи пользователя со сроком в календарь
+// iCalendar (RFC 5545): каждая задача — VTODO со статусом
+// NEEDS-ACTION, IN-PROCESS или COMPLETED по категории статуса
+func (a *TaskAPI) ExportTasksToICS(ctx context.Context, w io.Writer, opts ICSOptions) error {
+	tasks, err := a.taskUseCase.GetAllTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var due []*entity.Task
+	for _, task := range tasks {
+		if task.DueAt != nil {
+			due = append(due, task)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].DueAt.Equal(*due[j].DueAt) {
+			return due[i].DueAt.Before(*due[j].DueAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	cal := ical.NewWriter(w)
+	cal.Begin("VCALENDAR")
+	cal.Raw("VERSION", "2.0")
+	cal.Raw("PRODID", icsProdID)
+	cal.Raw("CALSCALE", "GREGORIAN")
+	cal.Raw("METHOD", "PUBLISH")
+	if opts.Name != "" {
+		cal.Text("NAME", opts.Name)
+		cal.Text("X-WR-CALNAME", opts.Name)
+	}
+	cal.Raw("REFRESH-INTERVAL;VALUE=DURATION", icsRefresh)
+	cal.Raw("X-PUBLISHED-TTL", icsRefresh)
+
+	for _, task := range due {
+		writeICSTodo(cal, task)
+		if opts.Events {
+			writeICSEvent(cal, task)
+		}
+	}
+
+	cal.End("VCALENDAR")
+	return cal.Flush()
+}
+
+func writeICSTodo(cal *ical.Writer, task *entity.Task) {
+	cal.Begin("VTODO")
+	cal.Raw("UID", icsUID(task.ID))
+	cal.Time("DTSTAMP", task.UpdatedAt)
+	cal.Time("CREATED", task.CreatedAt)
+	cal.Time("LAST-MODIFIED", task.UpdatedAt)
+	cal.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		cal.Text("DESCRIPTION", task.Description)
+	}
+	cal.Time("DUE", *task.DueAt)
+
+	status := icsStatus(task)
+	cal.Raw("STATUS", status)
+	if status == "COMPLETED" {
+		cal.Raw("PERCENT-COMPLETE", "100")
+		if task.CompletedAt != nil {
+			cal.Time("COMPLETED", *task.CompletedAt)
+		}
+	}
+
+	if task.ParentID != "" {
+		cal.Raw("RELATED-TO", icsUID(task.ParentID))
+	}
+	cal.End("VTODO")
+}
+
+// writeICSEvent пишет событие в момент срока задачи. UID отличается от UID
+// задачи: в одном календаре компоненты с одинаковым UID считаются одним
+// объектом.
+func writeICSEvent(cal *ical.Writer, task *entity.Task) {
+	cal.Begin("VEVENT")
+	cal.Raw("UID", icsUID(task.ID+"-due"))
+	cal.Time("DTSTAMP", task.UpdatedAt)
+	cal.Time("LAST-MODIFIED", task.UpdatedAt)
+	cal.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		cal.Text("DESCRIPTION", task.Description)
+	}
+	cal.Time("DTSTART", *task.DueAt)
+	cal.Time("DTEND", task.DueAt.Add(icsEventDuration))
+	// Срок задачи не занимает время в расписании
+	cal.Raw("TRANSP", "TRANSPARENT")
+	cal.Raw("RELATED-TO", icsUID(task.ID))
+	cal.End("VEVENT")
+}
+
+// icsStatus переводит статус задачи в STATUS компонента VTODO. Задачи без
+// категории (созданные до появления рабочих процессов) сопоставляются по
+// стандартным статусам.
+func icsStatus(task *entity.Task) string {
+	switch task.StatusCategory {
+	case entity.CategoryDone:
+		return "COMPLETED"
+	case entity.CategoryActive:
+		return "IN-PROCESS"
+	case entity.CategoryTodo:
+		return "NEEDS-ACTION"
+	}
+
+	switch task.Status {
+	case entity.StatusDone:
+		return "COMPLETED"
+	case entity.StatusInProgress:
+		return "IN-PROCESS"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+func icsUID(id string) string {
+	return id + "@" + icsUIDDomain
+}
@@ -0,0 +1,105 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"io"
+)
+
+// ExportFormat формат потокового экспорта задач
+type ExportFormat string
+
+const (
+	// ExportJSONArray массив задач JSON
+	ExportJSONArray ExportFormat = "json"
+	// ExportNDJSON задачи по одной на строку (newline-delimited JSON)
+	ExportNDJSON ExportFormat = "ndjson"
+	// ExportTodoTxt строки формата todo.txt
+	ExportTodoTxt ExportFormat = "todotxt"
+)
+
+// exportPageSize сколько задач читается из хранилища за раз; после каждой
+// страницы записанное отправляется клиенту
+const exportPageSize = 500
+
+// StreamTasks пишет задачи пользователя в w по мере чтения из хранилища,
+// не собирая их в памяти. После каждой страницы вызывается flush, чтобы
+// клиент получал данные, не дожидаясь конца выгрузки. Если ошибка возникла
+// после начала записи, в w остается оборванный поток.
+func (a *TaskAPI) StreamTasks(ctx context.Context, w io.Writer, format ExportFormat, flush func() error) error {
+	if format != ExportJSONArray && format != ExportNDJSON && format != ExportTodoTxt {
+		return errors.New("validation failed: unknown export format " + string(format))
+	}
+
+	// Для todo.txt нужны имена проектов и рабочий процесс
+	var todoTxt *todoTxtCodec
+	if format == ExportTodoTxt {
+		codec, err := a.newTodoTxtCodec(ctx)
+		if err != nil {
+			return err
+		}
+		todoTxt = codec
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	written := 0
+
+	// separate пишет перед задачей открывающую скобку или разделитель массива
+	separate := func() error {
+		if format != ExportJSONArray {
+			return nil
+		}
+		if written == 0 {
+			_, err := buffered.WriteString("[")
+			return err
+		}
+		_, err := buffered.WriteString(",")
+		return err
+	}
+
+	// encode пишет одну задачу в выбранном формате
+	encode := func(task *entity.Task) error {
+		if todoTxt != nil {
+			_, err := buffered.WriteString(todoTxt.format(task).String() + "\n")
+			return err
+		}
+		if err := separate(); err != nil {
+			return err
+		}
+		return encoder.Encode(task)
+	}
+
+	err := a.taskUseCase.EachTask(ctx, exportPageSize, func(task *entity.Task) error {
+		if err := encode(task); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportPageSize == 0 {
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == ExportJSONArray {
+		if written == 0 {
+			buffered.WriteString("[")
+		}
+		buffered.WriteString("]\n")
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"testing"
+)
+
+func TestStreamTasksPagesThroughAllTasks(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := userContext("user-123")
+
+	want := make(map[string]bool)
+	for i := 0; i < 2*exportPageSize+17; i++ {
+		task := &entity.Task{Title: fmt.Sprintf("Task %d", i)}
+		if err := api.tasks.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		want[task.ID] = true
+
+		// Архивные, удаленные и чужие задачи в выгрузку не попадают
+		switch i % 50 {
+		case 1:
+			if _, err := api.tasks.ArchiveTask(ctx, task.ID); err != nil {
+				t.Fatalf("archive task: %v", err)
+			}
+			delete(want, task.ID)
+		case 2:
+			if err := api.tasks.DeleteTask(ctx, task.ID); err != nil {
+				t.Fatalf("delete task: %v", err)
+			}
+			delete(want, task.ID)
+		case 3:
+			if err := api.tasks.CreateTask(userContext("user-456"), &entity.Task{Title: "Alice's task"}); err != nil {
+				t.Fatalf("create task of alice: %v", err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	flushes := 0
+	if err := api.StreamTasks(ctx, &buf, ExportNDJSON, func() error { flushes++; return nil }); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	previous := ""
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var task entity.Task
+		if err := json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			t.Fatalf("decode line: %v", err)
+		}
+		if !want[task.ID] || seen[task.ID] || task.ID <= previous {
+			t.Fatalf("unexpected task %s after %s (wanted %v, seen %v)", task.ID, previous, want[task.ID], seen[task.ID])
+		}
+		seen[task.ID], previous = true, task.ID
+	}
+	if len(seen) != len(want) || flushes < 2 {
+		t.Fatalf("streamed %d of %d tasks with %d flushes", len(seen), len(want), flushes)
+	}
+}
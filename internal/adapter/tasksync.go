@@ -0,0 +1,686 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sync"
+	"time"
+)
+
+// Ограничения полей задачи. Источник приводит к ним записи внешней
+// системы, чтобы одна слишком длинная запись не останавливала синхронизацию:
+// ошибка применения изменения не дает сохранить токен, и следующая
+// синхронизация споткнулась бы о ту же запись.
+const (
+	syncTitleLimit       = 100
+	syncDescriptionLimit = 1000
+	syncCommentLimit     = 10000
+	syncTagLimit         = 50
+	syncMaxTags          = 20
+)
+
+// syncFlushBatch число изменений связей, после которого прогон
+// синхронизации записывает их, не дожидаясь конца: при сбое посреди
+// большого прогона теряются не все связи уже созданных записей
+const syncFlushBatch = 100
+
+// ExternalTask запись внешней системы, приведенная к полям задачи. Статус
+// уже переведен источником в статус рабочего процесса.
+type ExternalTask struct {
+	ID          string
+	Title       string
+	Description string
+	// FullDescription полное описание записи, если Description обрезано до
+	// ограничения задачи; синхронизация сохраняет его комментарием к задаче
+	FullDescription string
+	Status          entity.TaskStatus
+	// StatusCategory категория статуса; при выгрузке заполняется по
+	// рабочему процессу пространства
+	StatusCategory entity.StatusCategory
+	DueAt          *time.Time
+	AssigneeID     string // ID нашего пользователя; источник сам сопоставляет учетные записи
+	Tags           []string
+	Deleted        bool // запись удалена во внешней системе
+	UpdatedAt      time.Time
+}
+
+func (t ExternalTask) fields() entity.SyncFields {
+	return entity.SyncFields{
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		DueAt:       t.DueAt,
+		AssigneeID:  t.AssigneeID,
+		Tags:        t.Tags,
+	}
+}
+
+// ExternalTaskSource внешняя система, с которой синхронизируются задачи
+type ExternalTaskSource interface {
+	// Name имя источника; по нему хранятся связи и токены изменений
+	Name() string
+	// Changes возвращает записи, измененные после token, включая удаленные,
+	// и токен для следующего вызова; пустой token означает полную выборку
+	Changes(ctx context.Context, token string) ([]ExternalTask, string, error)
+	// Create создает запись и возвращает её с назначенным ID
+	Create(ctx context.Context, task ExternalTask) (ExternalTask, error)
+	Update(ctx context.Context, task ExternalTask) (ExternalTask, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// LimitedSource источник, который хранит не все синхронизируемые поля
+type LimitedSource interface {
+	// UnsupportedFields имена полей (due_at, assignee_id, tags), которых
+	// нет во внешней системе; их значения при синхронизации берутся у задачи
+	UnsupportedFields() []string
+	// OpenClosedStatus сообщает, что записи источника только открыты или
+	// закрыты. Статус записи тогда совпадает со статусом задачи, если оба
+	// относятся или оба не относятся к категории done, поэтому
+	// промежуточные статусы рабочего процесса не теряются.
+	OpenClosedStatus() bool
+}
+
+// SyncOptions настройки синхронизации
+type SyncOptions struct {
+	// Resolution разрешение конфликтов; по умолчанию last_writer_wins
+	Resolution entity.SyncResolution
+	// PushNew создает во внешней системе записи для задач, которых там нет
+	PushNew bool
+	// Full игнорирует сохраненный токен и сверяет все записи источника
+	Full bool
+}
+
+// SyncCounts число изменений, примененных к одной стороне
+type SyncCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// SyncConflict поле, измененное обеими сторонами, и выбранное значение
+type SyncConflict struct {
+	TaskID     string                `json:"task_id"`
+	ExternalID string                `json:"external_id"`
+	Field      string                `json:"field"`
+	Resolution entity.SyncResolution `json:"resolution"`
+	Winner     string                `json:"winner"` // ours или theirs
+}
+
+// SyncError ошибка синхронизации одной записи
+type SyncError struct {
+	TaskID     string `json:"task_id,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// SyncReport отчет о синхронизации. Local — изменения, внесенные в задачи,
+// Remote — во внешнюю систему.
+type SyncReport struct {
+	Source     string         `json:"source"`
+	Full       bool           `json:"full"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Local      SyncCounts     `json:"local"`
+	Remote     SyncCounts     `json:"remote"`
+	Conflicts  []SyncConflict `json:"conflicts"`
+	Errors     []SyncError    `json:"errors"`
+}
+
+// syncRun состояние одного прогона синхронизации
+type syncRun struct {
+	api         *TaskAPI
+	source      ExternalTaskSource
+	opts        SyncOptions
+	report      *SyncReport
+	userID      string
+	workspaceID string
+	byTask      map[string]*entity.SyncLink
+	byExternal  map[string]*entity.SyncLink
+	// handled задачи, сверенные по изменениям источника
+	handled map[string]bool
+	// unsupported поля, которых нет в источнике
+	unsupported map[string]bool
+	openClosed  bool
+	workflow    *entity.Workflow
+	// pending изменения связей по задачам, еще не записанные в хранилище;
+	// nil означает удаление связи
+	pending map[string]*entity.SyncLink
+}
+
+// SyncWithExternalAPI выполняет двустороннюю синхронизацию задач текущего
+// пространства с внешней системой. Из источника читаются только изменения
+// после токена прошлой синхронизации; поля, измененные одной стороной,
+// переносятся на другую, а измененные обеими — разрешаются по
+// opts.Resolution. Удаление на одной стороне удаляет запись на другой
+// (у нас задача переносится в корзину). Ошибки отдельных записей попадают
+// в отчет; токен сохраняется, только если изменения источника применены
+// без ошибок, иначе они будут прочитаны повторно.
+func (a *TaskAPI) SyncWithExternalAPI(ctx context.Context, source ExternalTaskSource, opts SyncOptions) (report *SyncReport, err error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	switch opts.Resolution {
+	case "":
+		opts.Resolution = entity.SyncLastWriterWins
+	case entity.SyncOurs, entity.SyncTheirs, entity.SyncLastWriterWins:
+	default:
+		return nil, errors.New("validation failed: unknown conflict resolution " + string(opts.Resolution))
+	}
+
+	workspaceID, _ := ctx.Value("workspace_id").(string)
+	if workspaceID == "" {
+		workspaceID = entity.DefaultWorkspaceID
+	}
+
+	// Параллельные прогоны создали бы одни и те же записи дважды
+	unlock := a.lockSync(source.Name(), userID, workspaceID)
+	defer unlock()
+
+	run := &syncRun{
+		api:         a,
+		source:      source,
+		opts:        opts,
+		userID:      userID,
+		workspaceID: workspaceID,
+		byTask:      make(map[string]*entity.SyncLink),
+		byExternal:  make(map[string]*entity.SyncLink),
+		handled:     make(map[string]bool),
+		unsupported: make(map[string]bool),
+		pending:     make(map[string]*entity.SyncLink),
+		report: &SyncReport{
+			Source:    source.Name(),
+			Full:      opts.Full,
+			StartedAt: time.Now(),
+			Conflicts: []SyncConflict{},
+			Errors:    []SyncError{},
+		},
+	}
+
+	if limited, ok := source.(LimitedSource); ok {
+		for _, field := range limited.UnsupportedFields() {
+			run.unsupported[field] = true
+		}
+		run.openClosed = limited.OpenClosedStatus()
+	}
+
+	// Связи записей, созданных до ошибки, сохраняются, иначе следующий
+	// прогон создал бы эти записи заново
+	defer func() {
+		if flushErr := run.flush(ctx); flushErr != nil && err == nil {
+			report, err = nil, flushErr
+		}
+	}()
+
+	workflow, err := a.taskUseCase.GetWorkflow(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	run.workflow = workflow
+
+	token := ""
+	if !opts.Full {
+		cursor, err := a.syncRepo.GetCursor(ctx, source.Name(), userID, workspaceID)
+		if err != nil && err.Error() != "sync cursor not found" {
+			return nil, err
+		}
+		if cursor != nil {
+			token = cursor.Token
+		}
+	}
+	run.report.Full = token == ""
+
+	links, err := a.syncRepo.GetLinks(ctx, source.Name(), userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		run.byTask[link.TaskID] = link
+		run.byExternal[link.ExternalID] = link
+	}
+
+	changes, nextToken, err := source.Changes(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, external := range changes {
+		if err := run.pull(ctx, external); err != nil {
+			run.fail(run.byExternal[external.ID], external.ID, err)
+		}
+	}
+	pullFailed := len(run.report.Errors) > 0
+
+	if err := run.pushChanges(ctx); err != nil {
+		return nil, err
+	}
+
+	// Токен записывается после связей: иначе после сбоя изменения,
+	// связи которых не записаны, больше не были бы прочитаны
+	if err := run.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	if !pullFailed {
+		err := a.syncRepo.SaveCursor(ctx, &entity.SyncCursor{
+			Source:      source.Name(),
+			UserID:      userID,
+			WorkspaceID: workspaceID,
+			Token:       nextToken,
+			SyncedAt:    time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	run.report.FinishedAt = time.Now()
+	return run.report, nil
+}
+
+// pull применяет изменение записи источника
+func (r *syncRun) pull(ctx context.Context, external ExternalTask) error {
+	link := r.byExternal[external.ID]
+	if link == nil {
+		if external.Deleted {
+			return nil
+		}
+		return r.createLocal(ctx, external)
+	}
+
+	r.handled[link.TaskID] = true
+
+	task, err := r.localTask(ctx, link.TaskID)
+	if err != nil {
+		return err
+	}
+	remoteChanged := !external.Deleted && !r.remote(external, link.Base).Equal(link.Base)
+
+	switch {
+	case task == nil && external.Deleted:
+		return r.unlink(ctx, link)
+
+	case task == nil:
+		// Удалена у нас, изменена во внешней системе
+		if remoteChanged && r.conflict(link, "deleted", time.Time{}, external.UpdatedAt) == "theirs" {
+			if err := r.unlink(ctx, link); err != nil {
+				return err
+			}
+			return r.createLocal(ctx, external)
+		}
+		return r.deleteRemote(ctx, link)
+
+	case external.Deleted:
+		// Удалена во внешней системе; если задача с тех пор изменена у нас,
+		// это конфликт
+		localChanged := !entity.SyncFieldsOf(task).Equal(link.Base)
+		if localChanged && r.conflict(link, "deleted", task.UpdatedAt, external.UpdatedAt) == "ours" {
+			if err := r.unlink(ctx, link); err != nil {
+				return err
+			}
+			return r.createRemote(ctx, task)
+		}
+		return r.deleteLocal(ctx, link)
+	}
+
+	return r.merge(ctx, link, task, external)
+}
+
+// pushChanges переносит во внешнюю систему изменения задач, не затронутых
+// изменениями источника, и при PushNew создает записи для новых задач
+func (r *syncRun) pushChanges(ctx context.Context) error {
+	links := make([]*entity.SyncLink, 0, len(r.byTask))
+	for _, link := range r.byTask {
+		if !r.handled[link.TaskID] {
+			links = append(links, link)
+		}
+	}
+
+	for _, link := range links {
+		task, err := r.localTask(ctx, link.TaskID)
+		if err != nil {
+			r.fail(link, link.ExternalID, err)
+			continue
+		}
+
+		switch {
+		case task == nil:
+			err = r.deleteRemote(ctx, link)
+		case !entity.SyncFieldsOf(task).Equal(link.Base):
+			err = r.updateRemote(ctx, link, task, entity.SyncFieldsOf(task))
+		}
+		if err != nil {
+			r.fail(link, link.ExternalID, err)
+		}
+	}
+
+	if !r.opts.PushNew {
+		return nil
+	}
+
+	tasks, err := r.api.taskUseCase.GetAllTasks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if task.WorkspaceID != r.workspaceID || r.byTask[task.ID] != nil {
+			continue
+		}
+		if err := r.createRemote(ctx, task); err != nil {
+			r.report.Errors = append(r.report.Errors, SyncError{TaskID: task.ID, Error: err.Error()})
+		}
+	}
+
+	return nil
+}
+
+// merge сводит изменения полей задачи и записи источника относительно
+// состояния прошлой синхронизации и записывает результат на обе стороны
+func (r *syncRun) merge(ctx context.Context, link *entity.SyncLink, task *entity.Task, external ExternalTask) error {
+	base, ours, theirs := link.Base, entity.SyncFieldsOf(task), r.remote(external, link.Base)
+	merged := ours
+
+	pick := func(field string, oursEqual, theirsEqual, sidesEqual bool) string {
+		switch {
+		case sidesEqual || theirsEqual:
+			return "ours"
+		case oursEqual:
+			return "theirs"
+		}
+		return r.conflict(link, field, task.UpdatedAt, external.UpdatedAt)
+	}
+
+	if pick("title", ours.Title == base.Title, theirs.Title == base.Title, ours.Title == theirs.Title) == "theirs" {
+		merged.Title = theirs.Title
+	}
+	fullDescription := false
+	if pick("description", ours.Description == base.Description, theirs.Description == base.Description, ours.Description == theirs.Description) == "theirs" {
+		merged.Description = theirs.Description
+		fullDescription = true
+	}
+	if pick("status", ours.Status == base.Status, theirs.Status == base.Status, ours.Status == theirs.Status) == "theirs" {
+		merged.Status = theirs.Status
+	}
+	if pick("due_at", sameDue(ours.DueAt, base.DueAt), sameDue(theirs.DueAt, base.DueAt), sameDue(ours.DueAt, theirs.DueAt)) == "theirs" {
+		merged.DueAt = theirs.DueAt
+	}
+	if pick("assignee_id", ours.AssigneeID == base.AssigneeID, theirs.AssigneeID == base.AssigneeID, ours.AssigneeID == theirs.AssigneeID) == "theirs" {
+		merged.AssigneeID = theirs.AssigneeID
+	}
+	if pick("tags", entity.SameTags(ours.Tags, base.Tags), entity.SameTags(theirs.Tags, base.Tags), entity.SameTags(ours.Tags, theirs.Tags)) == "theirs" {
+		merged.Tags = theirs.Tags
+	}
+
+	if !merged.Equal(ours) {
+		merged.Apply(task)
+		if err := r.api.taskUseCase.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+		r.report.Local.Updated++
+
+		if fullDescription {
+			if err := r.keepFullDescription(ctx, task.ID, external); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !merged.Equal(r.remote(external, merged)) {
+		return r.updateRemote(ctx, link, task, merged)
+	}
+
+	return r.saveLink(ctx, link.TaskID, link.ExternalID, merged)
+}
+
+// conflict выбирает сторону по настройке и записывает конфликт в отчет.
+// Нулевое время стороны означает, что время её изменения неизвестно.
+func (r *syncRun) conflict(link *entity.SyncLink, field string, oursAt, theirsAt time.Time) string {
+	winner := "ours"
+	switch r.opts.Resolution {
+	case entity.SyncTheirs:
+		winner = "theirs"
+	case entity.SyncLastWriterWins:
+		if theirsAt.After(oursAt) {
+			winner = "theirs"
+		}
+	}
+
+	r.report.Conflicts = append(r.report.Conflicts, SyncConflict{
+		TaskID:     link.TaskID,
+		ExternalID: link.ExternalID,
+		Field:      field,
+		Resolution: r.opts.Resolution,
+		Winner:     winner,
+	})
+	return winner
+}
+
+func (r *syncRun) createLocal(ctx context.Context, external ExternalTask) error {
+	task := &entity.Task{}
+	r.remote(external, entity.SyncFields{}).Apply(task)
+	if err := r.api.taskUseCase.CreateTask(ctx, task); err != nil {
+		return err
+	}
+	r.report.Local.Created++
+
+	if err := r.keepFullDescription(ctx, task.ID, external); err != nil {
+		return err
+	}
+
+	// Статус по умолчанию мог быть выбран рабочим процессом, поэтому
+	// общим состоянием считаются поля созданной задачи
+	if fields := entity.SyncFieldsOf(task); !fields.Equal(r.remote(external, fields)) {
+		if _, err := r.source.Update(ctx, r.external(external.ID, fields)); err != nil {
+			return err
+		}
+		r.report.Remote.Updated++
+	}
+
+	r.handled[task.ID] = true
+	return r.saveLink(ctx, task.ID, external.ID, entity.SyncFieldsOf(task))
+}
+
+// keepFullDescription сохраняет комментарием полное описание записи,
+// обрезанное до ограничения задачи
+func (r *syncRun) keepFullDescription(ctx context.Context, taskID string, external ExternalTask) error {
+	if external.FullDescription == "" {
+		return nil
+	}
+
+	return r.api.commentUseCase.AddComment(ctx, &entity.Comment{
+		TaskID: taskID,
+		Body:   entity.TruncateText("Full description from "+r.source.Name()+":\n\n"+external.FullDescription, syncCommentLimit),
+	})
+}
+
+func (r *syncRun) createRemote(ctx context.Context, task *entity.Task) error {
+	fields := entity.SyncFieldsOf(task)
+	created, err := r.source.Create(ctx, r.external("", fields))
+	if err != nil {
+		return err
+	}
+	r.report.Remote.Created++
+
+	r.handled[task.ID] = true
+	return r.saveLink(ctx, task.ID, created.ID, fields)
+}
+
+func (r *syncRun) updateRemote(ctx context.Context, link *entity.SyncLink, task *entity.Task, fields entity.SyncFields) error {
+	_, err := r.source.Update(ctx, r.external(link.ExternalID, fields))
+	if err != nil {
+		return err
+	}
+	r.report.Remote.Updated++
+
+	return r.saveLink(ctx, task.ID, link.ExternalID, fields)
+}
+
+func (r *syncRun) deleteLocal(ctx context.Context, link *entity.SyncLink) error {
+	if err := r.api.taskUseCase.DeleteTask(ctx, link.TaskID); err != nil && err.Error() != "task not found" {
+		return err
+	}
+	r.report.Local.Deleted++
+
+	return r.unlink(ctx, link)
+}
+
+func (r *syncRun) deleteRemote(ctx context.Context, link *entity.SyncLink) error {
+	if err := r.source.Delete(ctx, link.ExternalID); err != nil {
+		return err
+	}
+	r.report.Remote.Deleted++
+
+	return r.unlink(ctx, link)
+}
+
+// remote возвращает поля записи источника; поля, которых в источнике нет,
+// берутся из fill
+func (r *syncRun) remote(external ExternalTask, fill entity.SyncFields) entity.SyncFields {
+	fields := external.fields()
+	if r.unsupported["due_at"] {
+		fields.DueAt = fill.DueAt
+	}
+	if r.unsupported["assignee_id"] {
+		fields.AssigneeID = fill.AssigneeID
+	}
+	if r.unsupported["tags"] {
+		fields.Tags = fill.Tags
+	}
+	if r.openClosed && fill.Status != "" && r.done(fields.Status) == r.done(fill.Status) {
+		fields.Status = fill.Status
+	}
+	return fields
+}
+
+// external возвращает запись источника с полями fields
+func (r *syncRun) external(id string, fields entity.SyncFields) ExternalTask {
+	external := ExternalTask{
+		ID:          id,
+		Title:       fields.Title,
+		Description: fields.Description,
+		Status:      fields.Status,
+		DueAt:       fields.DueAt,
+		AssigneeID:  fields.AssigneeID,
+		Tags:        fields.Tags,
+	}
+	if status, ok := r.workflow.Status(fields.Status); ok {
+		external.StatusCategory = status.Category
+	}
+	return external
+}
+
+func (r *syncRun) done(key entity.TaskStatus) bool {
+	status, ok := r.workflow.Status(key)
+	return ok && status.Category == entity.CategoryDone
+}
+
+func (r *syncRun) saveLink(ctx context.Context, taskID, externalID string, base entity.SyncFields) error {
+	link := &entity.SyncLink{
+		Source:      r.source.Name(),
+		UserID:      r.userID,
+		WorkspaceID: r.workspaceID,
+		TaskID:      taskID,
+		ExternalID:  externalID,
+		Base:        base,
+		SyncedAt:    time.Now(),
+	}
+	r.byTask[taskID] = link
+	r.byExternal[externalID] = link
+	r.pending[taskID] = link
+
+	if len(r.pending) >= syncFlushBatch {
+		return r.flush(ctx)
+	}
+	return nil
+}
+
+func (r *syncRun) unlink(ctx context.Context, link *entity.SyncLink) error {
+	delete(r.byTask, link.TaskID)
+	delete(r.byExternal, link.ExternalID)
+	r.pending[link.TaskID] = nil
+	return nil
+}
+
+// flush записывает накопленные изменения связей одной записью
+func (r *syncRun) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	var links []*entity.SyncLink
+	var removed []string
+	for taskID, link := range r.pending {
+		if link == nil {
+			removed = append(removed, taskID)
+		} else {
+			links = append(links, link)
+		}
+	}
+
+	if err := r.api.syncRepo.SaveLinks(ctx, r.source.Name(), links, removed); err != nil {
+		return err
+	}
+
+	clear(r.pending)
+	return nil
+}
+
+// lockSync блокирует прогоны синхронизации пользователя с источником в
+// пространстве и возвращает функцию снятия блокировки. Прогоны других
+// пользователей и источников не ждут медленный внешний сервис.
+func (a *TaskAPI) lockSync(source, userID, workspaceID string) func() {
+	key := source + "/" + workspaceID + "/" + userID
+
+	a.syncMutex.Lock()
+	lock, exists := a.syncLocks[key]
+	if !exists {
+		lock = &sync.Mutex{}
+		a.syncLocks[key] = lock
+	}
+	a.syncMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// localTask возвращает задачу или nil, если она удалена или в корзине
+func (r *syncRun) localTask(ctx context.Context, id string) (*entity.Task, error) {
+	task, err := r.api.taskUseCase.GetTask(ctx, id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *syncRun) fail(link *entity.SyncLink, externalID string, err error) {
+	syncErr := SyncError{ExternalID: externalID, Error: err.Error()}
+	if link != nil {
+		syncErr.TaskID = link.TaskID
+	}
+	r.report.Errors = append(r.report.Errors, syncErr)
+}
+
+// syncTags приводит метки записи к ограничениям меток задачи: лишние
+// метки отбрасываются, длинные обрезаются
+func syncTags(labels []string) []string {
+	var tags []string
+	for _, tag := range entity.NormalizeTags(labels) {
+		if len(tags) == syncMaxTags {
+			break
+		}
+		tags = append(tags, entity.TruncateText(tag, syncTagLimit))
+	}
+	return entity.NormalizeTags(tags)
+}
+
+func sameDue(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,256 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySource внешняя система в памяти. Токен изменений — номер версии:
+// Changes возвращает записи, измененные после неё. Если задан blocked,
+// Changes сообщает в entered и ждет закрытия blocked.
+type memorySource struct {
+	mutex   sync.Mutex
+	records map[string]ExternalTask
+	changed map[string]int
+	version int
+
+	entered chan struct{}
+	blocked chan struct{}
+}
+
+func newMemorySource() *memorySource {
+	return &memorySource{records: make(map[string]ExternalTask), changed: make(map[string]int)}
+}
+
+func (s *memorySource) Name() string { return "memory" }
+
+func (s *memorySource) Changes(ctx context.Context, token string) ([]ExternalTask, string, error) {
+	if s.blocked != nil {
+		s.entered <- struct{}{}
+		<-s.blocked
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	after, _ := strconv.Atoi(token)
+	var changes []ExternalTask
+	for id, version := range s.changed {
+		if version > after {
+			changes = append(changes, s.records[id])
+		}
+	}
+	return changes, strconv.Itoa(s.version), nil
+}
+
+func (s *memorySource) Create(ctx context.Context, task ExternalTask) (ExternalTask, error) {
+	s.mutex.Lock()
+	task.ID = fmt.Sprintf("ext-%d", len(s.records)+1)
+	s.mutex.Unlock()
+
+	return s.put(task), nil
+}
+
+func (s *memorySource) Update(ctx context.Context, task ExternalTask) (ExternalTask, error) {
+	return s.put(task), nil
+}
+
+func (s *memorySource) Delete(ctx context.Context, id string) error {
+	task := s.get(id)
+	task.Deleted = true
+	s.put(task)
+	return nil
+}
+
+// put сохраняет запись как измененную сейчас, если время изменения не задано
+func (s *memorySource) put(task ExternalTask) ExternalTask {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = time.Now()
+	}
+	s.version++
+	s.records[task.ID] = task
+	s.changed[task.ID] = s.version
+	return task
+}
+
+func (s *memorySource) get(id string) ExternalTask {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.records[id]
+}
+
+// syncedTask синхронизирует запись source с новой задачей и возвращает задачу
+func syncedTask(t *testing.T, api *testAPI, ctx context.Context, source *memorySource, external ExternalTask) *entity.Task {
+	t.Helper()
+
+	source.put(external)
+	if _, err := api.SyncWithExternalAPI(ctx, source, SyncOptions{}); err != nil {
+		t.Fatalf("initial sync: %v", err)
+	}
+
+	links, err := api.syncRepo.GetLinks(ctx, source.Name(), "user-123", entity.DefaultWorkspaceID)
+	if err != nil || len(links) != 1 || links[0].ExternalID != external.ID {
+		t.Fatalf("links after initial sync = %+v, %v", links, err)
+	}
+	task, err := api.tasks.GetTask(ctx, links[0].TaskID)
+	if err != nil {
+		t.Fatalf("get synced task: %v", err)
+	}
+	return task
+}
+
+func TestSyncMergesChangesOfBothSides(t *testing.T) {
+	api := newTestAPI(t)
+	source := newMemorySource()
+	ctx := userContext("user-123")
+
+	task := syncedTask(t, api, ctx, source, ExternalTask{ID: "ext-1", Title: "Write docs", Status: entity.StatusTodo})
+
+	// У нас изменено название, во внешней системе — описание и метки
+	task.Title = "Write user docs"
+	if err := api.tasks.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	external := source.get("ext-1")
+	external.Description, external.Tags, external.UpdatedAt = "Cover the sync API", []string{"docs"}, time.Time{}
+	source.put(external)
+
+	report, err := api.SyncWithExternalAPI(ctx, source, SyncOptions{})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(report.Conflicts) != 0 || len(report.Errors) != 0 || report.Local.Updated != 1 || report.Remote.Updated != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	local, err := api.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	remote := source.get("ext-1")
+	for side, fields := range map[string]entity.SyncFields{"local": entity.SyncFieldsOf(local), "remote": remote.fields()} {
+		if fields.Title != "Write user docs" || fields.Description != "Cover the sync API" || !entity.SameTags(fields.Tags, []string{"docs"}) {
+			t.Fatalf("%s after merge = %+v", side, fields)
+		}
+	}
+
+	// Сведенное состояние становится общим: повторный прогон ничего не меняет
+	report, err = api.SyncWithExternalAPI(ctx, source, SyncOptions{})
+	if err != nil || report.Local != (SyncCounts{}) || report.Remote != (SyncCounts{}) {
+		t.Fatalf("second sync = %+v, %v", report, err)
+	}
+}
+
+func TestSyncResolvesConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		resolution entity.SyncResolution
+		remoteAt   time.Duration // время изменения записи относительно задачи
+		winner     string
+	}{
+		{entity.SyncOurs, time.Hour, "ours"},
+		{entity.SyncTheirs, -time.Hour, "theirs"},
+		{entity.SyncLastWriterWins, time.Hour, "theirs"},
+		{entity.SyncLastWriterWins, -time.Hour, "ours"},
+	} {
+		t.Run(fmt.Sprintf("%s/%v", tc.resolution, tc.remoteAt), func(t *testing.T) {
+			api := newTestAPI(t)
+			source := newMemorySource()
+			ctx := userContext("user-123")
+
+			task := syncedTask(t, api, ctx, source, ExternalTask{ID: "ext-1", Title: "Plan sprint", Status: entity.StatusTodo})
+
+			task.Title = "Plan sprint 12"
+			if err := api.tasks.UpdateTask(ctx, task); err != nil {
+				t.Fatalf("update task: %v", err)
+			}
+			external := source.get("ext-1")
+			external.Title, external.UpdatedAt = "Plan next sprint", task.UpdatedAt.Add(tc.remoteAt)
+			source.put(external)
+
+			report, err := api.SyncWithExternalAPI(ctx, source, SyncOptions{Resolution: tc.resolution})
+			if err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			if len(report.Conflicts) != 1 || report.Conflicts[0].Field != "title" || report.Conflicts[0].Winner != tc.winner {
+				t.Fatalf("conflicts = %+v, want title won by %s", report.Conflicts, tc.winner)
+			}
+
+			want := "Plan sprint 12"
+			if tc.winner == "theirs" {
+				want = "Plan next sprint"
+			}
+			local, err := api.tasks.GetTask(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("get task: %v", err)
+			}
+			if local.Title != want || source.get("ext-1").Title != want {
+				t.Fatalf("titles after sync: local %q, remote %q; want %q", local.Title, source.get("ext-1").Title, want)
+			}
+		})
+	}
+}
+
+func TestSyncPropagatesDeletion(t *testing.T) {
+	api := newTestAPI(t)
+	source := newMemorySource()
+	ctx := userContext("user-123")
+
+	// Удаление во внешней системе переносит задачу в корзину
+	task := syncedTask(t, api, ctx, source, ExternalTask{ID: "ext-1", Title: "Old idea", Status: entity.StatusTodo})
+	if err := source.Delete(ctx, "ext-1"); err != nil {
+		t.Fatalf("delete record: %v", err)
+	}
+	report, err := api.SyncWithExternalAPI(ctx, source, SyncOptions{})
+	if err != nil || report.Local.Deleted != 1 {
+		t.Fatalf("sync after remote deletion = %+v, %v", report, err)
+	}
+	if _, err := api.tasks.GetTask(ctx, task.ID); err == nil {
+		t.Fatal("task is not deleted after its record was deleted")
+	}
+
+	// Удаление задачи удаляет запись во внешней системе
+	task = syncedTask(t, api, ctx, source, ExternalTask{ID: "ext-2", Title: "Another idea", Status: entity.StatusTodo})
+	if err := api.tasks.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	report, err = api.SyncWithExternalAPI(ctx, source, SyncOptions{})
+	if err != nil || report.Remote.Deleted != 1 || !source.get("ext-2").Deleted {
+		t.Fatalf("sync after local deletion = %+v, %v", report, err)
+	}
+
+	if links, err := api.syncRepo.GetLinks(ctx, source.Name(), "user-123", entity.DefaultWorkspaceID); err != nil || len(links) != 0 {
+		t.Fatalf("links after deletions = %+v, %v", links, err)
+	}
+}
+
+func TestSyncDoesNotWaitForOtherUsers(t *testing.T) {
+	api := newTestAPI(t)
+
+	slow := newMemorySource()
+	slow.entered, slow.blocked = make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := api.SyncWithExternalAPI(userContext("user-123"), slow, SyncOptions{})
+		done <- err
+	}()
+	<-slow.entered
+
+	// Пока источник отвечает первому пользователю, второй синхронизируется
+	if _, err := api.SyncWithExternalAPI(userContext("user-456"), newMemorySource(), SyncOptions{}); err != nil {
+		t.Fatalf("sync of another user: %v", err)
+	}
+
+	close(slow.blocked)
+	if err := <-done; err != nil {
+		t.Fatalf("slow sync: %v", err)
+	}
+}
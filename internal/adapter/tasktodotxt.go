@@ -0,0 +1,394 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/todotxt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoTxtPriorityTag префикс метки, в которой хранится приоритет todo.txt:
+// задача с меткой pri:A выгружается как (A)
+const todoTxtPriorityTag = "pri:"
+
+// todoTxtDueLayout формат срока со временем; значение расширения не может
+// содержать двоеточие, поэтому время пишется без разделителей. Срок в
+// полночь UTC пишется одной датой.
+const todoTxtDueLayout = "2006-01-02T150405Z"
+
+// Расширения todo.txt, которые переносятся в поля задачи. Остальные
+// расширения остаются в названии задачи как есть.
+const (
+	todoTxtID       = "id"
+	todoTxtStatus   = "status"
+	todoTxtDue      = "due"
+	todoTxtAssignee = "assignee"
+	todoTxtParent   = "parent"
+)
+
+// TodoTxtImportLine результат импорта одной строки; Line — номер строки файла
+type TodoTxtImportLine struct {
+	Line    int          `json:"line"`
+	Task    *entity.Task `json:"task,omitempty"`
+	Updated bool         `json:"updated,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// TodoTxtImportReport отчет об импорте todo.txt
+type TodoTxtImportReport struct {
+	Total    int                 `json:"total"`
+	Created  int                 `json:"created"`
+	Updated  int                 `json:"updated"`
+	Failed   int                 `json:"failed"`
+	Projects []string            `json:"created_projects,omitempty"`
+	Lines    []TodoTxtImportLine `json:"lines"`
+}
+
+// todoTxtCodec переводит задачи в строки todo.txt и обратно для текущего
+// пользователя и пространства
+type todoTxtCodec struct {
+	api      *TaskAPI
+	workflow *entity.Workflow
+	// doneStatus статус для выполненной задачи без status:
+	doneStatus entity.TaskStatus
+	// projectNames имена проектов пользователя по ID для +проектов
+	projectNames map[string]string
+	// projects проекты текущего пространства по токену имени
+	projects map[string]*entity.Project
+	// lastRanks последний ранг проекта, чтобы задачи вставали в конец
+	lastRanks map[string]string
+}
+
+func (a *TaskAPI) newTodoTxtCodec(ctx context.Context) (*todoTxtCodec, error) {
+	workspaceID, _ := ctx.Value("workspace_id").(string)
+	if workspaceID == "" {
+		workspaceID = entity.DefaultWorkspaceID
+	}
+
+	workflow, err := a.taskUseCase.GetWorkflow(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := a.projectUseCase.GetAllProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := &todoTxtCodec{
+		api:          a,
+		workflow:     workflow,
+		projectNames: make(map[string]string),
+		projects:     make(map[string]*entity.Project),
+		lastRanks:    make(map[string]string),
+	}
+
+	for _, status := range workflow.Statuses {
+		if status.Category == entity.CategoryDone {
+			codec.doneStatus = status.Key
+			break
+		}
+	}
+
+	for _, project := range projects {
+		codec.projectNames[project.ID] = project.Name
+		if project.WorkspaceID == workspaceID {
+			codec.projects[strings.ToLower(todotxt.Token(project.Name))] = project
+		}
+	}
+
+	return codec, nil
+}
+
+// format переводит задачу в строку todo.txt. Описание, чек-лист и
+// повторение в формат не попадают; статус пишется расширением status:,
+// только если его нельзя восстановить по отметке выполнения.
+func (c *todoTxtCodec) format(task *entity.Task) *todotxt.Task {
+	line := &todotxt.Task{
+		Done: task.IsDone(),
+		Text: task.Title,
+	}
+
+	createdAt := task.CreatedAt.UTC()
+	line.CreatedAt = &createdAt
+	if line.Done && task.CompletedAt != nil {
+		completedAt := task.CompletedAt.UTC()
+		line.CompletedAt = &completedAt
+	}
+
+	if name, ok := c.projectNames[task.ProjectID]; ok {
+		line.Projects = append(line.Projects, todotxt.Token(name))
+	}
+
+	for _, tag := range task.Tags {
+		if priority, ok := todoTxtPriority(tag); ok && line.Priority == "" {
+			line.Priority = priority
+			continue
+		}
+		line.Contexts = append(line.Contexts, tag)
+	}
+
+	if task.DueAt != nil {
+		due := task.DueAt.UTC()
+		layout := todoTxtDueLayout
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			layout = todotxt.DateLayout
+		}
+		line.Extensions = append(line.Extensions, todotxt.Extension{Key: todoTxtDue, Value: due.Format(layout)})
+	}
+	if task.Status != c.defaultStatus(line.Done) {
+		line.Extensions = append(line.Extensions, todotxt.Extension{Key: todoTxtStatus, Value: string(task.Status)})
+	}
+	if task.AssigneeID != "" {
+		line.Extensions = append(line.Extensions, todotxt.Extension{Key: todoTxtAssignee, Value: task.AssigneeID})
+	}
+	if task.ParentID != "" {
+		line.Extensions = append(line.Extensions, todotxt.Extension{Key: todoTxtParent, Value: task.ParentID})
+	}
+	line.Extensions = append(line.Extensions, todotxt.Extension{Key: todoTxtID, Value: task.ID})
+
+	return line
+}
+
+func (c *todoTxtCodec) defaultStatus(done bool) entity.TaskStatus {
+	if done {
+		return c.doneStatus
+	}
+	return c.workflow.InitialStatus
+}
+
+// ImportTasksFromTodoTxt создает и изменяет задачи по строкам todo.txt.
+// Строка с id: существующей задачи пользователя изменяет её (описание,
+// чек-лист и повторение сохраняются), остальные строки создают задачи;
+// parent: может ссылаться на id: строки выше в том же файле. Последний
+// +проект выбирает проект пространства по имени (пробелы в имени — это
+// подчеркивания), неизвестный проект создается: экспорт пишет проект
+// задачи после её названия. @контексты становятся
+// метками, приоритет — меткой pri:A. Ошибка в строке не прерывает импорт.
+// Файл, выгруженный экспортом, загружается без изменений задач: слова
+// названия, похожие на разметку, экспорт экранирует.
+func (a *TaskAPI) ImportTasksFromTodoTxt(ctx context.Context, r io.Reader) (*TodoTxtImportReport, error) {
+	codec, err := a.newTodoTxtCodec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TodoTxtImportReport{Lines: []TodoTxtImportLine{}}
+
+	// id: из файла -> ID созданной задачи
+	imported := make(map[string]string)
+
+	reader := todotxt.NewReader(r)
+	for {
+		line, number, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, errors.New("validation failed: line is too long")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		report.Total++
+
+		task, updated, err := codec.importLine(ctx, line, imported, report)
+		if err != nil {
+			report.Failed++
+			report.Lines = append(report.Lines, TodoTxtImportLine{Line: number, Error: err.Error()})
+			continue
+		}
+
+		if updated {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		report.Lines = append(report.Lines, TodoTxtImportLine{Line: number, Task: task, Updated: updated})
+	}
+
+	return report, nil
+}
+
+// importLine создает или изменяет задачу по строке; возвращает задачу и
+// признак того, что она уже существовала
+func (c *todoTxtCodec) importLine(ctx context.Context, line *todotxt.Task, imported map[string]string, report *TodoTxtImportReport) (*entity.Task, bool, error) {
+	var existing *entity.Task
+	fileID, hasID := line.Extension(todoTxtID)
+	if hasID {
+		task, err := c.api.taskUseCase.GetTask(ctx, fileID)
+		switch {
+		case err == nil:
+			existing = task
+		case err.Error() != "task not found":
+			return nil, false, err
+		}
+	}
+
+	task := &entity.Task{}
+	if existing != nil {
+		copied := *existing
+		task = &copied
+	}
+
+	// Расширения, которые не переносятся в поля, и лишние проекты
+	// остаются в названии, чтобы вернуться при следующем экспорте
+	words := []string{line.Text}
+	for i, project := range line.Projects {
+		if i < len(line.Projects)-1 {
+			words = append(words, "+"+project)
+		}
+	}
+	task.DueAt, task.AssigneeID, task.ParentID, task.Status = nil, "", "", ""
+	for _, ext := range line.Extensions {
+		switch ext.Key {
+		case todoTxtID:
+		case todoTxtStatus:
+			task.Status = entity.TaskStatus(ext.Value)
+		case todoTxtDue:
+			due, err := time.Parse(todotxt.DateLayout, ext.Value)
+			if err != nil {
+				due, err = time.Parse(todoTxtDueLayout, ext.Value)
+			}
+			if err != nil {
+				return nil, false, errors.New("validation failed: invalid due " + strconv.Quote(ext.Value))
+			}
+			task.DueAt = &due
+		case todoTxtAssignee:
+			task.AssigneeID = ext.Value
+		case todoTxtParent:
+			task.ParentID = ext.Value
+			if newID, ok := imported[ext.Value]; ok {
+				task.ParentID = newID
+			}
+		default:
+			words = append(words, ext.Key+":"+ext.Value)
+		}
+	}
+	task.Title = strings.TrimSpace(strings.Join(words, " "))
+
+	tags := append([]string(nil), line.Contexts...)
+	if line.Priority != "" {
+		tags = append(tags, todoTxtPriorityTag+line.Priority)
+	}
+	task.Tags = entity.NormalizeTags(tags)
+	// Приоритет пишется отдельно от меток, поэтому порядок меток в строке
+	// может отличаться: тот же набор меток задачу не меняет
+	if existing != nil && entity.SameTags(task.Tags, existing.Tags) {
+		task.Tags = existing.Tags
+	}
+
+	// Без status: статус задачи сохраняется, пока с ним согласна отметка
+	// выполнения
+	if task.Status == "" {
+		task.Status = c.defaultStatus(line.Done)
+		if existing != nil && existing.IsDone() == line.Done {
+			task.Status = existing.Status
+		}
+	}
+
+	projectID := ""
+	if len(line.Projects) > 0 {
+		project, err := c.project(ctx, line.Projects[len(line.Projects)-1], report)
+		if err != nil {
+			return nil, false, err
+		}
+		projectID = project.ID
+	}
+	if existing == nil || projectID != existing.ProjectID {
+		task.ProjectID, task.Rank = projectID, ""
+		if projectID != "" {
+			rank, err := c.nextRank(ctx, projectID)
+			if err != nil {
+				return nil, false, err
+			}
+			task.Rank = rank
+		}
+	}
+
+	if existing != nil {
+		if err := c.api.taskUseCase.UpdateTask(ctx, task); err != nil {
+			return nil, false, err
+		}
+		return task, true, nil
+	}
+
+	// Новая задача проверяется как обычная, а даты из файла сохраняются
+	// при записи
+	if err := c.api.taskUseCase.ValidateNewTask(ctx, task); err != nil {
+		return nil, false, err
+	}
+	if line.CreatedAt != nil {
+		task.CreatedAt = *line.CreatedAt
+	}
+	if line.CompletedAt != nil && task.IsDone() {
+		task.CompletedAt = line.CompletedAt
+	}
+	if err := c.api.taskUseCase.ImportTask(ctx, task); err != nil {
+		return nil, false, err
+	}
+
+	if hasID {
+		imported[fileID] = task.ID
+	}
+
+	return task, false, nil
+}
+
+// project находит проект пространства по имени или создает его; пробелы
+// и подчеркивания в имени при поиске не различаются
+func (c *todoTxtCodec) project(ctx context.Context, name string, report *TodoTxtImportReport) (*entity.Project, error) {
+	token := strings.ToLower(todotxt.Token(name))
+	if project, ok := c.projects[token]; ok {
+		return project, nil
+	}
+
+	project := &entity.Project{Name: name}
+	if errMsgs := project.Validate(); len(errMsgs) > 0 {
+		return nil, errors.New("validation failed: " + errMsgs[0])
+	}
+	if err := c.api.projectUseCase.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+
+	c.projects[token] = project
+	c.projectNames[project.ID] = project.Name
+	report.Projects = append(report.Projects, project.Name)
+	return project, nil
+}
+
+// nextRank возвращает ранг после всех задач проекта
+func (c *todoTxtCodec) nextRank(ctx context.Context, projectID string) (string, error) {
+	last, ok := c.lastRanks[projectID]
+	if !ok {
+		tasks, err := c.api.projectUseCase.GetProjectTasks(ctx, projectID)
+		if err != nil {
+			return "", err
+		}
+		for _, task := range tasks {
+			last = max(last, task.Rank)
+		}
+	}
+
+	rank, err := entity.RankBetween(last, "")
+	if err != nil {
+		return "", err
+	}
+	c.lastRanks[projectID] = rank
+	return rank, nil
+}
+
+// todoTxtPriority выделяет букву приоритета из метки pri:A
+func todoTxtPriority(tag string) (string, bool) {
+	priority, ok := strings.CutPrefix(tag, todoTxtPriorityTag)
+	if !ok || len(priority) != 1 || priority[0] < 'A' || priority[0] > 'Z' {
+		return "", false
+	}
+	return priority, true
+}
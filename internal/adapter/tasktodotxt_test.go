@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// createTodoTxtTasks создает задачи, в названиях и метках которых есть
+// все, что todo.txt понял бы как разметку
+func createTodoTxtTasks(t *testing.T, api *testAPI, ctx context.Context) {
+	t.Helper()
+
+	project := &entity.Project{Name: "Side Project"}
+	if err := api.projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	dueAt := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	dueDate := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	parent := &entity.Task{
+		Title:     "Deploy +prod with @ops at 10:30 key:value",
+		Tags:      []string{"home office", "pri:A", "100%"},
+		DueAt:     &dueAt,
+		ProjectID: project.ID,
+	}
+	for _, task := range []*entity.Task{
+		parent,
+		{Title: "x marks the spot", DueAt: &dueDate, AssigneeID: "user-456"},
+		{Title: `2026-01-01 \ review`, Status: entity.StatusInProgress},
+	} {
+		if err := api.tasks.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create task %q: %v", task.Title, err)
+		}
+	}
+
+	child := &entity.Task{Title: "Write notes", ParentID: parent.ID, Status: entity.StatusDone}
+	if err := api.tasks.CreateTask(ctx, child); err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+}
+
+func exportTodoTxt(t *testing.T, api *testAPI, ctx context.Context) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := api.StreamTasks(ctx, &buf, ExportTodoTxt, func() error { return nil }); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// todoTxtFields поля задачи, которые переносит todo.txt
+type todoTxtFields struct {
+	Title      string
+	Tags       []string
+	Status     entity.TaskStatus
+	DueAt      string
+	AssigneeID string
+	ParentID   string
+	ProjectID  string
+}
+
+func todoTxtSnapshot(t *testing.T, api *testAPI, ctx context.Context) map[string]todoTxtFields {
+	t.Helper()
+
+	tasks, err := api.tasks.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("get tasks: %v", err)
+	}
+
+	snapshot := make(map[string]todoTxtFields)
+	for _, task := range tasks {
+		fields := todoTxtFields{
+			Title:      task.Title,
+			Tags:       task.Tags,
+			Status:     task.Status,
+			AssigneeID: task.AssigneeID,
+			ParentID:   task.ParentID,
+			ProjectID:  task.ProjectID,
+		}
+		if task.DueAt != nil {
+			fields.DueAt = task.DueAt.UTC().Format(time.RFC3339)
+		}
+		snapshot[task.ID] = fields
+	}
+	return snapshot
+}
+
+func TestTodoTxtRoundTripKeepsTasks(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := userContext("user-123")
+	createTodoTxtTasks(t, api, ctx)
+
+	before := todoTxtSnapshot(t, api, ctx)
+	exported := exportTodoTxt(t, api, ctx)
+
+	report, err := api.ImportTasksFromTodoTxt(ctx, bytes.NewReader(exported))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Updated != len(before) || report.Created != 0 || report.Failed != 0 || len(report.Projects) != 0 {
+		t.Fatalf("report = %+v, want every task updated in place", report)
+	}
+
+	if after := todoTxtSnapshot(t, api, ctx); !reflect.DeepEqual(after, before) {
+		t.Fatalf("tasks changed by export and import:\nbefore %+v\nafter  %+v\nfile:\n%s", before, after, exported)
+	}
+	if again := exportTodoTxt(t, api, ctx); !bytes.Equal(again, exported) {
+		t.Fatalf("second export differs:\n%s\nfirst:\n%s", again, exported)
+	}
+}
+
+func TestTodoTxtImportIntoEmptyAccount(t *testing.T) {
+	source := newTestAPI(t)
+	ctx := userContext("user-123")
+	createTodoTxtTasks(t, source, ctx)
+	exported := exportTodoTxt(t, source, ctx)
+
+	target := newTestAPI(t)
+	report, err := target.ImportTasksFromTodoTxt(ctx, bytes.NewReader(exported))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Created != 4 || report.Failed != 0 || !reflect.DeepEqual(report.Projects, []string{"Side_Project"}) {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// Задачи совпадают по полям, кроме ID и ссылок на них
+	strip := func(snapshot map[string]todoTxtFields) []string {
+		var titles []string
+		for _, fields := range snapshot {
+			titles = append(titles, fields.Title+" "+fields.DueAt+" "+string(fields.Status))
+		}
+		sort.Strings(titles)
+		return titles
+	}
+	if got, want := strip(todoTxtSnapshot(t, target, ctx)), strip(todoTxtSnapshot(t, source, ctx)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("imported %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+)
+
+// Attachment файл, прикрепленный к задаче. Содержимое хранится в blob-хранилище
+// под ключом Hash (SHA-256), поэтому одинаковые файлы хранятся один раз.
+type Attachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	UploaderID  string    `json:"uploader_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Hash        string    `json:"hash"`
+	CreatedAt   time.Time `json:"created_at"`
+}
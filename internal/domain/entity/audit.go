@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// AuditAction действие над задачей, записанное в журнал
+type AuditAction string
+
+const (
+	AuditCreated    AuditAction = "created"
+	AuditUpdated    AuditAction = "updated"
+	AuditRestored   AuditAction = "restored" // изменение, вернувшее задачу к прошлой ревизии
+	AuditArchived   AuditAction = "archived"
+	AuditUnarchived AuditAction = "unarchived"
+	AuditTrashed    AuditAction = "trashed"
+	AuditUntrashed  AuditAction = "untrashed" // возврат из корзины
+	AuditDeleted    AuditAction = "deleted"
+)
+
+// FieldChange изменение одного поля задачи; имена полей совпадают с JSON
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// AuditEntry неизменяемая запись журнала изменений задачи. Revision — номер
+// изменения в истории задачи, Snapshot — состояние задачи после него.
+type AuditEntry struct {
+	ID           string        `json:"id"`
+	TaskID       string        `json:"task_id"`
+	Revision     int64         `json:"revision"`
+	Action       AuditAction   `json:"action"`
+	ActorID      string        `json:"actor_id"`
+	At           time.Time     `json:"at"`
+	Changes      []FieldChange `json:"changes"`
+	Snapshot     *Task         `json:"snapshot"`
+	RestoredFrom int64         `json:"restored_from,omitempty"`
+}
+
+// auditIgnoredFields не меняются пользователем или меняются при каждом
+// сохранении и не несут смысла в истории
+var auditIgnoredFields = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// NewAuditEntry строит запись журнала по событию задачи. ID записи совпадает
+// с ID события, ревизия — с его номером в потоке задачи.
+func NewAuditEntry(event *DomainEvent) *AuditEntry {
+	entry := &AuditEntry{
+		ID:           event.ID,
+		TaskID:       event.TaskID,
+		Revision:     event.Sequence,
+		ActorID:      event.ActorID,
+		At:           event.OccurredAt,
+		Snapshot:     event.Task,
+		RestoredFrom: event.RestoredFrom,
+	}
+
+	switch {
+	case event.Type == TaskCreatedEvent:
+		entry.Action = AuditCreated
+		entry.Changes = DiffTasks(nil, event.Task)
+	case event.Type == TaskDeletedEvent:
+		entry.Action = AuditDeleted
+	case event.Type == TaskArchivedEvent:
+		entry.Action = AuditArchived
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	case event.Type == TaskUnarchivedEvent:
+		entry.Action = AuditUnarchived
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	case event.Type == TaskTrashedEvent:
+		entry.Action = AuditTrashed
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	case event.Type == TaskUntrashedEvent:
+		entry.Action = AuditUntrashed
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	case event.RestoredFrom > 0:
+		entry.Action = AuditRestored
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	default:
+		entry.Action = AuditUpdated
+		entry.Changes = DiffTasks(event.Previous, event.Task)
+	}
+
+	return entry
+}
+
+// DiffTasks возвращает изменившиеся поля задачи, отсортированные по имени.
+// previous nil — задача создана, все заполненные поля считаются новыми.
+// Поле, отсутствующее в одном из состояний (omitempty), имеет значение nil.
+func DiffTasks(previous, current *Task) []FieldChange {
+	before := taskFields(previous)
+	after := taskFields(current)
+
+	var changes []FieldChange
+	for field, value := range after {
+		if auditIgnoredFields[field] || previous == nil && reflect.ValueOf(value).IsZero() {
+			continue
+		}
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, FieldChange{Field: field, Old: before[field], New: value})
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok && !auditIgnoredFields[field] {
+			changes = append(changes, FieldChange{Field: field, Old: old})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
+
+// taskFields представляет задачу как набор JSON-полей
+func taskFields(task *Task) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if task == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+
+	return fields
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// CalendarFeed подписка календаря на задачи пользователя в пространстве.
+// Календарные клиенты не умеют передавать заголовок авторизации, поэтому
+// лента открывается по секретному токену в адресе; токен можно заменить,
+// если адрес попал к посторонним.
+type CalendarFeed struct {
+	UserID      string    `json:"user_id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Token       string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+)
+
+// ChecklistItem пункт чек-листа задачи; порядок пунктов задается рангом
+type ChecklistItem struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Rank      string    `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate Валидация пункта чек-листа
+func (i *ChecklistItem) Validate() []string {
+	var errors []string
+
+	if i.Text == "" {
+		errors = append(errors, "text is required")
+	}
+
+	if len(i.Text) > 500 {
+		errors = append(errors, "text must be less than 500 characters")
+	}
+
+	return errors
+}
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"regexp"
+	"time"
+)
+
+// CommentRevision предыдущая версия текста комментария
+type CommentRevision struct {
+	Body     string    `json:"body"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+// Comment комментарий к задаче в формате Markdown. ParentID указывает
+// на комментарий, ответом на который он является.
+type Comment struct {
+	ID        string            `json:"id"`
+	TaskID    string            `json:"task_id"`
+	ParentID  string            `json:"parent_id,omitempty"`
+	AuthorID  string            `json:"author_id"`
+	Body      string            `json:"body"`
+	Mentions  []string          `json:"mentions"`
+	History   []CommentRevision `json:"history"`
+	Deleted   bool              `json:"deleted"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// CommentThread комментарий с ответами
+type CommentThread struct {
+	Comment *Comment         `json:"comment"`
+	Replies []*CommentThread `json:"replies"`
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+
+// Validate Валидация комментария
+func (c *Comment) Validate() []string {
+	var errors []string
+
+	if c.Body == "" {
+		errors = append(errors, "body is required")
+	}
+
+	if len(c.Body) > 10000 {
+		errors = append(errors, "body must be less than 10000 characters")
+	}
+
+	return errors
+}
+
+// ExtractMentions возвращает имена пользователей, упомянутых через @username,
+// без повторов и в порядке появления
+func ExtractMentions(body string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// Точка в конце обычно завершает предложение, а не имя
+		username := trimTrailingDots(match[1])
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+
+	return usernames
+}
+
+func trimTrailingDots(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '.' || s[len(s)-1] == '-') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// TaskDependency связь "задача BlockerID блокирует задачу BlockedID"
+type TaskDependency struct {
+	BlockerID string    `json:"blocker_id"`
+	BlockedID string    `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DependencyGraph ориентированный ациклический граф зависимостей
+// для набора задач; ребра ведут от блокирующей задачи к блокируемой
+type DependencyGraph struct {
+	tasks map[string]*Task
+	edges map[string][]string
+	ids   []string
+}
+
+// NewDependencyGraph строит граф по набору задач. Связи с задачами
+// вне набора отбрасываются.
+func NewDependencyGraph(tasks []*Task, deps []*TaskDependency) *DependencyGraph {
+	g := &DependencyGraph{
+		tasks: make(map[string]*Task),
+		edges: make(map[string][]string),
+	}
+
+	for _, task := range tasks {
+		if _, exists := g.tasks[task.ID]; exists {
+			continue
+		}
+		g.tasks[task.ID] = task
+		g.ids = append(g.ids, task.ID)
+	}
+
+	// Стабильный порядок обхода: сначала более старые задачи
+	sort.Slice(g.ids, func(i, j int) bool {
+		a, b := g.tasks[g.ids[i]], g.tasks[g.ids[j]]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	for _, dep := range deps {
+		_, okBlocker := g.tasks[dep.BlockerID]
+		_, okBlocked := g.tasks[dep.BlockedID]
+		if okBlocker && okBlocked {
+			g.edges[dep.BlockerID] = append(g.edges[dep.BlockerID], dep.BlockedID)
+		}
+	}
+
+	return g
+}
+
+// TopologicalOrder возвращает задачи в порядке, при котором каждая
+// блокирующая задача идет раньше заблокированных ею (алгоритм Кана)
+func (g *DependencyGraph) TopologicalOrder() ([]*Task, error) {
+	inDegree := make(map[string]int, len(g.ids))
+	for _, id := range g.ids {
+		for _, next := range g.edges[id] {
+			inDegree[next]++
+		}
+	}
+
+	var queue []string
+	for _, id := range g.ids {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	var order []*Task
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, g.tasks[id])
+
+		for _, next := range g.edges[id] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(order) != len(g.ids) {
+		return nil, errors.New("dependency cycle detected")
+	}
+
+	return order, nil
+}
+
+// CriticalPath возвращает самую длинную цепочку зависимостей, считая
+// только незавершенные задачи: именно она ограничивает срок завершения
+// всего набора
+func (g *DependencyGraph) CriticalPath() ([]*Task, error) {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	length := make(map[string]int, len(order))
+	prev := make(map[string]string, len(order))
+
+	for _, task := range order {
+		length[task.ID] += g.weight(task)
+	}
+
+	for _, task := range order {
+		for _, next := range g.edges[task.ID] {
+			candidate := length[task.ID] + g.weight(g.tasks[next])
+			if candidate > length[next] {
+				length[next] = candidate
+				prev[next] = task.ID
+			}
+		}
+	}
+
+	end := ""
+	for _, task := range order {
+		if end == "" || length[task.ID] > length[end] {
+			end = task.ID
+		}
+	}
+
+	if end == "" || length[end] == 0 {
+		return []*Task{}, nil
+	}
+
+	var path []*Task
+	for id := end; id != ""; id = prev[id] {
+		path = append([]*Task{g.tasks[id]}, path...)
+	}
+
+	return path, nil
+}
+
+func (g *DependencyGraph) weight(task *Task) int {
+	if task.IsDone() {
+		return 0
+	}
+	return 1
+}
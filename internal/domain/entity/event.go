@@ -0,0 +1,93 @@
+package entity
+
+import "time"
+
+// DomainEventType тип доменного события задачи
+type DomainEventType string
+
+const (
+	TaskCreatedEvent DomainEventType = "task.created"
+	TaskUpdatedEvent DomainEventType = "task.updated"
+	TaskDeletedEvent DomainEventType = "task.deleted" // окончательное удаление
+	// TaskTrashedEvent и TaskUntrashedEvent перенос задачи в корзину и возврат из неё
+	TaskTrashedEvent   DomainEventType = "task.trashed"
+	TaskUntrashedEvent DomainEventType = "task.untrashed"
+	// TaskArchivedEvent и TaskUnarchivedEvent перенос задачи в архив и возврат из него
+	TaskArchivedEvent   DomainEventType = "task.archived"
+	TaskUnarchivedEvent DomainEventType = "task.unarchived"
+)
+
+// DomainEvent факт изменения задачи. Sequence растет на единицу для каждого
+// следующего события одной задачи и задает порядок их публикации.
+type DomainEvent struct {
+	ID          string          `json:"id"`
+	Type        DomainEventType `json:"type"`
+	TaskID      string          `json:"task_id"`
+	Sequence    int64           `json:"sequence"`
+	WorkspaceID string          `json:"workspace_id"`
+	ActorID     string          `json:"actor_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	// Task состояние задачи после изменения; для удаления — последнее состояние
+	Task *Task `json:"task"`
+	// Previous состояние до изменения; для создания и удаления пусто
+	Previous *Task `json:"previous,omitempty"`
+	// RestoredFrom номер ревизии, к которой изменение вернуло задачу
+	RestoredFrom int64 `json:"restored_from,omitempty"`
+	// FromBackup изменение сделано восстановлением резервной копии: о таких
+	// событиях не оповещают ни пользователей, ни внешние системы
+	FromBackup bool `json:"from_backup,omitempty"`
+	// Recategorized изменение только переносит категорию статуса после
+	// изменения рабочего процесса; сама задача пользователем не менялась
+	Recategorized bool `json:"recategorized,omitempty"`
+}
+
+// Silent сообщает, что о событии не оповещают ни пользователей, ни внешние
+// системы: задачу изменил не пользователь, а восстановление резервной копии
+// или изменение рабочего процесса
+func (e *DomainEvent) Silent() bool {
+	return e.FromBackup || e.Recategorized
+}
+
+// StatusChanged сообщает, изменился ли статус задачи в этом событии
+func (e *DomainEvent) StatusChanged() bool {
+	return e.Type == TaskUpdatedEvent && e.Previous != nil && e.Previous.Status != e.Task.Status
+}
+
+// OutboxStatus состояние записи outbox
+type OutboxStatus string
+
+const (
+	OutboxPending    OutboxStatus = "pending"
+	OutboxPublishing OutboxStatus = "publishing" // запись захвачена ретранслятором
+	OutboxPublished  OutboxStatus = "published"
+	OutboxFailed     OutboxStatus = "failed" // попытки исчерпаны
+)
+
+// OutboxRecord событие в outbox вместе с ходом его публикации. Delivered
+// хранит подписчиков, уже обработавших событие, чтобы повтор после сбоя
+// одного подписчика не доставлял событие остальным еще раз.
+type OutboxRecord struct {
+	Event         *DomainEvent `json:"event"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	Delivered     []string     `json:"delivered,omitempty"`
+	LastError     string       `json:"last_error,omitempty"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LeaseUntil    time.Time    `json:"lease_until"`
+	PublishedAt   *time.Time   `json:"published_at,omitempty"`
+}
+
+// IsFinished сообщает, что запись больше не будет публиковаться
+func (r *OutboxRecord) IsFinished() bool {
+	return r.Status == OutboxPublished || r.Status == OutboxFailed
+}
+
+// IsDeliveredTo сообщает, обработал ли подписчик событие
+func (r *OutboxRecord) IsDeliveredTo(subscriber string) bool {
+	for _, name := range r.Delivered {
+		if name == subscriber {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// ImportJobStatus состояние фонового импорта
+type ImportJobStatus string
+
+const (
+	ImportJobQueued    ImportJobStatus = "queued"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob фоновый импорт задач из экспорта другого трекера. Файл
+// хранится до завершения импорта, Processed — число обработанных записей:
+// после перезапуска сервиса импорт продолжается с этой записи.
+type ImportJob struct {
+	ID          string `json:"id"`
+	UserID      string `json:"user_id"`
+	WorkspaceID string `json:"workspace_id"`
+	Format      string `json:"format"`
+	FileKey     string `json:"file_key"`
+	ProjectID   string `json:"project_id,omitempty"`
+	// StatusMap соответствие колонок или статусов источника статусам
+	// рабочего процесса; остальные определяются по категории
+	StatusMap map[string]TaskStatus `json:"status_map,omitempty"`
+	Status    ImportJobStatus       `json:"status"`
+	Total     int                   `json:"total"`
+	Processed int                   `json:"processed"`
+	Imported  int                   `json:"imported"`
+	Skipped   int                   `json:"skipped"`
+	Comments  int                   `json:"comments"`
+	Checklist int                   `json:"checklist_items"`
+	Problems  []string              `json:"problems"`
+	Error     string                `json:"error,omitempty"`
+	// LastRank ранг последней импортированной задачи: задачи встают в
+	// проект в порядке источника
+	LastRank   string     `json:"last_rank,omitempty"`
+	LeaseUntil time.Time  `json:"lease_until,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Progress доля обработанных записей от 0 до 1
+func (j *ImportJob) Progress() float64 {
+	if j.Total == 0 {
+		if j.Status == ImportJobCompleted {
+			return 1
+		}
+		return 0
+	}
+	return float64(j.Processed) / float64(j.Total)
+}
+
+// Finished сообщает, завершен ли импорт
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportJobCompleted || j.Status == ImportJobFailed
+}
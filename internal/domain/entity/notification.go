@@ -0,0 +1,116 @@
+package entity
+
+import "time"
+
+// NotificationEvent тип события, о котором пользователь получает уведомление
+type NotificationEvent string
+
+const (
+	EventTaskAssigned NotificationEvent = "task_assigned"
+	EventTaskUpdated  NotificationEvent = "task_updated"
+	EventTaskOverdue  NotificationEvent = "task_overdue"
+)
+
+// NotificationEvents все типы событий, доступные для подписки
+var NotificationEvents = []NotificationEvent{EventTaskAssigned, EventTaskUpdated, EventTaskOverdue}
+
+// NotificationPreferences настройки уведомлений пользователя
+type NotificationPreferences struct {
+	UserID         string              `json:"user_id"`
+	EmailEnabled   bool                `json:"email_enabled"`
+	Locale         string              `json:"locale"`
+	Digest         bool                `json:"digest"` // объединять серии событий в одно письмо
+	DisabledEvents []NotificationEvent `json:"disabled_events"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences настройки пользователя, который их не менял
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:       userID,
+		EmailEnabled: true,
+		Locale:       "en",
+		Digest:       true,
+	}
+}
+
+// Validate Валидация настроек уведомлений
+func (p *NotificationPreferences) Validate() []string {
+	var errors []string
+
+	for _, event := range p.DisabledEvents {
+		if !isNotificationEvent(event) {
+			errors = append(errors, "unknown event "+string(event))
+		}
+	}
+
+	return errors
+}
+
+// Allows сообщает, нужно ли отправлять пользователю письмо о событии
+func (p *NotificationPreferences) Allows(event NotificationEvent) bool {
+	if !p.EmailEnabled {
+		return false
+	}
+
+	for _, disabled := range p.DisabledEvents {
+		if disabled == event {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Disable отключает письма о событии; пустое событие отключает все письма
+func (p *NotificationPreferences) Disable(event NotificationEvent) {
+	if event == "" {
+		p.EmailEnabled = false
+		return
+	}
+
+	for _, disabled := range p.DisabledEvents {
+		if disabled == event {
+			return
+		}
+	}
+
+	p.DisabledEvents = append(p.DisabledEvents, event)
+}
+
+// EmailChange изменение поля задачи в письме
+type EmailChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// EmailEvent событие, о котором пользователю будет отправлено письмо
+type EmailEvent struct {
+	Type      NotificationEvent `json:"type"`
+	TaskID    string            `json:"task_id"`
+	TaskTitle string            `json:"task_title"`
+	TaskURL   string            `json:"task_url"`
+	DueAt     string            `json:"due_at,omitempty"`
+	Changes   []EmailChange     `json:"changes,omitempty"`
+}
+
+// PendingEmail накопленные события пользователя, письмо о которых еще не
+// отправлено. Очередь хранится вместе с настройками, чтобы событие,
+// подтвержденное в outbox, не терялось при перезапуске до отправки письма.
+type PendingEmail struct {
+	UserID   string       `json:"user_id"`
+	Events   []EmailEvent `json:"events"`
+	First    time.Time    `json:"first"`
+	Last     time.Time    `json:"last"`
+	Attempts int          `json:"attempts"`
+}
+
+func isNotificationEvent(event NotificationEvent) bool {
+	for _, known := range NotificationEvents {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
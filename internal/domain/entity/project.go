@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// BoardColumn колонка доски; каждая колонка соответствует статусу рабочего процесса
+type BoardColumn struct {
+	Status TaskStatus `json:"status"`
+	Name   string     `json:"name"`
+}
+
+// Project проект, объединяющий задачи в доску
+type Project struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	UserID      string        `json:"user_id"`
+	WorkspaceID string        `json:"workspace_id"`
+	Columns     []BoardColumn `json:"columns"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Validate Валидация проекта
+func (p *Project) Validate() []string {
+	var errors []string
+
+	if p.Name == "" {
+		errors = append(errors, "name is required")
+	}
+
+	if len(p.Name) > 100 {
+		errors = append(errors, "name must be less than 100 characters")
+	}
+
+	if len(p.Description) > 1000 {
+		errors = append(errors, "description must be less than 1000 characters")
+	}
+
+	seen := make(map[TaskStatus]bool)
+	for _, column := range p.Columns {
+		if column.Status == "" {
+			errors = append(errors, "column status is required")
+			continue
+		}
+		if seen[column.Status] {
+			errors = append(errors, fmt.Sprintf("duplicate column for status %s", column.Status))
+		}
+		seen[column.Status] = true
+	}
+
+	return errors
+}
+
+// Column возвращает колонку, соответствующую статусу
+func (p *Project) Column(status TaskStatus) (BoardColumn, bool) {
+	for _, column := range p.Columns {
+		if column.Status == status {
+			return column, true
+		}
+	}
+	return BoardColumn{}, false
+}
+
+// BoardColumnView колонка доски с упорядоченными задачами
+type BoardColumnView struct {
+	Column BoardColumn `json:"column"`
+	Tasks  []*Task     `json:"tasks"`
+}
+
+// Board доска проекта
+type Board struct {
+	Project *Project           `json:"project"`
+	Columns []*BoardColumnView `json:"columns"`
+	// Unmapped задачи проекта, статусу которых не соответствует ни одна колонка
+	Unmapped []*Task `json:"unmapped"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ранги задают ручной порядок задач в колонке. Это строки в системе
+// счисления по основанию 36, которые сравниваются лексикографически:
+// между любыми двумя рангами всегда можно вставить третий, поэтому
+// перемещение задачи меняет только её собственный ранг.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+// RankBetween возвращает ранг строго между prev и next. Пустой prev
+// означает начало колонки, пустой next — конец.
+func RankBetween(prev, next string) (string, error) {
+	if !validRank(prev) || !validRank(next) {
+		return "", errors.New("invalid rank")
+	}
+
+	if next != "" && prev >= next {
+		return "", errors.New("rank order violated")
+	}
+
+	return rankMidpoint(prev, next), nil
+}
+
+// rankMidpoint вычисляет середину между a и b (b == "" — бесконечность).
+// Ранги никогда не заканчиваются на "0", иначе перед ними нельзя было бы
+// ничего вставить.
+func rankMidpoint(a, b string) string {
+	n := 0
+	for {
+		da := rankDigitAt(a, n, 0)
+		db := rankBase
+		if b != "" {
+			db = rankDigitAt(b, n, 0)
+		}
+
+		if da != db {
+			// Первые n разрядов у a и b совпадают
+			prefix := padRank(a, n)[:n]
+
+			if db-da > 1 {
+				return prefix + string(rankDigits[(da+db)/2])
+			}
+
+			rest := ""
+			if n+1 < len(a) {
+				rest = a[n+1:]
+			}
+			return prefix + string(rankDigits[da]) + rankMidpoint(rest, "")
+		}
+
+		n++
+	}
+}
+
+func rankDigitAt(rank string, i, fallback int) int {
+	if i >= len(rank) {
+		return fallback
+	}
+	return strings.IndexByte(rankDigits, rank[i])
+}
+
+func padRank(rank string, n int) string {
+	for len(rank) < n {
+		rank += "0"
+	}
+	return rank
+}
+
+func validRank(rank string) bool {
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(rank, "0")
+}
@@ -0,0 +1,444 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecurrenceFrequency частота повторения (FREQ в RRULE)
+type RecurrenceFrequency string
+
+const (
+	FrequencyDaily   RecurrenceFrequency = "DAILY"
+	FrequencyWeekly  RecurrenceFrequency = "WEEKLY"
+	FrequencyMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+// maxRecurrencePeriods ограничивает перебор периодов, чтобы правило,
+// не дающее вхождений, не зацикливало планировщик
+const maxRecurrencePeriods = 100000
+
+// Recurrence правило повторения задачи. Rule — подмножество RRULE из
+// RFC 5545 (FREQ=DAILY/WEEKLY/MONTHLY, INTERVAL, BYDAY, COUNT, UNTIL),
+// Start — первое вхождение (DTSTART), TimeZone — часовой пояс IANA,
+// в котором считается локальное время вхождений.
+type Recurrence struct {
+	Rule     string    `json:"rule"`
+	TimeZone string    `json:"timezone"`
+	Start    time.Time `json:"start"`
+}
+
+// WeekdayNum день недели BYDAY с необязательным порядковым номером
+// (1MO — первый понедельник месяца, -1FR — последняя пятница)
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// RRule разобранное правило повторения
+type RRule struct {
+	Freq     RecurrenceFrequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    *time.Time
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Validate проверяет правило и часовой пояс
+func (r *Recurrence) Validate() error {
+	if _, err := r.Location(); err != nil {
+		return err
+	}
+
+	_, err := ParseRRule(r.Rule, time.UTC)
+	return err
+}
+
+// Location возвращает часовой пояс правила (UTC, если не задан)
+func (r *Recurrence) Location() (*time.Location, error) {
+	if r.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return nil, errors.New("unknown timezone " + r.TimeZone)
+	}
+
+	return loc, nil
+}
+
+// Next возвращает вхождение, следующее строго после after, и его номер
+// в серии, начиная с 1. Нулевой номер означает, что вхождения закончились
+// (исчерпаны COUNT или UNTIL).
+func (r *Recurrence) Next(after time.Time) (time.Time, int, error) {
+	loc, err := r.Location()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	rule, err := ParseRRule(r.Rule, loc)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	next, index := rule.next(r.Start.In(loc), 0, 0, after)
+	return next, index, nil
+}
+
+// NextFrom работает как Next, но перебирает вхождения не с начала серии,
+// а с известного вхождения known с номером index, поэтому не замедляется
+// по мере продвижения серии. Если known позже after или не вхождение
+// правила (например, срок задачи изменен вручную), перебор идет с начала
+// серии.
+func (r *Recurrence) NextFrom(after, known time.Time, index int) (time.Time, int, error) {
+	loc, err := r.Location()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	rule, err := ParseRRule(r.Rule, loc)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	start := r.Start.In(loc)
+	period, emitted, ok := rule.locate(start, known.In(loc), index)
+	if !ok || after.Before(known) {
+		period, emitted = 0, 0
+	}
+
+	next, index := rule.next(start, period, emitted, after)
+	return next, index, nil
+}
+
+// Occurrences возвращает до limit вхождений, начиная с первого
+func (r *Recurrence) Occurrences(limit int) ([]time.Time, error) {
+	loc, err := r.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := ParseRRule(r.Rule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []time.Time
+	rule.iterate(r.Start.In(loc), func(t time.Time) bool {
+		result = append(result, t)
+		return len(result) < limit
+	})
+
+	return result, nil
+}
+
+// ParseRRule разбирает строку RRULE. Локальное значение UNTIL (без "Z")
+// трактуется в часовом поясе loc.
+func ParseRRule(s string, loc *time.Location) (*RRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("recurrence rule is required")
+	}
+
+	rule := &RRule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = RecurrenceFrequency(strings.ToUpper(value))
+			if rule.Freq != FrequencyDaily && rule.Freq != FrequencyWeekly && rule.Freq != FrequencyMonthly {
+				return nil, errors.New("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				wd, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "WKST":
+			// Недели всегда начинаются с понедельника
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be used together")
+	}
+
+	if rule.Freq != FrequencyMonthly {
+		for _, wd := range rule.ByDay {
+			if wd.N != 0 {
+				return nil, errors.New("numbered BYDAY is only supported with FREQ=MONTHLY")
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		// Дата без времени включает весь день
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, errors.New("invalid UNTIL value " + value)
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, errors.New("invalid BYDAY value " + s)
+	}
+
+	wd, ok := rruleWeekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, errors.New("invalid BYDAY value " + s)
+	}
+
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, errors.New("invalid BYDAY value " + s)
+		}
+	}
+
+	return WeekdayNum{Weekday: wd, N: n}, nil
+}
+
+// iterate перебирает вхождения по порядку начиная с start, пока yield
+// возвращает true и не исчерпаны COUNT/UNTIL. Время суток берется из start;
+// даты считаются в часовом поясе start, поэтому вхождения сохраняют
+// локальное время при переходе на летнее время.
+func (r *RRule) iterate(start time.Time, yield func(time.Time) bool) {
+	r.iterateFrom(start, 0, 0, yield)
+}
+
+// iterateFrom перебирает вхождения, как iterate, начиная с периода period;
+// emitted — число вхождений в предыдущих периодах
+func (r *RRule) iterateFrom(start time.Time, period, emitted int, yield func(time.Time) bool) {
+	for ; period < maxRecurrencePeriods; period++ {
+		for _, t := range r.periodCandidates(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return
+			}
+
+			emitted++
+			if !yield(t) {
+				return
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// next возвращает вхождение после after и его номер, перебирая с периода
+// period, до которого было emitted вхождений; нулевой номер означает, что
+// вхождения закончились
+func (r *RRule) next(start time.Time, period, emitted int, after time.Time) (time.Time, int) {
+	var next time.Time
+	index, found := emitted, false
+	r.iterateFrom(start, period, emitted, func(t time.Time) bool {
+		index++
+		if t.After(after) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+
+	if !found {
+		return time.Time{}, 0
+	}
+	return next, index
+}
+
+// locate возвращает период вхождения known с номером index и число
+// вхождений в предыдущих периодах; ok ложно, если known не вхождение правила
+func (r *RRule) locate(start, known time.Time, index int) (period, emitted int, ok bool) {
+	if index < 1 || known.Before(start) || r.Until != nil && known.After(*r.Until) || r.Count > 0 && index > r.Count {
+		return 0, 0, false
+	}
+
+	period = r.periodOf(start, known)
+	if period < 0 || period >= maxRecurrencePeriods {
+		return 0, 0, false
+	}
+
+	before := 0
+	for _, t := range r.periodCandidates(start, period) {
+		switch {
+		case t.Before(start):
+		case t.Equal(known):
+			emitted = index - 1 - before
+			return period, emitted, emitted >= 0
+		case t.Before(known):
+			before++
+		}
+	}
+
+	return 0, 0, false
+}
+
+// periodOf возвращает номер периода правила, в который попадает дата t
+func (r *RRule) periodOf(start, t time.Time) int {
+	// Число календарных дней между датами; часы не учитываются, чтобы
+	// переход на летнее время не сдвигал счет
+	days := func(from, to time.Time) int {
+		a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+		b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+		return int(b.Sub(a) / (24 * time.Hour))
+	}
+	weekStart := func(t time.Time) time.Time {
+		return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	}
+
+	switch r.Freq {
+	case FrequencyDaily:
+		return days(start, t) / r.Interval
+	case FrequencyWeekly:
+		return days(weekStart(start), weekStart(t)) / 7 / r.Interval
+	case FrequencyMonthly:
+		return ((t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())) / r.Interval
+	}
+	return 0
+}
+
+// periodCandidates возвращает упорядоченные вхождения n-го периода правила
+func (r *RRule) periodCandidates(start time.Time, n int) []time.Time {
+	loc := start.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	var result []time.Time
+
+	switch r.Freq {
+	case FrequencyDaily:
+		t := at(start.Year(), start.Month(), start.Day()+n*r.Interval)
+		if len(r.ByDay) == 0 || r.matchesWeekday(t.Weekday()) {
+			result = append(result, t)
+		}
+
+	case FrequencyWeekly:
+		// Неделя начинается с понедельника
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := at(start.Year(), start.Month(), start.Day()-offset+7*n*r.Interval)
+		for i := 0; i < 7; i++ {
+			t := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			if len(r.ByDay) == 0 && t.Weekday() == start.Weekday() || r.matchesWeekday(t.Weekday()) {
+				result = append(result, t)
+			}
+		}
+
+	case FrequencyMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, loc)
+		year, month := first.Year(), first.Month()
+		days := daysIn(year, month, loc)
+
+		if len(r.ByDay) == 0 {
+			// Месяцы без такого числа пропускаются, как требует RFC 5545
+			if start.Day() <= days {
+				result = append(result, at(year, month, start.Day()))
+			}
+			break
+		}
+
+		seen := make(map[int]bool)
+		for _, wd := range r.ByDay {
+			for _, day := range monthWeekdays(year, month, days, wd, loc) {
+				if !seen[day] {
+					seen[day] = true
+					result = append(result, at(year, month, day))
+				}
+			}
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	}
+
+	return result
+}
+
+func (r *RRule) matchesWeekday(wd time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// monthWeekdays возвращает числа месяца, подходящие под BYDAY
+func monthWeekdays(year int, month time.Month, days int, wd WeekdayNum, loc *time.Location) []int {
+	var matches []int
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, loc).Weekday()
+	for day := 1 + (int(wd.Weekday)-int(firstWeekday)+7)%7; day <= days; day += 7 {
+		matches = append(matches, day)
+	}
+
+	switch {
+	case wd.N > 0 && wd.N <= len(matches):
+		return matches[wd.N-1 : wd.N]
+	case wd.N < 0 && -wd.N <= len(matches):
+		return matches[len(matches)+wd.N : len(matches)+wd.N+1]
+	case wd.N == 0:
+		return matches
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reminder напоминание о задаче: за Before до срока задачи или в момент At
+type Reminder struct {
+	ID        string        `json:"id"`
+	TaskID    string        `json:"task_id"`
+	UserID    string        `json:"user_id"`
+	Before    time.Duration `json:"before"`
+	At        *time.Time    `json:"at,omitempty"`
+	Channels  []string      `json:"channels"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Validate Валидация напоминания
+func (r *Reminder) Validate() []string {
+	var errors []string
+
+	if r.At == nil && r.Before < 0 {
+		errors = append(errors, "before must not be negative")
+	}
+
+	if r.At != nil && r.Before != 0 {
+		errors = append(errors, "before and at cannot be used together")
+	}
+
+	if len(r.Channels) == 0 {
+		errors = append(errors, "at least one channel is required")
+	}
+
+	return errors
+}
+
+// FireTime возвращает момент срабатывания напоминания для задачи;
+// false, если напоминание относительное, а срок у задачи не задан
+func (r *Reminder) FireTime(task *Task) (time.Time, bool) {
+	if r.At != nil {
+		return *r.At, true
+	}
+
+	if task.DueAt == nil {
+		return time.Time{}, false
+	}
+
+	return task.DueAt.Add(-r.Before), true
+}
+
+// ReminderJobStatus состояние задания на отправку напоминания
+type ReminderJobStatus string
+
+const (
+	ReminderJobPending   ReminderJobStatus = "pending"
+	ReminderJobFiring    ReminderJobStatus = "firing"
+	ReminderJobDelivered ReminderJobStatus = "delivered"
+	ReminderJobFailed    ReminderJobStatus = "failed"
+)
+
+// ReminderJob задание на отправку одного напоминания в один канал.
+// Задание хранит снимок задачи, чтобы его можно было отправить после
+// перезапуска сервиса без обращения к задаче.
+type ReminderJob struct {
+	ID            string            `json:"id"`
+	ReminderID    string            `json:"reminder_id"`
+	TaskID        string            `json:"task_id"`
+	UserID        string            `json:"user_id"`
+	Channel       string            `json:"channel"`
+	FireAt        time.Time         `json:"fire_at"`
+	TaskTitle     string            `json:"task_title"`
+	DueAt         *time.Time        `json:"due_at,omitempty"`
+	Status        ReminderJobStatus `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	LeaseUntil    time.Time         `json:"lease_until,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time        `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// ReminderJobID строит детерминированный ID задания: одно напоминание
+// не может быть запланировано дважды на тот же момент в тот же канал,
+// а ID служит ключом идемпотентности при доставке
+func ReminderJobID(reminderID, channel string, fireAt time.Time) string {
+	return fmt.Sprintf("%s:%s:%d", reminderID, channel, fireAt.Unix())
+}
@@ -0,0 +1,106 @@
+package entity
+
+import "time"
+
+// SyncResolution способ разрешения конфликта синхронизации: поле изменено
+// и у нас, и во внешней системе
+type SyncResolution string
+
+const (
+	SyncOurs           SyncResolution = "ours"             // побеждает значение задачи
+	SyncTheirs         SyncResolution = "theirs"           // побеждает значение внешней системы
+	SyncLastWriterWins SyncResolution = "last_writer_wins" // побеждает сторона, изменившая запись позже
+)
+
+// SyncFields поля задачи, которые синхронизируются с внешней системой
+type SyncFields struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      TaskStatus `json:"status"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	AssigneeID  string     `json:"assignee_id,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+}
+
+// SyncFieldsOf возвращает синхронизируемые поля задачи
+func SyncFieldsOf(task *Task) SyncFields {
+	return SyncFields{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		DueAt:       task.DueAt,
+		AssigneeID:  task.AssigneeID,
+		Tags:        task.Tags,
+	}
+}
+
+// Apply записывает поля в задачу
+func (f SyncFields) Apply(task *Task) {
+	task.Title = f.Title
+	task.Description = f.Description
+	task.Status = f.Status
+	task.DueAt = f.DueAt
+	task.AssigneeID = f.AssigneeID
+	task.Tags = append([]string(nil), f.Tags...)
+}
+
+// Equal сравнивает поля; моменты времени сравниваются без учета часового
+// пояса, метки — без учета порядка
+func (f SyncFields) Equal(other SyncFields) bool {
+	return f.Title == other.Title &&
+		f.Description == other.Description &&
+		f.Status == other.Status &&
+		sameTime(f.DueAt, other.DueAt) &&
+		f.AssigneeID == other.AssigneeID &&
+		SameTags(f.Tags, other.Tags)
+}
+
+// SameTags сообщает, совпадают ли наборы меток
+func SameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, tag := range a {
+		counts[tag]++
+	}
+	for _, tag := range b {
+		if counts[tag] == 0 {
+			return false
+		}
+		counts[tag]--
+	}
+
+	return true
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// SyncLink связь задачи с записью внешней системы. Base — значения полей
+// после последней синхронизации: общий предок, относительно которого
+// определяется, какая сторона изменила поле.
+type SyncLink struct {
+	Source      string     `json:"source"`
+	UserID      string     `json:"user_id"`
+	WorkspaceID string     `json:"workspace_id"`
+	TaskID      string     `json:"task_id"`
+	ExternalID  string     `json:"external_id"`
+	Base        SyncFields `json:"base"`
+	SyncedAt    time.Time  `json:"synced_at"`
+}
+
+// SyncCursor токен изменений внешней системы: с него начинается
+// следующая инкрементальная синхронизация
+type SyncCursor struct {
+	Source      string    `json:"source"`
+	UserID      string    `json:"user_id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Token       string    `json:"token"`
+	SyncedAt    time.Time `json:"synced_at"`
+}
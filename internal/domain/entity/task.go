@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type TaskStatus string
@@ -13,13 +15,30 @@ const (
 )
 
 type Task struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	UserID      string     `json:"user_id"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Status         TaskStatus     `json:"status"`
+	StatusCategory StatusCategory `json:"status_category,omitempty"`
+	UserID         string         `json:"user_id"`
+	AssigneeID     string         `json:"assignee_id,omitempty"`
+	WorkspaceID    string         `json:"workspace_id,omitempty"`
+	ParentID       string         `json:"parent_id,omitempty"`
+	ProjectID      string         `json:"project_id,omitempty"`
+	Rank           string         `json:"rank,omitempty"`
+	Tags           []string       `json:"tags,omitempty"`
+	ChecklistDone  int            `json:"checklist_done"` // счетчики чек-листа ведет use case чек-листов
+	ChecklistTotal int            `json:"checklist_total"`
+	DueAt          *time.Time     `json:"due_at,omitempty"`
+	Recurrence     *Recurrence    `json:"recurrence,omitempty"`   // правило хранится только у последнего экземпляра серии
+	SeriesID       string         `json:"series_id,omitempty"`    // ID первого экземпляра повторяющейся задачи
+	Occurrence     int            `json:"occurrence,omitempty"`   // номер экземпляра в серии, начиная с 1
+	CompletedAt    *time.Time     `json:"completed_at,omitempty"` // переход в завершающий статус
+	ArchivedAt     *time.Time     `json:"archived_at,omitempty"`  // архивация не зависит от статуса
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`   // задача в корзине с этого момента
+	DeletedBy      string         `json:"deleted_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 // Validate Валидация задачи
@@ -38,10 +57,91 @@ func (t *Task) Validate() []string {
 		errors = append(errors, "description must be less than 1000 characters")
 	}
 
-	// Проверка статуса
-	if t.Status != StatusTodo && t.Status != StatusInProgress && t.Status != StatusDone {
-		errors = append(errors, "invalid status")
+	// Допустимость конкретного статуса определяется рабочим процессом
+	// пространства и проверяется в use case
+	if t.Status == "" {
+		errors = append(errors, "status is required")
+	}
+
+	if len(t.Tags) > 20 {
+		errors = append(errors, "task can have at most 20 tags")
+	}
+
+	for _, tag := range t.Tags {
+		if tag == "" || len(tag) > 50 {
+			errors = append(errors, "tag must be 1 to 50 characters")
+			break
+		}
+	}
+
+	if t.ParentID != "" && t.ParentID == t.ID {
+		errors = append(errors, "task cannot be its own parent")
+	}
+
+	if t.Recurrence != nil {
+		if err := t.Recurrence.Validate(); err != nil {
+			errors = append(errors, "invalid recurrence: "+err.Error())
+		}
 	}
 
 	return errors
 }
+
+// NormalizeTags убирает пробелы по краям, пустые метки и повторы,
+// сохраняя порядок
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	return result
+}
+
+// TruncateText укорачивает текст до limit байт по границе символа,
+// обозначая обрыв многоточием
+func TruncateText(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	const ellipsis = "…"
+	cut := limit - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
+
+// IsDone сообщает, находится ли задача в завершающем статусе рабочего процесса
+func (t *Task) IsDone() bool {
+	if t.StatusCategory != "" {
+		return t.StatusCategory == CategoryDone
+	}
+	return t.Status == StatusDone
+}
+
+// IsArchived сообщает, находится ли задача в архиве
+func (t *Task) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
+// IsTrashed сообщает, находится ли задача в корзине
+func (t *Task) IsTrashed() bool {
+	return t.DeletedAt != nil
+}
+
+// ChecklistCompletion возвращает долю выполненных пунктов чек-листа (0..1)
+func (t *Task) ChecklistCompletion() float64 {
+	if t.ChecklistTotal == 0 {
+		return 0
+	}
+	return float64(t.ChecklistDone) / float64(t.ChecklistTotal)
+}
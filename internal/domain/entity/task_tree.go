@@ -0,0 +1,48 @@
+package entity
+
+// TaskNode узел дерева задач: задача и её подзадачи любой глубины
+type TaskNode struct {
+	Task     *Task       `json:"task"`
+	Children []*TaskNode `json:"children"`
+	Progress int         `json:"progress"`
+}
+
+// NewTaskNode создает узел дерева без подзадач
+func NewTaskNode(task *Task) *TaskNode {
+	return &TaskNode{
+		Task:     task,
+		Children: []*TaskNode{},
+	}
+}
+
+// CalculateProgress пересчитывает прогресс узла и всех его потомков.
+// Прогресс — процент завершенных потомков; у листа он равен
+// 100, если сама задача завершена, и 0 в противном случае.
+func (n *TaskNode) CalculateProgress() {
+	n.calculate()
+}
+
+// calculate выставляет прогресс узлам поддерева и возвращает
+// количество завершенных и всех потомков узла
+func (n *TaskNode) calculate() (done, total int) {
+	for _, child := range n.Children {
+		childDone, childTotal := child.calculate()
+
+		done += childDone
+		total += childTotal + 1
+		if child.Task.IsDone() {
+			done++
+		}
+	}
+
+	switch {
+	case total > 0:
+		n.Progress = done * 100 / total
+	case n.Task.IsDone():
+		n.Progress = 100
+	default:
+		n.Progress = 0
+	}
+
+	return done, total
+}
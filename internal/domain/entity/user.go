@@ -0,0 +1,32 @@
+package entity
+
+// WorkspaceRole роль пользователя в рабочем пространстве
+type WorkspaceRole string
+
+const (
+	WorkspaceMember WorkspaceRole = "member"
+	WorkspaceAdmin  WorkspaceRole = "admin" // меняет настройки пространства, например рабочий процесс
+)
+
+// User пользователь сервиса
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	// Workspaces роли пользователя в рабочих пространствах. В пространстве
+	// по умолчанию участником считается каждый пользователь.
+	Workspaces map[string]WorkspaceRole `json:"workspaces,omitempty"`
+}
+
+// RoleIn возвращает роль пользователя в пространстве; false — пользователь
+// в нём не состоит
+func (u *User) RoleIn(workspaceID string) (WorkspaceRole, bool) {
+	if role, ok := u.Workspaces[workspaceID]; ok {
+		return role, true
+	}
+	if workspaceID == DefaultWorkspaceID {
+		return WorkspaceMember, true
+	}
+	return "", false
+}
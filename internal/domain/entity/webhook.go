@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"net/url"
+	"time"
+)
+
+// WebhookEvent тип события задачи, на которое можно подписаться
+type WebhookEvent string
+
+const (
+	WebhookTaskCreated       WebhookEvent = "task.created"
+	WebhookTaskUpdated       WebhookEvent = "task.updated"
+	WebhookTaskDeleted       WebhookEvent = "task.deleted"
+	WebhookTaskArchived      WebhookEvent = "task.archived"
+	WebhookTaskUnarchived    WebhookEvent = "task.unarchived"
+	WebhookTaskTrashed       WebhookEvent = "task.trashed"
+	WebhookTaskUntrashed     WebhookEvent = "task.untrashed"
+	WebhookTaskStatusChanged WebhookEvent = "task.status_changed"
+)
+
+// WebhookEvents все события, доступные для подписки
+var WebhookEvents = []WebhookEvent{WebhookTaskCreated, WebhookTaskUpdated, WebhookTaskDeleted, WebhookTaskArchived, WebhookTaskUnarchived, WebhookTaskTrashed, WebhookTaskUntrashed, WebhookTaskStatusChanged}
+
+// WebhookSubscription подписка внешней системы на события задач пространства.
+// Пустой список Events означает подписку на все события.
+type WebhookSubscription struct {
+	ID          string         `json:"id"`
+	WorkspaceID string         `json:"workspace_id"`
+	UserID      string         `json:"user_id"`
+	URL         string         `json:"url"`
+	Secret      string         `json:"-"` // ключ подписи HMAC-SHA256
+	Events      []WebhookEvent `json:"events"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Validate Валидация подписки
+func (s *WebhookSubscription) Validate() []string {
+	var errors []string
+
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errors = append(errors, "url must be an absolute http(s) URL")
+	}
+
+	for _, event := range s.Events {
+		if !isWebhookEvent(event) {
+			errors = append(errors, "unknown event "+string(event))
+		}
+	}
+
+	return errors
+}
+
+// Matches сообщает, подписана ли подписка на событие
+func (s *WebhookSubscription) Matches(event WebhookEvent) bool {
+	if !s.Active {
+		return false
+	}
+
+	if len(s.Events) == 0 {
+		return true
+	}
+
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWebhookEvent(event WebhookEvent) bool {
+	for _, known := range WebhookEvents {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus состояние доставки события подписчику
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySending   WebhookDeliveryStatus = "sending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead доставка исчерпала попытки и попала в список
+	// недоставленных; её можно отправить повторно через replay
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookAttempt запись журнала об одной попытке доставки
+type WebhookAttempt struct {
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Response   string        `json:"response,omitempty"` // начало тела ответа
+	Duration   time.Duration `json:"duration"`
+}
+
+// WebhookDelivery доставка одного события одному подписчику вместе с журналом попыток
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	SubscriptionID string                `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        []byte                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       []WebhookAttempt      `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LeaseUntil     time.Time             `json:"lease_until"`
+	ReplayOf       string                `json:"replay_of,omitempty"` // исходная доставка, если это повтор
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
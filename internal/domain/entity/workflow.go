@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatusCategory группа статусов, по которой остальная система понимает
+// смысл пользовательского статуса
+type StatusCategory string
+
+const (
+	CategoryTodo   StatusCategory = "todo"
+	CategoryActive StatusCategory = "active"
+	CategoryDone   StatusCategory = "done"
+)
+
+// AnyStatus в поле From перехода означает переход из любого статуса
+const AnyStatus TaskStatus = "*"
+
+// DefaultWorkspaceID рабочее пространство, используемое, если клиент его не указал
+const DefaultWorkspaceID = "default"
+
+// WorkflowStatus статус рабочего процесса
+type WorkflowStatus struct {
+	Key      TaskStatus     `json:"key"`
+	Name     string         `json:"name"`
+	Category StatusCategory `json:"category"`
+}
+
+// WorkflowTransition разрешенный переход между статусами. Guards — имена
+// условий, которые должны выполняться для перехода, Hooks — имена
+// действий, выполняемых после успешного перехода.
+type WorkflowTransition struct {
+	From   TaskStatus `json:"from"`
+	To     TaskStatus `json:"to"`
+	Guards []string   `json:"guards,omitempty"`
+	Hooks  []string   `json:"hooks,omitempty"`
+}
+
+// Workflow набор статусов и переходов рабочего пространства
+type Workflow struct {
+	WorkspaceID   string               `json:"workspace_id"`
+	Name          string               `json:"name"`
+	InitialStatus TaskStatus           `json:"initial_status"`
+	Statuses      []WorkflowStatus     `json:"statuses"`
+	Transitions   []WorkflowTransition `json:"transitions"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// DefaultWorkflow стандартный процесс TODO -> IN_PROGRESS -> DONE,
+// в котором разрешены любые переходы
+func DefaultWorkflow(workspaceID string) *Workflow {
+	return &Workflow{
+		WorkspaceID:   workspaceID,
+		Name:          "Default",
+		InitialStatus: StatusTodo,
+		Statuses: []WorkflowStatus{
+			{Key: StatusTodo, Name: "To do", Category: CategoryTodo},
+			{Key: StatusInProgress, Name: "In progress", Category: CategoryActive},
+			{Key: StatusDone, Name: "Done", Category: CategoryDone},
+		},
+		Transitions: []WorkflowTransition{
+			{From: AnyStatus, To: StatusTodo},
+			{From: AnyStatus, To: StatusInProgress},
+			{From: AnyStatus, To: StatusDone},
+		},
+	}
+}
+
+// Validate Валидация рабочего процесса
+func (w *Workflow) Validate() []string {
+	var errors []string
+
+	if w.Name == "" {
+		errors = append(errors, "workflow name is required")
+	}
+
+	if len(w.Statuses) == 0 {
+		errors = append(errors, "workflow must define at least one status")
+	}
+
+	seen := make(map[TaskStatus]bool)
+	for _, status := range w.Statuses {
+		if status.Key == "" || status.Key == AnyStatus {
+			errors = append(errors, "status key is required")
+			continue
+		}
+
+		if seen[status.Key] {
+			errors = append(errors, fmt.Sprintf("duplicate status %s", status.Key))
+		}
+		seen[status.Key] = true
+
+		if status.Category != CategoryTodo && status.Category != CategoryActive && status.Category != CategoryDone {
+			errors = append(errors, fmt.Sprintf("status %s has invalid category", status.Key))
+		}
+	}
+
+	if !seen[w.InitialStatus] {
+		errors = append(errors, "initial status must be one of the workflow statuses")
+	}
+
+	for _, tr := range w.Transitions {
+		if tr.From != AnyStatus && !seen[tr.From] {
+			errors = append(errors, fmt.Sprintf("transition from unknown status %s", tr.From))
+		}
+		if !seen[tr.To] {
+			errors = append(errors, fmt.Sprintf("transition to unknown status %s", tr.To))
+		}
+	}
+
+	return errors
+}
+
+// Status возвращает описание статуса по ключу
+func (w *Workflow) Status(key TaskStatus) (WorkflowStatus, bool) {
+	for _, status := range w.Statuses {
+		if status.Key == key {
+			return status, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// FindTransition возвращает переход from -> to. Явно описанный переход
+// из конкретного статуса имеет приоритет над переходом из любого статуса.
+func (w *Workflow) FindTransition(from, to TaskStatus) (*WorkflowTransition, bool) {
+	var wildcard *WorkflowTransition
+
+	for i := range w.Transitions {
+		tr := &w.Transitions[i]
+		if tr.To != to {
+			continue
+		}
+
+		if tr.From == from {
+			return tr, true
+		}
+		if tr.From == AnyStatus && wildcard == nil {
+			wildcard = tr
+		}
+	}
+
+	return wildcard, wildcard != nil
+}
+
+// AvailableTransitions возвращает переходы, доступные из статуса
+func (w *Workflow) AvailableTransitions(from TaskStatus) []WorkflowTransition {
+	var result []WorkflowTransition
+
+	for _, status := range w.Statuses {
+		if status.Key == from {
+			continue
+		}
+		if tr, ok := w.FindTransition(from, status.Key); ok {
+			result = append(result, *tr)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type AttachmentHandler struct {
+	attachmentUseCase *usecase.AttachmentUseCase
+}
+
+func NewAttachmentHandler(attachmentUseCase *usecase.AttachmentUseCase) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentUseCase: attachmentUseCase,
+	}
+}
+
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	TaskID      string `json:"task_id"`
+	UploaderID  string `json:"uploader_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func toAttachmentResponse(attachment *entity.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		TaskID:      attachment.TaskID,
+		UploaderID:  attachment.UploaderID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Hash:        attachment.Hash,
+		CreatedAt:   attachment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// UploadAttachment обрабатывает multipart-запрос с файлом в поле "file".
+// Файл читается потоком, без буферизации всей формы в памяти.
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	// Запас на заголовки частей multipart-формы
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentUseCase.MaxSize()+1<<20)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Multipart form is required", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "File field is required", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeAttachmentError(w, err)
+			return
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachmentUseCase.UploadAttachment(r.Context(), r.PathValue("id"), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			writeAttachmentError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(toAttachmentResponse(attachment))
+		return
+	}
+}
+
+// GetAttachments обрабатывает запрос на получение списка вложений задачи
+func (h *AttachmentHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	attachments, err := h.attachmentUseCase.GetAttachments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	resp := []AttachmentResponse{}
+	for _, attachment := range attachments {
+		resp = append(resp, toAttachmentResponse(attachment))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DownloadAttachment обрабатывает запрос на скачивание вложения
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, content, err := h.attachmentUseCase.OpenAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment_id"))
+	if err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+
+	io.Copy(w, content)
+}
+
+// DeleteAttachment обрабатывает запрос на удаление вложения
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	if err := h.attachmentUseCase.DeleteAttachment(r.Context(), r.PathValue("id"), r.PathValue("attachment_id")); err != nil {
+		writeAttachmentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAttachmentError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr), strings.Contains(err.Error(), "exceeds maximum size"):
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+	case strings.Contains(err.Error(), "is not allowed"):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditHandler struct {
+	auditUseCase *usecase.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+type AuditEntryResponse struct {
+	Revision     int64                `json:"revision"`
+	Action       string               `json:"action"`
+	ActorID      string               `json:"actor_id"`
+	At           string               `json:"at"`
+	Changes      []entity.FieldChange `json:"changes"`
+	RestoredFrom int64                `json:"restored_from,omitempty"`
+	// Snapshot состояние задачи после ревизии; только в ответе на запрос ревизии
+	Snapshot *TaskResponse `json:"snapshot,omitempty"`
+}
+
+func toAuditEntryResponse(entry *entity.AuditEntry) AuditEntryResponse {
+	resp := AuditEntryResponse{
+		Revision:     entry.Revision,
+		Action:       string(entry.Action),
+		ActorID:      entry.ActorID,
+		At:           entry.At.Format("2006-01-02T15:04:05Z07:00"),
+		Changes:      entry.Changes,
+		RestoredFrom: entry.RestoredFrom,
+	}
+	if resp.Changes == nil {
+		resp.Changes = []entity.FieldChange{}
+	}
+	return resp
+}
+
+// GetHistory обрабатывает запрос на получение истории изменений задачи
+func (h *AuditHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.auditUseCase.GetHistory(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	resp := []AuditEntryResponse{}
+	for _, entry := range entries {
+		resp = append(resp, toAuditEntryResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetRevision обрабатывает запрос на получение ревизии задачи вместе с её состоянием
+func (h *AuditHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	revision, err := strconv.ParseInt(r.PathValue("revision"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.auditUseCase.GetRevision(r.Context(), r.PathValue("id"), revision)
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	resp := toAuditEntryResponse(entry)
+	snapshot := toTaskResponse(entry.Snapshot)
+	resp.Snapshot = &snapshot
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTaskAsOf обрабатывает запрос на получение состояния задачи на момент ?at= (RFC 3339)
+func (h *AuditHandler) GetTaskAsOf(w http.ResponseWriter, r *http.Request) {
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if err != nil {
+		http.Error(w, "Invalid at, expected RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.auditUseCase.GetTaskAsOf(r.Context(), r.PathValue("id"), at)
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+// RestoreRevision обрабатывает запрос на восстановление задачи к ревизии
+func (h *AuditHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	revision, err := strconv.ParseInt(r.PathValue("revision"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.auditUseCase.RestoreRevision(r.Context(), r.PathValue("id"), revision)
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+func writeAuditError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case strings.Contains(err.Error(), "cycle detected") || strings.Contains(err.Error(), "is blocked by") ||
+		strings.Contains(err.Error(), "transition not allowed") || strings.Contains(err.Error(), "transition guard"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
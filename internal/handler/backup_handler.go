@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/backup"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBackupSize ограничение размера восстанавливаемого архива
+const maxBackupSize = 1 << 30
+
+type BackupHandler struct {
+	backupUseCase *usecase.BackupUseCase
+}
+
+func NewBackupHandler(backupUseCase *usecase.BackupUseCase) *BackupHandler {
+	return &BackupHandler{
+		backupUseCase: backupUseCase,
+	}
+}
+
+// Backup обрабатывает запрос на создание резервной копии; архив
+// отправляется по мере создания
+func (h *BackupHandler) Backup(w http.ResponseWriter, r *http.Request) {
+	filename := "backup-" + time.Now().UTC().Format("20060102-150405") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+
+	out := &countingWriter{w: w}
+	if err := h.backupUseCase.Backup(r.Context(), out); err != nil && out.n == 0 {
+		w.Header().Del("Content-Disposition")
+		writeBackupError(w, err)
+	}
+}
+
+// Restore обрабатывает запрос на восстановление из архива в теле запроса.
+// Параметры: ?workspace= — пространство, в которое восстанавливаются
+// данные; ?dry_run=true — только проверка архива.
+func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := usecase.RestoreOptions{WorkspaceID: query.Get("workspace")}
+	if value := query.Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	// Zip читается с произвольного места, поэтому архив сначала
+	// сохраняется во временный файл
+	file, err := os.CreateTemp("", "restore-*.zip")
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	size, err := io.Copy(file, http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+
+	archive, err := backup.NewReader(file, size)
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+
+	report, err := h.backupUseCase.Restore(r.Context(), archive, opts)
+	if err != nil && (report == nil || len(report.Problems) == 0) {
+		writeBackupError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func writeBackupError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"), strings.Contains(err.Error(), "invalid backup archive"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "request body too large"):
+		http.Error(w, "Archive too large", http.StatusRequestEntityTooLarge)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/adapter"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CalendarHandler struct {
+	calendarUseCase *usecase.CalendarUseCase
+	taskAPI         *adapter.TaskAPI
+}
+
+func NewCalendarHandler(calendarUseCase *usecase.CalendarUseCase, taskAPI *adapter.TaskAPI) *CalendarHandler {
+	return &CalendarHandler{
+		calendarUseCase: calendarUseCase,
+		taskAPI:         taskAPI,
+	}
+}
+
+type CalendarFeedResponse struct {
+	URL         string    `json:"url"`
+	WorkspaceID string    `json:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (h *CalendarHandler) toCalendarFeedResponse(feed *entity.CalendarFeed) CalendarFeedResponse {
+	return CalendarFeedResponse{
+		URL:         h.calendarUseCase.FeedURL(feed),
+		WorkspaceID: feed.WorkspaceID,
+		CreatedAt:   feed.CreatedAt,
+	}
+}
+
+// GetFeed обрабатывает запрос на получение адреса подписки календаря
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.calendarUseCase.GetFeed(r.Context())
+	if err != nil {
+		writeCalendarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.toCalendarFeedResponse(feed))
+}
+
+// RotateFeed обрабатывает запрос на замену секретного адреса подписки
+func (h *CalendarHandler) RotateFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.calendarUseCase.RotateFeed(r.Context())
+	if err != nil {
+		writeCalendarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.toCalendarFeedResponse(feed))
+}
+
+// DeleteFeed обрабатывает запрос на отключение подписки календаря
+func (h *CalendarHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
+	if err := h.calendarUseCase.DeleteFeed(r.Context()); err != nil {
+		writeCalendarError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Feed отдает календарю ленту задач по токену из адреса подписки;
+// ?events=true добавляет к задачам события в момент срока
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := adapter.ICSOptions{Name: "Tasks"}
+	if value := query.Get("events"); value != "" {
+		events, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid events", http.StatusBadRequest)
+			return
+		}
+		opts.Events = events
+	}
+
+	ctx, err := h.calendarUseCase.OpenFeed(r.Context(), query.Get("token"))
+	if err != nil {
+		writeCalendarError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.taskAPI.ExportTasksToICS(ctx, &buf, opts); err != nil {
+		writeCalendarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=tasks.ics")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write(buf.Bytes())
+}
+
+func writeCalendarError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
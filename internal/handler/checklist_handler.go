@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type ChecklistHandler struct {
+	checklistUseCase *usecase.ChecklistUseCase
+}
+
+func NewChecklistHandler(checklistUseCase *usecase.ChecklistUseCase) *ChecklistHandler {
+	return &ChecklistHandler{
+		checklistUseCase: checklistUseCase,
+	}
+}
+
+// ChecklistItemRequest запрос на добавление или изменение пункта чек-листа
+type ChecklistItemRequest struct {
+	Text string `json:"text"`
+	// Done nil — отметка о выполнении не меняется
+	Done *bool `json:"done"`
+	// AfterItemID пункт, после которого встанет новый; пусто — в конец списка
+	AfterItemID string `json:"after_item_id"`
+}
+
+// MoveChecklistItemRequest запрос на перестановку пункта; пустой AfterItemID — в начало
+type MoveChecklistItemRequest struct {
+	AfterItemID string `json:"after_item_id"`
+}
+
+type ChecklistItemResponse struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	Done      bool   `json:"done"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func toChecklistItemResponse(item *entity.ChecklistItem) ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:        item.ID,
+		Text:      item.Text,
+		Done:      item.Done,
+		CreatedAt: item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// GetChecklist обрабатывает запрос на получение чек-листа задачи
+func (h *ChecklistHandler) GetChecklist(w http.ResponseWriter, r *http.Request) {
+	items, err := h.checklistUseCase.GetChecklist(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeChecklistError(w, err)
+		return
+	}
+
+	resp := []ChecklistItemResponse{}
+	for _, item := range items {
+		resp = append(resp, toChecklistItemResponse(item))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AddItem обрабатывает запрос на добавление пункта чек-листа
+func (h *ChecklistHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var req ChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.checklistUseCase.AddItem(r.Context(), r.PathValue("id"), req.Text, req.AfterItemID)
+	if err != nil {
+		writeChecklistError(w, err)
+		return
+	}
+
+	if req.Done != nil && *req.Done {
+		if item, err = h.checklistUseCase.SetItemDone(r.Context(), r.PathValue("id"), item.ID, true); err != nil {
+			writeChecklistError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toChecklistItemResponse(item))
+}
+
+// UpdateItem обрабатывает запрос на изменение текста и/или отметки пункта
+func (h *ChecklistHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	var req ChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	taskID, itemID := r.PathValue("id"), r.PathValue("item_id")
+
+	var item *entity.ChecklistItem
+	var err error
+
+	if req.Text != "" {
+		if item, err = h.checklistUseCase.EditItem(r.Context(), taskID, itemID, req.Text); err != nil {
+			writeChecklistError(w, err)
+			return
+		}
+	}
+
+	if req.Done != nil {
+		if item, err = h.checklistUseCase.SetItemDone(r.Context(), taskID, itemID, *req.Done); err != nil {
+			writeChecklistError(w, err)
+			return
+		}
+	}
+
+	if item == nil {
+		http.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toChecklistItemResponse(item))
+}
+
+// ToggleItem обрабатывает запрос на переключение отметки пункта
+func (h *ChecklistHandler) ToggleItem(w http.ResponseWriter, r *http.Request) {
+	item, err := h.checklistUseCase.ToggleItem(r.Context(), r.PathValue("id"), r.PathValue("item_id"))
+	if err != nil {
+		writeChecklistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toChecklistItemResponse(item))
+}
+
+// MoveItem обрабатывает запрос на перестановку пункта
+func (h *ChecklistHandler) MoveItem(w http.ResponseWriter, r *http.Request) {
+	var req MoveChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.checklistUseCase.MoveItem(r.Context(), r.PathValue("id"), r.PathValue("item_id"), req.AfterItemID)
+	if err != nil {
+		writeChecklistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toChecklistItemResponse(item))
+}
+
+// DeleteItem обрабатывает запрос на удаление пункта
+func (h *ChecklistHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	if err := h.checklistUseCase.DeleteItem(r.Context(), r.PathValue("id"), r.PathValue("item_id")); err != nil {
+		writeChecklistError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeChecklistError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
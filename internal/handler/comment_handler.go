@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type CommentHandler struct {
+	commentUseCase *usecase.CommentUseCase
+}
+
+func NewCommentHandler(commentUseCase *usecase.CommentUseCase) *CommentHandler {
+	return &CommentHandler{
+		commentUseCase: commentUseCase,
+	}
+}
+
+// CommentRequest запрос на создание или изменение комментария
+type CommentRequest struct {
+	Body     string `json:"body"`
+	ParentID string `json:"parent_id"`
+}
+
+type CommentRevisionResponse struct {
+	Body     string `json:"body"`
+	EditedAt string `json:"edited_at"`
+}
+
+type CommentResponse struct {
+	ID        string                    `json:"id"`
+	TaskID    string                    `json:"task_id"`
+	ParentID  string                    `json:"parent_id,omitempty"`
+	AuthorID  string                    `json:"author_id"`
+	Body      string                    `json:"body"`
+	Mentions  []string                  `json:"mentions"`
+	Edited    bool                      `json:"edited"`
+	History   []CommentRevisionResponse `json:"history"`
+	Deleted   bool                      `json:"deleted"`
+	CreatedAt string                    `json:"created_at"`
+	UpdatedAt string                    `json:"updated_at"`
+	Replies   []CommentResponse         `json:"replies,omitempty"`
+}
+
+func toCommentResponse(comment *entity.Comment) CommentResponse {
+	resp := CommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		ParentID:  comment.ParentID,
+		AuthorID:  comment.AuthorID,
+		Body:      comment.Body,
+		Mentions:  []string{},
+		Edited:    len(comment.History) > 0,
+		History:   []CommentRevisionResponse{},
+		Deleted:   comment.Deleted,
+		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: comment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	resp.Mentions = append(resp.Mentions, comment.Mentions...)
+	for _, rev := range comment.History {
+		resp.History = append(resp.History, CommentRevisionResponse{
+			Body:     rev.Body,
+			EditedAt: rev.EditedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	return resp
+}
+
+func toCommentThreadResponse(thread *entity.CommentThread) CommentResponse {
+	resp := toCommentResponse(thread.Comment)
+	resp.Replies = []CommentResponse{}
+	for _, reply := range thread.Replies {
+		resp.Replies = append(resp.Replies, toCommentThreadResponse(reply))
+	}
+	return resp
+}
+
+// GetComments обрабатывает запрос на получение обсуждения задачи
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	threads, err := h.commentUseCase.GetComments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	resp := []CommentResponse{}
+	for _, thread := range threads {
+		resp = append(resp, toCommentThreadResponse(thread))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AddComment обрабатывает запрос на добавление комментария к задаче
+func (h *CommentHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	comment := &entity.Comment{
+		TaskID:   r.PathValue("id"),
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	}
+
+	if err := h.commentUseCase.AddComment(r.Context(), comment); err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCommentResponse(comment))
+}
+
+// EditComment обрабатывает запрос на изменение комментария
+func (h *CommentHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.commentUseCase.EditComment(r.Context(), r.PathValue("id"), r.PathValue("comment_id"), req.Body)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCommentResponse(comment))
+}
+
+// DeleteComment обрабатывает запрос на удаление комментария
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	if err := h.commentUseCase.DeleteComment(r.Context(), r.PathValue("id"), r.PathValue("comment_id")); err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCommentError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type DependencyHandler struct {
+	dependencyUseCase *usecase.DependencyUseCase
+}
+
+func NewDependencyHandler(dependencyUseCase *usecase.DependencyUseCase) *DependencyHandler {
+	return &DependencyHandler{
+		dependencyUseCase: dependencyUseCase,
+	}
+}
+
+// AddDependencyRequest запрос на добавление блокирующей задачи
+type AddDependencyRequest struct {
+	BlockerID string `json:"blocker_id"`
+}
+
+// DependenciesResponse зависимости задачи в обе стороны
+type DependenciesResponse struct {
+	BlockedBy []TaskResponse `json:"blocked_by"`
+	Blocks    []TaskResponse `json:"blocks"`
+}
+
+// GetDependencies обрабатывает запрос на получение зависимостей задачи
+func (h *DependencyHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	deps, err := h.dependencyUseCase.GetDependencies(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDependencyError(w, err)
+		return
+	}
+
+	resp := DependenciesResponse{
+		BlockedBy: []TaskResponse{},
+		Blocks:    []TaskResponse{},
+	}
+	for _, task := range deps.BlockedBy {
+		resp.BlockedBy = append(resp.BlockedBy, toTaskResponse(task))
+	}
+	for _, task := range deps.Blocks {
+		resp.Blocks = append(resp.Blocks, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AddDependency обрабатывает запрос на добавление блокирующей задачи
+func (h *DependencyHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	var req AddDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.dependencyUseCase.AddDependency(r.Context(), req.BlockerID, r.PathValue("id")); err != nil {
+		writeDependencyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// RemoveDependency обрабатывает запрос на удаление блокирующей задачи
+func (h *DependencyHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	if err := h.dependencyUseCase.RemoveDependency(r.Context(), r.PathValue("blocker_id"), r.PathValue("id")); err != nil {
+		writeDependencyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTopologicalOrder обрабатывает запрос на получение порядка выполнения задач.
+// Набор задач передается параметром ids через запятую, по умолчанию — все задачи.
+func (h *DependencyHandler) GetTopologicalOrder(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.dependencyUseCase.GetTopologicalOrder(r.Context(), parseIDs(r))
+	if err != nil {
+		writeDependencyError(w, err)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetCriticalPath обрабатывает запрос на получение критического пути набора задач
+func (h *DependencyHandler) GetCriticalPath(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.dependencyUseCase.GetCriticalPath(r.Context(), parseIDs(r))
+	if err != nil {
+		writeDependencyError(w, err)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseIDs извлекает список ID задач из параметра ids
+func parseIDs(r *http.Request) []string {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func writeDependencyError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "cycle detected"), strings.Contains(err.Error(), "already exists"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxTrackerExportSize предельный размер экспорта Trello или Jira
+const maxTrackerExportSize = 100 << 20
+
+type ImportHandler struct {
+	importUseCase *usecase.ImportUseCase
+}
+
+func NewImportHandler(importUseCase *usecase.ImportUseCase) *ImportHandler {
+	return &ImportHandler{
+		importUseCase: importUseCase,
+	}
+}
+
+// ImportJobResponse состояние импорта; Progress — процент обработанных записей
+type ImportJobResponse struct {
+	ID             string                 `json:"id"`
+	Format         string                 `json:"format"`
+	Status         entity.ImportJobStatus `json:"status"`
+	ProjectID      string                 `json:"project_id,omitempty"`
+	Total          int                    `json:"total"`
+	Processed      int                    `json:"processed"`
+	Progress       float64                `json:"progress"`
+	Imported       int                    `json:"imported"`
+	Skipped        int                    `json:"skipped"`
+	Comments       int                    `json:"comments"`
+	ChecklistItems int                    `json:"checklist_items"`
+	Problems       []string               `json:"problems"`
+	Error          string                 `json:"error,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	StartedAt      *time.Time             `json:"started_at,omitempty"`
+	FinishedAt     *time.Time             `json:"finished_at,omitempty"`
+}
+
+func toImportJobResponse(job *entity.ImportJob) ImportJobResponse {
+	problems := job.Problems
+	if problems == nil {
+		problems = []string{}
+	}
+
+	return ImportJobResponse{
+		ID:             job.ID,
+		Format:         job.Format,
+		Status:         job.Status,
+		ProjectID:      job.ProjectID,
+		Total:          job.Total,
+		Processed:      job.Processed,
+		Progress:       float64(int(job.Progress()*1000)) / 10,
+		Imported:       job.Imported,
+		Skipped:        job.Skipped,
+		Comments:       job.Comments,
+		ChecklistItems: job.Checklist,
+		Problems:       problems,
+		Error:          job.Error,
+		CreatedAt:      job.CreatedAt,
+		StartedAt:      job.StartedAt,
+		FinishedAt:     job.FinishedAt,
+	}
+}
+
+// StartImport принимает экспорт Trello или Jira в теле запроса и ставит
+// импорт в очередь. Параметры: ?project= — проект для задач (без него
+// создается проект с именем доски); ?map=Колонка:STATUS (можно повторять) —
+// соответствие колонок или статусов источника статусам рабочего процесса.
+// Отвечает 202 с состоянием импорта; прогресс доступен по заголовку Location.
+func (h *ImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := usecase.ImportOptions{ProjectID: query.Get("project")}
+	for _, pair := range query["map"] {
+		state, status, ok := strings.Cut(pair, ":")
+		if !ok || state == "" || status == "" {
+			http.Error(w, "Invalid map, expected state:STATUS", http.StatusBadRequest)
+			return
+		}
+		if opts.StatusMap == nil {
+			opts.StatusMap = make(map[string]entity.TaskStatus)
+		}
+		opts.StatusMap[state] = entity.TaskStatus(status)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxTrackerExportSize)
+	job, err := h.importUseCase.StartImport(r.Context(), r.PathValue("format"), r.Body, opts)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/import/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toImportJobResponse(job))
+}
+
+// GetJobs возвращает импорты текущего пользователя
+func (h *ImportHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.importUseCase.GetJobs(r.Context())
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	response := make([]ImportJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, toImportJobResponse(job))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJob возвращает состояние и прогресс импорта
+func (h *ImportHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importUseCase.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toImportJobResponse(job))
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed") || strings.Contains(err.Error(), "invalid import file"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "request body too large"):
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
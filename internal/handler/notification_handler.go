@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+type NotificationHandler struct {
+	emailUseCase *usecase.EmailNotificationUseCase
+}
+
+func NewNotificationHandler(emailUseCase *usecase.EmailNotificationUseCase) *NotificationHandler {
+	return &NotificationHandler{
+		emailUseCase: emailUseCase,
+	}
+}
+
+// NotificationPreferencesRequest запрос на изменение настроек уведомлений;
+// поля, которых нет в запросе, не меняются, пустой список disabled_events
+// включает все события
+type NotificationPreferencesRequest struct {
+	EmailEnabled   *bool                      `json:"email_enabled"`
+	Locale         string                     `json:"locale"`
+	Digest         *bool                      `json:"digest"`
+	DisabledEvents []entity.NotificationEvent `json:"disabled_events"`
+}
+
+type NotificationPreferencesResponse struct {
+	EmailEnabled   bool                       `json:"email_enabled"`
+	Locale         string                     `json:"locale"`
+	Digest         bool                       `json:"digest"`
+	DisabledEvents []entity.NotificationEvent `json:"disabled_events"`
+}
+
+func toNotificationPreferencesResponse(preferences *entity.NotificationPreferences) NotificationPreferencesResponse {
+	resp := NotificationPreferencesResponse{
+		EmailEnabled:   preferences.EmailEnabled,
+		Locale:         preferences.Locale,
+		Digest:         preferences.Digest,
+		DisabledEvents: preferences.DisabledEvents,
+	}
+	if resp.DisabledEvents == nil {
+		resp.DisabledEvents = []entity.NotificationEvent{}
+	}
+	return resp
+}
+
+// unsubscribePage страница подтверждения отписки: сама ссылка из письма
+// ничего не меняет, чтобы отписку не выполняли почтовые сканеры ссылок
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+{{if .Done}}<p>You have been unsubscribed.</p>{{else}}
+<form method="post"><p>Stop receiving these emails?</p><button type="submit">Unsubscribe</button></form>{{end}}
+</body></html>`))
+
+// GetPreferences обрабатывает запрос на получение настроек уведомлений
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	preferences, err := h.emailUseCase.GetPreferences(r.Context())
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toNotificationPreferencesResponse(preferences))
+}
+
+// UpdatePreferences обрабатывает запрос на изменение настроек уведомлений
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req NotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	preferences, err := h.emailUseCase.GetPreferences(r.Context())
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	if req.EmailEnabled != nil {
+		preferences.EmailEnabled = *req.EmailEnabled
+	}
+	if req.Locale != "" {
+		preferences.Locale = req.Locale
+	}
+	if req.Digest != nil {
+		preferences.Digest = *req.Digest
+	}
+	if req.DisabledEvents != nil {
+		preferences.DisabledEvents = req.DisabledEvents
+	}
+
+	if err := h.emailUseCase.UpdatePreferences(r.Context(), preferences); err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toNotificationPreferencesResponse(preferences))
+}
+
+// Unsubscribe обрабатывает переход по ссылке отписки (GET) и отписку
+// в один клик из почтового клиента (POST, RFC 8058)
+func (h *NotificationHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	done := false
+	if r.Method == http.MethodPost {
+		if _, err := h.emailUseCase.Unsubscribe(r.Context(), token); err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		done = true
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, struct{ Done bool }{done})
+}
+
+func writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"), strings.Contains(err.Error(), "invalid unsubscribe token"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "unauthorized"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type ProjectHandler struct {
+	projectUseCase *usecase.ProjectUseCase
+}
+
+func NewProjectHandler(projectUseCase *usecase.ProjectUseCase) *ProjectHandler {
+	return &ProjectHandler{
+		projectUseCase: projectUseCase,
+	}
+}
+
+// ProjectRequest запрос на создание или изменение проекта
+type ProjectRequest struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Columns     []entity.BoardColumn `json:"columns"`
+}
+
+type ProjectResponse struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Columns     []entity.BoardColumn `json:"columns"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+// BoardColumnResponse колонка доски с задачами
+type BoardColumnResponse struct {
+	Status entity.TaskStatus `json:"status"`
+	Name   string            `json:"name"`
+	Tasks  []TaskResponse    `json:"tasks"`
+}
+
+type BoardResponse struct {
+	Project  ProjectResponse       `json:"project"`
+	Columns  []BoardColumnResponse `json:"columns"`
+	Unmapped []TaskResponse        `json:"unmapped"`
+}
+
+// MoveTaskRequest запрос на перемещение задачи по доске
+type MoveTaskRequest struct {
+	TaskID string            `json:"task_id"`
+	Status entity.TaskStatus `json:"status"`
+	// AfterTaskID задача, после которой встанет перемещаемая; пусто — начало колонки
+	AfterTaskID string `json:"after_task_id"`
+}
+
+func toProjectResponse(project *entity.Project) ProjectResponse {
+	return ProjectResponse{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		Columns:     project.Columns,
+		CreatedAt:   project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// CreateProject обрабатывает запрос на создание проекта
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	project := &entity.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		Columns:     req.Columns,
+	}
+
+	if err := h.projectUseCase.CreateProject(r.Context(), project); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toProjectResponse(project))
+}
+
+// GetAllProjects обрабатывает запрос на получение всех проектов пользователя
+func (h *ProjectHandler) GetAllProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projectUseCase.GetAllProjects(r.Context())
+	if err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	resp := []ProjectResponse{}
+	for _, project := range projects {
+		resp = append(resp, toProjectResponse(project))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetProject обрабатывает запрос на получение проекта
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectUseCase.GetProject(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toProjectResponse(project))
+}
+
+// UpdateProject обрабатывает запрос на изменение проекта
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	var req ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	project := &entity.Project{
+		ID:          r.PathValue("id"),
+		Name:        req.Name,
+		Description: req.Description,
+		Columns:     req.Columns,
+	}
+
+	if err := h.projectUseCase.UpdateProject(r.Context(), project); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toProjectResponse(project))
+}
+
+// DeleteProject обрабатывает запрос на удаление проекта
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	if err := h.projectUseCase.DeleteProject(r.Context(), r.PathValue("id")); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateProjectTask обрабатывает запрос на создание задачи в проекте
+func (h *ProjectHandler) CreateProjectTask(w http.ResponseWriter, r *http.Request) {
+	var req CreateTaskRequest
+
+	validationErrors, err := ValidateRequest(r, &req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(validationErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ValidationErrors{Errors: validationErrors})
+		return
+	}
+
+	task := &entity.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+	}
+	if req.ParentID != nil {
+		task.ParentID = *req.ParentID
+	}
+
+	if err := h.projectUseCase.CreateProjectTask(r.Context(), r.PathValue("id"), task); err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+// GetProjectTasks обрабатывает запрос на получение задач проекта
+func (h *ProjectHandler) GetProjectTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.projectUseCase.GetProjectTasks(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetBoard обрабатывает запрос на получение доски проекта
+func (h *ProjectHandler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	board, err := h.projectUseCase.GetBoard(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	resp := BoardResponse{
+		Project:  toProjectResponse(board.Project),
+		Columns:  []BoardColumnResponse{},
+		Unmapped: []TaskResponse{},
+	}
+
+	for _, view := range board.Columns {
+		column := BoardColumnResponse{
+			Status: view.Column.Status,
+			Name:   view.Column.Name,
+			Tasks:  []TaskResponse{},
+		}
+		for _, task := range view.Tasks {
+			column.Tasks = append(column.Tasks, toTaskResponse(task))
+		}
+		resp.Columns = append(resp.Columns, column)
+	}
+
+	for _, task := range board.Unmapped {
+		resp.Unmapped = append(resp.Unmapped, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// MoveTask обрабатывает запрос на перемещение задачи между колонками и внутри колонки
+func (h *ProjectHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	var req MoveTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.TaskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.projectUseCase.MoveTask(r.Context(), r.PathValue("id"), req.TaskID, req.Status, req.AfterTaskID)
+	if err != nil {
+		writeProjectError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+func writeProjectError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "transition not allowed"), strings.Contains(err.Error(), "transition guard"),
+		strings.Contains(err.Error(), "is blocked by"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ReminderHandler struct {
+	reminderUseCase *usecase.ReminderUseCase
+}
+
+func NewReminderHandler(reminderUseCase *usecase.ReminderUseCase) *ReminderHandler {
+	return &ReminderHandler{
+		reminderUseCase: reminderUseCase,
+	}
+}
+
+// CreateReminderRequest запрос на создание напоминания: Before — интервал
+// до срока задачи ("15m", "24h"), либо абсолютный момент At
+type CreateReminderRequest struct {
+	Before   string     `json:"before"`
+	At       *time.Time `json:"at"`
+	Channels []string   `json:"channels"`
+}
+
+type ReminderJobResponse struct {
+	ID          string `json:"id"`
+	Channel     string `json:"channel"`
+	FireAt      string `json:"fire_at"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	DeliveredAt string `json:"delivered_at,omitempty"`
+}
+
+type ReminderResponse struct {
+	ID        string                `json:"id"`
+	TaskID    string                `json:"task_id"`
+	Before    string                `json:"before,omitempty"`
+	At        string                `json:"at,omitempty"`
+	Channels  []string              `json:"channels"`
+	Jobs      []ReminderJobResponse `json:"jobs"`
+	CreatedAt string                `json:"created_at"`
+}
+
+func toReminderResponse(reminder *entity.Reminder, jobs []*entity.ReminderJob) ReminderResponse {
+	resp := ReminderResponse{
+		ID:        reminder.ID,
+		TaskID:    reminder.TaskID,
+		Channels:  reminder.Channels,
+		Jobs:      []ReminderJobResponse{},
+		CreatedAt: reminder.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if reminder.At != nil {
+		resp.At = reminder.At.Format("2006-01-02T15:04:05Z07:00")
+	} else {
+		resp.Before = reminder.Before.String()
+	}
+
+	for _, job := range jobs {
+		if job.ReminderID != reminder.ID {
+			continue
+		}
+
+		jobResp := ReminderJobResponse{
+			ID:        job.ID,
+			Channel:   job.Channel,
+			FireAt:    job.FireAt.Format("2006-01-02T15:04:05Z07:00"),
+			Status:    string(job.Status),
+			Attempts:  job.Attempts,
+			LastError: job.LastError,
+		}
+		if job.DeliveredAt != nil {
+			jobResp.DeliveredAt = job.DeliveredAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		resp.Jobs = append(resp.Jobs, jobResp)
+	}
+
+	return resp
+}
+
+// GetReminders обрабатывает запрос на получение напоминаний задачи вместе с заданиями отправки
+func (h *ReminderHandler) GetReminders(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+
+	reminders, err := h.reminderUseCase.GetReminders(r.Context(), taskID)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	jobs, err := h.reminderUseCase.GetJobs(r.Context(), taskID)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	resp := []ReminderResponse{}
+	for _, reminder := range reminders {
+		resp = append(resp, toReminderResponse(reminder, jobs))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CreateReminder обрабатывает запрос на создание напоминания
+func (h *ReminderHandler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	var req CreateReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reminder := &entity.Reminder{At: req.At, Channels: req.Channels}
+	if req.Before != "" {
+		before, err := time.ParseDuration(req.Before)
+		if err != nil {
+			http.Error(w, "Invalid before duration", http.StatusBadRequest)
+			return
+		}
+		reminder.Before = before
+	}
+
+	if err := h.reminderUseCase.AddReminder(r.Context(), r.PathValue("id"), reminder); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	jobs, err := h.reminderUseCase.GetJobs(r.Context(), reminder.TaskID)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toReminderResponse(reminder, jobs))
+}
+
+// DeleteReminder обрабатывает запрос на удаление напоминания
+func (h *ReminderHandler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	if err := h.reminderUseCase.DeleteReminder(r.Context(), r.PathValue("id"), r.PathValue("reminder_id")); err != nil {
+		writeReminderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeReminderError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type ReportHandler struct {
+	reportUseCase *usecase.ReportUseCase
+}
+
+func NewReportHandler(reportUseCase *usecase.ReportUseCase) *ReportHandler {
+	return &ReportHandler{
+		reportUseCase: reportUseCase,
+	}
+}
+
+// Export обрабатывает запрос на отчет о задачах: ?format=markdown (по
+// умолчанию) или ?format=html, ?project=ID — только задачи проекта.
+// Фильтры те же, что у списка задач (?archived=include|only).
+func (h *ReportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseTaskListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := usecase.ReportFormat(query.Get("format"))
+	if format == "" || format == "md" {
+		format = usecase.ReportMarkdown
+	}
+
+	data, err := h.reportUseCase.RenderReport(r.Context(), format, usecase.ReportOptions{
+		ProjectID: query.Get("project"),
+		Filter:    filter,
+	})
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	contentType, filename := "text/markdown; charset=utf-8", "tasks.md"
+	if format == usecase.ReportHTML {
+		contentType, filename = "text/html; charset=utf-8", "tasks.html"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Write(data)
+}
+
+func writeReportError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type SearchHandler struct {
+	searchUseCase *usecase.SearchUseCase
+}
+
+func NewSearchHandler(searchUseCase *usecase.SearchUseCase) *SearchHandler {
+	return &SearchHandler{
+		searchUseCase: searchUseCase,
+	}
+}
+
+// SearchTasks обрабатывает запрос на поиск задач по словам ?q=
+func (h *SearchHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.searchUseCase.Search(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		writeSearchError(w, err)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeSearchError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "unauthorized"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
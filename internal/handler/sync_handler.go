@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/adapter"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SyncHandler struct {
+	taskAPI *adapter.TaskAPI
+}
+
+func NewSyncHandler(taskAPI *adapter.TaskAPI) *SyncHandler {
+	return &SyncHandler{
+		taskAPI: taskAPI,
+	}
+}
+
+// Sync обрабатывает запрос на синхронизацию с внешней системой. Параметры:
+// ?resolution=ours|theirs|last_writer_wins — разрешение конфликтов;
+// ?push_new=true — создать во внешней системе записи для новых задач;
+// ?full=true — сверить все записи, а не только изменения.
+func (h *SyncHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := adapter.SyncOptions{Resolution: entity.SyncResolution(query.Get("resolution"))}
+	for name, target := range map[string]*bool{"push_new": &opts.PushNew, "full": &opts.Full} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid "+name, http.StatusBadRequest)
+			return
+		}
+		*target = parsed
+	}
+
+	report, err := h.taskAPI.SyncSource(r.Context(), r.PathValue("source"), opts)
+	if err != nil {
+		writeSyncError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func writeSyncError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type TaskHandler struct {
@@ -23,15 +27,147 @@ type CreateTaskRequest struct {
 	Title       string            `json:"title"`
 	Description string            `json:"description"`
 	Status      entity.TaskStatus `json:"status"`
+	// ParentID nil — родитель не меняется, "" — задача становится корневой
+	ParentID *string `json:"parent_id"`
+	// AssigneeID nil — исполнитель не меняется, "" — исполнитель снимается
+	AssigneeID *string `json:"assignee_id"`
+	// DueAt отсутствует — срок не меняется, null — срок снимается
+	DueAt OptionalTime `json:"due_at"`
+	// Tags nil — метки не меняются, пустой список — метки снимаются
+	Tags *[]string `json:"tags"`
+	// Recurrence nil — правило не меняется, пустое rule — повторение отключается
+	Recurrence *RecurrenceRequest `json:"recurrence"`
+}
+
+// OptionalTime время в запросе, которое отличает отсутствующее поле от null
+type OptionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON вызывается только для присутствующего поля, в том числе для null
+func (t *OptionalTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	return json.Unmarshal(data, &t.Value)
+}
+
+// RecurrenceRequest правило повторения задачи в запросе
+type RecurrenceRequest struct {
+	Rule     string     `json:"rule"`
+	TimeZone string     `json:"timezone"`
+	Start    *time.Time `json:"start"`
+}
+
+// OccurrenceResponse запланированное вхождение повторяющейся задачи
+type OccurrenceResponse struct {
+	Occurrence int    `json:"occurrence"`
+	At         string `json:"at"`
 }
 
 type TaskResponse struct {
-	ID          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      entity.TaskStatus `json:"status"`
-	CreatedAt   string            `json:"created_at"`
-	UpdatedAt   string            `json:"updated_at"`
+	ID                  string                `json:"id"`
+	Title               string                `json:"title"`
+	Description         string                `json:"description"`
+	Status              entity.TaskStatus     `json:"status"`
+	StatusCategory      entity.StatusCategory `json:"status_category,omitempty"`
+	ParentID            string                `json:"parent_id,omitempty"`
+	AssigneeID          string                `json:"assignee_id,omitempty"`
+	ProjectID           string                `json:"project_id,omitempty"`
+	Rank                string                `json:"rank,omitempty"`
+	Tags                []string              `json:"tags,omitempty"`
+	ChecklistCompletion float64               `json:"checklist_completion"` // доля выполненных пунктов чек-листа (0..1)
+	ChecklistDone       int                   `json:"checklist_done"`
+	ChecklistTotal      int                   `json:"checklist_total"`
+	DueAt               string                `json:"due_at,omitempty"`
+	Recurrence          *entity.Recurrence    `json:"recurrence,omitempty"`
+	SeriesID            string                `json:"series_id,omitempty"`
+	Occurrence          int                   `json:"occurrence,omitempty"`
+	CompletedAt         string                `json:"completed_at,omitempty"`
+	ArchivedAt          string                `json:"archived_at,omitempty"`
+	DeletedAt           string                `json:"deleted_at,omitempty"`
+	DeletedBy           string                `json:"deleted_by,omitempty"`
+	CreatedAt           string                `json:"created_at"`
+	UpdatedAt           string                `json:"updated_at"`
+}
+
+// TaskTreeResponse узел дерева задач в ответе API
+type TaskTreeResponse struct {
+	TaskResponse
+	Progress int                `json:"progress"`
+	Children []TaskTreeResponse `json:"children"`
+}
+
+// toTaskResponse преобразует сущность задачи в ответ API
+func toTaskResponse(task *entity.Task) TaskResponse {
+	resp := TaskResponse{
+		ID:             task.ID,
+		Title:          task.Title,
+		Description:    task.Description,
+		Status:         task.Status,
+		StatusCategory: task.StatusCategory,
+		ParentID:       task.ParentID,
+		AssigneeID:     task.AssigneeID,
+		ProjectID:      task.ProjectID,
+		Rank:           task.Rank,
+		Tags:           task.Tags,
+
+		ChecklistCompletion: task.ChecklistCompletion(),
+		ChecklistDone:       task.ChecklistDone,
+		ChecklistTotal:      task.ChecklistTotal,
+		Recurrence:          task.Recurrence,
+		SeriesID:            task.SeriesID,
+		Occurrence:          task.Occurrence,
+		DeletedBy:           task.DeletedBy,
+		CreatedAt:           task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:           task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if task.DueAt != nil {
+		resp.DueAt = task.DueAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if task.CompletedAt != nil {
+		resp.CompletedAt = task.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if task.ArchivedAt != nil {
+		resp.ArchivedAt = task.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if task.DeletedAt != nil {
+		resp.DeletedAt = task.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	return resp
+}
+
+// toRecurrence преобразует правило из запроса; пустое правило означает отключение повторения
+func toRecurrence(req *RecurrenceRequest) *entity.Recurrence {
+	if req.Rule == "" {
+		return nil
+	}
+
+	recurrence := &entity.Recurrence{Rule: req.Rule, TimeZone: req.TimeZone}
+	if req.Start != nil {
+		recurrence.Start = *req.Start
+	}
+
+	return recurrence
+}
+
+// toTaskTreeResponse рекурсивно преобразует дерево задач в ответ API
+func toTaskTreeResponse(node *entity.TaskNode) TaskTreeResponse {
+	resp := TaskTreeResponse{
+		TaskResponse: toTaskResponse(node.Task),
+		Progress:     node.Progress,
+		Children:     []TaskTreeResponse{},
+	}
+
+	for _, child := range node.Children {
+		resp.Children = append(resp.Children, toTaskTreeResponse(child))
+	}
+
+	return resp
 }
 
 // CreateTask обрабатывает запрос на создание задачи
@@ -59,11 +195,21 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		Description: req.Description,
 		Status:      req.Status,
 	}
-
-	// Если статус не указан, устанавливаем значение по умолчанию
-	if task.Status == "" {
-		task.Status = entity.StatusTodo
+	if req.ParentID != nil {
+		task.ParentID = *req.ParentID
+	}
+	if req.AssigneeID != nil {
+		task.AssigneeID = *req.AssigneeID
+	}
+	task.DueAt = req.DueAt.Value
+	if req.Tags != nil {
+		task.Tags = entity.NormalizeTags(*req.Tags)
 	}
+	if req.Recurrence != nil {
+		task.Recurrence = toRecurrence(req.Recurrence)
+	}
+
+	// Если статус не указан, use case установит начальный статус рабочего процесса
 
 	// Передаем задачу в use case
 	if err := h.taskUseCase.CreateTask(r.Context(), task); err != nil {
@@ -72,7 +218,12 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if strings.Contains(err.Error(), "unauthorized") {
+		if strings.Contains(err.Error(), "parent task not found") {
+			http.Error(w, "Parent task not found", http.StatusBadRequest)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -82,14 +233,7 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Подготавливаем ответ
-	resp := TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	resp := toTaskResponse(task)
 
 	// Отправляем ответ
 	w.Header().Set("Content-Type", "application/json")
@@ -122,14 +266,7 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	resp := toTaskResponse(task)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -137,7 +274,13 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 
 // GetAllTasks обрабатывает запрос на получение всех задач пользователя
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.taskUseCase.GetAllTasks(r.Context())
+	filter, err := parseTaskListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.taskUseCase.ListTasks(r.Context(), filter)
 	if err != nil {
 		if strings.Contains(err.Error(), "unauthorized") {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -150,20 +293,25 @@ func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 
 	var resp []TaskResponse
 	for _, task := range tasks {
-		resp = append(resp, TaskResponse{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		resp = append(resp, toTaskResponse(task))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// parseTaskListFilter читает фильтры списка задач из запроса. По умолчанию
+// архив не возвращается: ?archived=include добавляет его к активным
+// задачам, ?archived=only возвращает только архив.
+func parseTaskListFilter(r *http.Request) (usecase.TaskListFilter, error) {
+	archived := usecase.ArchivedFilter(r.URL.Query().Get("archived"))
+	switch archived {
+	case usecase.ArchivedExclude, usecase.ArchivedInclude, usecase.ArchivedOnly:
+		return usecase.TaskListFilter{Archived: archived}, nil
+	}
+	return usecase.TaskListFilter{}, errors.New("Invalid archived, expected include or only")
+}
+
 // UpdateTask обрабатывает запрос на обновление задачи
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
@@ -201,6 +349,21 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	if req.Status != "" {
 		existingTask.Status = req.Status
 	}
+	if req.ParentID != nil {
+		existingTask.ParentID = *req.ParentID
+	}
+	if req.AssigneeID != nil {
+		existingTask.AssigneeID = *req.AssigneeID
+	}
+	if req.DueAt.Set {
+		existingTask.DueAt = req.DueAt.Value
+	}
+	if req.Tags != nil {
+		existingTask.Tags = entity.NormalizeTags(*req.Tags)
+	}
+	if req.Recurrence != nil {
+		existingTask.Recurrence = toRecurrence(req.Recurrence)
+	}
 
 	// Сохраняем изменения
 	if err := h.taskUseCase.UpdateTask(r.Context(), existingTask); err != nil {
@@ -209,6 +372,17 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if strings.Contains(err.Error(), "parent task not found") {
+			http.Error(w, "Parent task not found", http.StatusBadRequest)
+			return
+		}
+
+		if strings.Contains(err.Error(), "cycle detected") || strings.Contains(err.Error(), "is blocked by") ||
+			strings.Contains(err.Error(), "transition not allowed") || strings.Contains(err.Error(), "transition guard") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
 		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -218,14 +392,7 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := TaskResponse{
-		ID:          existingTask.ID,
-		Title:       existingTask.Title,
-		Description: existingTask.Description,
-		Status:      existingTask.Status,
-		CreatedAt:   existingTask.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   existingTask.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	resp := toTaskResponse(existingTask)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -245,6 +412,11 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if strings.Contains(err.Error(), "task has subtasks") {
+			http.Error(w, "Task has subtasks", http.StatusConflict)
+			return
+		}
+
 		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -256,3 +428,166 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetSubtasks обрабатывает запрос на получение непосредственных подзадач
+func (h *TaskHandler) GetSubtasks(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.taskUseCase.GetSubtasks(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTaskTree обрабатывает запрос на получение дерева задачи с прогрессом
+func (h *TaskHandler) GetTaskTree(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tree, err := h.taskUseCase.GetTaskTree(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskTreeResponse(tree))
+}
+
+// ArchiveTask обрабатывает запрос на перенос задачи в архив
+func (h *TaskHandler) ArchiveTask(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, h.taskUseCase.ArchiveTask)
+}
+
+// UnarchiveTask обрабатывает запрос на возврат задачи из архива
+func (h *TaskHandler) UnarchiveTask(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, h.taskUseCase.UnarchiveTask)
+}
+
+func (h *TaskHandler) setArchived(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id string) (*entity.Task, error)) {
+	task, err := action(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+
+		if strings.Contains(err.Error(), "archived") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+// GetTransitions обрабатывает запрос на получение доступных переходов статуса задачи
+func (h *TaskHandler) GetTransitions(w http.ResponseWriter, r *http.Request) {
+	transitions, err := h.taskUseCase.GetAvailableTransitions(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if transitions == nil {
+		transitions = []entity.WorkflowTransition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transitions)
+}
+
+// GetOccurrences обрабатывает запрос на получение ближайших вхождений повторяющейся задачи
+func (h *TaskHandler) GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	occurrences, err := h.taskUseCase.GetOccurrences(r.Context(), r.PathValue("id"), limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := []OccurrenceResponse{}
+	for _, occurrence := range occurrences {
+		resp = append(resp, OccurrenceResponse{
+			Occurrence: occurrence.Index,
+			At:         occurrence.At.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/adapter"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportSize ограничение размера импортируемого файла
+const maxImportSize = 10 << 20
+
+// TransferHandler импорт и экспорт задач во внешних форматах
+type TransferHandler struct {
+	taskAPI *adapter.TaskAPI
+}
+
+func NewTransferHandler(taskAPI *adapter.TaskAPI) *TransferHandler {
+	return &TransferHandler{
+		taskAPI: taskAPI,
+	}
+}
+
+// Export обрабатывает запрос на потоковый экспорт задач: ?format=json
+// (массив, по умолчанию), ?format=ndjson или ?format=todotxt. Ответ
+// сжимается gzip, если клиент его принимает.
+func (h *TransferHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := adapter.ExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = adapter.ExportJSONArray
+	}
+
+	contentType, filename := "application/json", "tasks.json"
+	switch format {
+	case adapter.ExportNDJSON:
+		contentType, filename = "application/x-ndjson", "tasks.ndjson"
+	case adapter.ExportTodoTxt:
+		contentType, filename = "text/plain; charset=utf-8", "todo.txt"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	out := &countingWriter{w: w}
+	var body io.Writer = out
+
+	var gz *gzip.Writer
+	if acceptsGzip(r.Header.Values("Accept-Encoding")) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(out)
+		body = gz
+	}
+
+	controller := http.NewResponseController(w)
+	flush := func() error {
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+
+	err := h.taskAPI.StreamTasks(r.Context(), body, format, flush)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	// Пока ничего не отправлено, можно ответить ошибкой; иначе статус уже
+	// ушел, и клиент получит оборванный поток
+	if out.n == 0 {
+		w.Header().Del("Content-Encoding")
+		w.Header().Del("Content-Disposition")
+		writeTransferError(w, err)
+	}
+}
+
+// ExportCSV обрабатывает запрос на экспорт задач в CSV; колонки
+// выбираются параметром ?columns=id,title,status
+func (h *TransferHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	var columns []string
+	if value := r.URL.Query().Get("columns"); value != "" {
+		for _, column := range strings.Split(value, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := h.taskAPI.ExportTasksToCSV(r.Context(), &buf, columns); err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=tasks.csv")
+	w.Write(buf.Bytes())
+}
+
+// ImportCSV обрабатывает запрос на импорт задач из CSV в теле запроса.
+// Параметры: ?dry_run=true — только проверка; ?map=Заголовок:поле (можно
+// повторять) — сопоставление колонок; ?errors=csv — вместо отчета в JSON
+// вернуть строки с ошибками файлом CSV.
+func (h *TransferHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := adapter.CSVImportOptions{Mapping: make(map[string]string)}
+	if value := query.Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	for _, pair := range query["map"] {
+		header, field, ok := strings.Cut(pair, ":")
+		if !ok {
+			http.Error(w, "Invalid map, expected header:field", http.StatusBadRequest)
+			return
+		}
+		opts.Mapping[header] = field
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := h.taskAPI.ImportTasksFromCSV(r.Context(), r.Body, opts)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	if query.Get("errors") == "csv" {
+		data, err := report.ErrorRowsCSV()
+		if err != nil {
+			writeTransferError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=import-errors.csv")
+		w.Header().Set("X-Import-Total", strconv.Itoa(report.Total))
+		w.Header().Set("X-Import-Created", strconv.Itoa(report.Created))
+		w.Header().Set("X-Import-Failed", strconv.Itoa(report.Failed))
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ImportTodoTxt обрабатывает запрос на импорт задач из файла todo.txt в
+// теле запроса. Строки с id: существующих задач изменяют их, остальные
+// создают новые задачи.
+func (h *TransferHandler) ImportTodoTxt(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := h.taskAPI.ImportTasksFromTodoTxt(r.Context(), r.Body)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// countingWriter считает отправленные байты, чтобы знать, начат ли ответ
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// acceptsGzip сообщает, принимает ли клиент ответ в gzip. Кодировка с
+// q=0 запрещена (RFC 9110, 12.5.3); явное значение для gzip важнее "*".
+func acceptsGzip(headers []string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, header := range headers {
+		for _, item := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(item, ";")
+			q := 1.0
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "q") {
+					if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+
+			switch strings.ToLower(strings.TrimSpace(coding)) {
+			case "gzip", "x-gzip":
+				gzipQ = q
+			case "*":
+				anyQ = q
+			}
+		}
+	}
+
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "request body too large"):
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type TrashHandler struct {
+	taskUseCase *usecase.TaskUseCase
+}
+
+func NewTrashHandler(taskUseCase *usecase.TaskUseCase) *TrashHandler {
+	return &TrashHandler{
+		taskUseCase: taskUseCase,
+	}
+}
+
+// GetTrash обрабатывает запрос на получение задач в корзине
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.taskUseCase.GetTrash(r.Context())
+	if err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	resp := []TaskResponse{}
+	for _, task := range tasks {
+		resp = append(resp, toTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTrashedTask обрабатывает запрос на получение задачи из корзины
+func (h *TrashHandler) GetTrashedTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.taskUseCase.GetTrashedTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+// RestoreTask обрабатывает запрос на возврат задачи из корзины
+func (h *TrashHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.taskUseCase.RestoreTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task))
+}
+
+// PurgeTask обрабатывает запрос на окончательное удаление задачи из корзины
+func (h *TrashHandler) PurgeTask(w http.ResponseWriter, r *http.Request) {
+	if err := h.taskUseCase.PurgeTask(r.Context(), r.PathValue("id")); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash обрабатывает запрос на очистку корзины
+func (h *TrashHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	if err := h.taskUseCase.EmptyTrash(r.Context()); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTrashError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
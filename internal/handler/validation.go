@@ -3,7 +3,6 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 )
 
 // ValidationError представляет ошибку валидации
@@ -51,15 +50,8 @@ func ValidateRequest(r *http.Request, schema interface{}) ([]ValidationError, er
 			})
 		}
 
-		if s.Status != "" {
-			status := strings.ToUpper(string(s.Status))
-			if status != "TODO" && status != "IN_PROGRESS" && status != "DONE" {
-				validationErrors = append(validationErrors, ValidationError{
-					Field:   "status",
-					Message: "Status must be one of: TODO, IN_PROGRESS, DONE",
-				})
-			}
-		}
+		// Набор допустимых статусов зависит от рабочего процесса
+		// пространства, поэтому статус проверяется в use case
 	}
 
 	return validationErrors, nil
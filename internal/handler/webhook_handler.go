@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+// WebhookRequest запрос на создание или изменение подписки; пустой Events — все события
+type WebhookRequest struct {
+	URL    string                `json:"url"`
+	Events []entity.WebhookEvent `json:"events"`
+	// Active nil — при создании подписка активна, при изменении не меняется
+	Active *bool `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID     string                `json:"id"`
+	URL    string                `json:"url"`
+	Events []entity.WebhookEvent `json:"events"`
+	Active bool                  `json:"active"`
+	// Secret ключ подписи; возвращается только при создании подписки
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID          string                  `json:"id"`
+	EventID     string                  `json:"event_id"`
+	Event       entity.WebhookEvent     `json:"event"`
+	Status      string                  `json:"status"`
+	Payload     json.RawMessage         `json:"payload"`
+	Attempts    []entity.WebhookAttempt `json:"attempts"`
+	NextAttempt string                  `json:"next_attempt_at,omitempty"`
+	ReplayOf    string                  `json:"replay_of,omitempty"`
+	CreatedAt   string                  `json:"created_at"`
+	DeliveredAt string                  `json:"delivered_at,omitempty"`
+}
+
+func toWebhookResponse(subscription *entity.WebhookSubscription) WebhookResponse {
+	resp := WebhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    subscription.Events,
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: subscription.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if resp.Events == nil {
+		resp.Events = []entity.WebhookEvent{}
+	}
+	return resp
+}
+
+func toWebhookDeliveryResponse(delivery *entity.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		Event:     delivery.Event,
+		Status:    string(delivery.Status),
+		Payload:   delivery.Payload,
+		Attempts:  delivery.Attempts,
+		ReplayOf:  delivery.ReplayOf,
+		CreatedAt: delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if resp.Attempts == nil {
+		resp.Attempts = []entity.WebhookAttempt{}
+	}
+	if delivery.Status == entity.WebhookDeliveryPending {
+		resp.NextAttempt = delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if delivery.DeliveredAt != nil {
+		resp.DeliveredAt = delivery.DeliveredAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
+
+// GetWebhooks обрабатывает запрос на получение подписок пространства
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookUseCase.GetSubscriptions(r.Context())
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := []WebhookResponse{}
+	for _, subscription := range subscriptions {
+		resp = append(resp, toWebhookResponse(subscription))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CreateWebhook обрабатывает запрос на создание подписки
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription := &entity.WebhookSubscription{URL: req.URL, Events: req.Events, Active: true}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := h.webhookUseCase.CreateSubscription(r.Context(), subscription); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := toWebhookResponse(subscription)
+	resp.Secret = subscription.Secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetWebhook обрабатывает запрос на получение подписки
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.webhookUseCase.GetSubscription(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWebhookResponse(subscription))
+}
+
+// UpdateWebhook обрабатывает запрос на изменение подписки
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.webhookUseCase.GetSubscription(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	active := existing.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	subscription, err := h.webhookUseCase.UpdateSubscription(r.Context(), existing.ID, req.URL, req.Events, active)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWebhookResponse(subscription))
+}
+
+// DeleteWebhook обрабатывает запрос на удаление подписки
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookUseCase.DeleteSubscription(r.Context(), r.PathValue("id")); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries обрабатывает запрос на получение журнала доставок;
+// ?status=dead возвращает список недоставленных событий
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	status := entity.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+
+	deliveries, err := h.webhookUseCase.GetDeliveries(r.Context(), r.PathValue("id"), status)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := []WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		resp = append(resp, toWebhookDeliveryResponse(delivery))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ReplayDelivery обрабатывает запрос на повторную отправку события
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookUseCase.ReplayDelivery(r.Context(), r.PathValue("id"), r.PathValue("delivery_id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toWebhookDeliveryResponse(delivery))
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied"):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
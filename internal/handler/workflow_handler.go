@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/usecase"
+	"net/http"
+	"strings"
+)
+
+type WorkflowHandler struct {
+	workflowUseCase *usecase.WorkflowUseCase
+}
+
+func NewWorkflowHandler(workflowUseCase *usecase.WorkflowUseCase) *WorkflowHandler {
+	return &WorkflowHandler{
+		workflowUseCase: workflowUseCase,
+	}
+}
+
+// GetWorkflow обрабатывает запрос на получение рабочего процесса пространства
+func (h *WorkflowHandler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := h.workflowUseCase.GetWorkflow(r.Context())
+	if err != nil {
+		if strings.Contains(err.Error(), "unauthorized") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// SaveWorkflow обрабатывает запрос на замену рабочего процесса пространства
+func (h *WorkflowHandler) SaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	var workflow entity.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.workflowUseCase.SaveWorkflow(r.Context(), &workflow); err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "access denied") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
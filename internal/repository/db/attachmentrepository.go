@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+type AttachmentRepository struct {
+	attachments map[string]*entity.Attachment
+	seq         int
+	mutex       sync.RWMutex
+}
+
+func NewAttachmentRepository() *AttachmentRepository {
+	return &AttachmentRepository{
+		attachments: make(map[string]*entity.Attachment),
+	}
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *entity.Attachment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if attachment.ID == "" {
+		r.seq++
+		attachment.ID = fmt.Sprintf("att-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	attachment.CreatedAt = time.Now()
+
+	clone := *attachment
+	r.attachments[attachment.ID] = &clone
+	return nil
+}
+
+func (r *AttachmentRepository) GetByID(ctx context.Context, id string) (*entity.Attachment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	attachment, exists := r.attachments[id]
+	if !exists {
+		return nil, errors.New("attachment not found")
+	}
+
+	clone := *attachment
+	return &clone, nil
+}
+
+func (r *AttachmentRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.Attachment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.Attachment
+
+	for _, attachment := range r.attachments {
+		if attachment.TaskID == taskID {
+			clone := *attachment
+			result = append(result, &clone)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.attachments[id]; !exists {
+		return errors.New("attachment not found")
+	}
+
+	delete(r.attachments, id)
+
+	return nil
+}
+
+func (r *AttachmentRepository) CountByHash(ctx context.Context, hash string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, attachment := range r.attachments {
+		if attachment.Hash == hash {
+			count++
+		}
+	}
+
+	return count, nil
+}
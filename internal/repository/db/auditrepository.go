@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+)
+
+// AuditStore хранилище задач, которое ведет журнал изменений. Записи
+// журнала строятся по событиям outbox при фиксации транзакции, поэтому
+// запись не теряется, даже если событие так и не будет опубликовано.
+type AuditStore interface {
+	auditHistory(taskID string) []*entity.AuditEntry
+}
+
+// AuditRepository журнал изменений задач поверх хранилища задач
+type AuditRepository struct {
+	store AuditStore
+}
+
+func NewAuditRepository(store AuditStore) *AuditRepository {
+	return &AuditRepository{
+		store: store,
+	}
+}
+
+func (r *AuditRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.AuditEntry, error) {
+	return r.store.auditHistory(taskID), nil
+}
+
+// auditLog записи журнала изменений по задачам. Не синхронизирован:
+// вызывается под мьютексом хранилища, которому принадлежит.
+type auditLog struct {
+	byTask map[string][]*entity.AuditEntry
+}
+
+func newAuditLog() *auditLog {
+	return &auditLog{
+		byTask: make(map[string][]*entity.AuditEntry),
+	}
+}
+
+// record добавляет запись по событию, которому уже назначены ID и номер.
+// События задачи фиксируются по порядку, поэтому история остается
+// отсортированной по ревизиям.
+func (l *auditLog) record(event *entity.DomainEvent) {
+	entry := entity.NewAuditEntry(cloneDomainEvent(event))
+	l.byTask[event.TaskID] = append(l.byTask[event.TaskID], entry)
+}
+
+func (l *auditLog) history(taskID string) []*entity.AuditEntry {
+	var result []*entity.AuditEntry
+	for _, entry := range l.byTask[taskID] {
+		result = append(result, cloneAuditEntry(entry))
+	}
+	return result
+}
+
+func cloneAuditEntry(entry *entity.AuditEntry) *entity.AuditEntry {
+	clone := *entry
+	clone.Changes = append([]entity.FieldChange(nil), entry.Changes...)
+	if entry.Snapshot != nil {
+		clone.Snapshot = cloneTask(entry.Snapshot)
+	}
+	return &clone
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CalendarFeedRepository хранит ленты календаря в JSON-файле, чтобы ссылки
+// на ленты, уже добавленные в календари, работали после перезапуска
+// сервиса. Каждое изменение записывается во временный файл и атомарно
+// заменяет основной.
+type CalendarFeedRepository struct {
+	path    string
+	feeds   map[string]*entity.CalendarFeed // ключ — пользователь и пространство
+	byToken map[string]string
+	mutex   sync.RWMutex
+}
+
+// calendarFeedRecord лента в файле; в entity.CalendarFeed токен скрыт от JSON
+type calendarFeedRecord struct {
+	UserID      string    `json:"user_id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Token       string    `json:"token"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewCalendarFeedRepository загружает ленты из файла path, если он существует
+func NewCalendarFeedRepository(path string) (*CalendarFeedRepository, error) {
+	r := &CalendarFeedRepository{
+		path:    path,
+		feeds:   make(map[string]*entity.CalendarFeed),
+		byToken: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []calendarFeedRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		key := calendarFeedKey(record.UserID, record.WorkspaceID)
+		r.feeds[key] = &entity.CalendarFeed{
+			UserID:      record.UserID,
+			WorkspaceID: record.WorkspaceID,
+			Token:       record.Token,
+			CreatedAt:   record.CreatedAt,
+		}
+		r.byToken[record.Token] = key
+	}
+
+	return r, nil
+}
+
+func (r *CalendarFeedRepository) GetByUser(ctx context.Context, userID, workspaceID string) (*entity.CalendarFeed, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	feed, exists := r.feeds[calendarFeedKey(userID, workspaceID)]
+	if !exists {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	clone := *feed
+	return &clone, nil
+}
+
+func (r *CalendarFeedRepository) GetByToken(ctx context.Context, token string) (*entity.CalendarFeed, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, exists := r.byToken[token]
+	if !exists {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	clone := *r.feeds[key]
+	return &clone, nil
+}
+
+func (r *CalendarFeedRepository) Save(ctx context.Context, feed *entity.CalendarFeed) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := calendarFeedKey(feed.UserID, feed.WorkspaceID)
+
+	// Прежний токен перестает открывать ленту
+	previous, existed := r.feeds[key]
+	if existed {
+		delete(r.byToken, previous.Token)
+	}
+
+	clone := *feed
+	r.feeds[key] = &clone
+	r.byToken[feed.Token] = key
+
+	if err := r.save(); err != nil {
+		delete(r.byToken, feed.Token)
+		delete(r.feeds, key)
+		if existed {
+			r.feeds[key] = previous
+			r.byToken[previous.Token] = key
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *CalendarFeedRepository) Delete(ctx context.Context, userID, workspaceID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := calendarFeedKey(userID, workspaceID)
+	feed, exists := r.feeds[key]
+	if !exists {
+		return errors.New("calendar feed not found")
+	}
+
+	delete(r.byToken, feed.Token)
+	delete(r.feeds, key)
+
+	if err := r.save(); err != nil {
+		r.feeds[key] = feed
+		r.byToken[feed.Token] = key
+		return err
+	}
+
+	return nil
+}
+
+// save записывает все ленты в файл. Вызывается под мьютексом.
+func (r *CalendarFeedRepository) save() error {
+	records := make([]calendarFeedRecord, 0, len(r.feeds))
+	for _, feed := range r.feeds {
+		records = append(records, calendarFeedRecord{
+			UserID:      feed.UserID,
+			WorkspaceID: feed.WorkspaceID,
+			Token:       feed.Token,
+			CreatedAt:   feed.CreatedAt,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return calendarFeedKey(records[i].UserID, records[i].WorkspaceID) < calendarFeedKey(records[j].UserID, records[j].WorkspaceID)
+	})
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".calendar-feeds-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	// Файл должен оказаться на диске до переименования, иначе после
+	// сбоя питания можно получить пустой файл лент
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func calendarFeedKey(userID, workspaceID string) string {
+	return workspaceID + "/" + userID
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ChecklistRepository struct {
+	items map[string]*entity.ChecklistItem
+	seq   int
+	mutex sync.RWMutex
+}
+
+func NewChecklistRepository() *ChecklistRepository {
+	return &ChecklistRepository{
+		items: make(map[string]*entity.ChecklistItem),
+	}
+}
+
+func (r *ChecklistRepository) Create(ctx context.Context, item *entity.ChecklistItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if item.ID == "" {
+		r.seq++
+		item.ID = fmt.Sprintf("chk-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = item.CreatedAt
+
+	clone := *item
+	r.items[item.ID] = &clone
+	return nil
+}
+
+func (r *ChecklistRepository) GetByID(ctx context.Context, id string) (*entity.ChecklistItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	item, exists := r.items[id]
+	if !exists {
+		return nil, errors.New("checklist item not found")
+	}
+
+	clone := *item
+	return &clone, nil
+}
+
+func (r *ChecklistRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.ChecklistItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.ChecklistItem
+
+	for _, item := range r.items {
+		if item.TaskID == taskID {
+			clone := *item
+			result = append(result, &clone)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rank != result[j].Rank {
+			return result[i].Rank < result[j].Rank
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *ChecklistRepository) Update(ctx context.Context, item *entity.ChecklistItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.items[item.ID]; !exists {
+		return errors.New("checklist item not found")
+	}
+
+	item.UpdatedAt = time.Now()
+
+	clone := *item
+	r.items[item.ID] = &clone
+	return nil
+}
+
+func (r *ChecklistRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.items[id]; !exists {
+		return errors.New("checklist item not found")
+	}
+
+	delete(r.items, id)
+
+	return nil
+}
+
+func (r *ChecklistRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, item := range r.items {
+		if item.TaskID == taskID {
+			delete(r.items, id)
+		}
+	}
+
+	return nil
+}
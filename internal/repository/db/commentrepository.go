@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+type CommentRepository struct {
+	comments map[string]*entity.Comment
+	seq      int
+	mutex    sync.RWMutex
+}
+
+func NewCommentRepository() *CommentRepository {
+	return &CommentRepository{
+		comments: make(map[string]*entity.Comment),
+	}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if comment.ID == "" {
+		r.seq++
+		comment.ID = fmt.Sprintf("cmt-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	r.comments[comment.ID] = cloneComment(comment)
+	return nil
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id string) (*entity.Comment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	comment, exists := r.comments[id]
+	if !exists {
+		return nil, errors.New("comment not found")
+	}
+
+	return cloneComment(comment), nil
+}
+
+func (r *CommentRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.Comment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.Comment
+
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID {
+			result = append(result, cloneComment(comment))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.comments[comment.ID]; !exists {
+		return errors.New("comment not found")
+	}
+
+	comment.UpdatedAt = time.Now()
+	r.comments[comment.ID] = cloneComment(comment)
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.comments[id]; !exists {
+		return errors.New("comment not found")
+	}
+
+	delete(r.comments, id)
+
+	return nil
+}
+
+func (r *CommentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, comment := range r.comments {
+		if comment.TaskID == taskID {
+			delete(r.comments, id)
+		}
+	}
+
+	return nil
+}
+
+func cloneComment(comment *entity.Comment) *entity.Comment {
+	clone := *comment
+	clone.Mentions = append([]string(nil), comment.Mentions...)
+	clone.History = append([]entity.CommentRevision(nil), comment.History...)
+	return &clone
+}
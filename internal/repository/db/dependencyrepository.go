@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sync"
+	"time"
+)
+
+type DependencyRepository struct {
+	// Связи хранятся в двух индексах для быстрого обхода в обе стороны
+	blockers map[string]map[string]*entity.TaskDependency // blockedID -> blockerID -> связь
+	blocked  map[string]map[string]*entity.TaskDependency // blockerID -> blockedID -> связь
+	mutex    sync.RWMutex
+}
+
+func NewDependencyRepository() *DependencyRepository {
+	return &DependencyRepository{
+		blockers: make(map[string]map[string]*entity.TaskDependency),
+		blocked:  make(map[string]map[string]*entity.TaskDependency),
+	}
+}
+
+func (r *DependencyRepository) Add(ctx context.Context, dep *entity.TaskDependency) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.blockers[dep.BlockedID][dep.BlockerID]; exists {
+		return errors.New("dependency already exists")
+	}
+
+	dep.CreatedAt = time.Now()
+
+	if r.blockers[dep.BlockedID] == nil {
+		r.blockers[dep.BlockedID] = make(map[string]*entity.TaskDependency)
+	}
+	if r.blocked[dep.BlockerID] == nil {
+		r.blocked[dep.BlockerID] = make(map[string]*entity.TaskDependency)
+	}
+
+	stored := *dep
+	r.blockers[dep.BlockedID][dep.BlockerID] = &stored
+	r.blocked[dep.BlockerID][dep.BlockedID] = &stored
+
+	return nil
+}
+
+func (r *DependencyRepository) Remove(ctx context.Context, blockerID, blockedID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.blockers[blockedID][blockerID]; !exists {
+		return errors.New("dependency not found")
+	}
+
+	delete(r.blockers[blockedID], blockerID)
+	delete(r.blocked[blockerID], blockedID)
+
+	return nil
+}
+
+func (r *DependencyRepository) RemoveAllForTask(ctx context.Context, taskID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for blockerID := range r.blockers[taskID] {
+		delete(r.blocked[blockerID], taskID)
+	}
+	for blockedID := range r.blocked[taskID] {
+		delete(r.blockers[blockedID], taskID)
+	}
+
+	delete(r.blockers, taskID)
+	delete(r.blocked, taskID)
+
+	return nil
+}
+
+func (r *DependencyRepository) GetBlockers(ctx context.Context, taskID string) ([]*entity.TaskDependency, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return copyDependencies(r.blockers[taskID]), nil
+}
+
+func (r *DependencyRepository) GetBlocked(ctx context.Context, taskID string) ([]*entity.TaskDependency, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return copyDependencies(r.blocked[taskID]), nil
+}
+
+func copyDependencies(deps map[string]*entity.TaskDependency) []*entity.TaskDependency {
+	result := make([]*entity.TaskDependency, 0, len(deps))
+	for _, dep := range deps {
+		clone := *dep
+		result = append(result, &clone)
+	}
+	return result
+}
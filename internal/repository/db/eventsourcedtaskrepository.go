@@ -0,0 +1,848 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Типы событий потока задачи
+const (
+	taskCreated          = "Created"
+	taskRetitled         = "Retitled"
+	taskDescribed        = "DescriptionChanged"
+	taskStatusChanged    = "StatusChanged"
+	taskReassigned       = "Reassigned"
+	taskReparented       = "Reparented"
+	taskMovedOnBoard     = "MovedOnBoard"
+	taskRescheduled      = "Rescheduled"
+	taskChecklistCounted = "ChecklistCounted"
+	taskArchived         = "Archived"
+	taskUnarchived       = "Unarchived"
+	taskTrashed          = "Trashed"
+	taskUntrashed        = "Untrashed"
+	taskUpdated          = "Updated" // поля, не покрытые специальными событиями
+	taskDeleted          = "Deleted"
+)
+
+// taskEventFields поля задачи (имена JSON), изменение которых записывается
+// событием соответствующего типа. Данные события содержат значения всех
+// полей группы, поэтому событие применяется без чтения предыдущих.
+var taskEventFields = []struct {
+	eventType string
+	fields    []string
+}{
+	{taskRetitled, []string{"title"}},
+	{taskDescribed, []string{"description"}},
+	{taskStatusChanged, []string{"status", "status_category", "completed_at"}},
+	{taskReassigned, []string{"assignee_id"}},
+	{taskReparented, []string{"parent_id"}},
+	{taskMovedOnBoard, []string{"project_id", "rank"}},
+	{taskRescheduled, []string{"due_at", "recurrence", "series_id", "occurrence"}},
+	{taskChecklistCounted, []string{"checklist_done", "checklist_total"}},
+	// Возврат из архива и корзины очищает те же поля и записывается
+	// событиями Unarchived и Untrashed
+	{taskArchived, []string{"archived_at"}},
+	{taskTrashed, []string{"deleted_at", "deleted_by"}},
+}
+
+// taskEventIgnoredFields не порождают событий: ID и время создания не
+// меняются, а время изменения равно времени события
+var taskEventIgnoredFields = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// taskEvent событие потока задачи. Data — значения измененных полей в
+// JSON-представлении entity.Task (для Created — задача целиком).
+type taskEvent struct {
+	TaskID  string          `json:"task_id"`
+	Version int64           `json:"version"`
+	Type    string          `json:"type"`
+	At      time.Time       `json:"at"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// taskSnapshot состояние задачи после события с номером Version; nil Task —
+// задача удалена
+type taskSnapshot struct {
+	TaskID  string       `json:"task_id"`
+	Version int64        `json:"version"`
+	At      time.Time    `json:"at"`
+	Task    *entity.Task `json:"task"`
+}
+
+// journalEntry строка журнала — одна зафиксированная транзакция: события
+// потоков задач вместе с доменными событиями outbox, либо отметка о
+// завершении публикации записей outbox
+type journalEntry struct {
+	Events   []taskEvent           `json:"events,omitempty"`
+	Outbox   []*entity.DomainEvent `json:"outbox,omitempty"`
+	Finished []string              `json:"finished,omitempty"`
+}
+
+// EventSourcedTaskRepository хранит задачи как потоки событий в журнале
+// только для добавления. Текущее состояние задач — проекция, которая при
+// запуске восстанавливается из последних снимков и событий после них.
+// Журнал хранит и историю, поэтому доступно состояние задачи на любой момент.
+type EventSourcedTaskRepository struct {
+	journalPath   string
+	snapshotsPath string
+	snapshotEvery int64
+
+	streams   map[string][]taskEvent
+	snapshots map[string][]taskSnapshot
+	tasks     *taskTable // проекция текущего состояния
+	versions  map[string]int64
+	seq       int
+	outbox    *outboxLog
+	audit     *auditLog // журнал изменений из событий outbox журнала
+	mutex     sync.RWMutex
+	txMutex   sync.Mutex
+}
+
+// NewEventSourcedTaskRepository загружает журнал и снимки из каталога dir.
+// Снимок задачи сохраняется каждые snapshotEvery событий её потока.
+func NewEventSourcedTaskRepository(dir string, snapshotEvery int) (*EventSourcedTaskRepository, error) {
+	if snapshotEvery <= 0 {
+		snapshotEvery = 100
+	}
+
+	r := &EventSourcedTaskRepository{
+		journalPath:   filepath.Join(dir, "tasks.journal"),
+		snapshotsPath: filepath.Join(dir, "tasks.snapshots"),
+		snapshotEvery: int64(snapshotEvery),
+		streams:       make(map[string][]taskEvent),
+		snapshots:     make(map[string][]taskSnapshot),
+		tasks:         newTaskTable(),
+		versions:      make(map[string]int64),
+		outbox:        newOutboxLog(),
+		audit:         newAuditLog(),
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSnapshots(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadJournal(); err != nil {
+		return nil, err
+	}
+
+	for id := range r.streams {
+		task, err := r.replay(id, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if task != nil {
+			r.tasks.put(task)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *EventSourcedTaskRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+
+	tx := &taskTx{writes: make(map[string]*entity.Task)}
+	if err := fn(context.WithValue(ctx, taskTxKey{}, tx)); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.commit(tx)
+}
+
+// commit превращает изменения транзакции в события потоков и записывает
+// их в журнал одной строкой вместе с событиями outbox; проекция меняется
+// только после успешной записи. Вызывается под мьютексом.
+func (r *EventSourcedTaskRepository) commit(tx *taskTx) error {
+	ids := make([]string, 0, len(tx.writes))
+	for id := range tx.writes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Все события транзакции получают время её фиксации: время изменения в
+	// самой задаче выставляют не все записи (например, счетчики чек-листа)
+	at := time.Now()
+
+	var events []taskEvent
+	for _, id := range ids {
+		changes, err := diffTask(id, r.tasks.get(id), tx.writes[id], r.versions[id], at)
+		if err != nil {
+			return err
+		}
+		events = append(events, changes...)
+	}
+
+	for _, event := range tx.events {
+		r.outbox.assign(event)
+	}
+
+	if len(events) > 0 || len(tx.events) > 0 {
+		if err := r.appendJournal(journalEntry{Events: events, Outbox: tx.events}); err != nil {
+			r.outbox.unassign(tx.events)
+			return err
+		}
+	}
+
+	for _, event := range events {
+		if err := r.apply(event); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range tx.events {
+		r.outbox.restore(event)
+		r.audit.record(event)
+	}
+
+	for _, id := range ids {
+		r.snapshotIfDue(id)
+	}
+
+	return nil
+}
+
+// GetAsOf возвращает состояние задачи на момент at
+func (r *EventSourcedTaskRepository) GetAsOf(ctx context.Context, id string, at time.Time) (*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	task, err := r.replay(id, at)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, errors.New("task not found")
+	}
+
+	return task, nil
+}
+
+func (r *EventSourcedTaskRepository) Create(ctx context.Context, task *entity.Task) error {
+	if txFromContext(ctx) == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.Create(ctx, task)
+		})
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if task.ID == "" {
+		r.seq++
+		task.ID = fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+
+	txFromContext(ctx).writes[task.ID] = cloneTask(task)
+	return nil
+}
+
+func (r *EventSourcedTaskRepository) GetByID(ctx context.Context, id string) (*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	task, exists := r.lookup(ctx, id)
+	if !exists {
+		return nil, errors.New("task not found")
+	}
+
+	return cloneTask(task), nil
+}
+
+func (r *EventSourcedTaskRepository) GetAll(ctx context.Context, userID string) ([]*entity.Task, error) {
+	return r.query(scopeActive, func(task *entity.Task) bool {
+		return task.UserID == userID
+	}), nil
+}
+
+func (r *EventSourcedTaskRepository) GetPage(ctx context.Context, userID, after string, limit int) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.page(scopeActive, after, limit, func(task *entity.Task) bool {
+		return task.UserID == userID
+	}), nil
+}
+
+func (r *EventSourcedTaskRepository) GetArchived(ctx context.Context, userID string) ([]*entity.Task, error) {
+	result := r.query(scopeArchived, func(task *entity.Task) bool {
+		return task.UserID == userID
+	})
+
+	sortTasksByArchivation(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) GetChildren(ctx context.Context, parentID string) ([]*entity.Task, error) {
+	result := r.query(scopeAll, func(task *entity.Task) bool {
+		return task.ParentID == parentID
+	})
+
+	sortTasksByCreation(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) GetByProject(ctx context.Context, projectID string) ([]*entity.Task, error) {
+	result := r.query(scopeActive, func(task *entity.Task) bool {
+		return task.ProjectID == projectID
+	})
+
+	sortTasksByRank(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) GetRecurring(ctx context.Context) ([]*entity.Task, error) {
+	return r.query(scopeAll, func(task *entity.Task) bool {
+		return task.Recurrence != nil
+	}), nil
+}
+
+func (r *EventSourcedTaskRepository) GetDueBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	return r.query(scopeActive, func(task *entity.Task) bool {
+		return task.DueAt != nil && task.DueAt.Before(before)
+	}), nil
+}
+
+func (r *EventSourcedTaskRepository) GetCompletedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	return r.query(scopeActive, func(task *entity.Task) bool {
+		return completedBefore(task, before)
+	}), nil
+}
+
+func (r *EventSourcedTaskRepository) GetTrashed(ctx context.Context, userID string) ([]*entity.Task, error) {
+	result := r.query(scopeTrash, func(task *entity.Task) bool {
+		return task.UserID == userID
+	})
+
+	sortTasksByDeletion(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) GetByWorkspace(ctx context.Context, workspaceID string) ([]*entity.Task, error) {
+	match := func(task *entity.Task) bool { return task.WorkspaceID == workspaceID }
+	result := append(r.query(scopeAll, match), r.query(scopeTrash, match)...)
+
+	sortTasksByCreation(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) GetTrashedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	result := r.query(scopeTrash, func(task *entity.Task) bool {
+		return task.DeletedAt.Before(before)
+	})
+
+	sortTasksByDeletion(result)
+	return result, nil
+}
+
+func (r *EventSourcedTaskRepository) Update(ctx context.Context, task *entity.Task) error {
+	if txFromContext(ctx) == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.Update(ctx, task)
+		})
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.lookup(ctx, task.ID); !exists {
+		return errors.New("task not found")
+	}
+
+	task.UpdatedAt = time.Now()
+	txFromContext(ctx).writes[task.ID] = cloneTask(task)
+
+	return nil
+}
+
+func (r *EventSourcedTaskRepository) SetChecklistStats(ctx context.Context, id string, done, total int) error {
+	if txFromContext(ctx) == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.SetChecklistStats(ctx, id, done, total)
+		})
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	task, exists := r.lookup(ctx, id)
+	if !exists {
+		return errors.New("task not found")
+	}
+
+	task = cloneTask(task)
+	task.ChecklistDone = done
+	task.ChecklistTotal = total
+	task.UpdatedAt = time.Now()
+	txFromContext(ctx).writes[id] = task
+
+	return nil
+}
+
+func (r *EventSourcedTaskRepository) Delete(ctx context.Context, id string) error {
+	if txFromContext(ctx) == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.Delete(ctx, id)
+		})
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.lookup(ctx, id); !exists {
+		return errors.New("task not found")
+	}
+
+	txFromContext(ctx).writes[id] = nil
+
+	return nil
+}
+
+func (r *EventSourcedTaskRepository) appendOutbox(ctx context.Context, event *entity.DomainEvent) error {
+	tx := txFromContext(ctx)
+	if tx == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.appendOutbox(ctx, event)
+		})
+	}
+
+	tx.events = append(tx.events, event)
+	return nil
+}
+
+func (r *EventSourcedTaskRepository) auditHistory(taskID string) []*entity.AuditEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.audit.history(taskID)
+}
+
+func (r *EventSourcedTaskRepository) claimOutbox(now, leaseUntil time.Time, limit int) []*entity.OutboxRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.claim(now, leaseUntil, limit)
+}
+
+// updateOutbox запоминает в журнале завершение публикации, чтобы после
+// перезапуска опубликованные события не отправлялись снова. Ход повторов
+// не сохраняется: незавершенная запись после перезапуска публикуется заново.
+func (r *EventSourcedTaskRepository) updateOutbox(record *entity.OutboxRecord) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if record.IsFinished() {
+		if err := r.appendJournal(journalEntry{Finished: []string{record.Event.ID}}); err != nil {
+			return err
+		}
+	}
+
+	return r.outbox.update(record)
+}
+
+func (r *EventSourcedTaskRepository) deleteOutboxBefore(before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.deletePublishedBefore(before), nil
+}
+
+// lookup возвращает задачу с учетом незафиксированных изменений транзакции.
+// Вызывается под мьютексом.
+func (r *EventSourcedTaskRepository) lookup(ctx context.Context, id string) (*entity.Task, bool) {
+	if tx := txFromContext(ctx); tx != nil {
+		if task, staged := tx.writes[id]; staged {
+			return task, task != nil
+		}
+	}
+
+	task := r.tasks.get(id)
+	return task, task != nil
+}
+
+func (r *EventSourcedTaskRepository) query(scope taskScope, match func(task *entity.Task) bool) []*entity.Task {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.find(scope, match)
+}
+
+// apply применяет событие к проекции и добавляет его в поток задачи
+func (r *EventSourcedTaskRepository) apply(event taskEvent) error {
+	task, err := applyTaskEvent(r.tasks.get(event.TaskID), event)
+	if err != nil {
+		return err
+	}
+
+	if task == nil {
+		r.tasks.remove(event.TaskID)
+	} else {
+		r.tasks.put(task)
+	}
+
+	r.versions[event.TaskID] = event.Version
+	r.streams[event.TaskID] = append(r.streams[event.TaskID], event)
+
+	return nil
+}
+
+// replay восстанавливает задачу по последнему подходящему снимку и событиям
+// после него. Нулевой at — текущее состояние, иначе состояние на момент at.
+func (r *EventSourcedTaskRepository) replay(id string, at time.Time) (*entity.Task, error) {
+	var task *entity.Task
+	var version int64
+
+	snapshots := r.snapshots[id]
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if at.IsZero() || !snapshots[i].At.After(at) {
+			if snapshots[i].Task != nil {
+				task = cloneTask(snapshots[i].Task)
+			}
+			version = snapshots[i].Version
+			break
+		}
+	}
+
+	for _, event := range r.streams[id] {
+		if event.Version <= version {
+			continue
+		}
+		if !at.IsZero() && event.At.After(at) {
+			break
+		}
+
+		var err error
+		if task, err = applyTaskEvent(task, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+// snapshotIfDue сохраняет снимок задачи, если её поток достиг очередной
+// границы. Снимок лишь ускоряет восстановление, поэтому ошибка записи не
+// отменяет уже зафиксированную транзакцию.
+func (r *EventSourcedTaskRepository) snapshotIfDue(id string) {
+	stream := r.streams[id]
+	if len(stream) == 0 {
+		return
+	}
+
+	last := stream[len(stream)-1]
+	var lastSnapshot int64
+	if snapshots := r.snapshots[id]; len(snapshots) > 0 {
+		lastSnapshot = snapshots[len(snapshots)-1].Version
+	}
+
+	if last.Version-lastSnapshot < r.snapshotEvery {
+		return
+	}
+
+	snapshot := taskSnapshot{TaskID: id, Version: last.Version, At: last.At}
+	if task := r.tasks.get(id); task != nil {
+		snapshot.Task = cloneTask(task)
+	}
+
+	if err := appendLine(r.snapshotsPath, snapshot, false); err != nil {
+		return
+	}
+
+	r.snapshots[id] = append(r.snapshots[id], snapshot)
+}
+
+func (r *EventSourcedTaskRepository) appendJournal(entry journalEntry) error {
+	return appendLine(r.journalPath, entry, true)
+}
+
+func (r *EventSourcedTaskRepository) loadSnapshots() error {
+	return readLines(r.snapshotsPath, func(line []byte) error {
+		var snapshot taskSnapshot
+		if err := json.Unmarshal(line, &snapshot); err != nil {
+			return err
+		}
+
+		r.snapshots[snapshot.TaskID] = append(r.snapshots[snapshot.TaskID], snapshot)
+		return nil
+	})
+}
+
+// loadJournal читает журнал: события — в потоки задач, доменные события —
+// в журнал изменений и в outbox, кроме уже опубликованных
+func (r *EventSourcedTaskRepository) loadJournal() error {
+	var pending []*entity.DomainEvent
+	finished := make(map[string]bool)
+
+	err := readLines(r.journalPath, func(line []byte) error {
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+
+		for _, event := range entry.Events {
+			// Счетчик ID продолжается после перезапуска
+			if event.Type == taskCreated {
+				r.seq++
+			}
+			r.versions[event.TaskID] = event.Version
+			r.streams[event.TaskID] = append(r.streams[event.TaskID], event)
+		}
+
+		pending = append(pending, entry.Outbox...)
+		for _, event := range entry.Outbox {
+			r.audit.record(event)
+		}
+		for _, id := range entry.Finished {
+			finished[id] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.outbox.eventSeq = len(pending)
+	for _, event := range pending {
+		if finished[event.ID] {
+			// Номер события в потоке задачи должен продолжаться после перезапуска
+			if event.Sequence > r.outbox.sequences[event.TaskID] {
+				r.outbox.sequences[event.TaskID] = event.Sequence
+			}
+			continue
+		}
+		r.outbox.restore(event)
+	}
+
+	return nil
+}
+
+// diffTask возвращает события, переводящие задачу из состояния previous в
+// current; version — номер последнего события её потока
+func diffTask(id string, previous, current *entity.Task, version int64, at time.Time) ([]taskEvent, error) {
+	switch {
+	case previous == nil && current == nil:
+		return nil, nil
+	case previous == nil:
+		data, err := json.Marshal(current)
+		if err != nil {
+			return nil, err
+		}
+		return []taskEvent{{TaskID: id, Version: version + 1, Type: taskCreated, At: at, Data: data}}, nil
+	case current == nil:
+		return []taskEvent{{TaskID: id, Version: version + 1, Type: taskDeleted, At: at}}, nil
+	}
+
+	before := taskFieldValues(previous)
+	after := taskFieldValues(current)
+
+	// Значения сравниваются в JSON: одинаковый момент времени в разных
+	// часовых поясах не является изменением
+	changed := make(map[string]bool)
+	for field, value := range after {
+		if taskEventIgnoredFields[field] {
+			continue
+		}
+
+		old, err := json.Marshal(before[field])
+		if err != nil {
+			return nil, err
+		}
+		current, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(old, current) {
+			changed[field] = true
+		}
+	}
+
+	var events []taskEvent
+	addEvent := func(eventType string, fields []string) error {
+		data := make(map[string]interface{})
+		for _, field := range fields {
+			data[field] = after[field]
+			delete(changed, field)
+		}
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		version++
+		events = append(events, taskEvent{TaskID: id, Version: version, Type: eventType, At: at, Data: encoded})
+		return nil
+	}
+
+	for _, group := range taskEventFields {
+		eventType := group.eventType
+		switch {
+		case eventType == taskArchived && !current.IsArchived():
+			eventType = taskUnarchived
+		case eventType == taskTrashed && !current.IsTrashed():
+			eventType = taskUntrashed
+		}
+
+		for _, field := range group.fields {
+			if changed[field] {
+				if err := addEvent(eventType, group.fields); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		var rest []string
+		for field := range changed {
+			rest = append(rest, field)
+		}
+		sort.Strings(rest)
+
+		if err := addEvent(taskUpdated, rest); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// applyTaskEvent возвращает состояние задачи после события; nil — задачи нет
+func applyTaskEvent(task *entity.Task, event taskEvent) (*entity.Task, error) {
+	switch event.Type {
+	case taskCreated:
+		var created entity.Task
+		if err := json.Unmarshal(event.Data, &created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	case taskDeleted:
+		return nil, nil
+	}
+
+	if task == nil {
+		return nil, fmt.Errorf("event %s of task %s applied before creation", event.Type, event.TaskID)
+	}
+
+	// Данные события — подмножество полей задачи в JSON, поэтому
+	// декодирование поверх копии меняет ровно эти поля
+	updated := cloneTask(task)
+	if err := json.Unmarshal(event.Data, updated); err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = event.At
+
+	return updated, nil
+}
+
+// taskFieldValues значения полей задачи по их именам в JSON. Значения
+// берутся из самих полей, а не из JSON, чтобы пустые значения с omitempty
+// тоже попадали в события и очищали поле при применении.
+func taskFieldValues(task *entity.Task) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	v := reflect.ValueOf(task).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		values[name] = v.Field(i).Interface()
+	}
+
+	return values
+}
+
+// appendLine дописывает значение строкой JSON в конец файла
+func appendLine(path string, value interface{}, sync bool) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+// readLines вызывает fn для каждой строки файла. Недописанная последняя
+// строка (сбой во время записи) отбрасывается и обрезается, чтобы
+// следующая запись начиналась с новой строки.
+func readLines(path string, fn func(line []byte) error) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if err := fn(trimmed); err != nil {
+				return fmt.Errorf("%s at offset %d: %w", path, offset, err)
+			}
+		}
+		offset += int64(len(line))
+	}
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ImportJobRepository хранит фоновые импорты в JSON-файле, чтобы прерванный
+// импорт продолжился после перезапуска. Как и задания напоминаний, каждое
+// изменение записывается во временный файл и атомарно заменяет основной.
+type ImportJobRepository struct {
+	path  string
+	jobs  map[string]*entity.ImportJob
+	seq   int
+	mutex sync.RWMutex
+}
+
+// NewImportJobRepository загружает импорты из файла path, если он существует
+func NewImportJobRepository(path string) (*ImportJobRepository, error) {
+	r := &ImportJobRepository{
+		path: path,
+		jobs: make(map[string]*entity.ImportJob),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*entity.ImportJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		r.jobs[job.ID] = job
+	}
+
+	return r, nil
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *entity.ImportJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if job.ID == "" {
+		r.seq++
+		job.ID = fmt.Sprintf("imp-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+	job.CreatedAt = time.Now()
+
+	r.jobs[job.ID] = cloneImportJob(job)
+
+	if err := r.save(); err != nil {
+		delete(r.jobs, job.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ImportJobRepository) GetByID(ctx context.Context, id string) (*entity.ImportJob, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, errors.New("import job not found")
+	}
+
+	return cloneImportJob(job), nil
+}
+
+func (r *ImportJobRepository) GetByUser(ctx context.Context, userID string) ([]*entity.ImportJob, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.ImportJob
+	for _, job := range r.jobs {
+		if job.UserID == userID {
+			result = append(result, cloneImportJob(job))
+		}
+	}
+
+	sortImportJobs(result)
+	return result, nil
+}
+
+// ClaimNext забирает самый ранний ожидающий импорт или импорт, аренда
+// которого истекла: процесс, выполнявший его, остановился
+func (r *ImportJobRepository) ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*entity.ImportJob, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var candidates []*entity.ImportJob
+	for _, job := range r.jobs {
+		if job.Status == entity.ImportJobQueued || job.Status == entity.ImportJobRunning && !job.LeaseUntil.After(now) {
+			candidates = append(candidates, job)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sortImportJobs(candidates)
+
+	job := candidates[0]
+	previous := *job
+	job.Status = entity.ImportJobRunning
+	job.LeaseUntil = leaseUntil
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+
+	if err := r.save(); err != nil {
+		*job = previous
+		return nil, err
+	}
+
+	return cloneImportJob(job), nil
+}
+
+func (r *ImportJobRepository) Update(ctx context.Context, job *entity.ImportJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.jobs[job.ID]
+	if !exists {
+		return errors.New("import job not found")
+	}
+
+	r.jobs[job.ID] = cloneImportJob(job)
+
+	if err := r.save(); err != nil {
+		r.jobs[job.ID] = previous
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает все импорты в файл; вызывается под блокировкой
+func (r *ImportJobRepository) save() error {
+	jobs := make([]*entity.ImportJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sortImportJobs(jobs)
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".import-jobs-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortImportJobs(jobs []*entity.ImportJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
+
+func cloneImportJob(job *entity.ImportJob) *entity.ImportJob {
+	clone := *job
+	clone.Problems = append([]string(nil), job.Problems...)
+	if job.StartedAt != nil {
+		startedAt := *job.StartedAt
+		clone.StartedAt = &startedAt
+	}
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		clone.FinishedAt = &finishedAt
+	}
+	if job.StatusMap != nil {
+		clone.StatusMap = make(map[string]entity.TaskStatus, len(job.StatusMap))
+		for key, status := range job.StatusMap {
+			clone.StatusMap[key] = status
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NotificationPreferencesRepository хранит настройки уведомлений, отметки
+// об отправленных письмах о просрочке и очередь неотправленных писем
+// в JSON-файле; как и задания
+// напоминаний, каждое изменение записывается во временный файл и атомарно
+// заменяет основной
+type NotificationPreferencesRepository struct {
+	path        string
+	preferences map[string]*entity.NotificationPreferences
+	overdue     map[string]time.Time // ID задачи -> срок, о просрочке которого сообщено
+	pending     []*entity.PendingEmail
+	mutex       sync.RWMutex
+}
+
+// notificationFile содержимое файла настроек уведомлений
+type notificationFile struct {
+	Preferences []*entity.NotificationPreferences `json:"preferences"`
+	Overdue     map[string]time.Time              `json:"overdue_notified"`
+	Pending     []*entity.PendingEmail            `json:"pending_emails,omitempty"`
+}
+
+// NewNotificationPreferencesRepository загружает настройки из файла path,
+// если он существует
+func NewNotificationPreferencesRepository(path string) (*NotificationPreferencesRepository, error) {
+	r := &NotificationPreferencesRepository{
+		path:        path,
+		preferences: make(map[string]*entity.NotificationPreferences),
+		overdue:     make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file notificationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, preferences := range file.Preferences {
+		r.preferences[preferences.UserID] = preferences
+	}
+	for taskID, dueAt := range file.Overdue {
+		r.overdue[taskID] = dueAt
+	}
+	r.pending = file.Pending
+
+	return r, nil
+}
+
+func (r *NotificationPreferencesRepository) GetByUser(ctx context.Context, userID string) (*entity.NotificationPreferences, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	preferences, exists := r.preferences[userID]
+	if !exists {
+		return nil, errors.New("notification preferences not found")
+	}
+
+	return cloneNotificationPreferences(preferences), nil
+}
+
+func (r *NotificationPreferencesRepository) Save(ctx context.Context, preferences *entity.NotificationPreferences) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, existed := r.preferences[preferences.UserID]
+
+	preferences.UpdatedAt = time.Now()
+	r.preferences[preferences.UserID] = cloneNotificationPreferences(preferences)
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.preferences[preferences.UserID] = previous
+		} else {
+			delete(r.preferences, preferences.UserID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// MarkOverdueNotified отмечает, что о просрочке задачи со сроком dueAt
+// сообщено, и возвращает false, если отметка уже была
+func (r *NotificationPreferencesRepository) MarkOverdueNotified(ctx context.Context, taskID string, dueAt time.Time) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, existed := r.overdue[taskID]
+	if existed && previous.Equal(dueAt) {
+		return false, nil
+	}
+
+	r.overdue[taskID] = dueAt
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.overdue[taskID] = previous
+		} else {
+			delete(r.overdue, taskID)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClearOverdueNotified удаляет отметку о просрочке задачи; отсутствие
+// отметки не ошибка
+func (r *NotificationPreferencesRepository) ClearOverdueNotified(ctx context.Context, taskID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.overdue[taskID]
+	if !exists {
+		return nil
+	}
+
+	delete(r.overdue, taskID)
+
+	if err := r.save(); err != nil {
+		r.overdue[taskID] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (r *NotificationPreferencesRepository) GetPendingEmails(ctx context.Context) ([]*entity.PendingEmail, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return clonePendingEmails(r.pending), nil
+}
+
+func (r *NotificationPreferencesRepository) SavePendingEmails(ctx context.Context, emails []*entity.PendingEmail) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.pending
+	r.pending = clonePendingEmails(emails)
+
+	if err := r.save(); err != nil {
+		r.pending = previous
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает настройки, отметки и очередь писем в файл; вызывается под блокировкой
+func (r *NotificationPreferencesRepository) save() error {
+	file := notificationFile{
+		Preferences: make([]*entity.NotificationPreferences, 0, len(r.preferences)),
+		Overdue:     r.overdue,
+		Pending:     r.pending,
+	}
+	for _, preferences := range r.preferences {
+		file.Preferences = append(file.Preferences, preferences)
+	}
+	sort.Slice(file.Preferences, func(i, j int) bool {
+		return file.Preferences[i].UserID < file.Preferences[j].UserID
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".notifications-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func cloneNotificationPreferences(preferences *entity.NotificationPreferences) *entity.NotificationPreferences {
+	clone := *preferences
+	clone.DisabledEvents = append([]entity.NotificationEvent(nil), preferences.DisabledEvents...)
+	return &clone
+}
+
+func clonePendingEmails(emails []*entity.PendingEmail) []*entity.PendingEmail {
+	var result []*entity.PendingEmail
+	for _, email := range emails {
+		clone := *email
+		clone.Events = make([]entity.EmailEvent, len(email.Events))
+		for i, event := range email.Events {
+			clone.Events[i] = event
+			clone.Events[i].Changes = append([]entity.EmailChange(nil), event.Changes...)
+		}
+		result = append(result, &clone)
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// OutboxStore хранилище задач, в транзакциях которого фиксируется outbox.
+// Реализуется хранилищами этого пакета: записи outbox лежат рядом с
+// задачами, поэтому событие фиксируется вместе с изменением.
+type OutboxStore interface {
+	// appendOutbox добавляет событие; внутри транзакции — при её фиксации
+	appendOutbox(ctx context.Context, event *entity.DomainEvent) error
+	claimOutbox(now, leaseUntil time.Time, limit int) []*entity.OutboxRecord
+	updateOutbox(record *entity.OutboxRecord) error
+	deleteOutboxBefore(before time.Time) (int, error)
+}
+
+// OutboxRepository outbox событий задач поверх хранилища задач
+type OutboxRepository struct {
+	store OutboxStore
+}
+
+func NewOutboxRepository(store OutboxStore) *OutboxRepository {
+	return &OutboxRepository{
+		store: store,
+	}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, event *entity.DomainEvent) error {
+	event = cloneDomainEvent(event)
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	return r.store.appendOutbox(ctx, event)
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.OutboxRecord, error) {
+	return r.store.claimOutbox(now, leaseUntil, limit), nil
+}
+
+func (r *OutboxRepository) Update(ctx context.Context, record *entity.OutboxRecord) error {
+	return r.store.updateOutbox(record)
+}
+
+func (r *OutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int, error) {
+	return r.store.deleteOutboxBefore(before)
+}
+
+// outboxLog записи outbox в порядке добавления. Не синхронизирован:
+// вызывается под мьютексом хранилища, которому принадлежит.
+type outboxLog struct {
+	records   []*entity.OutboxRecord
+	eventSeq  int
+	sequences map[string]int64 // номер последнего события каждой задачи
+}
+
+func newOutboxLog() *outboxLog {
+	return &outboxLog{
+		sequences: make(map[string]int64),
+	}
+}
+
+// append назначает событию ID и номер в потоке задачи и добавляет его в очередь
+func (l *outboxLog) append(event *entity.DomainEvent) {
+	l.assign(event)
+	l.restore(event)
+}
+
+// assign назначает событию ID и следующий номер в потоке задачи
+func (l *outboxLog) assign(event *entity.DomainEvent) {
+	l.eventSeq++
+	event.ID = fmt.Sprintf("evt-%s-%d", time.Now().Format("20060102150405"), l.eventSeq)
+	l.sequences[event.TaskID]++
+	event.Sequence = l.sequences[event.TaskID]
+}
+
+// unassign отменяет assign для событий, которые не удалось зафиксировать
+func (l *outboxLog) unassign(events []*entity.DomainEvent) {
+	for i := len(events) - 1; i >= 0; i-- {
+		l.eventSeq--
+		l.sequences[events[i].TaskID]--
+	}
+}
+
+// restore добавляет в очередь событие, которому ID и номер уже назначены
+// (например, загруженное с диска)
+func (l *outboxLog) restore(event *entity.DomainEvent) {
+	if event.Sequence > l.sequences[event.TaskID] {
+		l.sequences[event.TaskID] = event.Sequence
+	}
+
+	l.records = append(l.records, &entity.OutboxRecord{
+		Event:         event,
+		Status:        entity.OutboxPending,
+		NextAttemptAt: event.OccurredAt,
+	})
+}
+
+// claim забирает для каждой задачи её самую раннюю незавершенную запись,
+// если та готова к публикации
+func (l *outboxLog) claim(now, leaseUntil time.Time, limit int) []*entity.OutboxRecord {
+	var claimed []*entity.OutboxRecord
+	// Задачи, чья самая ранняя незавершенная запись уже просмотрена
+	heads := make(map[string]bool)
+
+	for _, record := range l.records {
+		if record.IsFinished() || heads[record.Event.TaskID] {
+			continue
+		}
+		heads[record.Event.TaskID] = true
+
+		ready := record.Status == entity.OutboxPending && !record.NextAttemptAt.After(now) ||
+			record.Status == entity.OutboxPublishing && !record.LeaseUntil.After(now)
+		if !ready {
+			continue
+		}
+
+		record.Status = entity.OutboxPublishing
+		record.LeaseUntil = leaseUntil
+		claimed = append(claimed, cloneOutboxRecord(record))
+
+		if limit > 0 && len(claimed) == limit {
+			break
+		}
+	}
+
+	return claimed
+}
+
+func (l *outboxLog) update(record *entity.OutboxRecord) error {
+	for i, existing := range l.records {
+		if existing.Event.ID == record.Event.ID {
+			l.records[i] = cloneOutboxRecord(record)
+			return nil
+		}
+	}
+
+	return errors.New("outbox record not found")
+}
+
+func (l *outboxLog) deletePublishedBefore(before time.Time) int {
+	kept := l.records[:0]
+	deleted := 0
+
+	for _, record := range l.records {
+		if record.Status == entity.OutboxPublished && record.PublishedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, record)
+	}
+
+	// Хвост старого массива не должен удерживать удаленные записи
+	for i := len(kept); i < len(l.records); i++ {
+		l.records[i] = nil
+	}
+	l.records = kept
+
+	return deleted
+}
+
+func cloneOutboxRecord(record *entity.OutboxRecord) *entity.OutboxRecord {
+	clone := *record
+	clone.Event = cloneDomainEvent(record.Event)
+	clone.Delivered = append([]string(nil), record.Delivered...)
+	if record.PublishedAt != nil {
+		publishedAt := *record.PublishedAt
+		clone.PublishedAt = &publishedAt
+	}
+	return &clone
+}
+
+func cloneDomainEvent(event *entity.DomainEvent) *entity.DomainEvent {
+	clone := *event
+	if event.Task != nil {
+		clone.Task = cloneTask(event.Task)
+	}
+	if event.Previous != nil {
+		clone.Previous = cloneTask(event.Previous)
+	}
+	return &clone
+}
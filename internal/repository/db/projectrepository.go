@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ProjectRepository struct {
+	projects map[string]*entity.Project
+	seq      int
+	mutex    sync.RWMutex
+}
+
+func NewProjectRepository() *ProjectRepository {
+	return &ProjectRepository{
+		projects: make(map[string]*entity.Project),
+	}
+}
+
+func (r *ProjectRepository) Create(ctx context.Context, project *entity.Project) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if project.ID == "" {
+		r.seq++
+		project.ID = fmt.Sprintf("prj-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+
+	r.projects[project.ID] = cloneProject(project)
+	return nil
+}
+
+func (r *ProjectRepository) GetByID(ctx context.Context, id string) (*entity.Project, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	project, exists := r.projects[id]
+	if !exists {
+		return nil, errors.New("project not found")
+	}
+
+	return cloneProject(project), nil
+}
+
+func (r *ProjectRepository) GetAll(ctx context.Context, userID string) ([]*entity.Project, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.Project
+
+	for _, project := range r.projects {
+		if project.UserID == userID {
+			result = append(result, cloneProject(project))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, project *entity.Project) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.projects[project.ID]; !exists {
+		return errors.New("project not found")
+	}
+
+	project.UpdatedAt = time.Now()
+	r.projects[project.ID] = cloneProject(project)
+
+	return nil
+}
+
+func (r *ProjectRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.projects[id]; !exists {
+		return errors.New("project not found")
+	}
+
+	delete(r.projects, id)
+
+	return nil
+}
+
+func cloneProject(project *entity.Project) *entity.Project {
+	clone := *project
+	clone.Columns = append([]entity.BoardColumn(nil), project.Columns...)
+	return &clone
+}
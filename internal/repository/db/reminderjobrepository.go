@@ -0,0 +1,277 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReminderJobRepository хранит задания напоминаний в JSON-файле, чтобы
+// запланированные отправки переживали перезапуск сервиса. Каждое изменение
+// записывается во временный файл и атомарно заменяет основной.
+type ReminderJobRepository struct {
+	path  string
+	jobs  map[string]*entity.ReminderJob
+	mutex sync.RWMutex
+}
+
+// NewReminderJobRepository загружает задания из файла path, если он существует
+func NewReminderJobRepository(path string) (*ReminderJobRepository, error) {
+	r := &ReminderJobRepository{
+		path: path,
+		jobs: make(map[string]*entity.ReminderJob),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*entity.ReminderJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		r.jobs[job.ID] = job
+	}
+
+	return r, nil
+}
+
+// Schedule добавляет задание; задание с тем же ID уже существует — ничего не меняется
+func (r *ReminderJobRepository) Schedule(ctx context.Context, job *entity.ReminderJob) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.jobs[job.ID]; exists {
+		return false, nil
+	}
+
+	job.CreatedAt = time.Now()
+	r.jobs[job.ID] = cloneReminderJob(job)
+
+	if err := r.save(); err != nil {
+		delete(r.jobs, job.ID)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ReminderJobRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.ReminderJob, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.ReminderJob
+
+	for _, job := range r.jobs {
+		if job.TaskID == taskID {
+			result = append(result, cloneReminderJob(job))
+		}
+	}
+
+	sortReminderJobs(result)
+	return result, nil
+}
+
+// ClaimDue выбирает задания, которые пора отправить, и помечает их как
+// отправляемые до leaseUntil. Задание, оставшееся в состоянии отправки
+// после сбоя, снова становится доступным, когда аренда истекает.
+func (r *ReminderJobRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*entity.ReminderJob, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var claimed []*entity.ReminderJob
+	previous := make(map[string]entity.ReminderJob)
+
+	for _, job := range r.jobs {
+		ready := job.Status == entity.ReminderJobPending && !job.NextAttemptAt.After(now) ||
+			job.Status == entity.ReminderJobFiring && !job.LeaseUntil.After(now)
+		if !ready {
+			continue
+		}
+
+		previous[job.ID] = *job
+		job.Status = entity.ReminderJobFiring
+		job.LeaseUntil = leaseUntil
+		job.Attempts++
+		claimed = append(claimed, cloneReminderJob(job))
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	if err := r.save(); err != nil {
+		for id, job := range previous {
+			job := job
+			r.jobs[id] = &job
+		}
+		return nil, err
+	}
+
+	sortReminderJobs(claimed)
+	return claimed, nil
+}
+
+func (r *ReminderJobRepository) Update(ctx context.Context, job *entity.ReminderJob) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.jobs[job.ID]
+	if !exists {
+		return errors.New("reminder job not found")
+	}
+
+	r.jobs[job.ID] = cloneReminderJob(job)
+
+	if err := r.save(); err != nil {
+		r.jobs[job.ID] = previous
+		return err
+	}
+
+	return nil
+}
+
+// CancelPending удаляет задание, только если оно еще ждет отправки; задание,
+// уже захваченное на отправку, остается
+func (r *ReminderJobRepository) CancelPending(ctx context.Context, id string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.jobs[id]
+	if !exists || previous.Status != entity.ReminderJobPending {
+		return false, nil
+	}
+
+	delete(r.jobs, id)
+
+	if err := r.save(); err != nil {
+		r.jobs[id] = previous
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RenamePending меняет название задачи в задании, ожидающем отправки;
+// остальные поля задания не трогает
+func (r *ReminderJobRepository) RenamePending(ctx context.Context, id, title string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.jobs[id]
+	if !exists || previous.Status != entity.ReminderJobPending || previous.TaskTitle == title {
+		return nil
+	}
+
+	job := cloneReminderJob(previous)
+	job.TaskTitle = title
+	r.jobs[id] = job
+
+	if err := r.save(); err != nil {
+		r.jobs[id] = previous
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFinishedBefore удаляет доставленные и окончательно неудачные
+// задания со временем срабатывания раньше before
+func (r *ReminderJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := make(map[string]*entity.ReminderJob)
+	for id, job := range r.jobs {
+		finished := job.Status == entity.ReminderJobDelivered || job.Status == entity.ReminderJobFailed
+		if finished && job.FireAt.Before(before) {
+			removed[id] = job
+			delete(r.jobs, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := r.save(); err != nil {
+		for id, job := range removed {
+			r.jobs[id] = job
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает все задания в файл; вызывается под блокировкой
+func (r *ReminderJobRepository) save() error {
+	jobs := make([]*entity.ReminderJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sortReminderJobs(jobs)
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".reminders-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	// Файл должен оказаться на диске до переименования, иначе после
+	// сбоя питания можно получить пустой файл заданий
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortReminderJobs(jobs []*entity.ReminderJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].FireAt.Equal(jobs[j].FireAt) {
+			return jobs[i].FireAt.Before(jobs[j].FireAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
+
+func cloneReminderJob(job *entity.ReminderJob) *entity.ReminderJob {
+	clone := *job
+	if job.DueAt != nil {
+		dueAt := *job.DueAt
+		clone.DueAt = &dueAt
+	}
+	if job.DeliveredAt != nil {
+		deliveredAt := *job.DeliveredAt
+		clone.DeliveredAt = &deliveredAt
+	}
+	return &clone
+}
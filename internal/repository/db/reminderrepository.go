@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReminderRepository хранит напоминания в JSON-файле: задания строятся по
+// ним, поэтому без напоминаний после перезапуска первое же событие задачи
+// отменило бы сохраненные задания. Файл заменяется атомарно, как у
+// ReminderJobRepository.
+type ReminderRepository struct {
+	path      string
+	reminders map[string]*entity.Reminder
+	seq       int
+	mutex     sync.RWMutex
+}
+
+// NewReminderRepository загружает напоминания из файла path, если он существует
+func NewReminderRepository(path string) (*ReminderRepository, error) {
+	r := &ReminderRepository{
+		path:      path,
+		reminders: make(map[string]*entity.Reminder),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []*entity.Reminder
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		return nil, err
+	}
+
+	for _, reminder := range reminders {
+		r.reminders[reminder.ID] = reminder
+	}
+	// Счетчик ID продолжается после перезапуска
+	r.seq = len(reminders)
+
+	return r, nil
+}
+
+func (r *ReminderRepository) Create(ctx context.Context, reminder *entity.Reminder) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if reminder.ID == "" {
+		r.seq++
+		reminder.ID = fmt.Sprintf("rmd-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	reminder.CreatedAt = time.Now()
+
+	previous, existed := r.reminders[reminder.ID]
+	r.reminders[reminder.ID] = cloneReminder(reminder)
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.reminders[reminder.ID] = previous
+		} else {
+			delete(r.reminders, reminder.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) GetByID(ctx context.Context, id string) (*entity.Reminder, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reminder, exists := r.reminders[id]
+	if !exists {
+		return nil, errors.New("reminder not found")
+	}
+
+	return cloneReminder(reminder), nil
+}
+
+func (r *ReminderRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.Reminder, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.Reminder
+
+	for _, reminder := range r.reminders {
+		if reminder.TaskID == taskID {
+			result = append(result, cloneReminder(reminder))
+		}
+	}
+
+	sortReminders(result)
+	return result, nil
+}
+
+func (r *ReminderRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.reminders[id]
+	if !exists {
+		return errors.New("reminder not found")
+	}
+
+	delete(r.reminders, id)
+
+	if err := r.save(); err != nil {
+		r.reminders[id] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := make(map[string]*entity.Reminder)
+	for id, reminder := range r.reminders {
+		if reminder.TaskID == taskID {
+			removed[id] = reminder
+			delete(r.reminders, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := r.save(); err != nil {
+		for id, reminder := range removed {
+			r.reminders[id] = reminder
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает все напоминания в файл; вызывается под блокировкой
+func (r *ReminderRepository) save() error {
+	reminders := make([]*entity.Reminder, 0, len(r.reminders))
+	for _, reminder := range r.reminders {
+		reminders = append(reminders, reminder)
+	}
+	sortReminders(reminders)
+
+	data, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".task-reminders-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortReminders(reminders []*entity.Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].CreatedAt.Equal(reminders[j].CreatedAt) {
+			return reminders[i].CreatedAt.Before(reminders[j].CreatedAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+}
+
+func cloneReminder(reminder *entity.Reminder) *entity.Reminder {
+	clone := *reminder
+	clone.Channels = append([]string(nil), reminder.Channels...)
+	if reminder.At != nil {
+		at := *reminder.At
+		clone.At = &at
+	}
+	return &clone
+}
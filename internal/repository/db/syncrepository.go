@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// SyncRepository хранит связи с внешними системами и токены изменений в
+// JSON-файле; как и задания напоминаний, каждое изменение записывается во
+// временный файл и атомарно заменяет основной
+type SyncRepository struct {
+	path    string
+	links   map[string]*entity.SyncLink   // ключ — источник и задача
+	cursors map[string]*entity.SyncCursor // ключ — источник, пространство и пользователь
+	mutex   sync.RWMutex
+}
+
+// syncFile содержимое файла синхронизации
+type syncFile struct {
+	Links   []*entity.SyncLink   `json:"links"`
+	Cursors []*entity.SyncCursor `json:"cursors"`
+}
+
+// NewSyncRepository загружает связи и токены из файла path, если он существует
+func NewSyncRepository(path string) (*SyncRepository, error) {
+	r := &SyncRepository{
+		path:    path,
+		links:   make(map[string]*entity.SyncLink),
+		cursors: make(map[string]*entity.SyncCursor),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file syncFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, link := range file.Links {
+		r.links[syncLinkKey(link.Source, link.TaskID)] = link
+	}
+	for _, cursor := range file.Cursors {
+		r.cursors[syncCursorKey(cursor.Source, cursor.UserID, cursor.WorkspaceID)] = cursor
+	}
+
+	return r, nil
+}
+
+func (r *SyncRepository) GetLinks(ctx context.Context, source, userID, workspaceID string) ([]*entity.SyncLink, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.SyncLink
+	for _, link := range r.links {
+		if link.Source == source && link.UserID == userID && link.WorkspaceID == workspaceID {
+			clone := *link
+			result = append(result, &clone)
+		}
+	}
+
+	sortSyncLinks(result)
+	return result, nil
+}
+
+// SaveLinks записывает все изменения связей прогона синхронизации одной
+// записью файла; при ошибке записи не применяется ни одно из них
+func (r *SyncRepository) SaveLinks(ctx context.Context, source string, links []*entity.SyncLink, removed []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := make(map[string]*entity.SyncLink)
+	keep := func(key string) {
+		if _, kept := previous[key]; !kept {
+			previous[key] = r.links[key]
+		}
+	}
+
+	for _, taskID := range removed {
+		key := syncLinkKey(source, taskID)
+		keep(key)
+		delete(r.links, key)
+	}
+	for _, link := range links {
+		key := syncLinkKey(source, link.TaskID)
+		keep(key)
+		clone := *link
+		clone.Source = source
+		r.links[key] = &clone
+	}
+
+	if err := r.save(); err != nil {
+		for key, link := range previous {
+			if link != nil {
+				r.links[key] = link
+			} else {
+				delete(r.links, key)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *SyncRepository) GetCursor(ctx context.Context, source, userID, workspaceID string) (*entity.SyncCursor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cursor, exists := r.cursors[syncCursorKey(source, userID, workspaceID)]
+	if !exists {
+		return nil, errors.New("sync cursor not found")
+	}
+
+	clone := *cursor
+	return &clone, nil
+}
+
+func (r *SyncRepository) SaveCursor(ctx context.Context, cursor *entity.SyncCursor) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := syncCursorKey(cursor.Source, cursor.UserID, cursor.WorkspaceID)
+	previous, existed := r.cursors[key]
+
+	clone := *cursor
+	r.cursors[key] = &clone
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.cursors[key] = previous
+		} else {
+			delete(r.cursors, key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает связи и токены в файл; вызывается под блокировкой
+func (r *SyncRepository) save() error {
+	file := syncFile{
+		Links:   make([]*entity.SyncLink, 0, len(r.links)),
+		Cursors: make([]*entity.SyncCursor, 0, len(r.cursors)),
+	}
+	for _, link := range r.links {
+		file.Links = append(file.Links, link)
+	}
+	for _, cursor := range r.cursors {
+		file.Cursors = append(file.Cursors, cursor)
+	}
+	sortSyncLinks(file.Links)
+	sort.Slice(file.Cursors, func(i, j int) bool {
+		a, b := file.Cursors[i], file.Cursors[j]
+		return syncCursorKey(a.Source, a.UserID, a.WorkspaceID) < syncCursorKey(b.Source, b.UserID, b.WorkspaceID)
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".sync-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortSyncLinks(links []*entity.SyncLink) {
+	sort.Slice(links, func(i, j int) bool {
+		return syncLinkKey(links[i].Source, links[i].TaskID) < syncLinkKey(links[j].Source, links[j].TaskID)
+	})
+}
+
+func syncLinkKey(source, taskID string) string {
+	return source + "/" + taskID
+}
+
+func syncCursorKey(source, userID, workspaceID string) string {
+	return source + "/" + workspaceID + "/" + userID
+}
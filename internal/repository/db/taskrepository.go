@@ -3,36 +3,171 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
 	"sync"
 	"time"
 )
 
 type TaskRepository struct {
 	// В реальном приложении здесь будет подключение к БД
-	tasks map[string]*entity.Task
+	tasks *taskTable
+	seq   int
+	// Outbox событий хранится рядом с задачами, чтобы событие фиксировалось
+	// в той же транзакции, что и изменение (см. OutboxRepository)
+	outbox *outboxLog
+	// Журнал изменений пишется вместе с outbox (см. AuditRepository)
+	audit *auditLog
 	mutex sync.RWMutex
+	// txMutex выстраивает транзакции в очередь: в памяти нет изоляции,
+	// поэтому одновременно фиксируется только одна
+	txMutex sync.Mutex
 }
 
+// taskTx незафиксированные изменения транзакции
+type taskTx struct {
+	writes map[string]*entity.Task // nil — задача удалена
+	events []*entity.DomainEvent
+}
+
+type taskTxKey struct{}
+
 func NewTaskRepository() *TaskRepository {
 	return &TaskRepository{
-		tasks: make(map[string]*entity.Task),
+		tasks:  newTaskTable(),
+		outbox: newOutboxLog(),
+		audit:  newAuditLog(),
+	}
+}
+
+// WithinTransaction выполняет fn в транзакции. Изменения, сделанные через
+// контекст fn, видны чтению по ID внутри неё и применяются к хранилищу
+// вместе с событиями outbox, только если fn завершилась без ошибки.
+// Вложенный вызов присоединяется к внешней транзакции.
+func (r *TaskRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+
+	tx := &taskTx{writes: make(map[string]*entity.Task)}
+	if err := fn(context.WithValue(ctx, taskTxKey{}, tx)); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, task := range tx.writes {
+		if task == nil {
+			r.tasks.remove(id)
+			continue
+		}
+		r.tasks.put(task)
 	}
+
+	for _, event := range tx.events {
+		r.outbox.append(event)
+		r.audit.record(event)
+	}
+
+	return nil
+}
+
+func txFromContext(ctx context.Context) *taskTx {
+	tx, _ := ctx.Value(taskTxKey{}).(*taskTx)
+	return tx
+}
+
+// lookup возвращает задачу с учетом незафиксированных изменений транзакции.
+// Вызывается под мьютексом.
+func (r *TaskRepository) lookup(ctx context.Context, id string) (*entity.Task, bool) {
+	if tx := txFromContext(ctx); tx != nil {
+		if task, staged := tx.writes[id]; staged {
+			return task, task != nil
+		}
+	}
+
+	task := r.tasks.get(id)
+	return task, task != nil
+}
+
+// store записывает задачу в транзакцию или, вне её, сразу в хранилище.
+// Вызывается под мьютексом; nil удаляет задачу.
+func (r *TaskRepository) store(ctx context.Context, id string, task *entity.Task) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.writes[id] = task
+		return
+	}
+
+	if task == nil {
+		r.tasks.remove(id)
+		return
+	}
+	r.tasks.put(task)
+}
+
+func (r *TaskRepository) appendOutbox(ctx context.Context, event *entity.DomainEvent) error {
+	// В транзакции событие ждет её фиксации
+	if tx := txFromContext(ctx); tx != nil {
+		tx.events = append(tx.events, event)
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.outbox.append(event)
+	r.audit.record(event)
+	return nil
+}
+
+func (r *TaskRepository) auditHistory(taskID string) []*entity.AuditEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.audit.history(taskID)
+}
+
+func (r *TaskRepository) claimOutbox(now, leaseUntil time.Time, limit int) []*entity.OutboxRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.claim(now, leaseUntil, limit)
+}
+
+func (r *TaskRepository) updateOutbox(record *entity.OutboxRecord) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.update(record)
+}
+
+func (r *TaskRepository) deleteOutboxBefore(before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.outbox.deletePublishedBefore(before), nil
 }
 
 func (r *TaskRepository) Create(ctx context.Context, task *entity.Task) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	// Имитация генерации ID
+	// Имитация генерации ID: счетчик исключает совпадения при создании
+	// нескольких задач в одну секунду (например, пачки подзадач)
 	if task.ID == "" {
-		task.ID = time.Now().Format("20060102150405")
+		r.seq++
+		task.ID = fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), r.seq)
 	}
 
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 
-	r.tasks[task.ID] = task
+	r.store(ctx, task.ID, cloneTask(task))
 	return nil
 }
 
@@ -40,25 +175,134 @@ func (r *TaskRepository) GetByID(ctx context.Context, id string) (*entity.Task,
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	task, exists := r.tasks[id]
+	task, exists := r.lookup(ctx, id)
 	if !exists {
 		return nil, errors.New("task not found")
 	}
 
-	return task, nil
+	return cloneTask(task), nil
 }
 
 func (r *TaskRepository) GetAll(ctx context.Context, userID string) ([]*entity.Task, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	var result []*entity.Task
+	return r.tasks.find(scopeActive, func(task *entity.Task) bool {
+		return task.UserID == userID
+	}), nil
+}
 
-	for _, task := range r.tasks {
-		if task.UserID == userID {
-			result = append(result, task)
-		}
-	}
+func (r *TaskRepository) GetPage(ctx context.Context, userID, after string, limit int) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.page(scopeActive, after, limit, func(task *entity.Task) bool {
+		return task.UserID == userID
+	}), nil
+}
+
+func (r *TaskRepository) GetArchived(ctx context.Context, userID string) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := r.tasks.find(scopeArchived, func(task *entity.Task) bool {
+		return task.UserID == userID
+	})
+
+	sortTasksByArchivation(result)
+
+	return result, nil
+}
+
+func (r *TaskRepository) GetChildren(ctx context.Context, parentID string) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Архивация не разрывает иерархию, поэтому подзадачи ищутся в обоих разделах
+	result := r.tasks.find(scopeAll, func(task *entity.Task) bool {
+		return task.ParentID == parentID
+	})
+
+	// Порядок подзадач должен быть стабильным между запросами
+	sortTasksByCreation(result)
+
+	return result, nil
+}
+
+func (r *TaskRepository) GetByProject(ctx context.Context, projectID string) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := r.tasks.find(scopeActive, func(task *entity.Task) bool {
+		return task.ProjectID == projectID
+	})
+
+	sortTasksByRank(result)
+
+	return result, nil
+}
+
+func (r *TaskRepository) GetRecurring(ctx context.Context) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.find(scopeAll, func(task *entity.Task) bool {
+		return task.Recurrence != nil
+	}), nil
+}
+
+func (r *TaskRepository) GetDueBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.find(scopeActive, func(task *entity.Task) bool {
+		return task.DueAt != nil && task.DueAt.Before(before)
+	}), nil
+}
+
+func (r *TaskRepository) GetCompletedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.tasks.find(scopeActive, func(task *entity.Task) bool {
+		return completedBefore(task, before)
+	}), nil
+}
+
+func (r *TaskRepository) GetTrashed(ctx context.Context, userID string) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := r.tasks.find(scopeTrash, func(task *entity.Task) bool {
+		return task.UserID == userID
+	})
+
+	sortTasksByDeletion(result)
+
+	return result, nil
+}
+
+func (r *TaskRepository) GetByWorkspace(ctx context.Context, workspaceID string) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	match := func(task *entity.Task) bool { return task.WorkspaceID == workspaceID }
+	result := append(r.tasks.find(scopeAll, match), r.tasks.find(scopeTrash, match)...)
+
+	sortTasksByCreation(result)
+
+	return result, nil
+}
+
+func (r *TaskRepository) GetTrashedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := r.tasks.find(scopeTrash, func(task *entity.Task) bool {
+		return task.DeletedAt.Before(before)
+	})
+
+	sortTasksByDeletion(result)
 
 	return result, nil
 }
@@ -67,13 +311,31 @@ func (r *TaskRepository) Update(ctx context.Context, task *entity.Task) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	_, exists := r.tasks[task.ID]
+	_, exists := r.lookup(ctx, task.ID)
+	if !exists {
+		return errors.New("task not found")
+	}
+
+	task.UpdatedAt = time.Now()
+	r.store(ctx, task.ID, cloneTask(task))
+
+	return nil
+}
+
+func (r *TaskRepository) SetChecklistStats(ctx context.Context, id string, done, total int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	task, exists := r.lookup(ctx, id)
 	if !exists {
 		return errors.New("task not found")
 	}
 
+	task = cloneTask(task)
+	task.ChecklistDone = done
+	task.ChecklistTotal = total
 	task.UpdatedAt = time.Now()
-	r.tasks[task.ID] = task
+	r.store(ctx, id, task)
 
 	return nil
 }
@@ -82,12 +344,71 @@ func (r *TaskRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	_, exists := r.tasks[id]
+	_, exists := r.lookup(ctx, id)
 	if !exists {
 		return errors.New("task not found")
 	}
 
-	delete(r.tasks, id)
+	r.store(ctx, id, nil)
 
 	return nil
 }
+
+func sortTasksByCreation(tasks []*entity.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+}
+
+// sortTasksByRank упорядочивает задачи проекта: ручной порядок задается
+// рангом, при равенстве — временем создания
+func sortTasksByRank(tasks []*entity.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Rank != tasks[j].Rank {
+			return tasks[i].Rank < tasks[j].Rank
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+}
+
+// sortTasksByArchivation упорядочивает архив: недавно архивированные первыми
+func sortTasksByArchivation(tasks []*entity.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ArchivedAt.After(*tasks[j].ArchivedAt)
+	})
+}
+
+// sortTasksByDeletion упорядочивает задачи корзины: недавно удаленные первыми
+func sortTasksByDeletion(tasks []*entity.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+	})
+}
+
+// cloneTask возвращает копию задачи, чтобы изменения у вызывающей стороны
+// не попадали в хранилище в обход Update (и не обходили проверки use case)
+func cloneTask(task *entity.Task) *entity.Task {
+	clone := *task
+	clone.Tags = append([]string(nil), task.Tags...)
+	if task.DueAt != nil {
+		dueAt := *task.DueAt
+		clone.DueAt = &dueAt
+	}
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		clone.Recurrence = &recurrence
+	}
+	if task.CompletedAt != nil {
+		completedAt := *task.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+	if task.ArchivedAt != nil {
+		archivedAt := *task.ArchivedAt
+		clone.ArchivedAt = &archivedAt
+	}
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
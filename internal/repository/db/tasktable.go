@@ -0,0 +1,199 @@
+package db
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+// taskScope часть задач, которую просматривает выборка
+type taskScope int
+
+const (
+	scopeActive   taskScope = iota // активные задачи вне корзины
+	scopeArchived                  // архивные задачи вне корзины
+	scopeAll                       // все задачи вне корзины
+	scopeTrash                     // задачи в корзине, активные и архивные
+)
+
+// taskTable задачи хранилища, разделенные на активные и архивные. Архив
+// растет без ограничений, поэтому хранится отдельно: выборки активных задач
+// его не просматривают. Не синхронизирована: вызывается под мьютексом
+// хранилища, которому принадлежит; постраничные выборки под блокировкой
+// чтения сортируют разделы под собственным мьютексом.
+type taskTable struct {
+	active   map[string]*entity.Task
+	archived map[string]*entity.Task
+	// activeOrder и archivedOrder ID задач раздела по возрастанию для
+	// постраничной выборки; nil, если состав раздела изменился после
+	// последней сортировки
+	activeOrder   []string
+	archivedOrder []string
+	orderMutex    sync.Mutex
+}
+
+func newTaskTable() *taskTable {
+	return &taskTable{
+		active:   make(map[string]*entity.Task),
+		archived: make(map[string]*entity.Task),
+	}
+}
+
+// get возвращает задачу из любого раздела; nil, если её нет
+func (t *taskTable) get(id string) *entity.Task {
+	if task, exists := t.active[id]; exists {
+		return task
+	}
+	return t.archived[id]
+}
+
+// put сохраняет задачу в разделе, соответствующем её архивации
+func (t *taskTable) put(task *entity.Task) {
+	if task.IsArchived() {
+		t.removeActive(task.ID)
+		if _, exists := t.archived[task.ID]; !exists {
+			t.archivedOrder = nil
+		}
+		t.archived[task.ID] = task
+		return
+	}
+
+	t.removeArchived(task.ID)
+	if _, exists := t.active[task.ID]; !exists {
+		t.activeOrder = nil
+	}
+	t.active[task.ID] = task
+}
+
+func (t *taskTable) remove(id string) {
+	t.removeActive(id)
+	t.removeArchived(id)
+}
+
+func (t *taskTable) removeActive(id string) {
+	if _, exists := t.active[id]; exists {
+		delete(t.active, id)
+		t.activeOrder = nil
+	}
+}
+
+func (t *taskTable) removeArchived(id string) {
+	if _, exists := t.archived[id]; exists {
+		delete(t.archived, id)
+		t.archivedOrder = nil
+	}
+}
+
+// orders возвращает упорядоченные ID разделов scope, сортируя разделы,
+// состав которых изменился. Возвращенные срезы не меняются: изменение
+// раздела заменяет порядок на nil.
+func (t *taskTable) orders(scope taskScope) [][]string {
+	t.orderMutex.Lock()
+	defer t.orderMutex.Unlock()
+
+	sorted := func(partition map[string]*entity.Task, order *[]string) []string {
+		if *order == nil {
+			*order = make([]string, 0, len(partition))
+			for id := range partition {
+				*order = append(*order, id)
+			}
+			sort.Strings(*order)
+		}
+		return *order
+	}
+
+	switch scope {
+	case scopeActive:
+		return [][]string{sorted(t.active, &t.activeOrder)}
+	case scopeArchived:
+		return [][]string{sorted(t.archived, &t.archivedOrder)}
+	}
+	return [][]string{sorted(t.active, &t.activeOrder), sorted(t.archived, &t.archivedOrder)}
+}
+
+// each вызывает fn для задач из scope без копирования
+func (t *taskTable) each(scope taskScope, fn func(task *entity.Task)) {
+	var partitions []map[string]*entity.Task
+	switch scope {
+	case scopeActive:
+		partitions = append(partitions, t.active)
+	case scopeArchived:
+		partitions = append(partitions, t.archived)
+	default:
+		partitions = append(partitions, t.active, t.archived)
+	}
+
+	for _, partition := range partitions {
+		for _, task := range partition {
+			if task.IsTrashed() == (scope == scopeTrash) {
+				fn(task)
+			}
+		}
+	}
+}
+
+// find возвращает копии задач из scope, для которых match истинна
+func (t *taskTable) find(scope taskScope, match func(task *entity.Task) bool) []*entity.Task {
+	var result []*entity.Task
+
+	t.each(scope, func(task *entity.Task) {
+		if match(task) {
+			result = append(result, cloneTask(task))
+		}
+	})
+
+	return result
+}
+
+// page возвращает копии не более limit задач из scope с ID больше after в
+// порядке ID, для которых match истинна. Разделы сортируются, только если
+// их состав изменился, поэтому выгрузка всех страниц подряд просматривает
+// каждую задачу один раз.
+func (t *taskTable) page(scope taskScope, after string, limit int, match func(task *entity.Task) bool) []*entity.Task {
+	if limit <= 0 {
+		return nil
+	}
+
+	// Слияние упорядоченных разделов с первого ID после after
+	orders := t.orders(scope)
+	next := make([]int, len(orders))
+	for i, order := range orders {
+		next[i] = sort.Search(len(order), func(j int) bool { return order[j] > after })
+	}
+
+	var result []*entity.Task
+	for len(result) < limit {
+		pick := -1
+		for i, order := range orders {
+			if next[i] < len(order) && (pick < 0 || order[next[i]] < orders[pick][next[pick]]) {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			break
+		}
+
+		task := t.get(orders[pick][next[pick]])
+		next[pick]++
+		if task.IsTrashed() == (scope == scopeTrash) && match(task) {
+			result = append(result, cloneTask(task))
+		}
+	}
+
+	return result
+}
+
+// completedBefore сообщает, завершена ли задача раньше before. У задач,
+// завершенных до появления времени завершения, оно оценивается временем
+// последнего изменения.
+func completedBefore(task *entity.Task, before time.Time) bool {
+	if !task.IsDone() {
+		return false
+	}
+
+	if task.CompletedAt != nil {
+		return task.CompletedAt.Before(before)
+	}
+	return task.UpdatedAt.Before(before)
+}
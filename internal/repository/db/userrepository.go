@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"strings"
+	"sync"
+)
+
+type UserRepository struct {
+	users map[string]*entity.User
+	mutex sync.RWMutex
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users: make(map[string]*entity.User),
+	}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *entity.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if user.ID == "" || user.Username == "" {
+		return errors.New("user id and username are required")
+	}
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Username, user.Username) && existing.ID != user.ID {
+			return errors.New("username already taken")
+		}
+	}
+
+	r.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+
+	return cloneUser(user), nil
+}
+
+// GetByUsername ищет пользователя по имени без учета регистра
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return cloneUser(user), nil
+		}
+	}
+
+	return nil, errors.New("user not found")
+}
+
+func cloneUser(user *entity.User) *entity.User {
+	clone := *user
+	if user.Workspaces != nil {
+		clone.Workspaces = make(map[string]entity.WorkspaceRole, len(user.Workspaces))
+		for workspaceID, role := range user.Workspaces {
+			clone.Workspaces[workspaceID] = role
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebhookDeliveryRepository хранит доставки вебхуков в JSON-файле. Событие
+// outbox считается обработанным, как только доставки поставлены в очередь,
+// поэтому очередь повторов, список неудачных доставок и их журнал должны
+// переживать перезапуск. Каждое изменение записывается во временный файл и
+// атомарно заменяет основной.
+type WebhookDeliveryRepository struct {
+	path       string
+	deliveries map[string]*entity.WebhookDelivery
+	seq        int
+	mutex      sync.RWMutex
+}
+
+// NewWebhookDeliveryRepository загружает доставки из файла path, если он существует
+func NewWebhookDeliveryRepository(path string) (*WebhookDeliveryRepository, error) {
+	r := &WebhookDeliveryRepository{
+		path:       path,
+		deliveries: make(map[string]*entity.WebhookDelivery),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*entity.WebhookDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, err
+	}
+
+	for _, delivery := range deliveries {
+		r.deliveries[delivery.ID] = delivery
+	}
+	// Счетчик ID продолжается после перезапуска
+	r.seq = len(deliveries)
+
+	return r, nil
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if delivery.ID == "" {
+		r.seq++
+		delivery.ID = fmt.Sprintf("dlv-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	delivery.CreatedAt = time.Now()
+
+	previous, existed := r.deliveries[delivery.ID]
+	r.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.deliveries[delivery.ID] = previous
+		} else {
+			delete(r.deliveries, delivery.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	delivery, exists := r.deliveries[id]
+	if !exists {
+		return nil, errors.New("delivery not found")
+	}
+
+	return cloneWebhookDelivery(delivery), nil
+}
+
+func (r *WebhookDeliveryRepository) GetBySubscription(ctx context.Context, subscriptionID string, status entity.WebhookDeliveryStatus) ([]*entity.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.WebhookDelivery
+
+	for _, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID && (status == "" || delivery.Status == status) {
+			result = append(result, cloneWebhookDelivery(delivery))
+		}
+	}
+
+	// Новые доставки первыми
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+
+	return result, nil
+}
+
+func (r *WebhookDeliveryRepository) HasEvent(ctx context.Context, subscriptionID, eventID string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID && delivery.EventID == eventID && delivery.ReplayOf == "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*entity.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var claimed []*entity.WebhookDelivery
+	previous := make(map[string]entity.WebhookDelivery)
+
+	for _, delivery := range r.deliveries {
+		ready := delivery.Status == entity.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) ||
+			delivery.Status == entity.WebhookDeliverySending && !delivery.LeaseUntil.After(now)
+		if !ready {
+			continue
+		}
+
+		previous[delivery.ID] = *delivery
+		delivery.Status = entity.WebhookDeliverySending
+		delivery.LeaseUntil = leaseUntil
+		claimed = append(claimed, cloneWebhookDelivery(delivery))
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	if err := r.save(); err != nil {
+		for id, delivery := range previous {
+			delivery := delivery
+			r.deliveries[id] = &delivery
+		}
+		return nil, err
+	}
+
+	// События отправляются в порядке возникновения
+	sortWebhookDeliveries(claimed)
+	return claimed, nil
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.deliveries[delivery.ID]
+	if !exists {
+		return errors.New("delivery not found")
+	}
+
+	r.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+
+	if err := r.save(); err != nil {
+		r.deliveries[delivery.ID] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) DeleteBySubscription(ctx context.Context, subscriptionID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := make(map[string]*entity.WebhookDelivery)
+	for id, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			removed[id] = delivery
+			delete(r.deliveries, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := r.save(); err != nil {
+		for id, delivery := range removed {
+			r.deliveries[id] = delivery
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает все доставки в файл; вызывается под блокировкой
+func (r *WebhookDeliveryRepository) save() error {
+	deliveries := make([]*entity.WebhookDelivery, 0, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		deliveries = append(deliveries, delivery)
+	}
+	sortWebhookDeliveries(deliveries)
+
+	data, err := json.MarshalIndent(deliveries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".webhook-deliveries-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	// Файл должен оказаться на диске до переименования, иначе после
+	// сбоя питания можно потерять всю очередь доставок
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortWebhookDeliveries(deliveries []*entity.WebhookDelivery) {
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+}
+
+func cloneWebhookDelivery(delivery *entity.WebhookDelivery) *entity.WebhookDelivery {
+	clone := *delivery
+	clone.Payload = append([]byte(nil), delivery.Payload...)
+	clone.Attempts = append([]entity.WebhookAttempt(nil), delivery.Attempts...)
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		clone.DeliveredAt = &deliveredAt
+	}
+	return &clone
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebhookRepository хранит подписки в JSON-файле: доставки, ожидающие
+// повтора, переживают перезапуск и без подписки не были бы отправлены.
+// Каждое изменение записывается во временный файл и атомарно заменяет
+// основной.
+type WebhookRepository struct {
+	path          string
+	subscriptions map[string]*entity.WebhookSubscription
+	seq           int
+	mutex         sync.RWMutex
+}
+
+// webhookSubscriptionRecord подписка в файле; в entity.WebhookSubscription
+// ключ подписи скрыт от JSON
+type webhookSubscriptionRecord struct {
+	*entity.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// NewWebhookRepository загружает подписки из файла path, если он существует
+func NewWebhookRepository(path string) (*WebhookRepository, error) {
+	r := &WebhookRepository{
+		path:          path,
+		subscriptions: make(map[string]*entity.WebhookSubscription),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []webhookSubscriptionRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		subscription := record.WebhookSubscription
+		subscription.Secret = record.Secret
+		r.subscriptions[subscription.ID] = subscription
+	}
+	// Счетчик ID продолжается после перезапуска
+	r.seq = len(records)
+
+	return r, nil
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if subscription.ID == "" {
+		r.seq++
+		subscription.ID = fmt.Sprintf("whk-%s-%d", time.Now().Format("20060102150405"), r.seq)
+	}
+
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = subscription.CreatedAt
+
+	previous, existed := r.subscriptions[subscription.ID]
+	r.subscriptions[subscription.ID] = cloneWebhookSubscription(subscription)
+
+	if err := r.save(); err != nil {
+		if existed {
+			r.subscriptions[subscription.ID] = previous
+		} else {
+			delete(r.subscriptions, subscription.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id string) (*entity.WebhookSubscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	subscription, exists := r.subscriptions[id]
+	if !exists {
+		return nil, errors.New("webhook not found")
+	}
+
+	return cloneWebhookSubscription(subscription), nil
+}
+
+func (r *WebhookRepository) GetByWorkspace(ctx context.Context, workspaceID string) ([]*entity.WebhookSubscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*entity.WebhookSubscription
+
+	for _, subscription := range r.subscriptions {
+		if subscription.WorkspaceID == workspaceID {
+			result = append(result, cloneWebhookSubscription(subscription))
+		}
+	}
+
+	sortWebhookSubscriptions(result)
+	return result, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.subscriptions[subscription.ID]
+	if !exists {
+		return errors.New("webhook not found")
+	}
+
+	subscription.UpdatedAt = time.Now()
+	r.subscriptions[subscription.ID] = cloneWebhookSubscription(subscription)
+
+	if err := r.save(); err != nil {
+		r.subscriptions[subscription.ID] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.subscriptions[id]
+	if !exists {
+		return errors.New("webhook not found")
+	}
+
+	delete(r.subscriptions, id)
+
+	if err := r.save(); err != nil {
+		r.subscriptions[id] = previous
+		return err
+	}
+
+	return nil
+}
+
+// save атомарно записывает все подписки в файл; вызывается под блокировкой
+func (r *WebhookRepository) save() error {
+	subscriptions := make([]*entity.WebhookSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sortWebhookSubscriptions(subscriptions)
+
+	records := make([]webhookSubscriptionRecord, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		records = append(records, webhookSubscriptionRecord{WebhookSubscription: subscription, Secret: subscription.Secret})
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".webhooks-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func sortWebhookSubscriptions(subscriptions []*entity.WebhookSubscription) {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if !subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+		}
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+}
+
+func cloneWebhookSubscription(subscription *entity.WebhookSubscription) *entity.WebhookSubscription {
+	clone := *subscription
+	clone.Events = append([]entity.WebhookEvent(nil), subscription.Events...)
+	return &clone
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"sync"
+	"time"
+)
+
+type WorkflowRepository struct {
+	workflows map[string]*entity.Workflow // workspaceID -> рабочий процесс
+	mutex     sync.RWMutex
+}
+
+func NewWorkflowRepository() *WorkflowRepository {
+	return &WorkflowRepository{
+		workflows: make(map[string]*entity.Workflow),
+	}
+}
+
+func (r *WorkflowRepository) GetByWorkspace(ctx context.Context, workspaceID string) (*entity.Workflow, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	workflow, exists := r.workflows[workspaceID]
+	if !exists {
+		return nil, errors.New("workflow not found")
+	}
+
+	return cloneWorkflow(workflow), nil
+}
+
+func (r *WorkflowRepository) Save(ctx context.Context, workflow *entity.Workflow) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	workflow.UpdatedAt = time.Now()
+	r.workflows[workflow.WorkspaceID] = cloneWorkflow(workflow)
+
+	return nil
+}
+
+func cloneWorkflow(workflow *entity.Workflow) *entity.Workflow {
+	clone := *workflow
+	clone.Statuses = append([]entity.WorkflowStatus(nil), workflow.Statuses...)
+	clone.Transitions = make([]entity.WorkflowTransition, len(workflow.Transitions))
+	for i, tr := range workflow.Transitions {
+		tr.Guards = append([]string(nil), tr.Guards...)
+		tr.Hooks = append([]string(nil), tr.Hooks...)
+		clone.Transitions[i] = tr
+	}
+	return &clone
+}
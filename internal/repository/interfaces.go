@@ -3,14 +3,239 @@ package repository
 import (
 	"context"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
 )
 
+// TaskRepository хранилище задач. Задачи в корзине возвращаются только
+// GetByID, GetByWorkspace и GetTrashed*, остальные выборки их не содержат. Архивные задачи
+// хранятся отдельно от активных и возвращаются только GetByID, GetChildren,
+// GetArchived, GetRecurring, GetByWorkspace и GetTrashed*.
 type TaskRepository interface {
 	Create(ctx context.Context, task *entity.Task) error
 	GetByID(ctx context.Context, id string) (*entity.Task, error)
 	GetAll(ctx context.Context, userID string) ([]*entity.Task, error)
+	// GetPage возвращает до limit активных задач пользователя с ID больше
+	// after в порядке ID; курсор следующей страницы — ID последней задачи
+	GetPage(ctx context.Context, userID, after string, limit int) ([]*entity.Task, error)
+	// GetArchived возвращает архивные задачи пользователя
+	GetArchived(ctx context.Context, userID string) ([]*entity.Task, error)
+	GetChildren(ctx context.Context, parentID string) ([]*entity.Task, error)
+	GetByProject(ctx context.Context, projectID string) ([]*entity.Task, error)
+	// GetRecurring возвращает задачи с правилом повторения (последние
+	// экземпляры серий), включая архивные: архивация экземпляра не
+	// завершает серию
+	GetRecurring(ctx context.Context) ([]*entity.Task, error)
+	// GetDueBefore возвращает задачи всех пользователей со сроком раньше before
+	GetDueBefore(ctx context.Context, before time.Time) ([]*entity.Task, error)
+	// GetCompletedBefore возвращает активные задачи всех пользователей,
+	// завершенные раньше before
+	GetCompletedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error)
+	// GetTrashed возвращает задачи пользователя в корзине
+	GetTrashed(ctx context.Context, userID string) ([]*entity.Task, error)
+	// GetByWorkspace возвращает все задачи пространства, включая архивные и
+	// задачи в корзине
+	GetByWorkspace(ctx context.Context, workspaceID string) ([]*entity.Task, error)
+	// GetTrashedBefore возвращает задачи всех пользователей, перенесенные в
+	// корзину раньше before
+	GetTrashedBefore(ctx context.Context, before time.Time) ([]*entity.Task, error)
 	Update(ctx context.Context, task *entity.Task) error
+	// SetChecklistStats обновляет только счетчики чек-листа задачи и время
+	// её изменения
+	SetChecklistStats(ctx context.Context, id string, done, total int) error
+	// Delete удаляет задачу окончательно; в корзину задача переносится через Update
 	Delete(ctx context.Context, id string) error
+	// WithinTransaction выполняет fn в одной транзакции: изменения задач через
+	// переданный в fn контекст и события, добавленные в OutboxRepository,
+	// фиксируются вместе или не фиксируются вовсе
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// TaskTimeTravelRepository хранилище задач, способное восстановить
+// состояние задачи на прошедший момент времени
+type TaskTimeTravelRepository interface {
+	GetAsOf(ctx context.Context, id string, at time.Time) (*entity.Task, error)
+}
+
+// OutboxRepository хранит доменные события до их публикации подписчикам
+type OutboxRepository interface {
+	// Append добавляет событие и назначает ему ID и порядковый номер в
+	// потоке задачи; внутри транзакции событие фиксируется вместе с ней
+	Append(ctx context.Context, event *entity.DomainEvent) error
+	// ClaimPending атомарно забирает готовые к публикации записи с арендой до
+	// leaseUntil. Для каждой задачи выдается не больше одной — самая ранняя
+	// незавершенная, поэтому события задачи публикуются строго по порядку.
+	ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.OutboxRecord, error)
+	Update(ctx context.Context, record *entity.OutboxRecord) error
+	// DeletePublishedBefore удаляет опубликованные до before записи
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// AuditRepository журнал изменений задач. Записи добавляет хранилище задач
+// в транзакции изменения вместе с событием outbox.
+type AuditRepository interface {
+	// GetByTask возвращает историю задачи по возрастанию ревизий
+	GetByTask(ctx context.Context, taskID string) ([]*entity.AuditEntry, error)
+}
+
+type DependencyRepository interface {
+	Add(ctx context.Context, dep *entity.TaskDependency) error
+	Remove(ctx context.Context, blockerID, blockedID string) error
+	RemoveAllForTask(ctx context.Context, taskID string) error
+	// GetBlockers возвращает связи, в которых задача заблокирована
+	GetBlockers(ctx context.Context, taskID string) ([]*entity.TaskDependency, error)
+	// GetBlocked возвращает связи, в которых задача блокирует другие
+	GetBlocked(ctx context.Context, taskID string) ([]*entity.TaskDependency, error)
+}
+
+type WorkflowRepository interface {
+	// GetByWorkspace возвращает рабочий процесс пространства или ошибку "workflow not found"
+	GetByWorkspace(ctx context.Context, workspaceID string) (*entity.Workflow, error)
+	Save(ctx context.Context, workflow *entity.Workflow) error
+}
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *entity.Project) error
+	GetByID(ctx context.Context, id string) (*entity.Project, error)
+	GetAll(ctx context.Context, userID string) ([]*entity.Project, error)
+	Update(ctx context.Context, project *entity.Project) error
+	Delete(ctx context.Context, id string) error
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *entity.User) error
+	GetByID(ctx context.Context, id string) (*entity.User, error)
+	GetByUsername(ctx context.Context, username string) (*entity.User, error)
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *entity.Comment) error
+	GetByID(ctx context.Context, id string) (*entity.Comment, error)
+	// GetByTask возвращает комментарии задачи в порядке создания
+	GetByTask(ctx context.Context, taskID string) ([]*entity.Comment, error)
+	Update(ctx context.Context, comment *entity.Comment) error
+	Delete(ctx context.Context, id string) error
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *entity.Attachment) error
+	GetByID(ctx context.Context, id string) (*entity.Attachment, error)
+	GetByTask(ctx context.Context, taskID string) ([]*entity.Attachment, error)
+	Delete(ctx context.Context, id string) error
+	// CountByHash возвращает число вложений, ссылающихся на одно содержимое
+	CountByHash(ctx context.Context, hash string) (int, error)
+}
+
+type ChecklistRepository interface {
+	Create(ctx context.Context, item *entity.ChecklistItem) error
+	GetByID(ctx context.Context, id string) (*entity.ChecklistItem, error)
+	// GetByTask возвращает пункты чек-листа в порядке рангов
+	GetByTask(ctx context.Context, taskID string) ([]*entity.ChecklistItem, error)
+	Update(ctx context.Context, item *entity.ChecklistItem) error
+	Delete(ctx context.Context, id string) error
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *entity.Reminder) error
+	GetByID(ctx context.Context, id string) (*entity.Reminder, error)
+	GetByTask(ctx context.Context, taskID string) ([]*entity.Reminder, error)
+	Delete(ctx context.Context, id string) error
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+// ReminderJobRepository хранилище заданий на отправку напоминаний;
+// должно переживать перезапуск сервиса
+type ReminderJobRepository interface {
+	// Schedule добавляет задание, если задания с таким ID еще нет,
+	// и сообщает, было ли оно добавлено
+	Schedule(ctx context.Context, job *entity.ReminderJob) (bool, error)
+	GetByTask(ctx context.Context, taskID string) ([]*entity.ReminderJob, error)
+	// ClaimDue атомарно забирает готовые к отправке задания с арендой до leaseUntil
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*entity.ReminderJob, error)
+	Update(ctx context.Context, job *entity.ReminderJob) error
+	// CancelPending и RenamePending меняют задание, только если оно все еще
+	// ждет отправки, чтобы не задеть задание, захваченное параллельно
+	CancelPending(ctx context.Context, id string) (bool, error)
+	RenamePending(ctx context.Context, id, title string) error
+	DeleteFinishedBefore(ctx context.Context, before time.Time) error
+}
+
+// NotificationPreferencesRepository хранилище настроек уведомлений,
+// отметок о письмах о просрочке и очереди неотправленных писем; должно
+// переживать перезапуск сервиса, иначе о каждой просрочке будет сообщено
+// заново, а накопленные события пропадут
+type NotificationPreferencesRepository interface {
+	// GetByUser возвращает настройки или ошибку "notification preferences not found"
+	GetByUser(ctx context.Context, userID string) (*entity.NotificationPreferences, error)
+	Save(ctx context.Context, preferences *entity.NotificationPreferences) error
+	// MarkOverdueNotified отмечает письмо о просрочке задачи со сроком dueAt
+	// и сообщает, не было ли отметки раньше
+	MarkOverdueNotified(ctx context.Context, taskID string, dueAt time.Time) (bool, error)
+	ClearOverdueNotified(ctx context.Context, taskID string) error
+	GetPendingEmails(ctx context.Context) ([]*entity.PendingEmail, error)
+	// SavePendingEmails заменяет очередь неотправленных писем целиком
+	SavePendingEmails(ctx context.Context, emails []*entity.PendingEmail) error
+}
+
+// CalendarFeedRepository хранилище подписок календаря; у пользователя одна
+// подписка в каждом пространстве
+type CalendarFeedRepository interface {
+	// GetByUser возвращает подписку или ошибку "calendar feed not found"
+	GetByUser(ctx context.Context, userID, workspaceID string) (*entity.CalendarFeed, error)
+	GetByToken(ctx context.Context, token string) (*entity.CalendarFeed, error)
+	// Save создает подписку или заменяет существующую вместе с токеном
+	Save(ctx context.Context, feed *entity.CalendarFeed) error
+	Delete(ctx context.Context, userID, workspaceID string) error
+}
+
+// SyncRepository хранилище связей задач с внешними системами и токенов
+// изменений; должно переживать перезапуск сервиса, иначе следующая
+// синхронизация создаст задачи заново
+type SyncRepository interface {
+	// GetLinks возвращает связи пользователя с записями источника в пространстве
+	GetLinks(ctx context.Context, source, userID, workspaceID string) ([]*entity.SyncLink, error)
+	// SaveLinks удаляет связи задач removed с источником и создает или
+	// заменяет связи links; изменения применяются вместе
+	SaveLinks(ctx context.Context, source string, links []*entity.SyncLink, removed []string) error
+	// GetCursor возвращает токен или ошибку "sync cursor not found"
+	GetCursor(ctx context.Context, source, userID, workspaceID string) (*entity.SyncCursor, error)
+	SaveCursor(ctx context.Context, cursor *entity.SyncCursor) error
+}
+
+// ImportJobRepository хранилище фоновых импортов; должно переживать
+// перезапуск сервиса, чтобы прерванный импорт продолжился
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *entity.ImportJob) error
+	GetByID(ctx context.Context, id string) (*entity.ImportJob, error)
+	GetByUser(ctx context.Context, userID string) ([]*entity.ImportJob, error)
+	// ClaimNext атомарно забирает ожидающий импорт или импорт с истекшей
+	// арендой и продлевает аренду до leaseUntil; nil, если забирать нечего
+	ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*entity.ImportJob, error)
+	Update(ctx context.Context, job *entity.ImportJob) error
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, subscription *entity.WebhookSubscription) error
+	GetByID(ctx context.Context, id string) (*entity.WebhookSubscription, error)
+	GetByWorkspace(ctx context.Context, workspaceID string) ([]*entity.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *entity.WebhookSubscription) error
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *entity.WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error)
+	// GetBySubscription возвращает доставки подписки, новые первыми;
+	// пустой status — доставки в любом состоянии
+	GetBySubscription(ctx context.Context, subscriptionID string, status entity.WebhookDeliveryStatus) ([]*entity.WebhookDelivery, error)
+	// HasEvent сообщает, создавалась ли уже подписке доставка события
+	// (не считая повторных отправок)
+	HasEvent(ctx context.Context, subscriptionID, eventID string) (bool, error)
+	// ClaimDue атомарно забирает готовые к отправке доставки с арендой до leaseUntil
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*entity.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *entity.WebhookDelivery) error
+	DeleteBySubscription(ctx context.Context, subscriptionID string) error
 }
 
 type LogRepository interface {
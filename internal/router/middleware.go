@@ -34,10 +34,18 @@ func LoggingMiddleware(logger *logger.Logger) func(next http.Handler) http.Handl
 	}
 }
 
-// AuthMiddleware проверяет авторизацию пользователя
-func AuthMiddleware() func(next http.Handler) http.Handler {
+// AuthMiddleware проверяет авторизацию пользователя. Запросы к publicPaths
+// (например, ссылки отписки из писем) пропускаются без проверки.
+func AuthMiddleware(publicPaths ...string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, path := range publicPaths {
+				if r.URL.Path == path {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
 			// Получаем токен из заголовка Authorization
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -67,6 +75,12 @@ func AuthMiddleware() func(next http.Handler) http.Handler {
 			// Добавляем userID в контекст запроса
 			ctx := context.WithValue(r.Context(), "user_id", userID)
 
+			// Рабочее пространство выбирается заголовком; без него use case
+			// использует пространство по умолчанию
+			if workspaceID := r.Header.Get("X-Workspace-ID"); workspaceID != "" {
+				ctx = context.WithValue(ctx, "workspace_id", workspaceID)
+			}
+
 			// Вызываем следующий обработчик с обновленным контекстом
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
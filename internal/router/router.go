@@ -51,6 +51,593 @@ func (r *Router) RegisterRoutes(taskHandler *handler.TaskHandler) {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+
+	// Иерархия задач: ID задачи передается в пути
+	r.Mux.HandleFunc("/tasks/{id}/children", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			taskHandler.GetSubtasks(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/tree", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			taskHandler.GetTaskTree(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Архивация задачи не зависит от её статуса
+	r.Mux.HandleFunc("/tasks/{id}/archive", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			taskHandler.ArchiveTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/unarchive", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			taskHandler.UnarchiveTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/transitions", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			taskHandler.GetTransitions(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Расписание повторяющейся задачи
+	r.Mux.HandleFunc("/tasks/{id}/occurrences", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			taskHandler.GetOccurrences(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterWorkflowRoutes регистрирует маршруты настройки рабочего процесса
+func (r *Router) RegisterWorkflowRoutes(workflowHandler *handler.WorkflowHandler) {
+	r.Mux.HandleFunc("/workflow", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			workflowHandler.GetWorkflow(w, req)
+		case http.MethodPut:
+			workflowHandler.SaveWorkflow(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterDependencyRoutes регистрирует маршруты зависимостей между задачами
+func (r *Router) RegisterDependencyRoutes(dependencyHandler *handler.DependencyHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/dependencies", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			dependencyHandler.GetDependencies(w, req)
+		case http.MethodPost:
+			dependencyHandler.AddDependency(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/dependencies/{blocker_id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodDelete:
+			dependencyHandler.RemoveDependency(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/dependencies/order", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			dependencyHandler.GetTopologicalOrder(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/dependencies/critical-path", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			dependencyHandler.GetCriticalPath(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterProjectRoutes регистрирует маршруты проектов и досок
+func (r *Router) RegisterProjectRoutes(projectHandler *handler.ProjectHandler) {
+	r.Mux.HandleFunc("/projects", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			projectHandler.GetAllProjects(w, req)
+		case http.MethodPost:
+			projectHandler.CreateProject(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/projects/{id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			projectHandler.GetProject(w, req)
+		case http.MethodPut:
+			projectHandler.UpdateProject(w, req)
+		case http.MethodDelete:
+			projectHandler.DeleteProject(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/projects/{id}/tasks", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			projectHandler.GetProjectTasks(w, req)
+		case http.MethodPost:
+			projectHandler.CreateProjectTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/projects/{id}/board", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			projectHandler.GetBoard(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/projects/{id}/board/move", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			projectHandler.MoveTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterCommentRoutes регистрирует маршруты обсуждения задач
+func (r *Router) RegisterCommentRoutes(commentHandler *handler.CommentHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/comments", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			commentHandler.GetComments(w, req)
+		case http.MethodPost:
+			commentHandler.AddComment(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/comments/{comment_id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPut:
+			commentHandler.EditComment(w, req)
+		case http.MethodDelete:
+			commentHandler.DeleteComment(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterAttachmentRoutes регистрирует маршруты вложений задач
+func (r *Router) RegisterAttachmentRoutes(attachmentHandler *handler.AttachmentHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/attachments", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			attachmentHandler.GetAttachments(w, req)
+		case http.MethodPost:
+			attachmentHandler.UploadAttachment(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/attachments/{attachment_id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			attachmentHandler.DownloadAttachment(w, req)
+		case http.MethodDelete:
+			attachmentHandler.DeleteAttachment(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterChecklistRoutes регистрирует маршруты чек-листов задач
+func (r *Router) RegisterChecklistRoutes(checklistHandler *handler.ChecklistHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/checklist", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			checklistHandler.GetChecklist(w, req)
+		case http.MethodPost:
+			checklistHandler.AddItem(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/checklist/{item_id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPut:
+			checklistHandler.UpdateItem(w, req)
+		case http.MethodDelete:
+			checklistHandler.DeleteItem(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/checklist/{item_id}/toggle", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			checklistHandler.ToggleItem(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/checklist/{item_id}/move", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			checklistHandler.MoveItem(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterReminderRoutes регистрирует маршруты напоминаний задач
+func (r *Router) RegisterReminderRoutes(reminderHandler *handler.ReminderHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/reminders", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			reminderHandler.GetReminders(w, req)
+		case http.MethodPost:
+			reminderHandler.CreateReminder(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/reminders/{reminder_id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodDelete:
+			reminderHandler.DeleteReminder(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterNotificationRoutes регистрирует маршруты настроек уведомлений и отписки.
+// Маршрут /unsubscribe должен быть открыт в AuthMiddleware: по ссылке из письма
+// переходят без токена авторизации.
+func (r *Router) RegisterNotificationRoutes(notificationHandler *handler.NotificationHandler) {
+	r.Mux.HandleFunc("/notifications/preferences", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			notificationHandler.GetPreferences(w, req)
+		case http.MethodPut, http.MethodPatch:
+			notificationHandler.UpdatePreferences(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/unsubscribe", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodPost:
+			notificationHandler.Unsubscribe(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterWebhookRoutes регистрирует маршруты подписок на вебхуки
+func (r *Router) RegisterWebhookRoutes(webhookHandler *handler.WebhookHandler) {
+	r.Mux.HandleFunc("/webhooks", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			webhookHandler.GetWebhooks(w, req)
+		case http.MethodPost:
+			webhookHandler.CreateWebhook(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/webhooks/{id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			webhookHandler.GetWebhook(w, req)
+		case http.MethodPut:
+			webhookHandler.UpdateWebhook(w, req)
+		case http.MethodDelete:
+			webhookHandler.DeleteWebhook(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/webhooks/{id}/deliveries", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			webhookHandler.GetDeliveries(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/replay", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			webhookHandler.ReplayDelivery(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterSearchRoutes регистрирует маршруты поиска
+func (r *Router) RegisterSearchRoutes(searchHandler *handler.SearchHandler) {
+	r.Mux.HandleFunc("/tasks/search", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			searchHandler.SearchTasks(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterAuditRoutes регистрирует маршруты истории изменений задач
+func (r *Router) RegisterAuditRoutes(auditHandler *handler.AuditHandler) {
+	r.Mux.HandleFunc("/tasks/{id}/history", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			auditHandler.GetHistory(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/history/{revision}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			auditHandler.GetRevision(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/as-of", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			auditHandler.GetTaskAsOf(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/tasks/{id}/history/{revision}/restore", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			auditHandler.RestoreRevision(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterTrashRoutes регистрирует маршруты корзины задач
+func (r *Router) RegisterTrashRoutes(trashHandler *handler.TrashHandler) {
+	r.Mux.HandleFunc("/trash", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			trashHandler.GetTrash(w, req)
+		case http.MethodDelete:
+			trashHandler.EmptyTrash(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/trash/{id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			trashHandler.GetTrashedTask(w, req)
+		case http.MethodDelete:
+			trashHandler.PurgeTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/trash/{id}/restore", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			trashHandler.RestoreTask(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterTransferRoutes регистрирует маршруты импорта и экспорта задач
+func (r *Router) RegisterTransferRoutes(transferHandler *handler.TransferHandler) {
+	r.Mux.HandleFunc("/api/export", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			transferHandler.Export(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/export/csv", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			transferHandler.ExportCSV(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/import/csv", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			transferHandler.ImportCSV(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/import/todotxt", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			transferHandler.ImportTodoTxt(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterCalendarRoutes регистрирует маршруты подписки календаря.
+// Маршрут /calendar.ics должен быть открыт в AuthMiddleware: календари
+// опрашивают ленту по секретному адресу без токена авторизации.
+func (r *Router) RegisterCalendarRoutes(calendarHandler *handler.CalendarHandler) {
+	r.Mux.HandleFunc("/calendar/feed", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			calendarHandler.GetFeed(w, req)
+		case http.MethodDelete:
+			calendarHandler.DeleteFeed(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/calendar/feed/rotate", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			calendarHandler.RotateFeed(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/calendar.ics", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			calendarHandler.Feed(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterBackupRoutes регистрирует маршруты резервного копирования
+func (r *Router) RegisterBackupRoutes(backupHandler *handler.BackupHandler) {
+	r.Mux.HandleFunc("/api/backup", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			backupHandler.Backup(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/restore", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			backupHandler.Restore(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterSyncRoutes регистрирует маршрут синхронизации с внешними системами
+func (r *Router) RegisterSyncRoutes(syncHandler *handler.SyncHandler) {
+	r.Mux.HandleFunc("/api/sync/{source}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			syncHandler.Sync(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterImportRoutes регистрирует маршруты импорта из Trello и Jira
+func (r *Router) RegisterImportRoutes(importHandler *handler.ImportHandler) {
+	r.Mux.HandleFunc("/api/import/jobs", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			importHandler.GetJobs(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/import/jobs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			importHandler.GetJob(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.Mux.HandleFunc("/api/import/{format}", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			importHandler.StartImport(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// RegisterReportRoutes регистрирует маршрут отчетов о задачах
+func (r *Router) RegisterReportRoutes(reportHandler *handler.ReportHandler) {
+	r.Mux.HandleFunc("/api/export/report", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			reportHandler.Export(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
 }
 
 func (r *Router) Start(addr string) error {
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// AttachmentConfig содержит ограничения на загружаемые файлы
+type AttachmentConfig struct {
+	// MaxSize максимальный размер файла в байтах
+	MaxSize int64
+	// AllowedTypes допустимые MIME-типы; значение с "/" на конце
+	// (например, "image/") разрешает все подтипы
+	AllowedTypes []string
+	// TempDir каталог для временных файлов при загрузке
+	TempDir string
+}
+
+type AttachmentUseCase struct {
+	taskUseCase    *TaskUseCase
+	attachmentRepo repository.AttachmentRepository
+	blobs          blobstore.BlobStore
+	logger         *logger.Logger
+	config         AttachmentConfig
+	// blobMutex защищает подсчет ссылок на содержимое при загрузке и удалении
+	blobMutex sync.Mutex
+}
+
+func NewAttachmentUseCase(taskUseCase *TaskUseCase, attachmentRepo repository.AttachmentRepository, blobs blobstore.BlobStore, logger *logger.Logger, config AttachmentConfig) *AttachmentUseCase {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 10 << 20
+	}
+
+	return &AttachmentUseCase{
+		taskUseCase:    taskUseCase,
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		logger:         logger,
+		config:         config,
+	}
+}
+
+// MaxSize возвращает максимальный размер вложения
+func (uc *AttachmentUseCase) MaxSize() int64 {
+	return uc.config.MaxSize
+}
+
+// UploadAttachment сохраняет файл и прикрепляет его к задаче. Содержимое
+// сначала пишется во временный файл с подсчетом хэша: ключ в хранилище
+// известен только после чтения всего файла.
+func (uc *AttachmentUseCase) UploadAttachment(ctx context.Context, taskID, fileName string, content io.Reader) (*entity.Attachment, error) {
+	uc.logger.Info("Uploading attachment", map[string]interface{}{"task": taskID, "file": fileName})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return nil, errors.New("validation failed: file name is required")
+	}
+
+	tmp, err := os.CreateTemp(uc.config.TempDir, "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), io.LimitReader(content, uc.config.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if size > uc.config.MaxSize {
+		return nil, fmt.Errorf("validation failed: file exceeds maximum size of %d bytes", uc.config.MaxSize)
+	}
+	if size == 0 {
+		return nil, errors.New("validation failed: file is empty")
+	}
+
+	contentType, err := uc.detectContentType(tmp, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !uc.isAllowedType(contentType) {
+		return nil, errors.New("validation failed: file type " + contentType + " is not allowed")
+	}
+
+	userID, _ := ctx.Value("user_id").(string)
+	attachment := &entity.Attachment{
+		TaskID:      taskID,
+		UploaderID:  userID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hex.EncodeToString(hasher.Sum(nil)),
+	}
+
+	uc.blobMutex.Lock()
+	defer uc.blobMutex.Unlock()
+
+	// Одинаковое содержимое хранится один раз
+	exists, err := uc.blobs.Exists(ctx, attachment.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := uc.blobs.Put(ctx, attachment.Hash, tmp, size, contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.attachmentRepo.Create(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// GetAttachments возвращает вложения задачи
+func (uc *AttachmentUseCase) GetAttachments(ctx context.Context, taskID string) ([]*entity.Attachment, error) {
+	uc.logger.Info("Getting attachments", map[string]interface{}{"task": taskID})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	return uc.attachmentRepo.GetByTask(ctx, taskID)
+}
+
+// OpenAttachment возвращает описание вложения и поток с его содержимым.
+// Поток должен быть закрыт вызывающей стороной.
+func (uc *AttachmentUseCase) OpenAttachment(ctx context.Context, taskID, attachmentID string) (*entity.Attachment, io.ReadCloser, error) {
+	uc.logger.Info("Downloading attachment", map[string]interface{}{"task": taskID, "id": attachmentID})
+
+	attachment, err := uc.getTaskAttachment(ctx, taskID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := uc.blobs.Get(ctx, attachment.Hash)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, errors.New("attachment content not found")
+		}
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// DeleteAttachment удаляет вложение задачи
+func (uc *AttachmentUseCase) DeleteAttachment(ctx context.Context, taskID, attachmentID string) error {
+	uc.logger.Info("Deleting attachment", map[string]interface{}{"task": taskID, "id": attachmentID})
+
+	attachment, err := uc.getTaskAttachment(ctx, taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	return uc.deleteAttachment(ctx, attachment)
+}
+
+// OnTaskDeleted удаляет вложения удаленной задачи
+func (uc *AttachmentUseCase) OnTaskDeleted(ctx context.Context, task *entity.Task) {
+	attachments, err := uc.attachmentRepo.GetByTask(ctx, task.ID)
+	if err != nil {
+		uc.logger.Error("Failed to list task attachments", err, map[string]interface{}{"task": task.ID})
+		return
+	}
+
+	for _, attachment := range attachments {
+		if err := uc.deleteAttachment(ctx, attachment); err != nil {
+			uc.logger.Error("Failed to delete attachment", err, map[string]interface{}{"id": attachment.ID})
+		}
+	}
+}
+
+// deleteAttachment удаляет описание вложения, а содержимое — только если
+// на него больше не ссылается ни одно вложение
+func (uc *AttachmentUseCase) deleteAttachment(ctx context.Context, attachment *entity.Attachment) error {
+	uc.blobMutex.Lock()
+	defer uc.blobMutex.Unlock()
+
+	if err := uc.attachmentRepo.Delete(ctx, attachment.ID); err != nil {
+		return err
+	}
+
+	refs, err := uc.attachmentRepo.CountByHash(ctx, attachment.Hash)
+	if err != nil {
+		return err
+	}
+
+	if refs == 0 {
+		return uc.blobs.Delete(ctx, attachment.Hash)
+	}
+
+	return nil
+}
+
+func (uc *AttachmentUseCase) getTaskAttachment(ctx context.Context, taskID, attachmentID string) (*entity.Attachment, error) {
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	attachment, err := uc.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachment.TaskID != taskID {
+		return nil, errors.New("attachment not found")
+	}
+
+	return attachment, nil
+}
+
+// detectContentType определяет тип по содержимому файла. Текстовые форматы
+// (логи, JSON, CSV) по содержимому неразличимы, поэтому для них тип
+// уточняется по расширению; бинарное содержимое расширением не переопределяется.
+func (uc *AttachmentUseCase) detectContentType(f *os.File, fileName string) (string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+
+	if mediaType == "text/plain" {
+		if byExt := mime.TypeByExtension(filepath.Ext(fileName)); byExt != "" {
+			extType, _, _ := mime.ParseMediaType(byExt)
+			if strings.HasPrefix(extType, "text/") || extType == "application/json" || extType == "application/xml" {
+				return extType, nil
+			}
+		}
+	}
+
+	return mediaType, nil
+}
+
+func (uc *AttachmentUseCase) isAllowedType(contentType string) bool {
+	if len(uc.config.AllowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range uc.config.AllowedTypes {
+		if strings.HasSuffix(allowed, "/") && strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+		if contentType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore/s3test"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestAttachmentsDedupeContentInS3(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	server := s3test.NewServer("access", "secret")
+	defer server.Close()
+	blobs := blobstore.NewS3Store(blobstore.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "attachments",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+
+	attachments := NewAttachmentUseCase(env.tasks, db.NewAttachmentRepository(), blobs, env.logger, AttachmentConfig{TempDir: t.TempDir()})
+
+	first := env.createTask(t, ctx, &entity.Task{Title: "First"})
+	second := env.createTask(t, ctx, &entity.Task{Title: "Second"})
+
+	a, err := attachments.UploadAttachment(ctx, first.ID, "notes.txt", strings.NewReader("same content"))
+	if err != nil {
+		t.Fatalf("upload to first task: %v", err)
+	}
+	b, err := attachments.UploadAttachment(ctx, second.ID, "copy.txt", strings.NewReader("same content"))
+	if err != nil {
+		t.Fatalf("upload to second task: %v", err)
+	}
+	other, err := attachments.UploadAttachment(ctx, second.ID, "other.txt", strings.NewReader("other content"))
+	if err != nil {
+		t.Fatalf("upload other content: %v", err)
+	}
+
+	if a.Hash != b.Hash || a.Hash == other.Hash {
+		t.Fatalf("hashes: %s, %s, %s", a.Hash, b.Hash, other.Hash)
+	}
+	if keys := server.Keys("attachments"); len(keys) != 2 {
+		t.Fatalf("stored objects = %v, want one per distinct content", keys)
+	}
+
+	puts := 0
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request, "PUT attachments/"+a.Hash) {
+			puts++
+		}
+	}
+	if puts != 1 {
+		t.Fatalf("same content uploaded %d times, want 1", puts)
+	}
+
+	_, content, err := attachments.OpenAttachment(ctx, second.ID, b.ID)
+	if err != nil {
+		t.Fatalf("open deduplicated attachment: %v", err)
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil || string(data) != "same content" {
+		t.Fatalf("content = %q, %v", data, err)
+	}
+
+	// Содержимое удаляется только вместе с последней ссылкой на него
+	if err := attachments.DeleteAttachment(ctx, first.ID, a.ID); err != nil {
+		t.Fatalf("delete first: %v", err)
+	}
+	if _, _, ok := server.Object("attachments", a.Hash); !ok {
+		t.Fatal("shared content deleted while still referenced")
+	}
+
+	if err := attachments.DeleteAttachment(ctx, second.ID, b.ID); err != nil {
+		t.Fatalf("delete second: %v", err)
+	}
+	if _, _, ok := server.Object("attachments", a.Hash); ok {
+		t.Fatal("content kept after the last reference was deleted")
+	}
+	if _, _, ok := server.Object("attachments", other.Hash); !ok {
+		t.Fatal("unrelated content deleted")
+	}
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+// AuditUseCase читает журнал изменений задач и восстанавливает задачи к
+// прошлым ревизиям. Журнал пишет хранилище задач в транзакции изменения
+// вместе с событием outbox, поэтому в него попадает каждое зафиксированное
+// изменение задачи.
+type AuditUseCase struct {
+	taskUseCase *TaskUseCase
+	auditRepo   repository.AuditRepository
+	// timeTravel nil, если хранилище задач не хранит прошлых состояний;
+	// тогда состояние на момент времени берется из журнала
+	timeTravel repository.TaskTimeTravelRepository
+	logger     *logger.Logger
+}
+
+func NewAuditUseCase(taskUseCase *TaskUseCase, auditRepo repository.AuditRepository, timeTravel repository.TaskTimeTravelRepository, logger *logger.Logger) *AuditUseCase {
+	return &AuditUseCase{
+		taskUseCase: taskUseCase,
+		auditRepo:   auditRepo,
+		timeTravel:  timeTravel,
+		logger:      logger,
+	}
+}
+
+// GetHistory возвращает историю задачи по возрастанию ревизий. История
+// удаленной задачи и задачи в корзине доступна её владельцу.
+func (uc *AuditUseCase) GetHistory(ctx context.Context, taskID string) ([]*entity.AuditEntry, error) {
+	uc.logger.Info("Getting task history", map[string]interface{}{"id": taskID})
+
+	_, err := uc.taskUseCase.GetTask(ctx, taskID)
+	if err != nil && err.Error() != "task not found" {
+		return nil, err
+	}
+
+	entries, historyErr := uc.auditRepo.GetByTask(ctx, taskID)
+	if historyErr != nil {
+		return nil, historyErr
+	}
+
+	if err != nil {
+		// Задачи уже нет — права проверяются по последнему известному состоянию
+		if len(entries) == 0 {
+			return nil, err
+		}
+
+		userID, _ := ctx.Value("user_id").(string)
+		if entries[len(entries)-1].Snapshot.UserID != userID {
+			return nil, errors.New("access denied")
+		}
+	}
+
+	return entries, nil
+}
+
+// GetRevision возвращает запись истории задачи с номером revision
+func (uc *AuditUseCase) GetRevision(ctx context.Context, taskID string, revision int64) (*entity.AuditEntry, error) {
+	entries, err := uc.GetHistory(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Revision == revision {
+			return entry, nil
+		}
+	}
+
+	return nil, errors.New("revision not found")
+}
+
+// GetTaskAsOf возвращает состояние задачи на момент at
+func (uc *AuditUseCase) GetTaskAsOf(ctx context.Context, taskID string, at time.Time) (*entity.Task, error) {
+	uc.logger.Info("Getting task as of", map[string]interface{}{"id": taskID, "at": at})
+
+	if uc.timeTravel != nil {
+		return uc.getTaskAsOfFromStore(ctx, taskID, at)
+	}
+
+	// Права проверяются и для удаленной задачи
+	entries, err := uc.GetHistory(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *entity.Task
+	for _, entry := range entries {
+		if entry.At.After(at) {
+			break
+		}
+		state = entry.Snapshot
+		if entry.Action == entity.AuditDeleted {
+			state = nil
+		}
+	}
+
+	if state == nil {
+		return nil, errors.New("task not found")
+	}
+
+	return state, nil
+}
+
+// getTaskAsOfFromStore берет состояние задачи из журнала хранилища. Права
+// проверяются по владельцу в этом же состоянии, а не по журналу изменений:
+// тот пополняется асинхронно и может еще не знать задачу.
+func (uc *AuditUseCase) getTaskAsOfFromStore(ctx context.Context, taskID string, at time.Time) (*entity.Task, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	task, err := uc.timeTravel.GetAsOf(ctx, taskID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.UserID != userID {
+		return nil, errors.New("access denied")
+	}
+
+	return task, nil
+}
+
+// RestoreRevision возвращает поля задачи к состоянию после ревизии revision.
+// Восстановление — обычное изменение через UpdateTask: оно проходит проверки
+// и рабочий процесс и само попадает в историю. Место на доске, правило
+// повторения и счетчики чек-листа ведут их собственные механизмы, поэтому
+// они не восстанавливаются.
+func (uc *AuditUseCase) RestoreRevision(ctx context.Context, taskID string, revision int64) (*entity.Task, error) {
+	uc.logger.Info("Restoring task revision", map[string]interface{}{"id": taskID, "revision": revision})
+
+	entry, err := uc.GetRevision(ctx, taskID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Action == entity.AuditDeleted {
+		return nil, errors.New("validation failed: cannot restore a deletion revision")
+	}
+
+	current, err := uc.taskUseCase.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := *entry.Snapshot
+	restored.ID = current.ID
+	restored.ProjectID = current.ProjectID
+	restored.Rank = current.Rank
+	restored.Recurrence = current.Recurrence
+	restored.CreatedAt = current.CreatedAt
+
+	if err := uc.taskUseCase.UpdateTask(withRestoredFrom(ctx, taskID, revision), &restored); err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"testing"
+	"time"
+)
+
+// newJournalTestEnv testEnv поверх хранилища задач с журналом событий в
+// каталоге dir
+func newJournalTestEnv(t *testing.T, dir string) (*testEnv, *db.EventSourcedTaskRepository) {
+	t.Helper()
+
+	store, err := db.NewEventSourcedTaskRepository(dir, 0)
+	if err != nil {
+		t.Fatalf("open task journal: %v", err)
+	}
+
+	env := newTestEnv(t)
+	env.outbox = db.NewOutboxRepository(store)
+	env.tasks = NewTaskUseCase(store, env.deps, env.workflows, env.users, env.outbox, env.logger, TaskUseCaseConfig{})
+
+	return env, store
+}
+
+func TestTaskAsOfSeesChecklistChangeAtItsTime(t *testing.T) {
+	env, store := newJournalTestEnv(t, t.TempDir())
+	ctx := userContext("user-123")
+	audit := NewAuditUseCase(env.tasks, db.NewAuditRepository(store), store, env.logger)
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Prepare release"})
+	beforeChecklist := time.Now()
+
+	checklist := NewChecklistUseCase(env.tasks, db.NewChecklistRepository(), store, env.logger)
+	if _, err := checklist.AddItem(ctx, task.ID, "Tag the commit", ""); err != nil {
+		t.Fatalf("add checklist item: %v", err)
+	}
+
+	before, err := audit.GetTaskAsOf(ctx, task.ID, beforeChecklist)
+	if err != nil {
+		t.Fatalf("as of before checklist: %v", err)
+	}
+	if before.ChecklistTotal != 0 {
+		t.Fatalf("checklist total before the item was added = %d", before.ChecklistTotal)
+	}
+
+	current, err := env.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if current.ChecklistTotal != 1 || !current.UpdatedAt.After(beforeChecklist) {
+		t.Fatalf("task after checklist change: total %d, updated at %v", current.ChecklistTotal, current.UpdatedAt)
+	}
+}
+
+func TestTaskAsOfOfDeletedTaskChecksOwnerInJournal(t *testing.T) {
+	env, store := newJournalTestEnv(t, t.TempDir())
+	ctx := userContext("user-123")
+	audit := NewAuditUseCase(env.tasks, db.NewAuditRepository(store), store, env.logger)
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Old idea"})
+	createdAt := time.Now()
+
+	if err := env.tasks.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("trash task: %v", err)
+	}
+	if err := env.tasks.PurgeTask(ctx, task.ID); err != nil {
+		t.Fatalf("purge task: %v", err)
+	}
+
+	state, err := audit.GetTaskAsOf(ctx, task.ID, createdAt)
+	if err != nil || state.Title != "Old idea" {
+		t.Fatalf("as of for owner = %+v, %v", state, err)
+	}
+
+	if _, err := audit.GetTaskAsOf(userContext("user-456"), task.ID, createdAt); err == nil || err.Error() != "access denied" {
+		t.Fatalf("as of for another user: error = %v, want access denied", err)
+	}
+	if _, err := audit.GetTaskAsOf(ctx, task.ID, time.Now()); err == nil || err.Error() != "task not found" {
+		t.Fatalf("as of after deletion: error = %v, want task not found", err)
+	}
+	if _, err := audit.GetTaskAsOf(context.Background(), task.ID, createdAt); err == nil || err.Error() != "unauthorized" {
+		t.Fatalf("as of without user: error = %v", err)
+	}
+}
+
+func TestHistoryIsWrittenWithChangeAndSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	env, store := newJournalTestEnv(t, dir)
+	ctx := userContext("user-123")
+	audit := NewAuditUseCase(env.tasks, db.NewAuditRepository(store), store, env.logger)
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Draft"})
+	task.Title = "Final"
+	if err := env.tasks.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+
+	// Relay не запускался: история пишется вместе с изменением
+	history, err := audit.GetHistory(ctx, task.ID)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history = %d entries, %v; want 2", len(history), err)
+	}
+	if history[1].Action != entity.AuditUpdated || len(history[1].Changes) != 1 || history[1].Changes[0].New != "Final" {
+		t.Fatalf("update entry = %+v", history[1])
+	}
+
+	// После перезапуска история восстанавливается из журнала, и к её
+	// ревизиям можно вернуться
+	env, store = newJournalTestEnv(t, dir)
+	audit = NewAuditUseCase(env.tasks, db.NewAuditRepository(store), store, env.logger)
+
+	restored, err := audit.RestoreRevision(ctx, task.ID, history[0].Revision)
+	if err != nil || restored.Title != "Draft" {
+		t.Fatalf("restore after restart = %+v, %v", restored, err)
+	}
+
+	reopened, err := audit.GetHistory(ctx, task.ID)
+	if err != nil || len(reopened) != 3 {
+		t.Fatalf("history after restart = %d entries, %v; want 3", len(reopened), err)
+	}
+	for i, entry := range reopened[:2] {
+		if entry.ID != history[i].ID || entry.Revision != history[i].Revision || entry.Action != history[i].Action {
+			t.Fatalf("entry %d after restart = %+v, want %+v", i, entry, history[i])
+		}
+	}
+	if reopened[2].Action != entity.AuditRestored || reopened[2].RestoredFrom != history[0].Revision || reopened[2].Revision != 3 {
+		t.Fatalf("restore entry = %+v", reopened[2])
+	}
+}
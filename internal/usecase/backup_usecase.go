@@ -0,0 +1,754 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/backup"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Документы архива резервной копии
+const (
+	backupTasks        = "tasks"
+	backupProjects     = "projects"
+	backupDependencies = "dependencies"
+	backupComments     = "comments"
+	backupChecklists   = "checklists"
+	backupAttachments  = "attachments"
+)
+
+// BackupUseCase создает резервную копию данных пользователя и
+// восстанавливает её в том же или другом экземпляре сервиса
+type BackupUseCase struct {
+	taskUseCase    *TaskUseCase
+	taskRepo       repository.TaskRepository
+	dependencyRepo repository.DependencyRepository
+	projectRepo    repository.ProjectRepository
+	commentRepo    repository.CommentRepository
+	checklistRepo  repository.ChecklistRepository
+	attachmentRepo repository.AttachmentRepository
+	blobs          blobstore.BlobStore
+	logger         *logger.Logger
+}
+
+func NewBackupUseCase(taskUseCase *TaskUseCase, taskRepo repository.TaskRepository, dependencyRepo repository.DependencyRepository, projectRepo repository.ProjectRepository, commentRepo repository.CommentRepository, checklistRepo repository.ChecklistRepository, attachmentRepo repository.AttachmentRepository, blobs blobstore.BlobStore, logger *logger.Logger) *BackupUseCase {
+	return &BackupUseCase{
+		taskUseCase:    taskUseCase,
+		taskRepo:       taskRepo,
+		dependencyRepo: dependencyRepo,
+		projectRepo:    projectRepo,
+		commentRepo:    commentRepo,
+		checklistRepo:  checklistRepo,
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		logger:         logger,
+	}
+}
+
+// RestoreOptions настройки восстановления
+type RestoreOptions struct {
+	// WorkspaceID пространство, в которое восстанавливается всё содержимое
+	// архива; пустое — текущее пространство запроса. Пространства из
+	// самого архива не используются: архив может быть составлен вручную.
+	WorkspaceID string
+	// DryRun только проверяет архив и сообщает, какие ID будут заменены
+	DryRun bool
+}
+
+// RestoreReport результат восстановления. Remapped — ID записей архива,
+// уже занятые в хранилище, и выданные вместо них новые ID (при DryRun
+// новые ID еще неизвестны и пусты).
+type RestoreReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Restored map[string]int    `json:"restored"`
+	Remapped map[string]string `json:"remapped_ids"`
+	Problems []string          `json:"problems,omitempty"`
+}
+
+// backupData содержимое архива
+type backupData struct {
+	tasks        []*entity.Task
+	projects     []*entity.Project
+	dependencies []*entity.TaskDependency
+	comments     []*entity.Comment
+	checklists   []*entity.ChecklistItem
+	attachments  []*entity.Attachment
+}
+
+// Backup записывает в w архив со всеми задачами пользователя, включая
+// архивные и задачи в корзине, его проектами, а также комментариями,
+// чек-листами, вложениями и связями этих задач. Содержимое вложений
+// копируется в архив, поэтому копия не зависит от хранилища файлов.
+func (uc *BackupUseCase) Backup(ctx context.Context, w io.Writer) error {
+	uc.logger.Info("Creating backup", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	data, err := uc.collect(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive := backup.NewWriter(w, userID)
+	if err := backup.WriteDocument(archive, backupTasks, data.tasks); err != nil {
+		return err
+	}
+	if err := backup.WriteDocument(archive, backupProjects, data.projects); err != nil {
+		return err
+	}
+	if err := backup.WriteDocument(archive, backupDependencies, data.dependencies); err != nil {
+		return err
+	}
+	if err := backup.WriteDocument(archive, backupComments, data.comments); err != nil {
+		return err
+	}
+	if err := backup.WriteDocument(archive, backupChecklists, data.checklists); err != nil {
+		return err
+	}
+	if err := backup.WriteDocument(archive, backupAttachments, data.attachments); err != nil {
+		return err
+	}
+
+	// Одинаковые файлы хранятся под одним ключом и копируются один раз
+	written := make(map[string]bool)
+	for _, attachment := range data.attachments {
+		if written[attachment.Hash] {
+			continue
+		}
+		written[attachment.Hash] = true
+
+		if err := uc.writeBlob(ctx, archive, attachment.Hash); err != nil {
+			return err
+		}
+	}
+
+	uc.logger.Info("Backup created", map[string]interface{}{"tasks": len(data.tasks), "blobs": len(written)})
+	return archive.Close()
+}
+
+// Restore восстанавливает содержимое архива от имени текущего пользователя.
+// Архив сначала проверяется целиком: при найденных проблемах ничего не
+// записывается, а отчет содержит их список. Записи, чьи ID уже заняты,
+// получают новые ID, и ссылки на них внутри архива исправляются.
+func (uc *BackupUseCase) Restore(ctx context.Context, archive *backup.Reader, opts RestoreOptions) (*RestoreReport, error) {
+	uc.logger.Info("Restoring backup", map[string]interface{}{"workspace": opts.WorkspaceID, "dry_run": opts.DryRun})
+
+	workspaceID := opts.WorkspaceID
+	if workspaceID == "" {
+		workspaceID = workspaceFromContext(ctx)
+	}
+
+	// Восстановить можно только в пространство, в котором состоит пользователь
+	if err := uc.taskUseCase.checkWorkspaceRole(ctx, workspaceID, entity.WorkspaceMember); err != nil {
+		return nil, err
+	}
+
+	data, err := readBackup(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range data.tasks {
+		task.WorkspaceID = workspaceID
+	}
+	for _, project := range data.projects {
+		project.WorkspaceID = workspaceID
+	}
+
+	report := &RestoreReport{
+		DryRun:   opts.DryRun,
+		Restored: make(map[string]int),
+		Remapped: make(map[string]string),
+	}
+
+	tasks, problems := uc.validate(ctx, archive, data)
+	if len(problems) > 0 {
+		report.Problems = problems
+		return report, errors.New("validation failed: " + problems[0])
+	}
+	data.tasks = tasks
+
+	if opts.DryRun {
+		uc.findConflicts(ctx, data, report)
+		return report, nil
+	}
+
+	if err := uc.restore(ctx, archive, data, report); err != nil {
+		return report, err
+	}
+
+	uc.logger.Info("Backup restored", map[string]interface{}{"tasks": report.Restored[backupTasks], "remapped": len(report.Remapped)})
+	return report, nil
+}
+
+// collect собирает данные пользователя для архива
+func (uc *BackupUseCase) collect(ctx context.Context, userID string) (*backupData, error) {
+	data := &backupData{}
+
+	for _, load := range []func(ctx context.Context, userID string) ([]*entity.Task, error){
+		uc.taskRepo.GetAll, uc.taskRepo.GetArchived, uc.taskRepo.GetTrashed,
+	} {
+		tasks, err := load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		data.tasks = append(data.tasks, tasks...)
+	}
+	sort.Slice(data.tasks, func(i, j int) bool {
+		return data.tasks[i].ID < data.tasks[j].ID
+	})
+
+	projects, err := uc.projectRepo.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	data.projects = projects
+
+	inBackup := make(map[string]bool)
+	for _, task := range data.tasks {
+		inBackup[task.ID] = true
+	}
+
+	for _, task := range data.tasks {
+		blockers, err := uc.dependencyRepo.GetBlockers(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range blockers {
+			if inBackup[dep.BlockerID] {
+				data.dependencies = append(data.dependencies, dep)
+			}
+		}
+
+		comments, err := uc.commentRepo.GetByTask(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		data.comments = append(data.comments, comments...)
+
+		items, err := uc.checklistRepo.GetByTask(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		data.checklists = append(data.checklists, items...)
+
+		attachments, err := uc.attachmentRepo.GetByTask(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		data.attachments = append(data.attachments, attachments...)
+	}
+
+	return data, nil
+}
+
+func (uc *BackupUseCase) writeBlob(ctx context.Context, archive *backup.Writer, key string) error {
+	blob, err := uc.blobs.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("blob %s: %w", key, err)
+	}
+	defer blob.Close()
+
+	return archive.WriteBlob(key, blob)
+}
+
+func readBackup(archive *backup.Reader) (*backupData, error) {
+	data := &backupData{}
+	var err error
+
+	if data.tasks, err = backup.ReadDocument[*entity.Task](archive, backupTasks); err != nil {
+		return nil, err
+	}
+	if data.projects, err = backup.ReadDocument[*entity.Project](archive, backupProjects); err != nil {
+		return nil, err
+	}
+	if data.dependencies, err = backup.ReadDocument[*entity.TaskDependency](archive, backupDependencies); err != nil {
+		return nil, err
+	}
+	if data.comments, err = backup.ReadDocument[*entity.Comment](archive, backupComments); err != nil {
+		return nil, err
+	}
+	if data.checklists, err = backup.ReadDocument[*entity.ChecklistItem](archive, backupChecklists); err != nil {
+		return nil, err
+	}
+	if data.attachments, err = backup.ReadDocument[*entity.Attachment](archive, backupAttachments); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// validate проверяет записи архива и ссылки между ними. Возвращает задачи
+// в порядке восстановления: родитель раньше подзадач.
+func (uc *BackupUseCase) validate(ctx context.Context, archive *backup.Reader, data *backupData) ([]*entity.Task, []string) {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	projects := make(map[string]bool)
+	for _, project := range data.projects {
+		if project.ID == "" || projects[project.ID] {
+			addProblem("project %q: missing or duplicate id", project.ID)
+		}
+		projects[project.ID] = true
+		if errMsgs := project.Validate(); len(errMsgs) > 0 {
+			addProblem("project %s: %s", project.ID, errMsgs[0])
+		}
+	}
+
+	tasks := make(map[string]*entity.Task)
+	workflows := make(map[string]*entity.Workflow)
+	for _, task := range data.tasks {
+		if task.ID == "" || tasks[task.ID] != nil {
+			addProblem("task %q: missing or duplicate id", task.ID)
+		}
+		tasks[task.ID] = task
+		if errMsgs := task.Validate(); len(errMsgs) > 0 {
+			addProblem("task %s: %s", task.ID, errMsgs[0])
+		}
+		if task.ProjectID != "" && !projects[task.ProjectID] {
+			addProblem("task %s: project %s is not in the archive", task.ID, task.ProjectID)
+		}
+
+		workspaceID := task.WorkspaceID
+		if workspaceID == "" {
+			workspaceID = workspaceFromContext(ctx)
+		}
+		workflow, ok := workflows[workspaceID]
+		if !ok {
+			var err error
+			if workflow, err = uc.taskUseCase.GetWorkflow(ctx, workspaceID); err != nil {
+				addProblem("workspace %s: %v", workspaceID, err)
+				continue
+			}
+			workflows[workspaceID] = workflow
+		}
+		if _, ok := workflow.Status(task.Status); !ok {
+			addProblem("task %s: status %s is not defined in workflow of workspace %s", task.ID, task.Status, workspaceID)
+		}
+	}
+
+	ordered := orderTasksForRestore(data.tasks, tasks)
+	for _, task := range data.tasks {
+		if task.ParentID != "" && tasks[task.ParentID] == nil {
+			addProblem("task %s: parent %s is not in the archive", task.ID, task.ParentID)
+		}
+	}
+	if len(ordered) < len(data.tasks) && len(problems) == 0 {
+		addProblem("tasks: parent references form a cycle")
+	}
+
+	for _, dep := range data.dependencies {
+		if tasks[dep.BlockerID] == nil || tasks[dep.BlockedID] == nil {
+			addProblem("dependency %s -> %s: task is not in the archive", dep.BlockerID, dep.BlockedID)
+		}
+	}
+	if cycle := dependencyCycle(data.dependencies); cycle != nil {
+		addProblem("dependencies: %s form a cycle", strings.Join(cycle, " -> "))
+	}
+
+	comments := make(map[string]bool)
+	for _, comment := range data.comments {
+		if comment.ID == "" || comments[comment.ID] {
+			addProblem("comment %q: missing or duplicate id", comment.ID)
+		}
+		comments[comment.ID] = true
+		if tasks[comment.TaskID] == nil {
+			addProblem("comment %s: task %s is not in the archive", comment.ID, comment.TaskID)
+		}
+	}
+	for _, comment := range data.comments {
+		if comment.ParentID != "" && !comments[comment.ParentID] {
+			addProblem("comment %s: parent %s is not in the archive", comment.ID, comment.ParentID)
+		}
+	}
+
+	for _, item := range data.checklists {
+		if tasks[item.TaskID] == nil {
+			addProblem("checklist item %s: task %s is not in the archive", item.ID, item.TaskID)
+		}
+		if errMsgs := item.Validate(); len(errMsgs) > 0 {
+			addProblem("checklist item %s: %s", item.ID, errMsgs[0])
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, attachment := range data.attachments {
+		if tasks[attachment.TaskID] == nil {
+			addProblem("attachment %s: task %s is not in the archive", attachment.ID, attachment.TaskID)
+		}
+		if checked[attachment.Hash] {
+			continue
+		}
+		checked[attachment.Hash] = true
+
+		if err := verifyBackupBlob(archive, attachment); err != nil {
+			addProblem("attachment %s: %v", attachment.ID, err)
+		}
+	}
+
+	return ordered, problems
+}
+
+// dependencyCycle возвращает задачи первого найденного цикла связей
+// "блокирует", начиная и заканчивая одной задачей, или nil
+func dependencyCycle(dependencies []*entity.TaskDependency) []string {
+	blocks := make(map[string][]string)
+	var roots []string
+	for _, dep := range dependencies {
+		if blocks[dep.BlockerID] == nil {
+			roots = append(roots, dep.BlockerID)
+		}
+		blocks[dep.BlockerID] = append(blocks[dep.BlockerID], dep.BlockedID)
+	}
+
+	// Задача на пути обхода в глубину — в path, полностью обойденная — в done
+	var path []string
+	onPath := make(map[string]bool)
+	done := make(map[string]bool)
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		if onPath[id] {
+			for i, pathID := range path {
+				if pathID == id {
+					return append(append([]string(nil), path[i:]...), id)
+				}
+			}
+		}
+		if done[id] {
+			return nil
+		}
+
+		path = append(path, id)
+		onPath[id] = true
+		for _, next := range blocks[id] {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		onPath[id] = false
+		done[id] = true
+
+		return nil
+	}
+
+	for _, id := range roots {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// verifyBackupBlob проверяет, что содержимое вложения есть в архиве и
+// совпадает с его хешем и размером
+func verifyBackupBlob(archive *backup.Reader, attachment *entity.Attachment) error {
+	blob, _, err := archive.OpenBlob(attachment.Hash)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, blob)
+	if err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(hash.Sum(nil)) != attachment.Hash || size != attachment.Size {
+		return errors.New("content does not match hash " + attachment.Hash)
+	}
+	return nil
+}
+
+// orderTasksForRestore упорядочивает задачи так, чтобы родитель шел раньше
+// подзадач; задачи с родителем вне архива и в цикле не попадают в результат
+func orderTasksForRestore(list []*entity.Task, byID map[string]*entity.Task) []*entity.Task {
+	sorted := append([]*entity.Task(nil), list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var ordered []*entity.Task
+	placed := make(map[string]bool)
+	for progress := true; progress; {
+		progress = false
+		for _, task := range sorted {
+			if placed[task.ID] {
+				continue
+			}
+			if task.ParentID != "" && (byID[task.ParentID] == nil || !placed[task.ParentID]) {
+				continue
+			}
+			placed[task.ID] = true
+			ordered = append(ordered, task)
+			progress = true
+		}
+	}
+
+	return ordered
+}
+
+// findConflicts отмечает в отчете ID записей, которые уже заняты
+func (uc *BackupUseCase) findConflicts(ctx context.Context, data *backupData, report *RestoreReport) {
+	for _, project := range data.projects {
+		if _, err := uc.projectRepo.GetByID(ctx, project.ID); err == nil {
+			report.Remapped[project.ID] = ""
+		}
+	}
+	for _, task := range data.tasks {
+		if _, err := uc.taskRepo.GetByID(ctx, task.ID); err == nil {
+			report.Remapped[task.ID] = ""
+		}
+	}
+	for _, comment := range data.comments {
+		if _, err := uc.commentRepo.GetByID(ctx, comment.ID); err == nil {
+			report.Remapped[comment.ID] = ""
+		}
+	}
+	for _, item := range data.checklists {
+		if _, err := uc.checklistRepo.GetByID(ctx, item.ID); err == nil {
+			report.Remapped[item.ID] = ""
+		}
+	}
+	for _, attachment := range data.attachments {
+		if _, err := uc.attachmentRepo.GetByID(ctx, attachment.ID); err == nil {
+			report.Remapped[attachment.ID] = ""
+		}
+	}
+}
+
+// restoreIDs новые ID записей одного вида, чьи ID из архива были заняты
+type restoreIDs map[string]string
+
+// resolve возвращает ID, под которым запись архива восстановлена
+func (m restoreIDs) resolve(id string) string {
+	if newID, ok := m[id]; ok {
+		return newID
+	}
+	return id
+}
+
+// restoreRollback отменяет уже сделанные записи восстановления в хранилищах
+// без транзакций
+type restoreRollback []func(ctx context.Context) error
+
+func (r *restoreRollback) add(undo func(ctx context.Context) error) {
+	*r = append(*r, undo)
+}
+
+// run отменяет записи в обратном порядке; ошибки отмены только пишутся
+// в журнал, чтобы отменить остальное
+func (r restoreRollback) run(ctx context.Context, log *logger.Logger) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if err := r[i](ctx); err != nil {
+			log.Error("Failed to roll back restored record", err, nil)
+		}
+	}
+}
+
+// restore записывает проверенные данные архива. Задачи записываются в одной
+// транзакции, а записи остальных хранилищ при ошибке удаляются, поэтому
+// неудачное восстановление ничего не оставляет. События задач помечаются
+// как восстановление и не рассылаются получателям уведомлений и вебхуков.
+func (uc *BackupUseCase) restore(ctx context.Context, archive *backup.Reader, data *backupData, report *RestoreReport) error {
+	var rollback restoreRollback
+
+	err := uc.taskRepo.WithinTransaction(withFromBackup(ctx), func(ctx context.Context) error {
+		return uc.write(ctx, archive, data, report, &rollback)
+	})
+	if err != nil {
+		// Отмена не должна прерываться вместе с запросом
+		rollback.run(context.WithoutCancel(ctx), uc.logger)
+		report.Restored = make(map[string]int)
+		report.Remapped = make(map[string]string)
+		return err
+	}
+
+	return nil
+}
+
+// write записывает данные архива. Ссылки на записи с замененными ID
+// исправляются, поэтому записи восстанавливаются после тех, на которые
+// ссылаются.
+func (uc *BackupUseCase) write(ctx context.Context, archive *backup.Reader, data *backupData, report *RestoreReport, rollback *restoreRollback) error {
+	userID, _ := ctx.Value("user_id").(string)
+
+	// remap освобождает занятый ID, чтобы хранилище выдало новый, и
+	// возвращает функцию, запоминающую замену после создания записи
+	remap := func(ids restoreIDs, id *string, exists bool) func() {
+		oldID := *id
+		if exists {
+			*id = ""
+		}
+		return func() {
+			if *id != oldID {
+				ids[oldID] = *id
+				report.Remapped[oldID] = *id
+			}
+		}
+	}
+
+	projectIDs := make(restoreIDs)
+	for _, project := range data.projects {
+		_, err := uc.projectRepo.GetByID(ctx, project.ID)
+		done := remap(projectIDs, &project.ID, err == nil)
+
+		project.UserID = userID
+		createdAt := project.CreatedAt
+		if err := uc.projectRepo.Create(ctx, project); err != nil {
+			return err
+		}
+		id := project.ID
+		rollback.add(func(ctx context.Context) error { return uc.projectRepo.Delete(ctx, id) })
+		project.CreatedAt = createdAt
+		if err := uc.projectRepo.Update(ctx, project); err != nil {
+			return err
+		}
+
+		done()
+		report.Restored[backupProjects]++
+	}
+
+	taskIDs := make(restoreIDs)
+	for _, task := range data.tasks {
+		_, err := uc.taskRepo.GetByID(ctx, task.ID)
+		done := remap(taskIDs, &task.ID, err == nil)
+
+		task.ParentID = taskIDs.resolve(task.ParentID)
+		task.SeriesID = taskIDs.resolve(task.SeriesID)
+		task.ProjectID = projectIDs.resolve(task.ProjectID)
+		if err := uc.taskUseCase.ImportTask(ctx, task); err != nil {
+			return fmt.Errorf("task %s: %w", task.Title, err)
+		}
+
+		done()
+		report.Restored[backupTasks]++
+	}
+
+	for _, dep := range data.dependencies {
+		dep.BlockerID = taskIDs.resolve(dep.BlockerID)
+		dep.BlockedID = taskIDs.resolve(dep.BlockedID)
+		err := uc.dependencyRepo.Add(ctx, dep)
+		if err != nil && err.Error() != "dependency already exists" {
+			return err
+		}
+		if err == nil {
+			blockerID, blockedID := dep.BlockerID, dep.BlockedID
+			rollback.add(func(ctx context.Context) error { return uc.dependencyRepo.Remove(ctx, blockerID, blockedID) })
+		}
+		report.Restored[backupDependencies]++
+	}
+
+	// Комментарии в архиве идут в порядке создания, ответ — после
+	// комментария, на который он отвечает
+	commentIDs := make(restoreIDs)
+	for _, comment := range data.comments {
+		_, err := uc.commentRepo.GetByID(ctx, comment.ID)
+		done := remap(commentIDs, &comment.ID, err == nil)
+
+		comment.TaskID = taskIDs.resolve(comment.TaskID)
+		comment.ParentID = commentIDs.resolve(comment.ParentID)
+		createdAt := comment.CreatedAt
+		if err := uc.commentRepo.Create(ctx, comment); err != nil {
+			return err
+		}
+		id := comment.ID
+		rollback.add(func(ctx context.Context) error { return uc.commentRepo.Delete(ctx, id) })
+		comment.CreatedAt = createdAt
+		if err := uc.commentRepo.Update(ctx, comment); err != nil {
+			return err
+		}
+
+		done()
+		report.Restored[backupComments]++
+	}
+
+	checklistIDs := make(restoreIDs)
+	for _, item := range data.checklists {
+		_, err := uc.checklistRepo.GetByID(ctx, item.ID)
+		done := remap(checklistIDs, &item.ID, err == nil)
+
+		item.TaskID = taskIDs.resolve(item.TaskID)
+		createdAt := item.CreatedAt
+		if err := uc.checklistRepo.Create(ctx, item); err != nil {
+			return err
+		}
+		id := item.ID
+		rollback.add(func(ctx context.Context) error { return uc.checklistRepo.Delete(ctx, id) })
+		item.CreatedAt = createdAt
+		if err := uc.checklistRepo.Update(ctx, item); err != nil {
+			return err
+		}
+
+		done()
+		report.Restored[backupChecklists]++
+	}
+
+	attachmentIDs := make(restoreIDs)
+	for _, attachment := range data.attachments {
+		if err := uc.restoreBlob(ctx, archive, attachment, rollback); err != nil {
+			return err
+		}
+
+		_, err := uc.attachmentRepo.GetByID(ctx, attachment.ID)
+		done := remap(attachmentIDs, &attachment.ID, err == nil)
+
+		attachment.TaskID = taskIDs.resolve(attachment.TaskID)
+		if err := uc.attachmentRepo.Create(ctx, attachment); err != nil {
+			return err
+		}
+		id := attachment.ID
+		rollback.add(func(ctx context.Context) error { return uc.attachmentRepo.Delete(ctx, id) })
+
+		done()
+		report.Restored[backupAttachments]++
+	}
+
+	return nil
+}
+
+// restoreBlob копирует содержимое вложения из архива, если его еще нет
+// в хранилище файлов. Отмена удаляет скопированное содержимое, если на него
+// так и не сослалось ни одно вложение.
+func (uc *BackupUseCase) restoreBlob(ctx context.Context, archive *backup.Reader, attachment *entity.Attachment, rollback *restoreRollback) error {
+	exists, err := uc.blobs.Exists(ctx, attachment.Hash)
+	if err != nil || exists {
+		return err
+	}
+
+	blob, size, err := archive.OpenBlob(attachment.Hash)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	if err := uc.blobs.Put(ctx, attachment.Hash, blob, size, attachment.ContentType); err != nil {
+		return err
+	}
+
+	hash := attachment.Hash
+	rollback.add(func(ctx context.Context) error {
+		refs, err := uc.attachmentRepo.CountByHash(ctx, hash)
+		if err != nil || refs > 0 {
+			return err
+		}
+		return uc.blobs.Delete(ctx, hash)
+	})
+	return nil
+}
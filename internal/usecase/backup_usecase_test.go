@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/backup"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backupTestEnv резервное копирование поверх testEnv; файлы вложений
+// хранятся во временном каталоге теста
+type backupTestEnv struct {
+	*testEnv
+	projects    *db.ProjectRepository
+	comments    *db.CommentRepository
+	checklists  *db.ChecklistRepository
+	attachments *db.AttachmentRepository
+	blobs       blobstore.BlobStore
+	backups     *BackupUseCase
+}
+
+func newBackupTestEnv(t *testing.T) *backupTestEnv {
+	t.Helper()
+
+	blobs, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create blob store: %v", err)
+	}
+
+	env := &backupTestEnv{
+		testEnv:     newTestEnv(t),
+		projects:    db.NewProjectRepository(),
+		comments:    db.NewCommentRepository(),
+		checklists:  db.NewChecklistRepository(),
+		attachments: db.NewAttachmentRepository(),
+		blobs:       blobs,
+	}
+	env.backups = NewBackupUseCase(env.tasks, env.taskRepo, env.deps, env.projects, env.comments, env.checklists, env.attachments, env.blobs, env.logger)
+
+	return env
+}
+
+// fill создает от имени ctx проект с двумя связанными задачами, назначенными
+// alice, комментарий, пункт чек-листа и вложение
+func (env *backupTestEnv) fill(t *testing.T, ctx context.Context) {
+	t.Helper()
+
+	project := &entity.Project{Name: "Launch"}
+	if err := NewProjectUseCase(env.tasks, env.projects, env.taskRepo, env.logger).CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	first := env.createTask(t, ctx, &entity.Task{Title: "Write docs", ProjectID: project.ID, AssigneeID: "user-456"})
+	second := env.createTask(t, ctx, &entity.Task{Title: "Publish docs", ProjectID: project.ID, AssigneeID: "user-456"})
+
+	if err := NewDependencyUseCase(env.tasks, env.deps, env.logger).AddDependency(ctx, first.ID, second.ID); err != nil {
+		t.Fatalf("add dependency: %v", err)
+	}
+	if err := NewCommentUseCase(env.tasks, env.comments, env.users, env.logger).AddComment(ctx, &entity.Comment{TaskID: first.ID, Body: "Draft is ready"}); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if _, err := NewChecklistUseCase(env.tasks, env.checklists, env.taskRepo, env.logger).AddItem(ctx, first.ID, "Proofread", ""); err != nil {
+		t.Fatalf("add checklist item: %v", err)
+	}
+
+	attachments := NewAttachmentUseCase(env.tasks, env.attachments, env.blobs, env.logger, AttachmentConfig{TempDir: t.TempDir()})
+	if _, err := attachments.UploadAttachment(ctx, first.ID, "notes.txt", strings.NewReader("release notes")); err != nil {
+		t.Fatalf("upload attachment: %v", err)
+	}
+}
+
+// archive создает резервную копию данных пользователя ctx
+func (env *backupTestEnv) archive(t *testing.T, ctx context.Context) *backup.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := env.backups.Backup(ctx, &buf); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	return openArchive(t, buf.Bytes())
+}
+
+func openArchive(t *testing.T, data []byte) *backup.Reader {
+	t.Helper()
+
+	archive, err := backup.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	return archive
+}
+
+// pendingEvents возвращает события outbox, ожидающие публикации
+func (env *backupTestEnv) pendingEvents(t *testing.T) []*entity.DomainEvent {
+	t.Helper()
+
+	now := time.Now()
+	records, err := env.outbox.ClaimPending(context.Background(), now, now.Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("claim outbox: %v", err)
+	}
+
+	var events []*entity.DomainEvent
+	for _, record := range records {
+		events = append(events, record.Event)
+	}
+	return events
+}
+
+func TestBackupRestoreDoesNotNotify(t *testing.T) {
+	ctx := userContext("user-123")
+	source := newBackupTestEnv(t)
+	source.fill(t, ctx)
+	archive := source.archive(t, ctx)
+
+	target := newBackupTestEnv(t)
+	report, err := target.backups.Restore(ctx, archive, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("restore: %v (report %+v)", err, report)
+	}
+	if report.Restored[backupTasks] != 2 || report.Restored[backupAttachments] != 1 {
+		t.Fatalf("restored = %v", report.Restored)
+	}
+
+	events := target.pendingEvents(t)
+	if len(events) != 2 {
+		t.Fatalf("outbox has %d events, want one per restored task", len(events))
+	}
+
+	for _, event := range events {
+		if !event.FromBackup {
+			t.Fatalf("event %s of task %s is not marked as restored", event.Type, event.TaskID)
+		}
+	}
+
+	// Восстановленные задачи назначены alice, но писем она не получает
+	emails := newEmailTestEnv(t)
+	for _, event := range events {
+		emails.handle(t, event)
+	}
+	emails.emails.Tick(ctx, time.Now().Add(time.Hour))
+	if sent := emails.sent(t); len(sent) != 0 {
+		t.Fatalf("sent %d emails about restored tasks", len(sent))
+	}
+
+	// и вебхуки пространства о них не срабатывают
+	webhookRepo, err := db.NewWebhookRepository(filepath.Join(t.TempDir(), "webhooks.json"))
+	if err != nil {
+		t.Fatalf("load webhooks: %v", err)
+	}
+	deliveryRepo, err := db.NewWebhookDeliveryRepository(filepath.Join(t.TempDir(), "webhook_deliveries.json"))
+	if err != nil {
+		t.Fatalf("load webhook deliveries: %v", err)
+	}
+	subscription := &entity.WebhookSubscription{
+		WorkspaceID: entity.DefaultWorkspaceID,
+		UserID:      "user-123",
+		URL:         "https://hooks.example.com/tasks",
+		Events:      []entity.WebhookEvent{entity.WebhookEvent(entity.TaskCreatedEvent)},
+		Active:      true,
+	}
+	if err := webhookRepo.Create(ctx, subscription); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	webhooks := NewWebhookUseCase(webhookRepo, deliveryRepo, target.logger, WebhookConfig{})
+	for _, event := range events {
+		if err := webhooks.HandleTaskEvent(ctx, event); err != nil {
+			t.Fatalf("webhooks: %v", err)
+		}
+	}
+	deliveries, err := deliveryRepo.GetBySubscription(ctx, subscription.ID, "")
+	if err != nil || len(deliveries) != 0 {
+		t.Fatalf("deliveries = %d, %v; want none for restored tasks", len(deliveries), err)
+	}
+}
+
+// failingBlobStore хранилище файлов, отказывающее в записи
+type failingBlobStore struct {
+	blobstore.BlobStore
+}
+
+func (s failingBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return errors.New("storage is full")
+}
+
+func TestBackupRestoreRollsBackOnFailure(t *testing.T) {
+	ctx := userContext("user-123")
+	source := newBackupTestEnv(t)
+	source.fill(t, ctx)
+	archive := source.archive(t, ctx)
+
+	// Вложения восстанавливаются последними, поэтому к отказу хранилища
+	// файлов все остальное уже записано
+	target := newBackupTestEnv(t)
+	target.blobs = failingBlobStore{target.blobs}
+	target.backups = NewBackupUseCase(target.tasks, target.taskRepo, target.deps, target.projects, target.comments, target.checklists, target.attachments, target.blobs, target.logger)
+
+	report, err := target.backups.Restore(ctx, archive, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "storage is full") {
+		t.Fatalf("restore error = %v, want storage failure", err)
+	}
+	if len(report.Restored) != 0 || len(report.Remapped) != 0 {
+		t.Fatalf("report = %+v, want nothing restored", report)
+	}
+
+	if tasks, err := target.taskRepo.GetAll(ctx, "user-123"); err != nil || len(tasks) != 0 {
+		t.Fatalf("tasks after failed restore = %d, %v", len(tasks), err)
+	}
+	if projects, err := target.projects.GetAll(ctx, "user-123"); err != nil || len(projects) != 0 {
+		t.Fatalf("projects after failed restore = %d, %v", len(projects), err)
+	}
+	if events := target.pendingEvents(t); len(events) != 0 {
+		t.Fatalf("outbox has %d events after failed restore", len(events))
+	}
+
+	// Архив восстанавливается в тот же экземпляр без замены ID: от
+	// неудачной попытки не осталось занятых ID
+	target.blobs = source.blobs
+	target.backups = NewBackupUseCase(target.tasks, target.taskRepo, target.deps, target.projects, target.comments, target.checklists, target.attachments, target.blobs, target.logger)
+	report, err = target.backups.Restore(ctx, archive, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(report.Remapped) != 0 || report.Restored[backupComments] != 1 || report.Restored[backupChecklists] != 1 || report.Restored[backupDependencies] != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestBackupRestoreRejectsDependencyCycle(t *testing.T) {
+	ctx := userContext("user-123")
+	now := time.Now()
+
+	tasks := []*entity.Task{
+		{ID: "a", Title: "A", Status: entity.StatusTodo, CreatedAt: now},
+		{ID: "b", Title: "B", Status: entity.StatusTodo, CreatedAt: now},
+		{ID: "c", Title: "C", Status: entity.StatusTodo, CreatedAt: now},
+	}
+	dependencies := []*entity.TaskDependency{
+		{BlockerID: "a", BlockedID: "b"},
+		{BlockerID: "b", BlockedID: "c"},
+		{BlockerID: "c", BlockedID: "b"},
+	}
+
+	var buf bytes.Buffer
+	writer := backup.NewWriter(&buf, "user-123")
+	for _, err := range []error{
+		backup.WriteDocument(writer, backupTasks, tasks),
+		backup.WriteDocument(writer, backupProjects, []*entity.Project{}),
+		backup.WriteDocument(writer, backupDependencies, dependencies),
+		backup.WriteDocument(writer, backupComments, []*entity.Comment{}),
+		backup.WriteDocument(writer, backupChecklists, []*entity.ChecklistItem{}),
+		backup.WriteDocument(writer, backupAttachments, []*entity.Attachment{}),
+		writer.Close(),
+	} {
+		if err != nil {
+			t.Fatalf("write archive: %v", err)
+		}
+	}
+
+	env := newBackupTestEnv(t)
+	report, err := env.backups.Restore(ctx, openArchive(t, buf.Bytes()), RestoreOptions{DryRun: true})
+	if err == nil || report == nil || len(report.Problems) != 1 || report.Problems[0] != "dependencies: b -> c -> b form a cycle" {
+		t.Fatalf("restore = %+v, %v; want the cycle reported", report, err)
+	}
+}
+
+func TestBackupRestoreStaysInCallerWorkspace(t *testing.T) {
+	ctx := userContext("user-123")
+
+	// Архив составлен вручную и указывает чужое пространство
+	tasks := []*entity.Task{{ID: "a", Title: "Injected", Status: entity.StatusTodo, WorkspaceID: "acme", CreatedAt: time.Now()}}
+	var buf bytes.Buffer
+	writer := backup.NewWriter(&buf, "user-123")
+	for _, err := range []error{
+		backup.WriteDocument(writer, backupTasks, tasks),
+		backup.WriteDocument(writer, backupProjects, []*entity.Project{{ID: "p", Name: "Injected", WorkspaceID: "acme"}}),
+		backup.WriteDocument(writer, backupDependencies, []*entity.TaskDependency{}),
+		backup.WriteDocument(writer, backupComments, []*entity.Comment{}),
+		backup.WriteDocument(writer, backupChecklists, []*entity.ChecklistItem{}),
+		backup.WriteDocument(writer, backupAttachments, []*entity.Attachment{}),
+		writer.Close(),
+	} {
+		if err != nil {
+			t.Fatalf("write archive: %v", err)
+		}
+	}
+
+	env := newBackupTestEnv(t)
+
+	// Пространство, в котором пользователь не состоит, недоступно
+	if _, err := env.backups.Restore(ctx, openArchive(t, buf.Bytes()), RestoreOptions{WorkspaceID: "acme"}); err == nil || err.Error() != "access denied" {
+		t.Fatalf("restore into foreign workspace: error = %v, want access denied", err)
+	}
+	foreign := context.WithValue(ctx, "workspace_id", "acme")
+	if _, err := env.backups.Restore(foreign, openArchive(t, buf.Bytes()), RestoreOptions{}); err == nil || err.Error() != "access denied" {
+		t.Fatalf("restore into foreign current workspace: error = %v, want access denied", err)
+	}
+
+	// Без явного пространства содержимое попадает в текущее
+	if _, err := env.backups.Restore(ctx, openArchive(t, buf.Bytes()), RestoreOptions{}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	task, err := env.taskRepo.GetByID(ctx, "a")
+	if err != nil || task.WorkspaceID != entity.DefaultWorkspaceID {
+		t.Fatalf("restored task = %+v, %v; want it in the default workspace", task, err)
+	}
+	project, err := env.projects.GetByID(ctx, "p")
+	if err != nil || project.WorkspaceID != entity.DefaultWorkspaceID {
+		t.Fatalf("restored project = %+v, %v; want it in the default workspace", project, err)
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CalendarConfig настройки подписок календаря
+type CalendarConfig struct {
+	BaseURL string // внешний адрес сервиса для ссылок подписки
+}
+
+// CalendarUseCase выдает пользователям секретные адреса ленты задач для
+// календарей и открывает ленту по такому адресу без авторизации
+type CalendarUseCase struct {
+	feedRepo repository.CalendarFeedRepository
+	logger   *logger.Logger
+	config   CalendarConfig
+}
+
+func NewCalendarUseCase(feedRepo repository.CalendarFeedRepository, logger *logger.Logger, config CalendarConfig) *CalendarUseCase {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &CalendarUseCase{
+		feedRepo: feedRepo,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// GetFeed возвращает подписку текущего пользователя в пространстве,
+// создавая её при первом обращении
+func (uc *CalendarUseCase) GetFeed(ctx context.Context) (*entity.CalendarFeed, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	feed, err := uc.feedRepo.GetByUser(ctx, userID, workspaceFromContext(ctx))
+	if err == nil {
+		return feed, nil
+	}
+	if err.Error() != "calendar feed not found" {
+		return nil, err
+	}
+
+	return uc.RotateFeed(ctx)
+}
+
+// RotateFeed выдает подписке новый токен; адрес со старым токеном
+// перестает работать
+func (uc *CalendarUseCase) RotateFeed(ctx context.Context) (*entity.CalendarFeed, error) {
+	uc.logger.Info("Issuing calendar feed token", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	feed := &entity.CalendarFeed{
+		UserID:      userID,
+		WorkspaceID: workspaceFromContext(ctx),
+		Token:       base64.RawURLEncoding.EncodeToString(token),
+		CreatedAt:   time.Now(),
+	}
+
+	if err := uc.feedRepo.Save(ctx, feed); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// DeleteFeed отключает подписку текущего пользователя в пространстве
+func (uc *CalendarUseCase) DeleteFeed(ctx context.Context) error {
+	uc.logger.Info("Deleting calendar feed", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	return uc.feedRepo.Delete(ctx, userID, workspaceFromContext(ctx))
+}
+
+// FeedURL адрес ленты, который добавляется в календарь как подписка
+func (uc *CalendarUseCase) FeedURL(feed *entity.CalendarFeed) string {
+	return uc.config.BaseURL + "/calendar.ics?token=" + url.QueryEscape(feed.Token)
+}
+
+// OpenFeed находит подписку по токену из адреса и возвращает контекст её
+// владельца, от имени которого читаются задачи ленты; авторизация не
+// требуется, доступ подтверждается знанием токена
+func (uc *CalendarUseCase) OpenFeed(ctx context.Context, token string) (context.Context, error) {
+	if token == "" {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	feed, err := uc.feedRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerCtx := context.WithValue(ctx, "user_id", feed.UserID)
+	ownerCtx = context.WithValue(ownerCtx, "workspace_id", feed.WorkspaceID)
+
+	return ownerCtx, nil
+}
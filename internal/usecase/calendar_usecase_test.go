@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"path/filepath"
+	"testing"
+)
+
+// openCalendar создает use case лент заново из файла, как после перезапуска
+func openCalendar(t *testing.T, path string) *CalendarUseCase {
+	t.Helper()
+
+	feeds, err := db.NewCalendarFeedRepository(path)
+	if err != nil {
+		t.Fatalf("load calendar feeds: %v", err)
+	}
+	return NewCalendarUseCase(feeds, logger.NewLogger(), CalendarConfig{BaseURL: "http://tasks.test"})
+}
+
+func TestCalendarFeedSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar_feeds.json")
+	ctx := userContext("user-123")
+
+	calendar := openCalendar(t, path)
+	first, err := calendar.GetFeed(ctx)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	rotated, err := calendar.RotateFeed(ctx)
+	if err != nil {
+		t.Fatalf("rotate feed: %v", err)
+	}
+	if _, err := calendar.GetFeed(userContext("user-456")); err != nil {
+		t.Fatalf("get feed of alice: %v", err)
+	}
+
+	calendar = openCalendar(t, path)
+
+	ownerCtx, err := calendar.OpenFeed(context.Background(), rotated.Token)
+	if err != nil {
+		t.Fatalf("open feed after restart: %v", err)
+	}
+	if ownerCtx.Value("user_id") != "user-123" {
+		t.Fatalf("feed opened for %v", ownerCtx.Value("user_id"))
+	}
+	if _, err := calendar.OpenFeed(context.Background(), first.Token); err == nil {
+		t.Fatal("rotated token still opens the feed after restart")
+	}
+
+	feed, err := calendar.GetFeed(ctx)
+	if err != nil || feed.Token != rotated.Token || !feed.CreatedAt.Equal(rotated.CreatedAt) {
+		t.Fatalf("feed after restart = %+v, %v; want %+v", feed, err, rotated)
+	}
+
+	// Удаление тоже переживает перезапуск
+	if err := calendar.DeleteFeed(ctx); err != nil {
+		t.Fatalf("delete feed: %v", err)
+	}
+	calendar = openCalendar(t, path)
+	if _, err := calendar.OpenFeed(context.Background(), rotated.Token); err == nil {
+		t.Fatal("deleted feed opens after restart")
+	}
+	if _, err := calendar.OpenFeed(context.Background(), ""); err == nil {
+		t.Fatal("empty token opens a feed")
+	}
+}
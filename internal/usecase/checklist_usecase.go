@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"sync"
+)
+
+type ChecklistUseCase struct {
+	taskUseCase   *TaskUseCase
+	checklistRepo repository.ChecklistRepository
+	taskRepo      repository.TaskRepository
+	logger        *logger.Logger
+	// mutex сериализует изменения чек-листов, чтобы ранги и счетчики
+	// задачи пересчитывались согласованно
+	mutex sync.Mutex
+}
+
+func NewChecklistUseCase(taskUseCase *TaskUseCase, checklistRepo repository.ChecklistRepository, taskRepo repository.TaskRepository, logger *logger.Logger) *ChecklistUseCase {
+	return &ChecklistUseCase{
+		taskUseCase:   taskUseCase,
+		checklistRepo: checklistRepo,
+		taskRepo:      taskRepo,
+		logger:        logger,
+	}
+}
+
+// GetChecklist возвращает пункты чек-листа задачи по порядку
+func (uc *ChecklistUseCase) GetChecklist(ctx context.Context, taskID string) ([]*entity.ChecklistItem, error) {
+	uc.logger.Info("Getting checklist", map[string]interface{}{"task": taskID})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	return uc.checklistRepo.GetByTask(ctx, taskID)
+}
+
+// AddItem добавляет пункт после пункта afterItemID; пустой afterItemID — в конец списка
+func (uc *ChecklistUseCase) AddItem(ctx context.Context, taskID, text, afterItemID string) (*entity.ChecklistItem, error) {
+	uc.logger.Info("Adding checklist item", map[string]interface{}{"task": taskID})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	item := &entity.ChecklistItem{TaskID: taskID, Text: text}
+	if errMsgs := item.Validate(); len(errMsgs) > 0 {
+		return nil, errors.New("validation failed: " + errMsgs[0])
+	}
+
+	items, err := uc.checklistRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if afterItemID == "" && len(items) > 0 {
+		afterItemID = items[len(items)-1].ID
+	}
+
+	item.Rank, err = uc.rankAfter(ctx, items, afterItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checklistRepo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return item, uc.refreshStats(ctx, taskID)
+}
+
+// EditItem меняет текст пункта
+func (uc *ChecklistUseCase) EditItem(ctx context.Context, taskID, itemID, text string) (*entity.ChecklistItem, error) {
+	uc.logger.Info("Editing checklist item", map[string]interface{}{"task": taskID, "id": itemID})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	item, err := uc.getTaskItem(ctx, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Text = text
+	if errMsgs := item.Validate(); len(errMsgs) > 0 {
+		return nil, errors.New("validation failed: " + errMsgs[0])
+	}
+
+	if err := uc.checklistRepo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// SetItemDone отмечает пункт выполненным или снимает отметку
+func (uc *ChecklistUseCase) SetItemDone(ctx context.Context, taskID, itemID string, done bool) (*entity.ChecklistItem, error) {
+	uc.logger.Info("Setting checklist item state", map[string]interface{}{"task": taskID, "id": itemID, "done": done})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	item, err := uc.getTaskItem(ctx, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, uc.setDone(ctx, item, done)
+}
+
+// ToggleItem инвертирует отметку о выполнении пункта
+func (uc *ChecklistUseCase) ToggleItem(ctx context.Context, taskID, itemID string) (*entity.ChecklistItem, error) {
+	uc.logger.Info("Toggling checklist item", map[string]interface{}{"task": taskID, "id": itemID})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	item, err := uc.getTaskItem(ctx, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, uc.setDone(ctx, item, !item.Done)
+}
+
+// MoveItem переставляет пункт после пункта afterItemID; пустой afterItemID — в начало списка
+func (uc *ChecklistUseCase) MoveItem(ctx context.Context, taskID, itemID, afterItemID string) (*entity.ChecklistItem, error) {
+	uc.logger.Info("Moving checklist item", map[string]interface{}{"task": taskID, "id": itemID, "after": afterItemID})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	item, err := uc.getTaskItem(ctx, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if afterItemID == itemID {
+		return nil, errors.New("validation failed: item cannot be moved after itself")
+	}
+
+	items, err := uc.checklistRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var others []*entity.ChecklistItem
+	for _, other := range items {
+		if other.ID != itemID {
+			others = append(others, other)
+		}
+	}
+
+	item.Rank, err = uc.rankAfter(ctx, others, afterItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checklistRepo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// DeleteItem удаляет пункт чек-листа
+func (uc *ChecklistUseCase) DeleteItem(ctx context.Context, taskID, itemID string) error {
+	uc.logger.Info("Deleting checklist item", map[string]interface{}{"task": taskID, "id": itemID})
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	if _, err := uc.getTaskItem(ctx, taskID, itemID); err != nil {
+		return err
+	}
+
+	if err := uc.checklistRepo.Delete(ctx, itemID); err != nil {
+		return err
+	}
+
+	return uc.refreshStats(ctx, taskID)
+}
+
+// CompleteGuard условие перехода: все пункты чек-листа задачи выполнены
+func (uc *ChecklistUseCase) CompleteGuard(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) error {
+	items, err := uc.checklistRepo.GetByTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	open := 0
+	for _, item := range items {
+		if !item.Done {
+			open++
+		}
+	}
+
+	if open > 0 {
+		return fmt.Errorf("checklist has %d unfinished items", open)
+	}
+
+	return nil
+}
+
+// OnTaskDeleted удаляет чек-лист удаленной задачи
+func (uc *ChecklistUseCase) OnTaskDeleted(ctx context.Context, task *entity.Task) {
+	if err := uc.checklistRepo.DeleteByTask(ctx, task.ID); err != nil {
+		uc.logger.Error("Failed to delete task checklist", err, map[string]interface{}{"task": task.ID})
+	}
+}
+
+func (uc *ChecklistUseCase) getTaskItem(ctx context.Context, taskID, itemID string) (*entity.ChecklistItem, error) {
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	item, err := uc.checklistRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.TaskID != taskID {
+		return nil, errors.New("checklist item not found")
+	}
+
+	return item, nil
+}
+
+// rankAfter вычисляет ранг позиции после пункта afterItemID в упорядоченном списке.
+// Если ранги соседей совпадают, список перенумеровывается.
+func (uc *ChecklistUseCase) rankAfter(ctx context.Context, items []*entity.ChecklistItem, afterItemID string) (string, error) {
+	index := -1
+	if afterItemID != "" {
+		for i, item := range items {
+			if item.ID == afterItemID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", errors.New("validation failed: checklist item " + afterItemID + " not found")
+		}
+	}
+
+	prev, next := "", ""
+	if index >= 0 {
+		prev = items[index].Rank
+	}
+	if index+1 < len(items) {
+		next = items[index+1].Rank
+	}
+
+	rank, err := entity.RankBetween(prev, next)
+	if err == nil {
+		return rank, nil
+	}
+
+	// Перенумерация с сохранением текущего порядка
+	last := ""
+	for _, item := range items {
+		if item.Rank, err = entity.RankBetween(last, ""); err != nil {
+			return "", err
+		}
+		if err := uc.checklistRepo.Update(ctx, item); err != nil {
+			return "", err
+		}
+		last = item.Rank
+	}
+
+	prev, next = "", ""
+	if index >= 0 {
+		prev = items[index].Rank
+	}
+	if index+1 < len(items) {
+		next = items[index+1].Rank
+	}
+
+	return entity.RankBetween(prev, next)
+}
+
+func (uc *ChecklistUseCase) setDone(ctx context.Context, item *entity.ChecklistItem, done bool) error {
+	item.Done = done
+	if err := uc.checklistRepo.Update(ctx, item); err != nil {
+		return err
+	}
+
+	return uc.refreshStats(ctx, item.TaskID)
+}
+
+// refreshStats пересчитывает счетчики чек-листа в задаче
+func (uc *ChecklistUseCase) refreshStats(ctx context.Context, taskID string) error {
+	items, err := uc.checklistRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+
+	_, err = uc.taskUseCase.persistUpdate(ctx, taskID, func(ctx context.Context) error {
+		return uc.taskRepo.SetChecklistStats(ctx, taskID, done, len(items))
+	})
+	return err
+}
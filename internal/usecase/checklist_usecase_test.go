@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"strings"
+	"testing"
+)
+
+func TestChecklistBlocksDoneUntilComplete(t *testing.T) {
+	env := newTestEnv(t)
+	env.tasks.config.DoneGuards = []string{"checklist_complete"}
+	checklists := NewChecklistUseCase(env.tasks, db.NewChecklistRepository(), env.taskRepo, env.logger)
+	env.tasks.RegisterGuard("checklist_complete", checklists.CompleteGuard)
+	ctx := userContext("user-123")
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Prepare release"})
+
+	var items []*entity.ChecklistItem
+	for _, text := range []string{"Update changelog", "Tag version"} {
+		item, err := checklists.AddItem(ctx, task.ID, text, "")
+		if err != nil {
+			t.Fatalf("add item %q: %v", text, err)
+		}
+		items = append(items, item)
+	}
+	if _, err := checklists.SetItemDone(ctx, task.ID, items[0].ID, true); err != nil {
+		t.Fatalf("complete item: %v", err)
+	}
+
+	// Счетчики чек-листа хранятся в задаче
+	current, err := env.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if current.ChecklistDone != 1 || current.ChecklistTotal != 2 {
+		t.Fatalf("checklist stats = %d/%d, want 1/2", current.ChecklistDone, current.ChecklistTotal)
+	}
+
+	current.Status = entity.StatusDone
+	err = env.tasks.UpdateTask(ctx, current)
+	if err == nil || !strings.Contains(err.Error(), "checklist has 1 unfinished items") {
+		t.Fatalf("complete task with open item: %v, want guard failure", err)
+	}
+
+	if _, err := checklists.ToggleItem(ctx, task.ID, items[1].ID); err != nil {
+		t.Fatalf("toggle item: %v", err)
+	}
+
+	current, err = env.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	current.Status = entity.StatusDone
+	if err := env.tasks.UpdateTask(ctx, current); err != nil {
+		t.Fatalf("complete task with finished checklist: %v", err)
+	}
+}
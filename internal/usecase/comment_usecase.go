@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+type CommentUseCase struct {
+	taskUseCase *TaskUseCase
+	commentRepo repository.CommentRepository
+	userRepo    repository.UserRepository
+	logger      *logger.Logger
+}
+
+func NewCommentUseCase(taskUseCase *TaskUseCase, commentRepo repository.CommentRepository, userRepo repository.UserRepository, logger *logger.Logger) *CommentUseCase {
+	return &CommentUseCase{
+		taskUseCase: taskUseCase,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+// AddComment добавляет комментарий или ответ (если задан ParentID) к задаче
+func (uc *CommentUseCase) AddComment(ctx context.Context, comment *entity.Comment) error {
+	uc.logger.Info("Adding comment", map[string]interface{}{"task": comment.TaskID})
+
+	if errMsgs := comment.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	// Комментировать можно только задачи, доступные пользователю
+	if _, err := uc.taskUseCase.GetTask(ctx, comment.TaskID); err != nil {
+		return err
+	}
+
+	userID, _ := ctx.Value("user_id").(string)
+	comment.AuthorID = userID
+
+	if comment.ParentID != "" {
+		parent, err := uc.commentRepo.GetByID(ctx, comment.ParentID)
+		if err != nil || parent.TaskID != comment.TaskID {
+			return errors.New("validation failed: parent comment not found")
+		}
+	}
+
+	comment.Mentions = uc.resolveMentions(ctx, comment.Body)
+
+	return uc.commentRepo.Create(ctx, comment)
+}
+
+// GetComments возвращает обсуждение задачи в виде дерева ответов
+func (uc *CommentUseCase) GetComments(ctx context.Context, taskID string) ([]*entity.CommentThread, error) {
+	uc.logger.Info("Getting comments", map[string]interface{}{"task": taskID})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	comments, err := uc.commentRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make(map[string]*entity.CommentThread, len(comments))
+	for _, comment := range comments {
+		threads[comment.ID] = &entity.CommentThread{Comment: comment, Replies: []*entity.CommentThread{}}
+	}
+
+	roots := []*entity.CommentThread{}
+	for _, comment := range comments {
+		thread := threads[comment.ID]
+		if parent, ok := threads[comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, thread)
+		} else {
+			roots = append(roots, thread)
+		}
+	}
+
+	return roots, nil
+}
+
+// EditComment меняет текст комментария, сохраняя предыдущую версию в истории
+func (uc *CommentUseCase) EditComment(ctx context.Context, taskID, commentID, body string) (*entity.Comment, error) {
+	uc.logger.Info("Editing comment", map[string]interface{}{"task": taskID, "id": commentID})
+
+	comment, err := uc.getOwnComment(ctx, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Deleted {
+		return nil, errors.New("comment not found")
+	}
+
+	if comment.Body == body {
+		return comment, nil
+	}
+
+	comment.History = append(comment.History, entity.CommentRevision{
+		Body:     comment.Body,
+		EditedAt: time.Now(),
+	})
+	comment.Body = body
+
+	if errMsgs := comment.Validate(); len(errMsgs) > 0 {
+		return nil, errors.New("validation failed: " + errMsgs[0])
+	}
+
+	comment.Mentions = uc.resolveMentions(ctx, comment.Body)
+
+	if err := uc.commentRepo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment удаляет комментарий. Комментарий с ответами заменяется
+// пометкой об удалении, чтобы не разрушать ветку обсуждения.
+func (uc *CommentUseCase) DeleteComment(ctx context.Context, taskID, commentID string) error {
+	uc.logger.Info("Deleting comment", map[string]interface{}{"task": taskID, "id": commentID})
+
+	comment, err := uc.getOwnComment(ctx, taskID, commentID)
+	if err != nil {
+		return err
+	}
+
+	comments, err := uc.commentRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range comments {
+		if other.ParentID == comment.ID {
+			comment.Deleted = true
+			comment.Body = ""
+			comment.Mentions = nil
+			comment.History = nil
+			return uc.commentRepo.Update(ctx, comment)
+		}
+	}
+
+	return uc.commentRepo.Delete(ctx, comment.ID)
+}
+
+// OnTaskDeleted удаляет обсуждение удаленной задачи
+func (uc *CommentUseCase) OnTaskDeleted(ctx context.Context, task *entity.Task) {
+	if err := uc.commentRepo.DeleteByTask(ctx, task.ID); err != nil {
+		uc.logger.Error("Failed to delete task comments", err, map[string]interface{}{"task": task.ID})
+	}
+}
+
+// getOwnComment возвращает комментарий задачи, если текущий пользователь — его автор
+func (uc *CommentUseCase) getOwnComment(ctx context.Context, taskID, commentID string) (*entity.Comment, error) {
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	comment, err := uc.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.TaskID != taskID {
+		return nil, errors.New("comment not found")
+	}
+
+	userID, _ := ctx.Value("user_id").(string)
+	if comment.AuthorID != userID {
+		return nil, errors.New("access denied")
+	}
+
+	return comment, nil
+}
+
+// resolveMentions превращает @username в ID существующих пользователей;
+// упоминания несуществующих пользователей игнорируются
+func (uc *CommentUseCase) resolveMentions(ctx context.Context, body string) []string {
+	var ids []string
+
+	for _, username := range entity.ExtractMentions(body) {
+		user, err := uc.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
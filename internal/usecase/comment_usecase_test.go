@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"strings"
+	"testing"
+)
+
+// addComment добавляет комментарий к задаче от имени пользователя контекста
+func addComment(t *testing.T, comments *CommentUseCase, ctx context.Context, comment *entity.Comment) *entity.Comment {
+	t.Helper()
+
+	if err := comments.AddComment(ctx, comment); err != nil {
+		t.Fatalf("add comment %q: %v", comment.Body, err)
+	}
+	return comment
+}
+
+func TestCommentThreads(t *testing.T) {
+	env := newTestEnv(t)
+	comments := NewCommentUseCase(env.tasks, db.NewCommentRepository(), env.users, env.logger)
+	ctx := userContext("user-123")
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Plan release"})
+	other := env.createTask(t, ctx, &entity.Task{Title: "Other task"})
+
+	question := addComment(t, comments, ctx, &entity.Comment{TaskID: task.ID, Body: "When do we ship?"})
+	answer := addComment(t, comments, ctx, &entity.Comment{TaskID: task.ID, ParentID: question.ID, Body: "Friday"})
+	addComment(t, comments, ctx, &entity.Comment{TaskID: task.ID, ParentID: answer.ID, Body: "Works for me"})
+	note := addComment(t, comments, ctx, &entity.Comment{TaskID: task.ID, Body: "Changelog is ready"})
+
+	// Ответ возможен только на комментарий той же задачи
+	err := comments.AddComment(ctx, &entity.Comment{TaskID: other.ID, ParentID: question.ID, Body: "Wrong thread"})
+	if err == nil || !strings.Contains(err.Error(), "parent comment not found") {
+		t.Fatalf("reply across tasks: %v, want parent comment not found", err)
+	}
+
+	threads, err := comments.GetComments(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get comments: %v", err)
+	}
+	if len(threads) != 2 || threads[0].Comment.ID != question.ID || threads[1].Comment.ID != note.ID {
+		t.Fatalf("root comments = %d, want question and note", len(threads))
+	}
+	replies := threads[0].Replies
+	if len(replies) != 1 || replies[0].Comment.Body != "Friday" || len(replies[0].Replies) != 1 || replies[0].Replies[0].Comment.Body != "Works for me" {
+		t.Fatalf("question thread is not nested: %+v", replies)
+	}
+
+	// Комментарий с ответами остается в ветке пометкой об удалении,
+	// комментарий без ответов удаляется
+	if err := comments.DeleteComment(ctx, task.ID, question.ID); err != nil {
+		t.Fatalf("delete question: %v", err)
+	}
+	if err := comments.DeleteComment(ctx, task.ID, note.ID); err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+
+	threads, err = comments.GetComments(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get comments: %v", err)
+	}
+	if len(threads) != 1 || !threads[0].Comment.Deleted || threads[0].Comment.Body != "" || len(threads[0].Replies) != 1 {
+		t.Fatalf("threads after delete = %+v, want tombstone with replies", threads)
+	}
+
+	// Чужой комментарий удалить нельзя
+	if err := comments.DeleteComment(userContext("user-456"), task.ID, answer.ID); err == nil {
+		t.Fatalf("another user deleted the comment")
+	}
+}
+
+func TestCommentMentions(t *testing.T) {
+	env := newTestEnv(t)
+	comments := NewCommentUseCase(env.tasks, db.NewCommentRepository(), env.users, env.logger)
+	ctx := userContext("user-123")
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Plan release"})
+
+	// Упоминания без повторов и в порядке появления; адреса почты
+	// и неизвестные пользователи не считаются упоминаниями
+	comment := addComment(t, comments, ctx, &entity.Comment{
+		TaskID: task.ID,
+		Body:   "Thanks @alice, cc @demo. Mail ops@alice or @ghost, @alice again",
+	})
+	if got := strings.Join(comment.Mentions, ","); got != "user-456,user-123" {
+		t.Fatalf("mentions = %s, want alice and demo", got)
+	}
+
+	edited, err := comments.EditComment(ctx, task.ID, comment.ID, "Only @demo now")
+	if err != nil {
+		t.Fatalf("edit comment: %v", err)
+	}
+	if got := strings.Join(edited.Mentions, ","); got != "user-123" {
+		t.Fatalf("mentions after edit = %s, want demo", got)
+	}
+	if len(edited.History) != 1 || !strings.HasPrefix(edited.History[0].Body, "Thanks @alice") {
+		t.Fatalf("history = %+v, want the original text", edited.History)
+	}
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"sync"
+)
+
+// TaskDependencies блокирующие задачи и задачи, заблокированные данной
+type TaskDependencies struct {
+	BlockedBy []*entity.Task
+	Blocks    []*entity.Task
+}
+
+type DependencyUseCase struct {
+	taskUseCase *TaskUseCase
+	depsRepo    repository.DependencyRepository
+	logger      *logger.Logger
+	// addMutex сериализует проверку цикла и добавление связи: иначе
+	// встречные связи A -> B и B -> A обе пройдут проверку
+	addMutex sync.Mutex
+}
+
+func NewDependencyUseCase(taskUseCase *TaskUseCase, depsRepo repository.DependencyRepository, logger *logger.Logger) *DependencyUseCase {
+	return &DependencyUseCase{
+		taskUseCase: taskUseCase,
+		depsRepo:    depsRepo,
+		logger:      logger,
+	}
+}
+
+// AddDependency добавляет связь "blockerID блокирует blockedID"
+func (uc *DependencyUseCase) AddDependency(ctx context.Context, blockerID, blockedID string) error {
+	uc.logger.Info("Adding dependency", map[string]interface{}{"blocker": blockerID, "blocked": blockedID})
+
+	if blockerID == "" || blockedID == "" {
+		return errors.New("validation failed: blocker and blocked tasks are required")
+	}
+
+	if blockerID == blockedID {
+		return errors.New("validation failed: task cannot block itself")
+	}
+
+	// Проверка прав доступа к обеим задачам
+	if _, err := uc.taskUseCase.GetTask(ctx, blockerID); err != nil {
+		return err
+	}
+	if _, err := uc.taskUseCase.GetTask(ctx, blockedID); err != nil {
+		return err
+	}
+
+	uc.addMutex.Lock()
+	defer uc.addMutex.Unlock()
+
+	// Новое ребро blocker -> blocked образует цикл, если blocker
+	// уже достижим из blocked
+	reachable, err := uc.isReachable(ctx, blockedID, blockerID)
+	if err != nil {
+		return err
+	}
+	if reachable {
+		return errors.New("cycle detected: " + blockedID + " already blocks " + blockerID)
+	}
+
+	return uc.depsRepo.Add(ctx, &entity.TaskDependency{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+}
+
+// RemoveDependency удаляет связь между задачами
+func (uc *DependencyUseCase) RemoveDependency(ctx context.Context, blockerID, blockedID string) error {
+	uc.logger.Info("Removing dependency", map[string]interface{}{"blocker": blockerID, "blocked": blockedID})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, blockedID); err != nil {
+		return err
+	}
+
+	return uc.depsRepo.Remove(ctx, blockerID, blockedID)
+}
+
+// GetDependencies возвращает задачи, блокирующие данную, и задачи, которые она блокирует
+func (uc *DependencyUseCase) GetDependencies(ctx context.Context, id string) (*TaskDependencies, error) {
+	uc.logger.Info("Getting dependencies", map[string]interface{}{"id": id})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+
+	result := &TaskDependencies{
+		BlockedBy: []*entity.Task{},
+		Blocks:    []*entity.Task{},
+	}
+
+	blockers, err := uc.depsRepo.GetBlockers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range blockers {
+		if task, err := uc.taskUseCase.GetTask(ctx, dep.BlockerID); err == nil {
+			result.BlockedBy = append(result.BlockedBy, task)
+		}
+	}
+
+	blocked, err := uc.depsRepo.GetBlocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range blocked {
+		if task, err := uc.taskUseCase.GetTask(ctx, dep.BlockedID); err == nil {
+			result.Blocks = append(result.Blocks, task)
+		}
+	}
+
+	return result, nil
+}
+
+// GetTopologicalOrder возвращает задачи набора в порядке выполнения с учетом
+// зависимостей. Пустой набор означает все задачи пользователя.
+func (uc *DependencyUseCase) GetTopologicalOrder(ctx context.Context, ids []string) ([]*entity.Task, error) {
+	uc.logger.Info("Getting topological order", map[string]interface{}{"count": len(ids)})
+
+	graph, err := uc.buildGraph(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return graph.TopologicalOrder()
+}
+
+// GetCriticalPath возвращает самую длинную цепочку незавершенных зависимых задач набора
+func (uc *DependencyUseCase) GetCriticalPath(ctx context.Context, ids []string) ([]*entity.Task, error) {
+	uc.logger.Info("Getting critical path", map[string]interface{}{"count": len(ids)})
+
+	graph, err := uc.buildGraph(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return graph.CriticalPath()
+}
+
+func (uc *DependencyUseCase) buildGraph(ctx context.Context, ids []string) (*entity.DependencyGraph, error) {
+	var tasks []*entity.Task
+
+	if len(ids) == 0 {
+		all, err := uc.taskUseCase.GetAllTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tasks = all
+	} else {
+		for _, id := range ids {
+			task, err := uc.taskUseCase.GetTask(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+	}
+
+	var deps []*entity.TaskDependency
+	for _, task := range tasks {
+		blocked, err := uc.depsRepo.GetBlocked(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, blocked...)
+	}
+
+	return entity.NewDependencyGraph(tasks, deps), nil
+}
+
+// isReachable проверяет обходом в ширину, ведет ли цепочка связей из from в to
+func (uc *DependencyUseCase) isReachable(ctx context.Context, from, to string) (bool, error) {
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id == to {
+			return true, nil
+		}
+
+		blocked, err := uc.depsRepo.GetBlocked(ctx, id)
+		if err != nil {
+			return false, err
+		}
+
+		for _, dep := range blocked {
+			if !visited[dep.BlockedID] {
+				visited[dep.BlockedID] = true
+				queue = append(queue, dep.BlockedID)
+			}
+		}
+	}
+
+	return false, nil
+}
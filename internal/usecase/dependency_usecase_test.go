@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowAddRepository задерживает добавление связи, расширяя окно между
+// проверкой цикла и записью
+type slowAddRepository struct {
+	*db.DependencyRepository
+}
+
+func (r slowAddRepository) Add(ctx context.Context, dep *entity.TaskDependency) error {
+	time.Sleep(5 * time.Millisecond)
+	return r.DependencyRepository.Add(ctx, dep)
+}
+
+// titles перечисляет названия задач через пробел
+func titles(tasks []*entity.Task) string {
+	var result []string
+	for _, task := range tasks {
+		result = append(result, task.Title)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	env := newTestEnv(t)
+	deps := NewDependencyUseCase(env.tasks, env.deps, env.logger)
+	ctx := userContext("user-123")
+
+	a := env.createTask(t, ctx, &entity.Task{Title: "A"})
+	b := env.createTask(t, ctx, &entity.Task{Title: "B"})
+	c := env.createTask(t, ctx, &entity.Task{Title: "C"})
+
+	for _, pair := range [][2]*entity.Task{{a, b}, {b, c}} {
+		if err := deps.AddDependency(ctx, pair[0].ID, pair[1].ID); err != nil {
+			t.Fatalf("add %s -> %s: %v", pair[0].Title, pair[1].Title, err)
+		}
+	}
+
+	// Замыкание цепочки и связь задачи с собой отклоняются
+	if err := deps.AddDependency(ctx, c.ID, a.ID); err == nil || !strings.Contains(err.Error(), "cycle detected") {
+		t.Fatalf("add C -> A: %v, want cycle detected", err)
+	}
+	if err := deps.AddDependency(ctx, a.ID, a.ID); err == nil || !strings.Contains(err.Error(), "cannot block itself") {
+		t.Fatalf("add A -> A: %v, want validation error", err)
+	}
+
+	// Параллельная ветка без цикла допустима
+	if err := deps.AddDependency(ctx, a.ID, c.ID); err != nil {
+		t.Fatalf("add A -> C: %v", err)
+	}
+
+	order, err := deps.GetTopologicalOrder(ctx, nil)
+	if err != nil {
+		t.Fatalf("topological order: %v", err)
+	}
+	if got := titles(order); got != "A B C" {
+		t.Fatalf("topological order = %s, want A B C", got)
+	}
+}
+
+func TestCriticalPathCountsUnfinishedTasks(t *testing.T) {
+	env := newTestEnv(t)
+	deps := NewDependencyUseCase(env.tasks, env.deps, env.logger)
+	ctx := userContext("user-123")
+
+	tasks := make(map[string]*entity.Task)
+	for _, title := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		tasks[title] = env.createTask(t, ctx, &entity.Task{Title: title})
+	}
+	for _, edge := range []string{"AB", "BC", "DE", "EF", "FG"} {
+		if err := deps.AddDependency(ctx, tasks[edge[:1]].ID, tasks[edge[1:]].ID); err != nil {
+			t.Fatalf("add %s: %v", edge, err)
+		}
+	}
+
+	path, err := deps.GetCriticalPath(ctx, nil)
+	if err != nil {
+		t.Fatalf("critical path: %v", err)
+	}
+	if got := titles(path); got != "D E F G" {
+		t.Fatalf("critical path = %s, want D E F G", got)
+	}
+
+	// Завершенные задачи не удлиняют цепочку
+	for _, title := range []string{"D", "E"} {
+		task := tasks[title]
+		task.Status = entity.StatusDone
+		if err := env.tasks.UpdateTask(ctx, task); err != nil {
+			t.Fatalf("complete %s: %v", title, err)
+		}
+	}
+
+	path, err = deps.GetCriticalPath(ctx, nil)
+	if err != nil {
+		t.Fatalf("critical path: %v", err)
+	}
+	if got := titles(path); got != "A B C" {
+		t.Fatalf("critical path after completing D and E = %s, want A B C", got)
+	}
+}
+
+func TestConcurrentOppositeDependenciesDoNotFormCycle(t *testing.T) {
+	env := newTestEnv(t)
+	deps := NewDependencyUseCase(env.tasks, slowAddRepository{env.deps}, env.logger)
+	ctx := userContext("user-123")
+
+	for i := 0; i < 5; i++ {
+		a := env.createTask(t, ctx, &entity.Task{Title: fmt.Sprintf("A%d", i)})
+		b := env.createTask(t, ctx, &entity.Task{Title: fmt.Sprintf("B%d", i)})
+
+		// Встречные связи добавляются одновременно: одна из них должна
+		// получить отказ
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, pair := range [][2]string{{a.ID, b.ID}, {b.ID, a.ID}} {
+			wg.Add(1)
+			go func(j int, blocker, blocked string) {
+				defer wg.Done()
+				errs[j] = deps.AddDependency(ctx, blocker, blocked)
+			}(j, pair[0], pair[1])
+		}
+		wg.Wait()
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("pair %d: errors = %v, want exactly one cycle rejection", i, errs)
+		}
+	}
+
+	if _, err := deps.GetTopologicalOrder(ctx, nil); err != nil {
+		t.Fatalf("topological order: %v", err)
+	}
+}
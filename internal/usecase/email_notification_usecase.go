@@ -0,0 +1,568 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEmailAttempts число попыток отправить письмо, после которого оно отбрасывается
+const maxEmailAttempts = 3
+
+// EmailNotificationConfig настройки почтовых уведомлений
+type EmailNotificationConfig struct {
+	BaseURL  string        // внешний адрес сервиса для ссылок в письмах
+	Secret   []byte        // ключ подписи ссылок отписки
+	Interval time.Duration // период отправки накопленных писем и поиска просроченных задач
+	// DigestWindow пауза без новых событий, после которой накопленные события
+	// пользователя отправляются одним письмом
+	DigestWindow time.Duration
+	// MaxDigestDelay предельная задержка первого события при непрерывном потоке
+	MaxDigestDelay time.Duration
+}
+
+// EmailNotificationUseCase отправляет исполнителям письма об изменениях
+// и просрочке их задач. События каждого пользователя копятся короткое время
+// и уходят одним письмом-дайджестом, если пользователь не отключил дайджесты.
+type EmailNotificationUseCase struct {
+	taskRepo        repository.TaskRepository
+	userRepo        repository.UserRepository
+	preferencesRepo repository.NotificationPreferencesRepository
+	mailer          notifier.Notifier
+	logger          *logger.Logger
+	config          EmailNotificationConfig
+	templates       *emailTemplates
+	// mutex защищает очередь писем. Очередь сохраняется в хранилище настроек
+	// при каждом изменении, а письма, которые сейчас отправляются (sending),
+	// остаются в сохраненной очереди до успешной отправки.
+	mutex   sync.Mutex
+	pending map[string]*emailBatch
+	sending map[string]*emailBatch
+}
+
+// emailBatch накопленные события одного пользователя
+type emailBatch struct {
+	events   []entity.EmailEvent
+	first    time.Time
+	last     time.Time
+	attempts int
+}
+
+func NewEmailNotificationUseCase(taskRepo repository.TaskRepository, userRepo repository.UserRepository, preferencesRepo repository.NotificationPreferencesRepository, mailer notifier.Notifier, logger *logger.Logger, config EmailNotificationConfig) *EmailNotificationUseCase {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.DigestWindow <= 0 {
+		config.DigestWindow = 2 * time.Minute
+	}
+	if config.MaxDigestDelay <= 0 {
+		config.MaxDigestDelay = 15 * time.Minute
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	uc := &EmailNotificationUseCase{
+		taskRepo:        taskRepo,
+		userRepo:        userRepo,
+		preferencesRepo: preferencesRepo,
+		mailer:          mailer,
+		logger:          logger,
+		config:          config,
+		templates:       loadEmailTemplates(),
+		pending:         make(map[string]*emailBatch),
+		sending:         make(map[string]*emailBatch),
+	}
+	uc.restorePending()
+
+	return uc
+}
+
+// restorePending возвращает в очередь письма, не отправленные до перезапуска
+func (uc *EmailNotificationUseCase) restorePending() {
+	emails, err := uc.preferencesRepo.GetPendingEmails(context.Background())
+	if err != nil {
+		uc.logger.Error("Failed to load pending emails", err, nil)
+		return
+	}
+
+	for _, email := range emails {
+		batch, ok := uc.pending[email.UserID]
+		if !ok {
+			uc.pending[email.UserID] = &emailBatch{
+				events:   email.Events,
+				first:    email.First,
+				last:     email.Last,
+				attempts: email.Attempts,
+			}
+			continue
+		}
+
+		batch.events = append(batch.events, email.Events...)
+		if email.First.Before(batch.first) {
+			batch.first = email.First
+		}
+		if email.Last.After(batch.last) {
+			batch.last = email.Last
+		}
+		if email.Attempts > batch.attempts {
+			batch.attempts = email.Attempts
+		}
+	}
+}
+
+// GetPreferences возвращает настройки уведомлений текущего пользователя
+func (uc *EmailNotificationUseCase) GetPreferences(ctx context.Context) (*entity.NotificationPreferences, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.preferences(ctx, userID)
+}
+
+// UpdatePreferences сохраняет настройки уведомлений текущего пользователя
+func (uc *EmailNotificationUseCase) UpdatePreferences(ctx context.Context, preferences *entity.NotificationPreferences) error {
+	uc.logger.Info("Updating notification preferences", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	preferences.UserID = userID
+	if preferences.Locale == "" {
+		preferences.Locale = defaultEmailLocale
+	}
+
+	if errMsgs := preferences.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	if !uc.templates.hasLocale(preferences.Locale) {
+		return fmt.Errorf("validation failed: unsupported locale %s (available: %s)", preferences.Locale, strings.Join(uc.templates.Locales(), ", "))
+	}
+
+	return uc.preferencesRepo.Save(ctx, preferences)
+}
+
+// UnsubscribeToken строит подписанный токен отписки пользователя от писем
+// о событии; пустое событие означает отписку от всех писем
+func (uc *EmailNotificationUseCase) UnsubscribeToken(userID string, event entity.NotificationEvent) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + "|" + string(event)))
+	return payload + "." + uc.sign(payload)
+}
+
+// Unsubscribe отключает письма по токену из письма; авторизация не требуется,
+// владение токеном подтверждается подписью
+func (uc *EmailNotificationUseCase) Unsubscribe(ctx context.Context, token string) (entity.NotificationEvent, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(uc.sign(payload))) {
+		return "", errors.New("invalid unsubscribe token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errors.New("invalid unsubscribe token")
+	}
+
+	userID, event, _ := strings.Cut(string(data), "|")
+	uc.logger.Info("Unsubscribing", map[string]interface{}{"user": userID, "event": event})
+
+	preferences, err := uc.preferences(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	preferences.Disable(entity.NotificationEvent(event))
+
+	return entity.NotificationEvent(event), uc.preferencesRepo.Save(ctx, preferences)
+}
+
+// HandleTaskEvent ставит в очередь письмо исполнителю о назначении или
+// изменении задачи. Исполнитель не получает писем о собственных изменениях.
+// Выполнение и удаление задачи снимают отметку о письме о просрочке.
+func (uc *EmailNotificationUseCase) HandleTaskEvent(ctx context.Context, taskEvent *entity.DomainEvent) error {
+	task, previous := taskEvent.Task, taskEvent.Previous
+
+	// Восстановление резервной копии не назначает задачи заново, а перенос
+	// категорий не меняет задачу для исполнителя
+	if taskEvent.Silent() {
+		return nil
+	}
+
+	if taskEvent.Type == entity.TaskDeletedEvent || task.IsDone() {
+		if err := uc.preferencesRepo.ClearOverdueNotified(ctx, task.ID); err != nil {
+			return err
+		}
+	}
+	isChange := taskEvent.Type == entity.TaskCreatedEvent || taskEvent.Type == entity.TaskUpdatedEvent
+	if !isChange || task.AssigneeID == "" || task.AssigneeID == taskEvent.ActorID {
+		return nil
+	}
+
+	event := uc.eventView(task)
+
+	switch {
+	case previous == nil || previous.AssigneeID != task.AssigneeID:
+		event.Type = entity.EventTaskAssigned
+	default:
+		event.Type = entity.EventTaskUpdated
+		event.Changes = taskChanges(previous, task)
+		if len(event.Changes) == 0 {
+			return nil
+		}
+	}
+
+	// Событие подтверждается в outbox, только если оно сохранено в очереди
+	return uc.enqueue(ctx, task.AssigneeID, event, time.Now())
+}
+
+// Run отправляет накопленные письма и ищет просроченные задачи сразу
+// и затем с заданным интервалом, пока не отменен контекст
+func (uc *EmailNotificationUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		uc.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick ставит в очередь письма о новых просрочках и отправляет готовые письма
+func (uc *EmailNotificationUseCase) Tick(ctx context.Context, now time.Time) {
+	uc.scanOverdue(ctx, now)
+
+	for userID, batch := range uc.takeReady(ctx, now) {
+		unsent, err := uc.send(ctx, userID, batch.events)
+		if err != nil {
+			uc.logger.Error("Failed to send notification email", err, map[string]interface{}{"user": userID})
+		}
+		uc.finish(ctx, userID, batch, unsent)
+	}
+}
+
+// scanOverdue ставит в очередь письма о задачах, срок которых истек; о каждой
+// просрочке (задача и срок) сообщается один раз, отметки хранятся вместе
+// с настройками уведомлений и переживают перезапуск
+func (uc *EmailNotificationUseCase) scanOverdue(ctx context.Context, now time.Time) {
+	tasks, err := uc.taskRepo.GetDueBefore(ctx, now)
+	if err != nil {
+		uc.logger.Error("Failed to load overdue tasks", err, nil)
+		return
+	}
+
+	for _, task := range tasks {
+		if task.IsDone() {
+			continue
+		}
+
+		marked, err := uc.preferencesRepo.MarkOverdueNotified(ctx, task.ID, *task.DueAt)
+		if err != nil {
+			uc.logger.Error("Failed to mark overdue task", err, map[string]interface{}{"task": task.ID})
+			continue
+		}
+		if !marked {
+			continue
+		}
+
+		// Без исполнителя о просрочке узнает владелец задачи
+		recipient := task.AssigneeID
+		if recipient == "" {
+			recipient = task.UserID
+		}
+
+		event := uc.eventView(task)
+		event.Type = entity.EventTaskOverdue
+		if err := uc.enqueue(ctx, recipient, event, now); err != nil {
+			// Без отметки о письме просрочка будет найдена снова
+			uc.logger.Error("Failed to queue overdue email", err, map[string]interface{}{"task": task.ID})
+			if err := uc.preferencesRepo.ClearOverdueNotified(ctx, task.ID); err != nil {
+				uc.logger.Error("Failed to clear overdue mark", err, map[string]interface{}{"task": task.ID})
+			}
+		}
+	}
+}
+
+// enqueue добавляет событие в очередь и сохраняет ее; при ошибке сохранения
+// событие в очередь не попадает
+func (uc *EmailNotificationUseCase) enqueue(ctx context.Context, userID string, event entity.EmailEvent, now time.Time) error {
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	batch, ok := uc.pending[userID]
+	if !ok {
+		batch = &emailBatch{first: now}
+		uc.pending[userID] = batch
+	}
+
+	previous := *batch
+	batch.events = append(batch.events, event)
+	batch.last = now
+
+	if err := uc.saveQueue(ctx); err != nil {
+		if ok {
+			*batch = previous
+		} else {
+			delete(uc.pending, userID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// finish убирает отправленное письмо из сохраненной очереди и возвращает
+// в очередь неотправленные события
+func (uc *EmailNotificationUseCase) finish(ctx context.Context, userID string, batch *emailBatch, unsent []entity.EmailEvent) {
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	delete(uc.sending, userID)
+
+	if len(unsent) > 0 {
+		batch.events = unsent
+		batch.attempts++
+		if batch.attempts >= maxEmailAttempts {
+			uc.logger.Error("Dropping notification email", errors.New("too many attempts"), map[string]interface{}{"user": userID})
+		} else {
+			if current, ok := uc.pending[userID]; ok {
+				batch.events = append(batch.events, current.events...)
+				batch.last = current.last
+			}
+			uc.pending[userID] = batch
+		}
+	}
+
+	// Если очередь не сохранится, письмо после перезапуска придет повторно
+	if err := uc.saveQueue(ctx); err != nil {
+		uc.logger.Error("Failed to save pending emails", err, map[string]interface{}{"user": userID})
+	}
+}
+
+// saveQueue сохраняет очередь вместе с отправляемыми письмами; вызывается
+// под блокировкой. Отправляемые письма пользователя идут раньше накопленных
+// после них, чтобы после перезапуска события сохранили порядок.
+func (uc *EmailNotificationUseCase) saveQueue(ctx context.Context) error {
+	var emails []*entity.PendingEmail
+	for _, queue := range []map[string]*emailBatch{uc.sending, uc.pending} {
+		for userID, batch := range queue {
+			emails = append(emails, &entity.PendingEmail{
+				UserID:   userID,
+				Events:   batch.events,
+				First:    batch.first,
+				Last:     batch.last,
+				Attempts: batch.attempts,
+			})
+		}
+	}
+	sort.SliceStable(emails, func(i, j int) bool {
+		return emails[i].UserID < emails[j].UserID
+	})
+
+	return uc.preferencesRepo.SavePendingEmails(ctx, emails)
+}
+
+// takeReady забирает из очереди события пользователей, которым пора отправить
+// письмо: серия событий закончилась, накопление длится слишком долго или
+// пользователь отключил дайджесты. Забранные события остаются в сохраненной
+// очереди до вызова finish.
+func (uc *EmailNotificationUseCase) takeReady(ctx context.Context, now time.Time) map[string]*emailBatch {
+	uc.mutex.Lock()
+	userIDs := make([]string, 0, len(uc.pending))
+	for userID := range uc.pending {
+		userIDs = append(userIDs, userID)
+	}
+	uc.mutex.Unlock()
+
+	// Настройки читаются без блокировки очереди
+	digest := make(map[string]bool)
+	for _, userID := range userIDs {
+		preferences, err := uc.preferences(ctx, userID)
+		digest[userID] = err != nil || preferences.Digest
+	}
+
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	ready := make(map[string]*emailBatch)
+	for _, userID := range userIDs {
+		batch, ok := uc.pending[userID]
+		if !ok {
+			continue
+		}
+
+		quiet := now.Sub(batch.last) >= uc.config.DigestWindow
+		tooOld := now.Sub(batch.first) >= uc.config.MaxDigestDelay
+		if quiet || tooOld || !digest[userID] {
+			ready[userID] = batch
+			uc.sending[userID] = batch
+			delete(uc.pending, userID)
+		}
+	}
+
+	return ready
+}
+
+// send отправляет пользователю события с учетом его настроек: одно событие —
+// отдельным письмом, несколько — дайджестом либо по одному письму на событие.
+// При ошибке возвращает события, письма о которых не отправлены, чтобы
+// повторная попытка не дублировала уже доставленные письма.
+func (uc *EmailNotificationUseCase) send(ctx context.Context, userID string, events []entity.EmailEvent) ([]entity.EmailEvent, error) {
+	preferences, err := uc.preferences(ctx, userID)
+	if err != nil {
+		return events, err
+	}
+
+	var allowed []entity.EmailEvent
+	for _, event := range events {
+		if preferences.Allows(event.Type) {
+			allowed = append(allowed, event)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		// Пользователь удален: письмо отправлять некому
+		return nil, nil
+	}
+
+	if user.Email == "" {
+		return nil, nil
+	}
+
+	if len(allowed) > 1 && preferences.Digest {
+		view := emailView{
+			UserName:       displayName(user),
+			Events:         allowed,
+			UnsubscribeURL: uc.unsubscribeURL(userID, ""),
+		}
+		if err := uc.deliver(ctx, user, "digest", preferences.Locale, view, ""); err != nil {
+			return allowed, err
+		}
+		return nil, nil
+	}
+
+	for i, event := range allowed {
+		view := emailView{
+			UserName:       displayName(user),
+			Event:          event,
+			UnsubscribeURL: uc.unsubscribeURL(userID, event.Type),
+		}
+		if err := uc.deliver(ctx, user, string(event.Type), preferences.Locale, view, event.TaskID); err != nil {
+			return allowed[i:], err
+		}
+	}
+
+	return nil, nil
+}
+
+func (uc *EmailNotificationUseCase) deliver(ctx context.Context, user *entity.User, name, locale string, view emailView, taskID string) error {
+	email, err := uc.templates.Render(name, locale, view)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return uc.mailer.Notify(ctx, notifier.Notification{
+		ID:             fmt.Sprintf("%s-%s-%d", name, user.ID, now.UnixNano()),
+		UserID:         user.ID,
+		To:             user.Email,
+		Subject:        email.Subject,
+		Body:           email.Text,
+		HTML:           email.HTML,
+		TaskID:         taskID,
+		SentAt:         now,
+		UnsubscribeURL: view.UnsubscribeURL,
+	})
+}
+
+// preferences возвращает настройки пользователя или настройки по умолчанию
+func (uc *EmailNotificationUseCase) preferences(ctx context.Context, userID string) (*entity.NotificationPreferences, error) {
+	preferences, err := uc.preferencesRepo.GetByUser(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return entity.DefaultNotificationPreferences(userID), nil
+		}
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (uc *EmailNotificationUseCase) eventView(task *entity.Task) entity.EmailEvent {
+	view := entity.EmailEvent{
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		TaskURL:   uc.config.BaseURL + "/tasks/?id=" + url.QueryEscape(task.ID),
+	}
+	if task.DueAt != nil {
+		view.DueAt = formatDueAt(task.DueAt)
+	}
+	return view
+}
+
+func (uc *EmailNotificationUseCase) unsubscribeURL(userID string, event entity.NotificationEvent) string {
+	return uc.config.BaseURL + "/unsubscribe?token=" + url.QueryEscape(uc.UnsubscribeToken(userID, event))
+}
+
+func (uc *EmailNotificationUseCase) sign(payload string) string {
+	mac := hmac.New(sha256.New, uc.config.Secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// taskChanges перечисляет изменения полей задачи, интересные исполнителю
+func taskChanges(previous, task *entity.Task) []entity.EmailChange {
+	var changes []entity.EmailChange
+
+	if previous.Title != task.Title {
+		changes = append(changes, entity.EmailChange{Field: "title", Old: previous.Title, New: task.Title})
+	}
+	if previous.Description != task.Description {
+		changes = append(changes, entity.EmailChange{Field: "description", Old: previous.Description, New: task.Description})
+	}
+	if previous.Status != task.Status {
+		changes = append(changes, entity.EmailChange{Field: "status", Old: string(previous.Status), New: string(task.Status)})
+	}
+	if formatDueAt(previous.DueAt) != formatDueAt(task.DueAt) {
+		changes = append(changes, entity.EmailChange{Field: "due_at", Old: formatDueAt(previous.DueAt), New: formatDueAt(task.DueAt)})
+	}
+
+	return changes
+}
+
+func formatDueAt(dueAt *time.Time) string {
+	if dueAt == nil {
+		return ""
+	}
+	return dueAt.Format("2006-01-02 15:04 MST")
+}
+
+func displayName(user *entity.User) string {
+	if user.Name != "" {
+		return user.Name
+	}
+	return user.Username
+}
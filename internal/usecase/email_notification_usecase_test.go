@@ -0,0 +1,332 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier/smtptest"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// emailTestEnv почтовые уведомления, отправляемые на локальный SMTP-сервер
+type emailTestEnv struct {
+	*testEnv
+	server          *smtptest.Server
+	mailer          *notifier.EmailNotifier
+	preferencesPath string
+	preferences     *db.NotificationPreferencesRepository
+	emails          *EmailNotificationUseCase
+}
+
+// sentEmail разобранное письмо: тема декодирована, части письма сняты
+// с quoted-printable
+type sentEmail struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Unsubscribe string
+}
+
+func newEmailTestEnv(t *testing.T) *emailTestEnv {
+	t.Helper()
+
+	server := smtptest.NewServer()
+	t.Cleanup(server.Close)
+
+	env := &emailTestEnv{
+		testEnv:         newTestEnv(t),
+		server:          server,
+		mailer:          notifier.NewEmailNotifier(notifier.SMTPConfig{Host: server.Host(), Port: server.Port(), From: "tasks@example.com"}),
+		preferencesPath: filepath.Join(t.TempDir(), "notifications.json"),
+	}
+	env.restart(t)
+
+	return env
+}
+
+// restart создает use case заново из файла настроек, как после перезапуска
+func (env *emailTestEnv) restart(t *testing.T) {
+	t.Helper()
+
+	preferences, err := db.NewNotificationPreferencesRepository(env.preferencesPath)
+	if err != nil {
+		t.Fatalf("load notification preferences: %v", err)
+	}
+
+	env.preferences = preferences
+	env.emails = NewEmailNotificationUseCase(env.taskRepo, env.users, preferences, env.mailer, env.logger, EmailNotificationConfig{
+		BaseURL:        "http://tasks.test/",
+		Secret:         []byte("secret"),
+		DigestWindow:   time.Minute,
+		MaxDigestDelay: 10 * time.Minute,
+	})
+}
+
+// assign создает от имени demo задачу, назначенную alice, и передает
+// событие создания в почтовые уведомления
+func (env *emailTestEnv) assign(t *testing.T, task *entity.Task) *entity.Task {
+	t.Helper()
+
+	task.AssigneeID = "user-456"
+	env.createTask(t, userContext("user-123"), task)
+	env.handle(t, &entity.DomainEvent{Type: entity.TaskCreatedEvent, Task: task, ActorID: "user-123"})
+	return task
+}
+
+func (env *emailTestEnv) handle(t *testing.T, event *entity.DomainEvent) {
+	t.Helper()
+
+	if err := env.emails.HandleTaskEvent(context.Background(), event); err != nil {
+		t.Fatalf("handle %s: %v", event.Type, err)
+	}
+}
+
+// sent возвращает все принятые сервером письма
+func (env *emailTestEnv) sent(t *testing.T) []sentEmail {
+	t.Helper()
+
+	var emails []sentEmail
+	for _, message := range env.server.Messages() {
+		emails = append(emails, parseEmail(t, message))
+	}
+	return emails
+}
+
+func parseEmail(t *testing.T, message smtptest.Message) sentEmail {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(message.Data))
+	if err != nil {
+		t.Fatalf("parse email: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+
+	email := sentEmail{
+		To:          msg.Header.Get("To"),
+		Subject:     subject,
+		Unsubscribe: msg.Header.Get("List-Unsubscribe"),
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parse content type: %v", err)
+	}
+
+	if mediaType != "multipart/alternative" {
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		email.Text = string(body)
+		return email
+	}
+
+	// multipart.Reader сам снимает quoted-printable с частей
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") {
+			email.HTML = string(body)
+		} else {
+			email.Text = string(body)
+		}
+	}
+
+	return email
+}
+
+func TestEmailNotificationsDigest(t *testing.T) {
+	env := newEmailTestEnv(t)
+	ctx := context.Background()
+
+	task := env.assign(t, &entity.Task{Title: "Write report"})
+
+	previous := *task
+	task.Title = "Write quarterly report"
+	env.handle(t, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, Task: task, Previous: &previous, ActorID: "user-123"})
+
+	// Серия событий еще не закончилась
+	env.emails.Tick(ctx, time.Now())
+	if emails := env.sent(t); len(emails) != 0 {
+		t.Fatalf("sent %d emails before digest window elapsed", len(emails))
+	}
+
+	env.emails.Tick(ctx, time.Now().Add(2*time.Minute))
+
+	emails := env.sent(t)
+	if len(emails) != 1 {
+		t.Fatalf("sent %d emails, want one digest", len(emails))
+	}
+
+	email := emails[0]
+	if email.To != "alice@example.com" || email.Subject != "2 task updates" {
+		t.Fatalf("digest to %q with subject %q", email.To, email.Subject)
+	}
+	for _, want := range []string{
+		"Hi Alice,",
+		`You were assigned to "Write report"`,
+		`"Write quarterly report" was updated (Title)`,
+		"http://tasks.test/tasks/?id=" + task.ID,
+	} {
+		if !strings.Contains(email.Text, want) {
+			t.Errorf("digest text does not contain %q:\n%s", want, email.Text)
+		}
+	}
+	if !strings.Contains(email.HTML, "Write quarterly report") {
+		t.Errorf("digest HTML does not mention the task:\n%s", email.HTML)
+	}
+	if !strings.HasPrefix(email.Unsubscribe, "<http://tasks.test/unsubscribe?token=") {
+		t.Errorf("List-Unsubscribe = %q", email.Unsubscribe)
+	}
+}
+
+func TestEmailNotificationsWithoutDigest(t *testing.T) {
+	env := newEmailTestEnv(t)
+	ctx := userContext("user-456")
+
+	if err := env.emails.UpdatePreferences(ctx, &entity.NotificationPreferences{EmailEnabled: true, Locale: "ru"}); err != nil {
+		t.Fatalf("update preferences: %v", err)
+	}
+
+	env.assign(t, &entity.Task{Title: "Починить сборку"})
+	env.assign(t, &entity.Task{Title: "Обновить зависимости"})
+
+	// Без дайджеста письма уходят сразу, по одному на событие
+	env.emails.Tick(ctx, time.Now())
+
+	emails := env.sent(t)
+	if len(emails) != 2 {
+		t.Fatalf("sent %d emails, want 2", len(emails))
+	}
+	if emails[0].Subject != "Вам назначена задача: Починить сборку" || emails[1].Subject != "Вам назначена задача: Обновить зависимости" {
+		t.Fatalf("subjects = %q, %q", emails[0].Subject, emails[1].Subject)
+	}
+	if !strings.Contains(emails[0].Text, "Здравствуйте, Alice!") {
+		t.Errorf("text is not rendered from the ru template:\n%s", emails[0].Text)
+	}
+}
+
+func TestEmailNotificationsRetryOnlyUnsent(t *testing.T) {
+	env := newEmailTestEnv(t)
+	ctx := userContext("user-456")
+
+	if err := env.emails.UpdatePreferences(ctx, &entity.NotificationPreferences{EmailEnabled: true}); err != nil {
+		t.Fatalf("update preferences: %v", err)
+	}
+
+	env.assign(t, &entity.Task{Title: "First"})
+	env.assign(t, &entity.Task{Title: "Second"})
+
+	env.server.Reject(func(message smtptest.Message) bool {
+		return strings.Contains(message.Data, "Second")
+	})
+	env.emails.Tick(ctx, time.Now())
+
+	env.server.Reject(nil)
+	env.emails.Tick(ctx, time.Now())
+
+	var subjects []string
+	for _, email := range env.sent(t) {
+		subjects = append(subjects, email.Subject)
+	}
+	if strings.Join(subjects, "; ") != "You were assigned: First; You were assigned: Second" {
+		t.Fatalf("subjects = %q, want each email exactly once", subjects)
+	}
+}
+
+func TestEmailNotificationsOverdueSentOnce(t *testing.T) {
+	env := newEmailTestEnv(t)
+	ctx := context.Background()
+
+	dueAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	task := env.assign(t, &entity.Task{Title: "Pay invoice", DueAt: &dueAt})
+
+	// Просрочка попадает в дайджест вместе с назначением
+	now := time.Now()
+	env.emails.Tick(ctx, now)
+	env.emails.Tick(ctx, now.Add(2*time.Minute))
+
+	emails := env.sent(t)
+	if len(emails) != 1 || emails[0].Subject != "2 task updates" || !strings.Contains(emails[0].Text, `"Pay invoice" is overdue`) {
+		t.Fatalf("emails = %+v, want digest with the overdue task", emails)
+	}
+
+	// Отметка о просрочке переживает перезапуск
+	env.restart(t)
+	env.emails.Tick(ctx, now.Add(4*time.Minute))
+	env.emails.Tick(ctx, now.Add(6*time.Minute))
+	if emails := env.sent(t); len(emails) != 1 {
+		t.Fatalf("sent %d emails after restart, want no repeated overdue email", len(emails))
+	}
+
+	// Выполнение задачи снимает отметку
+	previous := *task
+	task.Status, task.StatusCategory = entity.StatusDone, entity.CategoryDone
+	env.handle(t, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, Task: task, Previous: &previous, ActorID: "user-456"})
+
+	env.restart(t)
+	marked, err := env.preferences.MarkOverdueNotified(ctx, task.ID, dueAt)
+	if err != nil || !marked {
+		t.Fatalf("MarkOverdueNotified after completion = %v, %v; want mark cleared", marked, err)
+	}
+}
+
+func TestEmailNotificationsQueueSurvivesRestart(t *testing.T) {
+	env := newEmailTestEnv(t)
+	ctx := context.Background()
+
+	task := env.assign(t, &entity.Task{Title: "Write report"})
+
+	previous := *task
+	task.Title = "Write quarterly report"
+	env.handle(t, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, Task: task, Previous: &previous, ActorID: "user-123"})
+
+	// События подтверждены в outbox, но дайджест еще не отправлен
+	env.restart(t)
+
+	// Неудачная отправка оставляет письмо в сохраненной очереди
+	env.server.Reject(func(message smtptest.Message) bool { return true })
+	env.emails.Tick(ctx, time.Now().Add(2*time.Minute))
+	env.server.Reject(nil)
+
+	env.restart(t)
+	env.emails.Tick(ctx, time.Now().Add(4*time.Minute))
+
+	emails := env.sent(t)
+	if len(emails) != 1 || emails[0].Subject != "2 task updates" {
+		t.Fatalf("emails = %+v, want one digest after restarts", emails)
+	}
+
+	// Отправленное письмо удалено из очереди
+	env.restart(t)
+	env.emails.Tick(ctx, time.Now().Add(6*time.Minute))
+	if emails := env.sent(t); len(emails) != 1 {
+		t.Fatalf("sent %d emails, want the digest only once", len(emails))
+	}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"bytes"
+	"embed"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// defaultEmailLocale используется, если для языка пользователя нет шаблонов
+const defaultEmailLocale = "en"
+
+//go:embed templates/email/*.tmpl
+var emailTemplateFS embed.FS
+
+// emailTemplates шаблоны писем по языкам. Для каждого языка есть текстовый
+// файл <locale>.txt.tmpl (шаблоны <событие>.subject и <событие>.text)
+// и HTML-файл <locale>.html.tmpl (шаблоны <событие>.html).
+type emailTemplates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// emailView данные шаблона письма: одно событие (Event) или дайджест (Events)
+type emailView struct {
+	UserName       string
+	Event          entity.EmailEvent
+	Events         []entity.EmailEvent
+	UnsubscribeURL string
+}
+
+// renderedEmail готовое письмо
+type renderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func loadEmailTemplates() *emailTemplates {
+	t := &emailTemplates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	files, err := fs.Glob(emailTemplateFS, "templates/email/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		name := path.Base(file)
+		locale, kind, _ := strings.Cut(strings.TrimSuffix(name, ".tmpl"), ".")
+
+		switch kind {
+		case "txt":
+			t.text[locale] = texttemplate.Must(texttemplate.New(name).ParseFS(emailTemplateFS, file))
+		case "html":
+			t.html[locale] = htmltemplate.Must(htmltemplate.New(name).ParseFS(emailTemplateFS, file))
+		}
+	}
+
+	return t
+}
+
+// Locales возвращает языки, для которых есть шаблоны
+func (t *emailTemplates) Locales() []string {
+	var locales []string
+	for locale := range t.text {
+		if _, ok := t.html[locale]; ok {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func (t *emailTemplates) hasLocale(locale string) bool {
+	_, okText := t.text[locale]
+	_, okHTML := t.html[locale]
+	return okText && okHTML
+}
+
+// Render рендерит письмо типа name ("task_updated", "digest" и т. д.)
+// на языке locale, а при отсутствии шаблонов — на языке по умолчанию
+func (t *emailTemplates) Render(name, locale string, view emailView) (*renderedEmail, error) {
+	if !t.hasLocale(locale) {
+		locale = defaultEmailLocale
+	}
+
+	var subject, text, html bytes.Buffer
+
+	if err := t.text[locale].ExecuteTemplate(&subject, name+".subject", view); err != nil {
+		return nil, err
+	}
+	if err := t.text[locale].ExecuteTemplate(&text, name+".text", view); err != nil {
+		return nil, err
+	}
+	if err := t.html[locale].ExecuteTemplate(&html, name+".html", view); err != nil {
+		return nil, err
+	}
+
+	return &renderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
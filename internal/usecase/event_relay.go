@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+// EventHandler подписчик доменных событий. Ошибка означает, что событие нужно
+// доставить подписчику еще раз; доставка не менее одного раза, поэтому
+// подписчик должен спокойно переносить повтор события с тем же ID.
+type EventHandler func(ctx context.Context, event *entity.DomainEvent) error
+
+// EventRelayConfig настройки публикации событий из outbox
+type EventRelayConfig struct {
+	Interval time.Duration // период опроса outbox
+	// Lease время, на которое запись забирается на публикацию; если сервис
+	// упал во время публикации, по истечении аренды запись публикуется снова
+	Lease        time.Duration
+	BatchSize    int
+	MaxAttempts  int           // после стольких неудачных попыток событие пропускается
+	RetryBackoff time.Duration // задержка перед первым повтором, далее удваивается
+	MaxBackoff   time.Duration
+	Retention    time.Duration // сколько хранятся опубликованные записи
+}
+
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+// EventRelay публикует события из outbox подписчикам внутри процесса.
+// События одной задачи доставляются строго по порядку: следующее не
+// публикуется, пока предыдущее не обработано всеми подписчиками или не
+// исчерпало попытки.
+type EventRelay struct {
+	outboxRepo  repository.OutboxRepository
+	logger      *logger.Logger
+	config      EventRelayConfig
+	subscribers []eventSubscriber
+}
+
+func NewEventRelay(outboxRepo repository.OutboxRepository, logger *logger.Logger, config EventRelayConfig) *EventRelay {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 30 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 5 * time.Minute
+	}
+	if config.Retention <= 0 {
+		config.Retention = 24 * time.Hour
+	}
+
+	return &EventRelay{
+		outboxRepo: outboxRepo,
+		logger:     logger,
+		config:     config,
+	}
+}
+
+// Subscribe регистрирует подписчика под уникальным именем; по имени
+// запоминается, кому событие уже доставлено. Вызывается при старте приложения.
+func (r *EventRelay) Subscribe(name string, handler EventHandler) {
+	r.subscribers = append(r.subscribers, eventSubscriber{name: name, handler: handler})
+}
+
+// Run публикует накопленные события сразу и затем с заданным интервалом,
+// пока не отменен контекст
+func (r *EventRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick публикует все готовые к моменту now события. Записи забираются
+// пачками, пока outbox не опустеет: после публикации события задачи
+// следующая пачка содержит её очередное событие.
+func (r *EventRelay) Tick(ctx context.Context, now time.Time) {
+	for ctx.Err() == nil {
+		records, err := r.outboxRepo.ClaimPending(ctx, now, now.Add(r.config.Lease), r.config.BatchSize)
+		if err != nil {
+			r.logger.Error("Failed to claim outbox records", err, nil)
+			return
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		for _, record := range records {
+			r.publish(ctx, record, now)
+		}
+	}
+
+	if deleted, err := r.outboxRepo.DeletePublishedBefore(ctx, now.Add(-r.config.Retention)); err != nil {
+		r.logger.Error("Failed to clean up outbox", err, nil)
+	} else if deleted > 0 {
+		r.logger.Info("Outbox cleaned up", map[string]interface{}{"deleted": deleted})
+	}
+}
+
+// publish доставляет событие подписчикам, которые его еще не обработали
+func (r *EventRelay) publish(ctx context.Context, record *entity.OutboxRecord, now time.Time) {
+	event := record.Event
+
+	// Подписчики видят событие в контексте того, кто его вызвал
+	eventCtx := context.WithValue(ctx, "user_id", event.ActorID)
+	eventCtx = context.WithValue(eventCtx, "workspace_id", event.WorkspaceID)
+
+	var failures []error
+	for _, subscriber := range r.subscribers {
+		if record.IsDeliveredTo(subscriber.name) {
+			continue
+		}
+
+		if err := subscriber.handler(eventCtx, event); err != nil {
+			r.logger.Error("Event subscriber failed", err, map[string]interface{}{"event": event.ID, "subscriber": subscriber.name})
+			failures = append(failures, err)
+			continue
+		}
+
+		record.Delivered = append(record.Delivered, subscriber.name)
+	}
+
+	record.Attempts++
+
+	switch {
+	case len(failures) == 0:
+		publishedAt := now
+		record.Status = entity.OutboxPublished
+		record.PublishedAt = &publishedAt
+		record.LastError = ""
+	case record.Attempts >= r.config.MaxAttempts:
+		record.Status = entity.OutboxFailed
+		record.LastError = errors.Join(failures...).Error()
+		r.logger.Error("Event dropped after max attempts", errors.Join(failures...), map[string]interface{}{"event": event.ID, "task": event.TaskID})
+	default:
+		record.Status = entity.OutboxPending
+		record.NextAttemptAt = now.Add(r.backoff(record.Attempts))
+		record.LastError = errors.Join(failures...).Error()
+	}
+
+	if err := r.outboxRepo.Update(ctx, record); err != nil {
+		r.logger.Error("Failed to update outbox record", err, map[string]interface{}{"event": event.ID})
+	}
+}
+
+// backoff задержка перед повтором после attempts неудачных попыток
+func (r *EventRelay) backoff(attempts int) time.Duration {
+	delay := r.config.RetryBackoff
+	for i := 1; i < attempts && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxBackoff {
+		delay = r.config.MaxBackoff
+	}
+	return delay
+}
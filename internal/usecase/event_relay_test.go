@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventLog подписчик, записывающий полученные события
+type eventLog struct {
+	received []string
+	// failures сколько раз подряд подписчик еще откажет
+	failures int
+}
+
+func (l *eventLog) handle(ctx context.Context, event *entity.DomainEvent) error {
+	if l.failures > 0 {
+		l.failures--
+		return errors.New("subscriber unavailable")
+	}
+	l.received = append(l.received, string(event.Type)+":"+event.Task.Title)
+	return nil
+}
+
+// editTask создает задачу и дважды меняет её название
+func editTask(t *testing.T, env *testEnv) {
+	t.Helper()
+
+	ctx := userContext("user-123")
+	task := env.createTask(t, ctx, &entity.Task{Title: "v1"})
+	for _, title := range []string{"v2", "v3"} {
+		task.Title = title
+		if err := env.tasks.UpdateTask(ctx, task); err != nil {
+			t.Fatalf("update task: %v", err)
+		}
+	}
+}
+
+func TestEventRelayRetriesOnlyFailedSubscriberInOrder(t *testing.T) {
+	env := newTestEnv(t)
+	relay := NewEventRelay(env.outbox, env.logger, EventRelayConfig{RetryBackoff: time.Minute})
+	stable, flaky := &eventLog{}, &eventLog{failures: 1}
+	relay.Subscribe("stable", stable.handle)
+	relay.Subscribe("flaky", flaky.handle)
+
+	editTask(t, env)
+
+	// Пока первое событие не доставлено всем, следующие события задачи ждут
+	now := time.Now()
+	relay.Tick(context.Background(), now)
+	if got := strings.Join(stable.received, " "); got != "task.created:v1" {
+		t.Fatalf("stable received %q before retry, want only the first event", got)
+	}
+	if len(flaky.received) != 0 {
+		t.Fatalf("flaky received %q", flaky.received)
+	}
+
+	// Повтор после задержки не дублирует событие тому, кто его уже получил
+	relay.Tick(context.Background(), now.Add(time.Minute))
+	want := "task.created:v1 task.updated:v2 task.updated:v3"
+	if got := strings.Join(stable.received, " "); got != want {
+		t.Fatalf("stable received %q, want %q", got, want)
+	}
+	if got := strings.Join(flaky.received, " "); got != want {
+		t.Fatalf("flaky received %q, want %q", got, want)
+	}
+}
+
+func TestEventRelaySkipsEventAfterMaxAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	relay := NewEventRelay(env.outbox, env.logger, EventRelayConfig{MaxAttempts: 2, RetryBackoff: time.Minute})
+	subscriber := &eventLog{failures: 2}
+	relay.Subscribe("flaky", subscriber.handle)
+
+	editTask(t, env)
+
+	now := time.Now()
+	relay.Tick(context.Background(), now)
+	relay.Tick(context.Background(), now.Add(time.Minute))
+
+	// Исчерпавшее попытки событие пропускается, остальные доставляются
+	if got := strings.Join(subscriber.received, " "); got != "task.updated:v2 task.updated:v3" {
+		t.Fatalf("received %q, want the events after the dropped one", got)
+	}
+}
+
+func TestOutboxLeaseExpiresAfterCrash(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	env.createTask(t, userContext("user-123"), &entity.Task{Title: "v1"})
+
+	// Запись забрана на публикацию, но сервис упал до её завершения
+	now := time.Now()
+	records, err := env.outbox.ClaimPending(ctx, now, now.Add(30*time.Second), 0)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("claim = %d records, %v; want 1", len(records), err)
+	}
+
+	// До истечения аренды запись не выдается повторно
+	if records, err := env.outbox.ClaimPending(ctx, now.Add(10*time.Second), now.Add(time.Minute), 0); err != nil || len(records) != 0 {
+		t.Fatalf("claim during lease = %d records, %v; want none", len(records), err)
+	}
+
+	relay := NewEventRelay(env.outbox, env.logger, EventRelayConfig{})
+	subscriber := &eventLog{}
+	relay.Subscribe("log", subscriber.handle)
+
+	relay.Tick(ctx, now.Add(10*time.Second))
+	if len(subscriber.received) != 0 {
+		t.Fatalf("event published during lease: %q", subscriber.received)
+	}
+
+	relay.Tick(ctx, now.Add(31*time.Second))
+	if got := strings.Join(subscriber.received, " "); got != "task.created:v1" {
+		t.Fatalf("received %q after lease expired, want the event once", got)
+	}
+}
@@ -0,0 +1,569 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/importer"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ограничения полей задачи и комментария; длинные значения источника
+// укорачиваются, а полный текст сохраняется в описании или комментарии
+const (
+	importTitleLimit       = 100
+	importDescriptionLimit = 1000
+	importCommentLimit     = 10000
+	importTagLimit         = 50
+	importMaxTags          = 20
+	importMaxProblems      = 100
+)
+
+// importSources отображаемые имена источников в импортированных комментариях
+var importSources = map[string]string{
+	importer.FormatTrello: "Trello",
+	importer.FormatJira:   "Jira",
+}
+
+// ImportConfig настройки фонового импорта
+type ImportConfig struct {
+	// Interval как часто проверять очередь; новый импорт запускается сразу
+	Interval time.Duration
+	// Lease аренда импорта; продлевается после каждой записи, а импорт,
+	// аренда которого истекла, продолжает следующий обработчик
+	Lease time.Duration
+}
+
+// ImportOptions настройки импорта
+type ImportOptions struct {
+	// ProjectID проект, в который попадут задачи; пустой — создается
+	// проект с именем доски
+	ProjectID string
+	// StatusMap соответствие колонок Trello или статусов Jira статусам
+	// рабочего процесса
+	StatusMap map[string]entity.TaskStatus
+}
+
+// ImportUseCase импортирует задачи из экспортов Trello и Jira в фоне.
+// Задачам, комментариям и пунктам чек-листа назначаются ID, производные
+// от ID импорта и номера записи, поэтому прерванный импорт продолжается
+// без дублей: уже созданное при повторе пропускается.
+type ImportUseCase struct {
+	taskUseCase    *TaskUseCase
+	projectUseCase *ProjectUseCase
+	taskRepo       repository.TaskRepository
+	commentRepo    repository.CommentRepository
+	checklistRepo  repository.ChecklistRepository
+	jobRepo        repository.ImportJobRepository
+	blobs          blobstore.BlobStore
+	logger         *logger.Logger
+	config         ImportConfig
+	wake           chan struct{}
+}
+
+func NewImportUseCase(taskUseCase *TaskUseCase, projectUseCase *ProjectUseCase, taskRepo repository.TaskRepository, commentRepo repository.CommentRepository, checklistRepo repository.ChecklistRepository, jobRepo repository.ImportJobRepository, blobs blobstore.BlobStore, logger *logger.Logger, config ImportConfig) *ImportUseCase {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+
+	return &ImportUseCase{
+		taskUseCase:    taskUseCase,
+		projectUseCase: projectUseCase,
+		taskRepo:       taskRepo,
+		commentRepo:    commentRepo,
+		checklistRepo:  checklistRepo,
+		jobRepo:        jobRepo,
+		blobs:          blobs,
+		logger:         logger,
+		config:         config,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// StartImport проверяет файл экспорта, сохраняет его и ставит импорт в
+// очередь. Ошибки формата и настроек возвращаются сразу, ошибки отдельных
+// записей попадают в Problems импорта.
+func (uc *ImportUseCase) StartImport(ctx context.Context, format string, r io.Reader, opts ImportOptions) (*entity.ImportJob, error) {
+	uc.logger.Info("Starting import", map[string]interface{}{"format": format})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := importer.Parse(format, bytes.NewReader(data))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown import format") {
+			return nil, errors.New("validation failed: " + err.Error())
+		}
+		return nil, err
+	}
+
+	workspaceID := workspaceFromContext(ctx)
+	workflow, err := uc.taskUseCase.GetWorkflow(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for state, status := range opts.StatusMap {
+		if _, ok := workflow.Status(status); !ok {
+			return nil, errors.New("validation failed: status " + string(status) + " for " + state + " is not defined in workflow")
+		}
+	}
+
+	if opts.ProjectID != "" {
+		project, err := uc.projectUseCase.GetProject(ctx, opts.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if project.WorkspaceID != workspaceID {
+			return nil, errors.New("validation failed: project belongs to another workspace")
+		}
+	}
+
+	fileKey := "imports/" + strconv.FormatInt(time.Now().UnixNano(), 36) + ".json"
+	if err := uc.blobs.Put(ctx, fileKey, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return nil, err
+	}
+
+	job := &entity.ImportJob{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Format:      format,
+		FileKey:     fileKey,
+		ProjectID:   opts.ProjectID,
+		StatusMap:   opts.StatusMap,
+		Status:      entity.ImportJobQueued,
+		Total:       len(board.Items),
+		Skipped:     board.Skipped,
+		Problems:    []string{},
+	}
+	if err := uc.jobRepo.Create(ctx, job); err != nil {
+		uc.blobs.Delete(ctx, fileKey)
+		return nil, err
+	}
+
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// GetJob возвращает импорт текущего пользователя
+func (uc *ImportUseCase) GetJob(ctx context.Context, id string) (*entity.ImportJob, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	job, err := uc.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, errors.New("import job not found")
+	}
+
+	return job, nil
+}
+
+// GetJobs возвращает импорты текущего пользователя от ранних к поздним
+func (uc *ImportUseCase) GetJobs(ctx context.Context) ([]*entity.ImportJob, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.jobRepo.GetByUser(ctx, userID)
+}
+
+// Run выполняет импорты из очереди сразу, по сигналу StartImport и с
+// заданным интервалом, пока не отменен контекст. Импорт, прерванный
+// остановкой сервиса, продолжается после истечения его аренды.
+func (uc *ImportUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		uc.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-uc.wake:
+		}
+	}
+}
+
+// Tick выполняет все импорты, готовые к обработке
+func (uc *ImportUseCase) Tick(ctx context.Context, now time.Time) {
+	started := time.Now()
+	for ctx.Err() == nil {
+		// Импорт может идти дольше аренды: время выбора сдвигается на
+		// прошедшее с начала тика, чтобы истекшие за это время аренды
+		// брошенных импортов тоже учитывались
+		at := now.Add(time.Since(started))
+		job, err := uc.jobRepo.ClaimNext(ctx, at, time.Now().Add(uc.config.Lease))
+		if err != nil {
+			uc.logger.Error("Failed to claim import job", err, nil)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		uc.process(ctx, job)
+	}
+}
+
+// process импортирует записи, начиная с первой необработанной, и
+// сохраняет прогресс после каждой
+func (uc *ImportUseCase) process(ctx context.Context, job *entity.ImportJob) {
+	uc.logger.Info("Running import", map[string]interface{}{"job": job.ID, "processed": job.Processed, "total": job.Total})
+
+	// Импорт выполняется от имени владельца в его пространстве
+	ownerCtx := context.WithValue(ctx, "user_id", job.UserID)
+	ownerCtx = context.WithValue(ownerCtx, "workspace_id", job.WorkspaceID)
+
+	board, err := uc.loadBoard(ownerCtx, job)
+	if err != nil {
+		uc.fail(ctx, job, err)
+		return
+	}
+
+	workflow, err := uc.taskUseCase.GetWorkflow(ownerCtx, job.WorkspaceID)
+	if err != nil {
+		uc.fail(ctx, job, err)
+		return
+	}
+
+	if err := uc.prepareProject(ownerCtx, job, board); err != nil {
+		uc.fail(ctx, job, err)
+		return
+	}
+
+	positions := make(map[string]int, len(board.Items))
+	for i, item := range board.Items {
+		positions[item.ExternalID] = i
+	}
+
+	for i := job.Processed; i < len(board.Items); i++ {
+		// При остановке импорт продолжится после истечения аренды
+		if ctx.Err() != nil {
+			return
+		}
+
+		item := board.Items[i]
+		if err := uc.importItem(ownerCtx, job, workflow, i, item, positions); err != nil {
+			uc.addProblem(job, fmt.Sprintf("%s %q: %v", item.ExternalID, item.Title, err))
+		}
+
+		job.Processed = i + 1
+		job.LeaseUntil = time.Now().Add(uc.config.Lease)
+		if err := uc.jobRepo.Update(ctx, job); err != nil {
+			uc.logger.Error("Failed to save import progress", err, map[string]interface{}{"job": job.ID})
+			return
+		}
+	}
+
+	now := time.Now()
+	job.Status = entity.ImportJobCompleted
+	job.FinishedAt = &now
+	job.LeaseUntil = time.Time{}
+	if err := uc.jobRepo.Update(ctx, job); err != nil {
+		uc.logger.Error("Failed to complete import", err, map[string]interface{}{"job": job.ID})
+		return
+	}
+
+	if err := uc.blobs.Delete(ctx, job.FileKey); err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+		uc.logger.Error("Failed to delete import file", err, map[string]interface{}{"job": job.ID})
+	}
+
+	uc.logger.Info("Import completed", map[string]interface{}{"job": job.ID, "imported": job.Imported, "problems": len(job.Problems)})
+}
+
+func (uc *ImportUseCase) loadBoard(ctx context.Context, job *entity.ImportJob) (*importer.Board, error) {
+	rc, err := uc.blobs.Get(ctx, job.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return importer.Parse(job.Format, rc)
+}
+
+// prepareProject создает проект с именем доски, если проект не выбран, и
+// определяет ранг, после которого встанут импортированные задачи. ID
+// проекта совпадает с ID импорта, поэтому при повторе проект не дублируется.
+func (uc *ImportUseCase) prepareProject(ctx context.Context, job *entity.ImportJob, board *importer.Board) error {
+	if job.ProjectID == "" {
+		if _, err := uc.projectUseCase.GetProject(ctx, job.ID); err != nil {
+			if err.Error() != "project not found" {
+				return err
+			}
+			name := strings.TrimSpace(board.Name)
+			if name == "" {
+				name = importSources[job.Format] + " import"
+			}
+			project := &entity.Project{ID: job.ID, Name: entity.TruncateText(name, 100)}
+			if err := uc.projectUseCase.CreateProject(ctx, project); err != nil {
+				return err
+			}
+		}
+		job.ProjectID = job.ID
+	}
+
+	if job.Processed > 0 || job.LastRank != "" {
+		return nil
+	}
+
+	tasks, err := uc.taskRepo.GetByProject(ctx, job.ProjectID)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if task.Rank > job.LastRank {
+			job.LastRank = task.Rank
+		}
+	}
+
+	return uc.jobRepo.Update(ctx, job)
+}
+
+// importItem создает задачу записи с комментариями и чек-листом; уже
+// созданные при прошлом запуске части пропускаются
+func (uc *ImportUseCase) importItem(ctx context.Context, job *entity.ImportJob, workflow *entity.Workflow, index int, item importer.Item, positions map[string]int) error {
+	taskID := importTaskID(job, index)
+	source := importSources[job.Format]
+
+	title := strings.TrimSpace(item.Title)
+	description := item.Description
+	if len(title) > importTitleLimit {
+		description = title + "\n\n" + description
+		title = entity.TruncateText(title, importTitleLimit)
+	}
+
+	// Полное описание, не помещающееся в задачу, сохраняется первым комментарием
+	var comments []importer.Comment
+	if len(description) > importDescriptionLimit {
+		comments = append(comments, importer.Comment{Body: "Full description from " + source + ":\n\n" + description, CreatedAt: item.CreatedAt})
+		description = entity.TruncateText(description, importDescriptionLimit)
+	}
+	for _, comment := range item.Comments {
+		author := comment.Author
+		if author == "" {
+			author = "Unknown"
+		}
+		// Автором становится импортирующий пользователь; исходный автор
+		// указывается в тексте
+		comment.Body = fmt.Sprintf("**%s** (%s):\n\n%s", author, source, comment.Body)
+		comments = append(comments, comment)
+	}
+
+	if _, err := uc.taskRepo.GetByID(ctx, taskID); err != nil {
+		if err.Error() != "task not found" {
+			return err
+		}
+
+		task := &entity.Task{
+			ID:          taskID,
+			Title:       title,
+			Description: description,
+			Status:      importStatus(job, workflow, item),
+			WorkspaceID: job.WorkspaceID,
+			ProjectID:   job.ProjectID,
+			Tags:        importTags(item.Labels),
+			DueAt:       item.DueAt,
+			CreatedAt:   item.CreatedAt,
+		}
+
+		if position, ok := positions[item.ParentID]; ok && item.ParentID != "" {
+			parentID := importTaskID(job, position)
+			if _, err := uc.taskRepo.GetByID(ctx, parentID); err == nil {
+				task.ParentID = parentID
+			}
+		}
+
+		if status, ok := workflow.Status(task.Status); ok && status.Category == entity.CategoryDone {
+			completedAt := time.Now()
+			if item.CompletedAt != nil {
+				completedAt = *item.CompletedAt
+			}
+			task.CompletedAt = &completedAt
+		}
+
+		for _, checkItem := range item.Checklist {
+			task.ChecklistTotal++
+			if checkItem.Done {
+				task.ChecklistDone++
+			}
+		}
+
+		rank, err := entity.RankBetween(job.LastRank, "")
+		if err != nil {
+			return err
+		}
+		task.Rank = rank
+
+		if err := uc.taskUseCase.ImportTask(ctx, task); err != nil {
+			return err
+		}
+		job.LastRank = rank
+		job.Imported++
+	}
+
+	for n, comment := range comments {
+		if err := uc.importComment(ctx, job, taskID, fmt.Sprintf("%s-c%d", taskID, n+1), comment); err != nil {
+			return err
+		}
+	}
+
+	rank := ""
+	for n, checkItem := range item.Checklist {
+		var err error
+		if rank, err = entity.RankBetween(rank, ""); err != nil {
+			return err
+		}
+		if err := uc.importChecklistItem(ctx, job, taskID, fmt.Sprintf("%s-i%d", taskID, n+1), rank, checkItem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *ImportUseCase) importComment(ctx context.Context, job *entity.ImportJob, taskID, id string, c importer.Comment) error {
+	if _, err := uc.commentRepo.GetByID(ctx, id); err == nil {
+		return nil
+	}
+
+	comment := &entity.Comment{
+		ID:       id,
+		TaskID:   taskID,
+		AuthorID: job.UserID,
+		Body:     entity.TruncateText(c.Body, importCommentLimit),
+		Mentions: []string{},
+	}
+	if errMsgs := comment.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: comment " + errMsgs[0])
+	}
+
+	if err := uc.commentRepo.Create(ctx, comment); err != nil {
+		return err
+	}
+	if !c.CreatedAt.IsZero() {
+		comment.CreatedAt = c.CreatedAt
+		comment.UpdatedAt = c.CreatedAt
+		if err := uc.commentRepo.Update(ctx, comment); err != nil {
+			return err
+		}
+	}
+
+	job.Comments++
+	return nil
+}
+
+func (uc *ImportUseCase) importChecklistItem(ctx context.Context, job *entity.ImportJob, taskID, id, rank string, checkItem importer.CheckItem) error {
+	if _, err := uc.checklistRepo.GetByID(ctx, id); err == nil {
+		return nil
+	}
+
+	item := &entity.ChecklistItem{
+		ID:     id,
+		TaskID: taskID,
+		Text:   entity.TruncateText(strings.TrimSpace(checkItem.Text), 500),
+		Done:   checkItem.Done,
+		Rank:   rank,
+	}
+	if errMsgs := item.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: checklist item " + errMsgs[0])
+	}
+
+	if err := uc.checklistRepo.Create(ctx, item); err != nil {
+		return err
+	}
+
+	job.Checklist++
+	return nil
+}
+
+func (uc *ImportUseCase) fail(ctx context.Context, job *entity.ImportJob, err error) {
+	uc.logger.Error("Import failed", err, map[string]interface{}{"job": job.ID})
+
+	now := time.Now()
+	job.Status = entity.ImportJobFailed
+	job.Error = err.Error()
+	job.FinishedAt = &now
+	job.LeaseUntil = time.Time{}
+	if err := uc.jobRepo.Update(ctx, job); err != nil {
+		uc.logger.Error("Failed to save import job", err, map[string]interface{}{"job": job.ID})
+	}
+}
+
+// addProblem записывает ошибку записи; список ограничен, чтобы импорт
+// большого файла с систематической ошибкой не раздувал хранилище
+func (uc *ImportUseCase) addProblem(job *entity.ImportJob, problem string) {
+	switch {
+	case len(job.Problems) < importMaxProblems:
+		job.Problems = append(job.Problems, problem)
+	case len(job.Problems) == importMaxProblems:
+		job.Problems = append(job.Problems, "too many problems, the rest are not listed")
+	}
+}
+
+func importTaskID(job *entity.ImportJob, index int) string {
+	return fmt.Sprintf("%s-%d", job.ID, index+1)
+}
+
+// importStatus выбирает статус записи: явное соответствие, затем статус
+// процесса с тем же ключом или названием, затем первый статус угаданной
+// категории и, наконец, начальный статус процесса
+func importStatus(job *entity.ImportJob, workflow *entity.Workflow, item importer.Item) entity.TaskStatus {
+	if status, ok := job.StatusMap[item.State]; ok {
+		return status
+	}
+
+	for _, status := range workflow.Statuses {
+		if strings.EqualFold(string(status.Key), item.State) || strings.EqualFold(status.Name, item.State) {
+			return status.Key
+		}
+	}
+
+	for _, status := range workflow.Statuses {
+		if string(status.Category) == item.Category {
+			return status.Key
+		}
+	}
+
+	return workflow.InitialStatus
+}
+
+// importTags приводит метки источника к ограничениям задачи
+func importTags(labels []string) []string {
+	var tags []string
+	for _, tag := range entity.NormalizeTags(labels) {
+		if len(tags) == importMaxTags {
+			break
+		}
+		tags = append(tags, entity.TruncateText(tag, importTagLimit))
+	}
+	return entity.NormalizeTags(tags)
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"strings"
+	"sync"
+)
+
+type ProjectUseCase struct {
+	taskUseCase *TaskUseCase
+	projectRepo repository.ProjectRepository
+	taskRepo    repository.TaskRepository
+	logger      *logger.Logger
+	// moveMutex сериализует расчет рангов, чтобы параллельные перемещения
+	// не получили одинаковый ранг
+	moveMutex sync.Mutex
+}
+
+func NewProjectUseCase(taskUseCase *TaskUseCase, projectRepo repository.ProjectRepository, taskRepo repository.TaskRepository, logger *logger.Logger) *ProjectUseCase {
+	return &ProjectUseCase{
+		taskUseCase: taskUseCase,
+		projectRepo: projectRepo,
+		taskRepo:    taskRepo,
+		logger:      logger,
+	}
+}
+
+func (uc *ProjectUseCase) CreateProject(ctx context.Context, project *entity.Project) error {
+	uc.logger.Info("Creating project", map[string]interface{}{"name": project.Name})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	project.UserID = userID
+	project.WorkspaceID = workspaceFromContext(ctx)
+
+	if err := uc.prepareColumns(ctx, project); err != nil {
+		return err
+	}
+
+	return uc.projectRepo.Create(ctx, project)
+}
+
+func (uc *ProjectUseCase) GetProject(ctx context.Context, id string) (*entity.Project, error) {
+	uc.logger.Info("Getting project", map[string]interface{}{"id": id})
+
+	project, err := uc.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка прав доступа
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	if project.UserID != userID {
+		return nil, errors.New("access denied")
+	}
+
+	return project, nil
+}
+
+func (uc *ProjectUseCase) GetAllProjects(ctx context.Context) ([]*entity.Project, error) {
+	uc.logger.Info("Getting all projects", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.projectRepo.GetAll(ctx, userID)
+}
+
+func (uc *ProjectUseCase) UpdateProject(ctx context.Context, project *entity.Project) error {
+	uc.logger.Info("Updating project", map[string]interface{}{"id": project.ID})
+
+	existing, err := uc.GetProject(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+
+	project.UserID = existing.UserID
+	project.WorkspaceID = existing.WorkspaceID
+	project.CreatedAt = existing.CreatedAt
+
+	if err := uc.prepareColumns(ctx, project); err != nil {
+		return err
+	}
+
+	return uc.projectRepo.Update(ctx, project)
+}
+
+// DeleteProject удаляет проект; его задачи остаются и перестают относиться к проекту
+func (uc *ProjectUseCase) DeleteProject(ctx context.Context, id string) error {
+	uc.logger.Info("Deleting project", map[string]interface{}{"id": id})
+
+	project, err := uc.GetProject(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := uc.taskRepo.GetByProject(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Задачи в архиве и корзине тоже отвязываются, чтобы не вернуться из
+	// них в несуществующий проект
+	archived, err := uc.taskRepo.GetArchived(ctx, project.UserID)
+	if err != nil {
+		return err
+	}
+	trashed, err := uc.taskRepo.GetTrashed(ctx, project.UserID)
+	if err != nil {
+		return err
+	}
+	for _, task := range append(archived, trashed...) {
+		if task.ProjectID == id {
+			tasks = append(tasks, task)
+		}
+	}
+
+	for _, task := range tasks {
+		task.ProjectID = ""
+		task.Rank = ""
+		_, err := uc.taskUseCase.persistUpdate(ctx, task.ID, func(ctx context.Context) error {
+			return uc.taskRepo.Update(ctx, task)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.projectRepo.Delete(ctx, id)
+}
+
+// CreateProjectTask создает задачу в проекте в конце её колонки
+func (uc *ProjectUseCase) CreateProjectTask(ctx context.Context, projectID string, task *entity.Task) error {
+	uc.logger.Info("Creating project task", map[string]interface{}{"project": projectID, "title": task.Title})
+
+	uc.moveMutex.Lock()
+	defer uc.moveMutex.Unlock()
+
+	project, err := uc.GetProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	if project.WorkspaceID != workspaceFromContext(ctx) {
+		return errors.New("validation failed: project belongs to another workspace")
+	}
+
+	tasks, err := uc.taskRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	// Ранг больше всех рангов проекта гарантированно ставит задачу
+	// в конец любой колонки
+	last := ""
+	for _, t := range tasks {
+		if t.Rank > last {
+			last = t.Rank
+		}
+	}
+
+	rank, err := entity.RankBetween(last, "")
+	if err != nil {
+		return err
+	}
+
+	task.ProjectID = projectID
+	task.Rank = rank
+
+	return uc.taskUseCase.CreateTask(ctx, task)
+}
+
+// GetProjectTasks возвращает задачи проекта в порядке рангов
+func (uc *ProjectUseCase) GetProjectTasks(ctx context.Context, projectID string) ([]*entity.Task, error) {
+	uc.logger.Info("Getting project tasks", map[string]interface{}{"project": projectID})
+
+	if _, err := uc.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	return uc.taskRepo.GetByProject(ctx, projectID)
+}
+
+// GetBoard возвращает доску проекта: задачи, разложенные по колонкам
+func (uc *ProjectUseCase) GetBoard(ctx context.Context, projectID string) (*entity.Board, error) {
+	uc.logger.Info("Getting board", map[string]interface{}{"project": projectID})
+
+	project, err := uc.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := uc.taskRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &entity.Board{
+		Project:  project,
+		Unmapped: []*entity.Task{},
+	}
+
+	columns := make(map[entity.TaskStatus]*entity.BoardColumnView)
+	for _, column := range project.Columns {
+		view := &entity.BoardColumnView{Column: column, Tasks: []*entity.Task{}}
+		columns[column.Status] = view
+		board.Columns = append(board.Columns, view)
+	}
+
+	for _, task := range tasks {
+		if view, ok := columns[task.Status]; ok {
+			view.Tasks = append(view.Tasks, task)
+		} else {
+			board.Unmapped = append(board.Unmapped, task)
+		}
+	}
+
+	return board, nil
+}
+
+// MoveTask переносит задачу в колонку status сразу после задачи afterTaskID
+// (пустой afterTaskID — в начало колонки). Статус и ранг меняются одним
+// обновлением задачи, поэтому переход проверяется рабочим процессом.
+// Задача другого проекта или без проекта добавляется в этот проект.
+func (uc *ProjectUseCase) MoveTask(ctx context.Context, projectID, taskID string, status entity.TaskStatus, afterTaskID string) (*entity.Task, error) {
+	uc.logger.Info("Moving task", map[string]interface{}{"project": projectID, "task": taskID, "status": status})
+
+	uc.moveMutex.Lock()
+	defer uc.moveMutex.Unlock()
+
+	project, err := uc.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := uc.taskUseCase.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.WorkspaceID != project.WorkspaceID {
+		return nil, errors.New("validation failed: task and project belong to different workspaces")
+	}
+
+	if status == "" {
+		status = task.Status
+	}
+
+	if _, ok := project.Column(status); !ok {
+		return nil, errors.New("validation failed: project has no column for status " + string(status))
+	}
+
+	column, err := uc.columnTasks(ctx, projectID, status, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	rank, err := uc.rankAfter(column, afterTaskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			return nil, err
+		}
+
+		// Ранги колонки повреждены (пустые или совпадающие) — перенумеровываем
+		if err := uc.rebalance(ctx, column); err != nil {
+			return nil, err
+		}
+		if rank, err = uc.rankAfter(column, afterTaskID); err != nil {
+			return nil, err
+		}
+	}
+
+	task.ProjectID = projectID
+	task.Status = status
+	task.Rank = rank
+
+	if err := uc.taskUseCase.UpdateTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// columnTasks возвращает упорядоченные задачи колонки без перемещаемой задачи
+func (uc *ProjectUseCase) columnTasks(ctx context.Context, projectID string, status entity.TaskStatus, excludeID string) ([]*entity.Task, error) {
+	tasks, err := uc.taskRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var column []*entity.Task
+	for _, task := range tasks {
+		if task.Status == status && task.ID != excludeID {
+			column = append(column, task)
+		}
+	}
+
+	return column, nil
+}
+
+// rankAfter вычисляет ранг позиции сразу после задачи afterTaskID
+func (uc *ProjectUseCase) rankAfter(column []*entity.Task, afterTaskID string) (string, error) {
+	index := -1
+	if afterTaskID != "" {
+		for i, task := range column {
+			if task.ID == afterTaskID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", errors.New("validation failed: task " + afterTaskID + " is not in the target column")
+		}
+	}
+
+	prev, next := "", ""
+	if index >= 0 {
+		prev = column[index].Rank
+		if prev == "" {
+			return "", errors.New("rank order violated")
+		}
+	}
+	if index+1 < len(column) {
+		next = column[index+1].Rank
+	}
+
+	return entity.RankBetween(prev, next)
+}
+
+// rebalance заново раздает ранги задачам колонки, сохраняя их текущий порядок
+func (uc *ProjectUseCase) rebalance(ctx context.Context, column []*entity.Task) error {
+	uc.logger.Info("Rebalancing column ranks", map[string]interface{}{"count": len(column)})
+
+	last := ""
+	for _, task := range column {
+		rank, err := entity.RankBetween(last, "")
+		if err != nil {
+			return err
+		}
+
+		task.Rank = rank
+		_, err = uc.taskUseCase.persistUpdate(ctx, task.ID, func(ctx context.Context) error {
+			return uc.taskRepo.Update(ctx, task)
+		})
+		if err != nil {
+			return err
+		}
+		last = rank
+	}
+
+	return nil
+}
+
+// prepareColumns проверяет колонки проекта по рабочему процессу; если
+// колонки не заданы, создает по колонке на каждый статус процесса
+func (uc *ProjectUseCase) prepareColumns(ctx context.Context, project *entity.Project) error {
+	workflow, err := uc.taskUseCase.GetWorkflow(ctx, project.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	if len(project.Columns) == 0 {
+		for _, status := range workflow.Statuses {
+			project.Columns = append(project.Columns, entity.BoardColumn{Status: status.Key, Name: status.Name})
+		}
+	}
+
+	if errMsgs := project.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	for i, column := range project.Columns {
+		status, ok := workflow.Status(column.Status)
+		if !ok {
+			return errors.New("validation failed: status " + string(column.Status) + " is not defined in workflow")
+		}
+		if column.Name == "" {
+			project.Columns[i].Name = status.Name
+		}
+	}
+
+	return nil
+}
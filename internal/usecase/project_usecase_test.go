@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		want       string
+		err        string
+	}{
+		{prev: "", next: "", want: "i"},
+		{prev: "i", next: "", want: "r"},
+		{prev: "", next: "1", want: "0i"},
+		{prev: "a", next: "b", want: "ai"},
+		{prev: "az", next: "b", want: "azi"},
+		{prev: "b", next: "a", err: "rank order violated"},
+		{prev: "a", next: "a", err: "rank order violated"},
+		{prev: "a0", next: "", err: "invalid rank"},
+		{prev: "A", next: "", err: "invalid rank"},
+	}
+
+	for _, tt := range tests {
+		got, err := entity.RankBetween(tt.prev, tt.next)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("RankBetween(%q, %q) error = %v, want %q", tt.prev, tt.next, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("RankBetween(%q, %q) = %q, %v; want %q", tt.prev, tt.next, got, err, tt.want)
+		}
+	}
+}
+
+func TestRankBetweenAlwaysFitsBetween(t *testing.T) {
+	// Многократная вставка в начало и между соседями не исчерпывает ранги
+	first, last := "i", "j"
+	for i := 0; i < 200; i++ {
+		rank, err := entity.RankBetween("", first)
+		if err != nil || rank >= first || strings.HasSuffix(rank, "0") {
+			t.Fatalf("insert before %q = %q, %v", first, rank, err)
+		}
+		first = rank
+
+		rank, err = entity.RankBetween(first, last)
+		if err != nil || rank <= first || rank >= last || strings.HasSuffix(rank, "0") {
+			t.Fatalf("insert between %q and %q = %q, %v", first, last, rank, err)
+		}
+		last = rank
+	}
+}
+
+func TestMoveTaskRebalancesBrokenRanks(t *testing.T) {
+	env := newTestEnv(t)
+	projects := NewProjectUseCase(env.tasks, db.NewProjectRepository(), env.taskRepo, env.logger)
+	ctx := userContext("user-123")
+
+	project := &entity.Project{Name: "Website"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	var tasks []*entity.Task
+	for _, title := range []string{"A", "B", "C"} {
+		task := &entity.Task{Title: title}
+		if err := projects.CreateProjectTask(ctx, project.ID, task); err != nil {
+			t.Fatalf("create project task: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	// Перемещение в начало колонки меняет только ранг перемещаемой задачи
+	moved, err := projects.MoveTask(ctx, project.ID, tasks[2].ID, "", "")
+	if err != nil {
+		t.Fatalf("move C to top: %v", err)
+	}
+	if moved.Rank >= tasks[0].Rank {
+		t.Fatalf("rank of C = %q, want before A (%q)", moved.Rank, tasks[0].Rank)
+	}
+	assertProjectOrder(t, env, project.ID, "C A B")
+
+	// Совпадающие ранги (например, после импорта) перенумеровываются
+	// с сохранением порядка
+	for _, task := range tasks {
+		current, err := env.taskRepo.GetByID(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		current.Rank = "i"
+		if err := env.taskRepo.Update(ctx, current); err != nil {
+			t.Fatalf("break rank: %v", err)
+		}
+	}
+
+	if _, err := projects.MoveTask(ctx, project.ID, tasks[2].ID, "", tasks[0].ID); err != nil {
+		t.Fatalf("move C after A: %v", err)
+	}
+	assertProjectOrder(t, env, project.ID, "A C B")
+}
+
+// assertProjectOrder проверяет порядок задач проекта по рангам
+func assertProjectOrder(t *testing.T, env *testEnv, projectID, want string) {
+	t.Helper()
+
+	tasks, err := env.taskRepo.GetByProject(userContext("user-123"), projectID)
+	if err != nil {
+		t.Fatalf("get project tasks: %v", err)
+	}
+	if got := titles(tasks); got != want {
+		t.Fatalf("project order = %s, want %s", got, want)
+	}
+}
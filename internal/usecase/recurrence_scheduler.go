@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+// RecurrenceScheduler фоновый планировщик повторяющихся задач. Когда текущий
+// экземпляр серии завершен или наступил его срок, планировщик создает
+// следующий экземпляр и переносит на него правило повторения.
+type RecurrenceScheduler struct {
+	taskUseCase    *TaskUseCase
+	projectUseCase *ProjectUseCase
+	taskRepo       repository.TaskRepository
+	logger         *logger.Logger
+	interval       time.Duration
+}
+
+func NewRecurrenceScheduler(taskUseCase *TaskUseCase, projectUseCase *ProjectUseCase, taskRepo repository.TaskRepository, logger *logger.Logger, interval time.Duration) *RecurrenceScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &RecurrenceScheduler{
+		taskUseCase:    taskUseCase,
+		projectUseCase: projectUseCase,
+		taskRepo:       taskRepo,
+		logger:         logger,
+		interval:       interval,
+	}
+}
+
+// Run обрабатывает серии сразу и затем с заданным интервалом,
+// пока не отменен контекст
+func (s *RecurrenceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick создает следующие экземпляры для всех серий, готовых к продвижению
+func (s *RecurrenceScheduler) Tick(ctx context.Context, now time.Time) {
+	tasks, err := s.taskRepo.GetRecurring(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load recurring tasks", err, nil)
+		return
+	}
+
+	for _, task := range tasks {
+		if err := s.advance(ctx, task, now); err != nil {
+			s.logger.Error("Failed to advance recurring task", err, map[string]interface{}{"id": task.ID})
+		}
+	}
+}
+
+// advance создает следующий экземпляр серии. Пропущенные за время простоя
+// вхождения не создаются: следующий экземпляр получает ближайшее будущее
+// вхождение, а его номер учитывает пропущенные (важно для COUNT).
+// Новый экземпляр и снятие правила с текущего записываются в одной
+// транзакции: иначе после сбоя второй записи следующий тик создал бы
+// экземпляр повторно.
+func (s *RecurrenceScheduler) advance(ctx context.Context, task *entity.Task, now time.Time) error {
+	// Действия выполняются от имени владельца задачи в её пространстве
+	ownerCtx := context.WithValue(ctx, "user_id", task.UserID)
+	ownerCtx = context.WithValue(ownerCtx, "workspace_id", task.WorkspaceID)
+
+	return s.taskRepo.WithinTransaction(ownerCtx, func(ownerCtx context.Context) error {
+		// Выборка Tick могла устареть: пользователь мог изменить задачу или
+		// снять правило, поэтому решение принимается по её текущему состоянию
+		current, err := s.taskRepo.GetByID(ownerCtx, task.ID)
+		if err != nil {
+			return err
+		}
+
+		if current.Recurrence == nil || current.DueAt == nil {
+			return nil
+		}
+
+		if !current.IsDone() && now.Before(*current.DueAt) {
+			return nil
+		}
+
+		after := *current.DueAt
+		if now.After(after) {
+			after = now
+		}
+
+		at, index, err := current.Recurrence.NextFrom(after, *current.DueAt, current.Occurrence)
+		if err != nil {
+			return err
+		}
+
+		return s.replace(ownerCtx, current, at, index)
+	})
+}
+
+// replace создает экземпляр серии с номером index на момент at, если серия
+// не закончилась, и снимает правило повторения с текущего экземпляра
+func (s *RecurrenceScheduler) replace(ownerCtx context.Context, task *entity.Task, at time.Time, index int) error {
+	if index > 0 {
+		recurrence := *task.Recurrence
+		next := &entity.Task{
+			Title:       task.Title,
+			Description: task.Description,
+			ParentID:    task.ParentID,
+			AssigneeID:  task.AssigneeID,
+			DueAt:       &at,
+			Recurrence:  &recurrence,
+			SeriesID:    task.SeriesID,
+			Occurrence:  index,
+		}
+
+		var err error
+		if task.ProjectID != "" {
+			err = s.projectUseCase.CreateProjectTask(ownerCtx, task.ProjectID, next)
+		} else {
+			err = s.taskUseCase.CreateTask(ownerCtx, next)
+		}
+		if err != nil {
+			return err
+		}
+
+		s.logger.Info("Recurring task instance created", map[string]interface{}{
+			"series": task.SeriesID, "id": next.ID, "occurrence": index, "due_at": at,
+		})
+	} else {
+		s.logger.Info("Recurring series finished", map[string]interface{}{"series": task.SeriesID})
+	}
+
+	// Правило повторения переходит к новому экземпляру (или серия завершена),
+	// поэтому текущий экземпляр больше не обрабатывается планировщиком.
+	// Снимается только правило: остальные поля задачи остаются такими, какими
+	// их оставил пользователь, и смена статуса не проверяется заново.
+	_, err := s.taskUseCase.persistUpdate(ownerCtx, task.ID, func(ownerCtx context.Context) error {
+		current, err := s.taskRepo.GetByID(ownerCtx, task.ID)
+		if err != nil {
+			return err
+		}
+
+		current.Recurrence = nil
+		return s.taskRepo.Update(ownerCtx, current)
+	})
+	return err
+}
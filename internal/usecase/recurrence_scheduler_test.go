@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"testing"
+	"time"
+)
+
+// failingUpdateRepository отказывает в изменении задачи failID, как
+// хранилище, упавшее между созданием экземпляра и снятием правила
+type failingUpdateRepository struct {
+	*db.TaskRepository
+	failID string
+}
+
+func (r *failingUpdateRepository) Update(ctx context.Context, task *entity.Task) error {
+	if task.ID == r.failID {
+		return errors.New("storage unavailable")
+	}
+	return r.TaskRepository.Update(ctx, task)
+}
+
+// editingRecurringRepository после выборки серий меняет задачу от имени
+// пользователя, как правка, сделанная между чтением в Tick и транзакцией
+type editingRecurringRepository struct {
+	*db.TaskRepository
+	edit func()
+}
+
+func (r *editingRecurringRepository) GetRecurring(ctx context.Context) ([]*entity.Task, error) {
+	tasks, err := r.TaskRepository.GetRecurring(ctx)
+	if err == nil && r.edit != nil {
+		r.edit()
+		r.edit = nil
+	}
+	return tasks, err
+}
+
+func TestRecurrenceSchedulerKeepsConcurrentEdit(t *testing.T) {
+	env := newTestEnv(t)
+	repo := &editingRecurringRepository{TaskRepository: env.taskRepo}
+	projects := NewProjectUseCase(env.tasks, db.NewProjectRepository(), repo, env.logger)
+	scheduler := NewRecurrenceScheduler(env.tasks, projects, repo, env.logger, time.Minute)
+	ctx := userContext("user-123")
+
+	dueAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	task := env.createTask(t, ctx, &entity.Task{Title: "Water plants", DueAt: &dueAt, Recurrence: &entity.Recurrence{Rule: "FREQ=DAILY"}})
+
+	repo.edit = func() {
+		edited, err := env.tasks.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		edited.Title, edited.Status, edited.AssigneeID = "Water the plants", entity.StatusInProgress, "user-456"
+		if err := env.tasks.UpdateTask(ctx, edited); err != nil {
+			t.Fatalf("edit task: %v", err)
+		}
+	}
+	scheduler.Tick(context.Background(), time.Now())
+
+	current, err := env.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if current.Title != "Water the plants" || current.Status != entity.StatusInProgress || current.AssigneeID != "user-456" || current.Recurrence != nil {
+		t.Fatalf("instance after tick = %+v, want the edit kept and only the rule removed", current)
+	}
+
+	all, err := env.tasks.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("get tasks: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("%d tasks after tick, want the next instance created", len(all))
+	}
+	for _, next := range all {
+		if next.ID != task.ID && (next.Title != "Water the plants" || next.Occurrence != 2 || next.Recurrence == nil) {
+			t.Fatalf("next instance = %+v", next)
+		}
+	}
+}
+
+func TestRecurrenceSchedulerDoesNotDuplicateInstance(t *testing.T) {
+	env := newTestEnv(t)
+	repo := &failingUpdateRepository{TaskRepository: env.taskRepo}
+	tasks := NewTaskUseCase(repo, env.deps, env.workflows, env.users, env.outbox, env.logger, TaskUseCaseConfig{})
+	projects := NewProjectUseCase(tasks, db.NewProjectRepository(), repo, env.logger)
+	scheduler := NewRecurrenceScheduler(tasks, projects, repo, env.logger, time.Minute)
+	ctx := userContext("user-123")
+
+	dueAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	first := &entity.Task{Title: "Water plants", DueAt: &dueAt, Recurrence: &entity.Recurrence{Rule: "FREQ=DAILY"}}
+	if err := tasks.CreateTask(ctx, first); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	series := func() []*entity.Task {
+		all, err := tasks.GetAllTasks(ctx)
+		if err != nil {
+			t.Fatalf("get tasks: %v", err)
+		}
+		var result []*entity.Task
+		for _, task := range all {
+			if task.SeriesID == first.SeriesID {
+				result = append(result, task)
+			}
+		}
+		return result
+	}
+
+	// Правило не удалось снять: экземпляр не создается
+	repo.failID = first.ID
+	scheduler.Tick(context.Background(), time.Now())
+	if got := series(); len(got) != 1 {
+		t.Fatalf("series after failed tick has %d tasks, want 1", len(got))
+	}
+
+	repo.failID = ""
+	scheduler.Tick(context.Background(), time.Now())
+	scheduler.Tick(context.Background(), time.Now())
+
+	got := series()
+	if len(got) != 2 {
+		t.Fatalf("series after retries has %d tasks, want 2", len(got))
+	}
+	for _, task := range got {
+		if task.ID != first.ID && (task.Occurrence != 2 || task.Recurrence == nil) {
+			t.Fatalf("next instance = %+v", task)
+		}
+		if task.ID == first.ID && task.Recurrence != nil {
+			t.Fatal("rule is still on the first instance")
+		}
+	}
+}
+
+func TestOccurrencesMatchRule(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	start := time.Date(2025, time.January, 31, 9, 30, 0, 0, time.UTC)
+	for _, rule := range []string{
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=DAILY;BYDAY=MO,WE,FR",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+		"FREQ=MONTHLY",
+		"FREQ=MONTHLY;BYDAY=1MO,-1FR",
+		"FREQ=WEEKLY;BYDAY=SA;COUNT=40",
+	} {
+		recurrence := &entity.Recurrence{Rule: rule, TimeZone: "Europe/Berlin", Start: start}
+		want, err := recurrence.Occurrences(60)
+		if err != nil {
+			t.Fatalf("%s: occurrences: %v", rule, err)
+		}
+
+		// Экземпляр с 20-м вхождением: предпросмотр продолжает серию с него
+		clone := *recurrence
+		task := env.createTask(t, ctx, &entity.Task{Title: rule, DueAt: &want[19], Occurrence: 20, Recurrence: &clone})
+
+		got, err := env.tasks.GetOccurrences(ctx, task.ID, 100)
+		if err != nil {
+			t.Fatalf("%s: get occurrences: %v", rule, err)
+		}
+		if len(got) != len(want)-19 && len(got) != maxOccurrencesPreview {
+			t.Fatalf("%s: %d occurrences, want %d", rule, len(got), len(want)-19)
+		}
+		for i, occurrence := range got {
+			if 19+i >= len(want) {
+				break
+			}
+			if occurrence.Index != 20+i || !occurrence.At.Equal(want[19+i]) {
+				t.Fatalf("%s: occurrence %d = %d at %v, want %d at %v", rule, i, occurrence.Index, occurrence.At, 20+i, want[19+i])
+			}
+		}
+	}
+}
+
+func TestRecurrenceSchedulerAdvancesArchivedInstance(t *testing.T) {
+	env := newTestEnv(t)
+	projects := NewProjectUseCase(env.tasks, db.NewProjectRepository(), env.taskRepo, env.logger)
+	scheduler := NewRecurrenceScheduler(env.tasks, projects, env.taskRepo, env.logger, time.Minute)
+	ctx := userContext("user-123")
+
+	dueAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	task := env.createTask(t, ctx, &entity.Task{Title: "Weekly report", DueAt: &dueAt, Recurrence: &entity.Recurrence{Rule: "FREQ=WEEKLY"}})
+
+	// Незавершенный экземпляр убран в архив вручную
+	if _, err := env.tasks.ArchiveTask(ctx, task.ID); err != nil {
+		t.Fatalf("archive task: %v", err)
+	}
+
+	scheduler.Tick(context.Background(), time.Now())
+
+	active, err := env.tasks.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("get tasks: %v", err)
+	}
+	if len(active) != 1 || active[0].SeriesID != task.SeriesID || active[0].Occurrence != 2 || active[0].Recurrence == nil {
+		t.Fatalf("active tasks after tick = %+v, want the next instance of the series", active)
+	}
+
+	archived, err := env.tasks.GetTask(ctx, task.ID)
+	if err != nil || archived.Recurrence != nil || !archived.IsArchived() {
+		t.Fatalf("archived instance = %+v, %v", archived, err)
+	}
+}
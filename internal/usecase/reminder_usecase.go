@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
+	"sync"
+	"time"
+)
+
+// ReminderConfig настройки планировщика напоминаний
+type ReminderConfig struct {
+	Interval time.Duration // период опроса готовых заданий
+	// Lease время, на которое задание забирается на отправку; если сервис
+	// упал во время отправки, по истечении аренды задание отправляется снова
+	Lease        time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration // задержка перед первым повтором, далее удваивается
+	// Retention сколько хранятся отправленные задания. Вхождения старше этого
+	// окна не планируются, чтобы после очистки напоминание не пришло повторно.
+	Retention       time.Duration
+	DefaultChannels []string
+}
+
+// ReminderUseCase ведет напоминания задач и фоновую отправку уведомлений.
+// Доставка выполняется не менее одного раза: задание помечается отправленным
+// только после успешной отправки, а ID задания передается каналу как ключ
+// идемпотентности для отсечения дублей.
+type ReminderUseCase struct {
+	taskUseCase  *TaskUseCase
+	reminderRepo repository.ReminderRepository
+	jobRepo      repository.ReminderJobRepository
+	userRepo     repository.UserRepository
+	notifiers    map[string]notifier.Notifier
+	logger       *logger.Logger
+	config       ReminderConfig
+	// mutex сериализует пересчет заданий, чтобы параллельные изменения
+	// задачи не планировали устаревшие отправки
+	mutex sync.Mutex
+}
+
+func NewReminderUseCase(taskUseCase *TaskUseCase, reminderRepo repository.ReminderRepository, jobRepo repository.ReminderJobRepository, userRepo repository.UserRepository, notifiers map[string]notifier.Notifier, logger *logger.Logger, config ReminderConfig) *ReminderUseCase {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 30 * time.Second
+	}
+	if config.Retention <= 0 {
+		config.Retention = 30 * 24 * time.Hour
+	}
+
+	return &ReminderUseCase{
+		taskUseCase:  taskUseCase,
+		reminderRepo: reminderRepo,
+		jobRepo:      jobRepo,
+		userRepo:     userRepo,
+		notifiers:    notifiers,
+		logger:       logger,
+		config:       config,
+	}
+}
+
+// AddReminder добавляет напоминание к задаче и планирует его отправку
+func (uc *ReminderUseCase) AddReminder(ctx context.Context, taskID string, reminder *entity.Reminder) error {
+	uc.logger.Info("Adding reminder", map[string]interface{}{"task": taskID})
+
+	task, err := uc.taskUseCase.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	reminder.TaskID = task.ID
+	reminder.UserID = task.UserID
+	if len(reminder.Channels) == 0 {
+		reminder.Channels = uc.config.DefaultChannels
+	}
+
+	if errMsgs := reminder.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	for _, channel := range reminder.Channels {
+		if _, ok := uc.notifiers[channel]; !ok {
+			return errors.New("validation failed: unknown channel " + channel)
+		}
+	}
+
+	if _, ok := reminder.FireTime(task); !ok {
+		return errors.New("validation failed: task has no due date")
+	}
+
+	if err := uc.reminderRepo.Create(ctx, reminder); err != nil {
+		return err
+	}
+
+	return uc.syncTask(ctx, task)
+}
+
+// GetReminders возвращает напоминания задачи
+func (uc *ReminderUseCase) GetReminders(ctx context.Context, taskID string) ([]*entity.Reminder, error) {
+	uc.logger.Info("Getting reminders", map[string]interface{}{"task": taskID})
+
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	return uc.reminderRepo.GetByTask(ctx, taskID)
+}
+
+// GetJobs возвращает задания на отправку напоминаний задачи
+func (uc *ReminderUseCase) GetJobs(ctx context.Context, taskID string) ([]*entity.ReminderJob, error) {
+	if _, err := uc.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	return uc.jobRepo.GetByTask(ctx, taskID)
+}
+
+// DeleteReminder удаляет напоминание и отменяет его неотправленные задания
+func (uc *ReminderUseCase) DeleteReminder(ctx context.Context, taskID, reminderID string) error {
+	uc.logger.Info("Deleting reminder", map[string]interface{}{"task": taskID, "id": reminderID})
+
+	task, err := uc.taskUseCase.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	reminder, err := uc.reminderRepo.GetByID(ctx, reminderID)
+	if err != nil {
+		return err
+	}
+
+	if reminder.TaskID != task.ID {
+		return errors.New("reminder not found")
+	}
+
+	if err := uc.reminderRepo.Delete(ctx, reminderID); err != nil {
+		return err
+	}
+
+	return uc.syncTask(ctx, task)
+}
+
+// HandleTaskEvent пересчитывает задания после изменения срока или статуса
+// задачи; у удаленной задачи удаляет напоминания и отменяет их отправку.
+// Напоминания задачи в корзине сохраняются, но не отправляются.
+func (uc *ReminderUseCase) HandleTaskEvent(ctx context.Context, event *entity.DomainEvent) error {
+	if event.Type == entity.TaskDeletedEvent {
+		if err := uc.reminderRepo.DeleteByTask(ctx, event.TaskID); err != nil {
+			return err
+		}
+	}
+
+	return uc.syncTask(ctx, event.Task)
+}
+
+// syncTask приводит неотправленные задания задачи в соответствие с её
+// напоминаниями: лишние отменяются, недостающие планируются. Завершенная
+// или удаленная (в том числе в корзину) задача не получает напоминаний.
+func (uc *ReminderUseCase) syncTask(ctx context.Context, task *entity.Task) error {
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	reminders, err := uc.reminderRepo.GetByTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]*entity.ReminderJob)
+	horizon := time.Now().Add(-uc.config.Retention)
+
+	if !task.IsDone() && !task.IsTrashed() {
+		for _, reminder := range reminders {
+			fireAt, ok := reminder.FireTime(task)
+			if !ok || fireAt.Before(horizon) {
+				continue
+			}
+
+			for _, channel := range reminder.Channels {
+				job := &entity.ReminderJob{
+					ID:            entity.ReminderJobID(reminder.ID, channel, fireAt),
+					ReminderID:    reminder.ID,
+					TaskID:        task.ID,
+					UserID:        task.UserID,
+					Channel:       channel,
+					FireAt:        fireAt,
+					TaskTitle:     task.Title,
+					DueAt:         task.DueAt,
+					Status:        entity.ReminderJobPending,
+					NextAttemptAt: fireAt,
+				}
+				desired[job.ID] = job
+			}
+		}
+	}
+
+	jobs, err := uc.jobRepo.GetByTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		want, ok := desired[job.ID]
+		if job.Status != entity.ReminderJobPending {
+			// Отправляемые и отправленные задания не трогаем: они же
+			// защищают от повторного планирования того же вхождения
+			delete(desired, job.ID)
+			continue
+		}
+
+		// Tick мог захватить задание после чтения, поэтому изменения
+		// применяются, только если задание все еще ждет отправки
+		if !ok {
+			if _, err := uc.jobRepo.CancelPending(ctx, job.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		delete(desired, job.ID)
+		if job.TaskTitle != want.TaskTitle {
+			if err := uc.jobRepo.RenamePending(ctx, job.ID, want.TaskTitle); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, job := range desired {
+		if _, err := uc.jobRepo.Schedule(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run отправляет готовые напоминания сразу и затем с заданным интервалом,
+// пока не отменен контекст. Задания, не отправленные до остановки сервиса,
+// подхватываются при следующем запуске.
+func (uc *ReminderUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		uc.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick отправляет все готовые задания и удаляет давно завершенные
+func (uc *ReminderUseCase) Tick(ctx context.Context, now time.Time) {
+	jobs, err := uc.jobRepo.ClaimDue(ctx, now, now.Add(uc.config.Lease))
+	if err != nil {
+		uc.logger.Error("Failed to claim reminder jobs", err, nil)
+		return
+	}
+
+	for _, job := range jobs {
+		uc.deliver(ctx, job)
+	}
+
+	if err := uc.jobRepo.DeleteFinishedBefore(ctx, now.Add(-uc.config.Retention)); err != nil {
+		uc.logger.Error("Failed to purge reminder jobs", err, nil)
+	}
+}
+
+// deliver отправляет задание и записывает результат; при ошибке задание
+// возвращается в очередь с экспоненциальной задержкой
+func (uc *ReminderUseCase) deliver(ctx context.Context, job *entity.ReminderJob) {
+	err := uc.notify(ctx, job)
+	now := time.Now()
+
+	if err == nil {
+		job.Status = entity.ReminderJobDelivered
+		job.DeliveredAt = &now
+		job.LastError = ""
+		uc.logger.Info("Reminder delivered", map[string]interface{}{"job": job.ID, "attempt": job.Attempts})
+	} else {
+		job.LastError = err.Error()
+		if job.Attempts >= uc.config.MaxAttempts {
+			job.Status = entity.ReminderJobFailed
+		} else {
+			job.Status = entity.ReminderJobPending
+			job.NextAttemptAt = now.Add(uc.config.RetryBackoff << (job.Attempts - 1))
+		}
+		uc.logger.Error("Reminder delivery failed", err, map[string]interface{}{"job": job.ID, "attempt": job.Attempts})
+	}
+
+	job.LeaseUntil = time.Time{}
+	if err := uc.jobRepo.Update(ctx, job); err != nil {
+		uc.logger.Error("Failed to save reminder job", err, map[string]interface{}{"job": job.ID})
+	}
+}
+
+func (uc *ReminderUseCase) notify(ctx context.Context, job *entity.ReminderJob) error {
+	channel, ok := uc.notifiers[job.Channel]
+	if !ok {
+		return errors.New("unknown channel " + job.Channel)
+	}
+
+	n := notifier.Notification{
+		ID:      job.ID,
+		UserID:  job.UserID,
+		TaskID:  job.TaskID,
+		Subject: "Reminder: " + job.TaskTitle,
+		Body:    fmt.Sprintf("Task %q is due.", job.TaskTitle),
+		SentAt:  time.Now(),
+	}
+	if job.DueAt != nil {
+		n.Body = fmt.Sprintf("Task %q is due at %s.", job.TaskTitle, job.DueAt.Format("2006-01-02 15:04 MST"))
+	}
+
+	if user, err := uc.userRepo.GetByID(ctx, job.UserID); err == nil {
+		n.To = user.Email
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, uc.config.Lease/2)
+	defer cancel()
+
+	return channel.Notify(ctx, n)
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingNotifier запоминает отправленные уведомления
+type recordingNotifier struct {
+	mutex sync.Mutex
+	sent  []notifier.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// claimingJobRepository сразу после чтения заданий задачи захватывает их на
+// отправку, как Tick, выполненный между чтением и записью в syncTask
+type claimingJobRepository struct {
+	*db.ReminderJobRepository
+	claimed []*entity.ReminderJob
+}
+
+func (r *claimingJobRepository) GetByTask(ctx context.Context, taskID string) ([]*entity.ReminderJob, error) {
+	jobs, err := r.ReminderJobRepository.GetByTask(ctx, taskID)
+	if err != nil || r.claimed != nil {
+		return jobs, err
+	}
+
+	now := time.Now().Add(24 * time.Hour)
+	r.claimed, err = r.ReminderJobRepository.ClaimDue(ctx, now, now.Add(time.Minute))
+	return jobs, err
+}
+
+// newReminderRepository открывает напоминания, сохраненные в каталоге dir
+func newReminderRepository(t *testing.T, dir string) *db.ReminderRepository {
+	t.Helper()
+
+	repo, err := db.NewReminderRepository(filepath.Join(dir, "task_reminders.json"))
+	if err != nil {
+		t.Fatalf("load reminders: %v", err)
+	}
+	return repo
+}
+
+func TestReminderSyncKeepsJobClaimedByTick(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	jobs, err := db.NewReminderJobRepository(filepath.Join(t.TempDir(), "reminders.json"))
+	if err != nil {
+		t.Fatalf("load reminder jobs: %v", err)
+	}
+	claiming := &claimingJobRepository{ReminderJobRepository: jobs}
+	sink := &recordingNotifier{}
+	reminders := NewReminderUseCase(env.tasks, newReminderRepository(t, t.TempDir()), claiming, env.users, map[string]notifier.Notifier{"log": sink}, env.logger, ReminderConfig{})
+
+	dueAt := time.Now().Add(2 * time.Hour)
+	task := env.createTask(t, ctx, &entity.Task{Title: "Renew domain", DueAt: &dueAt})
+
+	// Планирование читает задания до того, как они появились: захватывать нечего
+	if err := reminders.AddReminder(ctx, task.ID, &entity.Reminder{Before: time.Hour, Channels: []string{"log"}}); err != nil {
+		t.Fatalf("add reminder: %v", err)
+	}
+
+	// Задача выполнена, пока напоминание уже отправляется
+	previous := *task
+	task.Status, task.StatusCategory, task.Title = entity.StatusDone, entity.CategoryDone, "Renew domain name"
+	if err := reminders.HandleTaskEvent(ctx, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, TaskID: task.ID, Task: task, Previous: &previous}); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+	if len(claiming.claimed) != 1 {
+		t.Fatalf("claimed %d jobs, want the scheduled one", len(claiming.claimed))
+	}
+
+	stored, err := jobs.GetByTask(ctx, task.ID)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("jobs after sync = %d, %v; want the claimed job kept", len(stored), err)
+	}
+	if job := stored[0]; job.Status != entity.ReminderJobFiring || job.TaskTitle != "Renew domain" || job.Attempts != 1 {
+		t.Fatalf("claimed job changed by sync: %+v", job)
+	}
+
+	// Отправка захваченного задания завершается и сохраняется
+	reminders.deliver(ctx, claiming.claimed[0])
+	stored, err = jobs.GetByTask(ctx, task.ID)
+	if err != nil || len(stored) != 1 || stored[0].Status != entity.ReminderJobDelivered || len(sink.sent) != 1 {
+		t.Fatalf("after delivery: jobs %+v, %v; sent %d", stored, err, len(sink.sent))
+	}
+}
+
+func TestReminderSyncCancelsPendingJob(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	jobs, err := db.NewReminderJobRepository(filepath.Join(t.TempDir(), "reminders.json"))
+	if err != nil {
+		t.Fatalf("load reminder jobs: %v", err)
+	}
+	reminders := NewReminderUseCase(env.tasks, newReminderRepository(t, t.TempDir()), jobs, env.users, map[string]notifier.Notifier{"log": &recordingNotifier{}}, env.logger, ReminderConfig{})
+
+	dueAt := time.Now().Add(2 * time.Hour)
+	task := env.createTask(t, ctx, &entity.Task{Title: "Renew domain", DueAt: &dueAt})
+	if err := reminders.AddReminder(ctx, task.ID, &entity.Reminder{Before: time.Hour, Channels: []string{"log"}}); err != nil {
+		t.Fatalf("add reminder: %v", err)
+	}
+
+	previous := *task
+	task.Title = "Renew domain name"
+	if err := reminders.HandleTaskEvent(ctx, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, TaskID: task.ID, Task: task, Previous: &previous}); err != nil {
+		t.Fatalf("handle rename: %v", err)
+	}
+	stored, err := jobs.GetByTask(ctx, task.ID)
+	if err != nil || len(stored) != 1 || stored[0].TaskTitle != "Renew domain name" {
+		t.Fatalf("jobs after rename = %+v, %v", stored, err)
+	}
+
+	previous = *task
+	task.Status, task.StatusCategory = entity.StatusDone, entity.CategoryDone
+	if err := reminders.HandleTaskEvent(ctx, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, TaskID: task.ID, Task: task, Previous: &previous}); err != nil {
+		t.Fatalf("handle completion: %v", err)
+	}
+	if stored, err := jobs.GetByTask(ctx, task.ID); err != nil || len(stored) != 0 {
+		t.Fatalf("jobs after completion = %+v, %v; want pending job cancelled", stored, err)
+	}
+}
+
+func TestRemindersSurviveRestart(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+	dir := t.TempDir()
+
+	open := func() (*ReminderUseCase, *db.ReminderJobRepository) {
+		jobs, err := db.NewReminderJobRepository(filepath.Join(dir, "reminders.json"))
+		if err != nil {
+			t.Fatalf("load reminder jobs: %v", err)
+		}
+		reminders := NewReminderUseCase(env.tasks, newReminderRepository(t, dir), jobs, env.users, map[string]notifier.Notifier{"log": &recordingNotifier{}}, env.logger, ReminderConfig{})
+		return reminders, jobs
+	}
+
+	reminders, _ := open()
+	dueAt := time.Now().Add(2 * time.Hour)
+	task := env.createTask(t, ctx, &entity.Task{Title: "Renew domain", DueAt: &dueAt})
+	if err := reminders.AddReminder(ctx, task.ID, &entity.Reminder{Before: time.Hour, Channels: []string{"log"}}); err != nil {
+		t.Fatalf("add reminder: %v", err)
+	}
+
+	// После перезапуска изменение задачи пересчитывает задания по
+	// сохраненным напоминаниям, а не отменяет их
+	reminders, jobs := open()
+	if stored, err := reminders.GetReminders(ctx, task.ID); err != nil || len(stored) != 1 {
+		t.Fatalf("reminders after restart = %+v, %v", stored, err)
+	}
+
+	previous := *task
+	later := dueAt.Add(time.Hour)
+	task.DueAt = &later
+	if err := reminders.HandleTaskEvent(ctx, &entity.DomainEvent{Type: entity.TaskUpdatedEvent, TaskID: task.ID, Task: task, Previous: &previous}); err != nil {
+		t.Fatalf("handle update: %v", err)
+	}
+
+	stored, err := jobs.GetByTask(ctx, task.ID)
+	if err != nil || len(stored) != 1 || !stored[0].FireAt.Equal(later.Add(-time.Hour)) || stored[0].Status != entity.ReminderJobPending {
+		t.Fatalf("jobs after restart and update = %+v, %v; want one job for the new due date", stored, err)
+	}
+}
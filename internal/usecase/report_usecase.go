@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// ReportFormat формат отчета о задачах
+type ReportFormat string
+
+const (
+	// ReportMarkdown Markdown со списками задач GitHub (- [ ] / - [x])
+	ReportMarkdown ReportFormat = "markdown"
+	// ReportHTML самостоятельная HTML-страница со встроенными стилями
+	ReportHTML ReportFormat = "html"
+)
+
+//go:embed templates/report/*.tmpl
+var reportTemplateFS embed.FS
+
+var (
+	reportMarkdownTemplate = texttemplate.Must(texttemplate.New("report.md.tmpl").Funcs(texttemplate.FuncMap{
+		"md":   escapeMarkdown,
+		"code": markdownCode,
+	}).ParseFS(reportTemplateFS, "templates/report/report.md.tmpl"))
+	reportHTMLTemplate = htmltemplate.Must(htmltemplate.New("report.html.tmpl").ParseFS(reportTemplateFS, "templates/report/report.html.tmpl"))
+)
+
+// ReportOptions что попадает в отчет: задачи проекта (пустой ProjectID —
+// все задачи пользователя), отобранные фильтрами списка задач
+type ReportOptions struct {
+	ProjectID string
+	Filter    TaskListFilter
+}
+
+// reportTask задача в данных шаблона; даты уже отформатированы
+type reportTask struct {
+	ID             string
+	Title          string
+	Done           bool
+	Archived       bool
+	Overdue        bool
+	DueAt          string
+	AssigneeID     string
+	Tags           []string
+	ChecklistDone  int
+	ChecklistTotal int
+}
+
+// reportGroup задачи одного статуса
+type reportGroup struct {
+	Status   entity.TaskStatus
+	Name     string
+	Category entity.StatusCategory
+	Tasks    []reportTask
+}
+
+// reportView данные шаблона отчета
+type reportView struct {
+	Title       string
+	GeneratedAt string
+	Archived    ArchivedFilter
+	Total       int
+	Done        int
+	Groups      []reportGroup
+}
+
+type ReportUseCase struct {
+	taskUseCase    *TaskUseCase
+	projectUseCase *ProjectUseCase
+	logger         *logger.Logger
+}
+
+func NewReportUseCase(taskUseCase *TaskUseCase, projectUseCase *ProjectUseCase, logger *logger.Logger) *ReportUseCase {
+	return &ReportUseCase{
+		taskUseCase:    taskUseCase,
+		projectUseCase: projectUseCase,
+		logger:         logger,
+	}
+}
+
+// RenderReport строит отчет о задачах, сгруппированных по статусам в
+// порядке рабочего процесса; для проекта группы называются и идут как
+// колонки доски. Статусы без задач пропускаются, статусы вне процесса
+// идут последними.
+func (uc *ReportUseCase) RenderReport(ctx context.Context, format ReportFormat, opts ReportOptions) ([]byte, error) {
+	uc.logger.Info("Rendering report", map[string]interface{}{"format": format, "project": opts.ProjectID})
+
+	if format != ReportMarkdown && format != ReportHTML {
+		return nil, errors.New("validation failed: unknown report format " + string(format))
+	}
+
+	tasks, err := uc.taskUseCase.ListTasks(ctx, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err := uc.taskUseCase.GetWorkflow(ctx, workspaceFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	view := reportView{
+		Title:       "Tasks",
+		GeneratedAt: now.UTC().Format("2006-01-02 15:04 UTC"),
+		Archived:    opts.Filter.Archived,
+	}
+
+	var groups []reportGroup
+	for _, status := range workflow.Statuses {
+		groups = append(groups, reportGroup{Status: status.Key, Name: status.Name, Category: status.Category})
+	}
+
+	if opts.ProjectID != "" {
+		project, err := uc.projectUseCase.GetProject(ctx, opts.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		view.Title = project.Name
+
+		var projectTasks []*entity.Task
+		for _, task := range tasks {
+			if task.ProjectID == project.ID {
+				projectTasks = append(projectTasks, task)
+			}
+		}
+		tasks = projectTasks
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Rank < tasks[j].Rank })
+
+		// Колонки доски задают порядок и названия групп
+		var columns []reportGroup
+		for _, column := range project.Columns {
+			group := reportGroup{Status: column.Status, Name: column.Name}
+			if group.Name == "" {
+				group.Name = string(column.Status)
+			}
+			if status, ok := workflow.Status(column.Status); ok {
+				group.Category = status.Category
+			}
+			columns = append(columns, group)
+		}
+		groups = columns
+	} else {
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].CreatedAt.Before(tasks[j].CreatedAt) })
+	}
+
+	index := make(map[entity.TaskStatus]int, len(groups))
+	for i, group := range groups {
+		index[group.Status] = i
+	}
+
+	for _, task := range tasks {
+		i, ok := index[task.Status]
+		if !ok {
+			i = len(groups)
+			index[task.Status] = i
+			groups = append(groups, reportGroup{Status: task.Status, Name: string(task.Status), Category: task.StatusCategory})
+		}
+		groups[i].Tasks = append(groups[i].Tasks, toReportTask(task, now))
+
+		view.Total++
+		if task.IsDone() {
+			view.Done++
+		}
+	}
+
+	for _, group := range groups {
+		if len(group.Tasks) > 0 {
+			view.Groups = append(view.Groups, group)
+		}
+	}
+
+	var buf bytes.Buffer
+	if format == ReportHTML {
+		err = reportHTMLTemplate.Execute(&buf, view)
+	} else {
+		err = reportMarkdownTemplate.Execute(&buf, view)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func toReportTask(task *entity.Task, now time.Time) reportTask {
+	item := reportTask{
+		ID:             task.ID,
+		Title:          task.Title,
+		Done:           task.IsDone(),
+		Archived:       task.ArchivedAt != nil,
+		AssigneeID:     task.AssigneeID,
+		Tags:           task.Tags,
+		ChecklistDone:  task.ChecklistDone,
+		ChecklistTotal: task.ChecklistTotal,
+	}
+
+	if task.DueAt != nil {
+		item.DueAt = task.DueAt.UTC().Format("2006-01-02")
+		item.Overdue = !item.Done && task.DueAt.Before(now)
+	}
+
+	return item
+}
+
+// markdownEscaper экранирует символы, которые Markdown понял бы как
+// разметку внутри строки списка
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+// escapeMarkdown готовит текст к выводу в строке Markdown: экранирует
+// разметку и заменяет переводы строк пробелами
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(text), " "))
+}
+
+// markdownCode готовит текст к выводу в `коде`: обратные кавычки в нем
+// экранировать нельзя, поэтому они заменяются
+func markdownCode(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "`", "'")
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"strings"
+	"testing"
+)
+
+func TestReportEscapesUserText(t *testing.T) {
+	env := newTestEnv(t)
+	projects := NewProjectUseCase(env.tasks, db.NewProjectRepository(), env.taskRepo, env.logger)
+	reports := NewReportUseCase(env.tasks, projects, env.logger)
+	ctx := userContext("user-123")
+
+	project := &entity.Project{Name: "Site <b>v2</b>"}
+	if err := projects.CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	for _, task := range []*entity.Task{
+		{Title: "Fix **bold** [link](http://evil) <script>alert(1)</script>\n# heading"},
+		{Title: "Ship `it`", AssigneeID: "user-456", Status: entity.StatusDone},
+	} {
+		if err := projects.CreateProjectTask(ctx, project.ID, task); err != nil {
+			t.Fatalf("create project task: %v", err)
+		}
+	}
+
+	markdown, err := reports.RenderReport(ctx, ReportMarkdown, ReportOptions{ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("render markdown: %v", err)
+	}
+	for _, want := range []string{
+		`# Site \<b\>v2\</b\>`,
+		`- [ ] Fix \*\*bold\*\* \[link\](http://evil) \<script\>alert(1)\</script\> \# heading`,
+		"- [x] Ship \\`it\\` · assignee `user-456`",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("markdown does not contain %q:\n%s", want, markdown)
+		}
+	}
+
+	html, err := reports.RenderReport(ctx, ReportHTML, ReportOptions{ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("render html: %v", err)
+	}
+	for _, unwanted := range []string{"<script>", "<b>v2"} {
+		if strings.Contains(string(html), unwanted) {
+			t.Errorf("html contains unescaped %q:\n%s", unwanted, html)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;alert(1)&lt;/script&gt;", "Site &lt;b&gt;v2&lt;/b&gt;"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("html does not contain %q:\n%s", want, html)
+		}
+	}
+
+	if _, err := reports.RenderReport(ctx, "pdf", ReportOptions{}); err == nil || !strings.Contains(err.Error(), "unknown report format") {
+		t.Fatalf("render pdf: %v, want validation error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// searchDocument проиндексированная задача
+type searchDocument struct {
+	task     *entity.Task
+	sequence int64 // номер последнего примененного события задачи
+	tokens   []string
+}
+
+// SearchUseCase полнотекстовый поиск по названию и описанию задач.
+// Индекс в памяти строится из доменных событий задач.
+type SearchUseCase struct {
+	logger    *logger.Logger
+	documents map[string]*searchDocument
+	index     map[string]map[string]struct{} // слово -> ID задач
+	mutex     sync.RWMutex
+}
+
+func NewSearchUseCase(logger *logger.Logger) *SearchUseCase {
+	return &SearchUseCase{
+		logger:    logger,
+		documents: make(map[string]*searchDocument),
+		index:     make(map[string]map[string]struct{}),
+	}
+}
+
+// HandleTaskEvent обновляет индекс. Повторные и устаревшие события
+// (с номером не больше уже примененного) пропускаются.
+func (uc *SearchUseCase) HandleTaskEvent(ctx context.Context, event *entity.DomainEvent) error {
+	uc.mutex.Lock()
+	defer uc.mutex.Unlock()
+
+	existing, indexed := uc.documents[event.TaskID]
+	if indexed && existing.sequence >= event.Sequence {
+		return nil
+	}
+
+	if indexed {
+		uc.unindex(event.TaskID, existing.tokens)
+	}
+
+	// Задачи в корзине не ищутся
+	if event.Type == entity.TaskDeletedEvent || event.Task.IsTrashed() {
+		// Номер запоминается, чтобы опоздавшее событие не вернуло задачу в индекс
+		uc.documents[event.TaskID] = &searchDocument{sequence: event.Sequence}
+		return nil
+	}
+
+	document := &searchDocument{
+		task:     event.Task,
+		sequence: event.Sequence,
+		tokens:   tokenize(event.Task.Title + " " + event.Task.Description),
+	}
+	uc.documents[event.TaskID] = document
+
+	for _, token := range document.tokens {
+		if uc.index[token] == nil {
+			uc.index[token] = make(map[string]struct{})
+		}
+		uc.index[token][event.TaskID] = struct{}{}
+	}
+
+	return nil
+}
+
+// Search возвращает задачи пользователя, содержащие все слова запроса,
+// недавно измененные первыми
+func (uc *SearchUseCase) Search(ctx context.Context, query string) ([]*entity.Task, error) {
+	uc.logger.Info("Searching tasks", map[string]interface{}{"query": query})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	tokens := tokenize(query)
+	if len(tokens) == 0 {
+		return nil, errors.New("validation failed: query is required")
+	}
+
+	uc.mutex.RLock()
+	defer uc.mutex.RUnlock()
+
+	var result []*entity.Task
+
+	for id := range uc.index[tokens[0]] {
+		task := uc.documents[id].task
+		if task.UserID != userID {
+			continue
+		}
+
+		matched := true
+		for _, token := range tokens[1:] {
+			if _, ok := uc.index[token][id]; !ok {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			clone := *task
+			result = append(result, &clone)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+
+	return result, nil
+}
+
+func (uc *SearchUseCase) unindex(id string, tokens []string) {
+	for _, token := range tokens {
+		delete(uc.index[token], id)
+		if len(uc.index[token]) == 0 {
+			delete(uc.index, token)
+		}
+	}
+}
+
+// tokenize разбивает текст на уникальные слова в нижнем регистре
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// ArchivedFilter выбор архивных задач в списке
+type ArchivedFilter string
+
+const (
+	// ArchivedExclude только активные задачи (по умолчанию)
+	ArchivedExclude ArchivedFilter = ""
+	// ArchivedInclude активные и архивные задачи
+	ArchivedInclude ArchivedFilter = "include"
+	// ArchivedOnly только архивные задачи
+	ArchivedOnly ArchivedFilter = "only"
+)
+
+// TaskListFilter фильтры списка задач пользователя
+type TaskListFilter struct {
+	Archived ArchivedFilter
+}
+
+// ListTasks возвращает задачи пользователя, отобранные фильтром
+func (uc *TaskUseCase) ListTasks(ctx context.Context, filter TaskListFilter) ([]*entity.Task, error) {
+	switch filter.Archived {
+	case ArchivedExclude:
+		return uc.GetAllTasks(ctx)
+	case ArchivedOnly:
+		return uc.GetArchivedTasks(ctx)
+	case ArchivedInclude:
+		tasks, err := uc.GetAllTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		archived, err := uc.GetArchivedTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return append(tasks, archived...), nil
+	}
+	return nil, errors.New("validation failed: invalid archived filter " + string(filter.Archived))
+}
+
+// GetArchivedTasks возвращает архивные задачи пользователя, недавно
+// архивированные первыми
+func (uc *TaskUseCase) GetArchivedTasks(ctx context.Context) ([]*entity.Task, error) {
+	uc.logger.Info("Getting archived tasks", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.repo.GetArchived(ctx, userID)
+}
+
+// ArchiveTask переносит задачу в архив. Статус и подзадачи не меняются:
+// архивная задача по-прежнему доступна по ID и в дереве родителя, но не
+// попадает в списки задач и на доски проектов.
+func (uc *TaskUseCase) ArchiveTask(ctx context.Context, id string) (*entity.Task, error) {
+	uc.logger.Info("Archiving task", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.IsArchived() {
+		return nil, errors.New("task is already archived")
+	}
+
+	now := time.Now()
+	if err := uc.setArchived(ctx, task, &now); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// UnarchiveTask возвращает задачу из архива
+func (uc *TaskUseCase) UnarchiveTask(ctx context.Context, id string) (*entity.Task, error) {
+	uc.logger.Info("Unarchiving task", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.IsArchived() {
+		return nil, errors.New("task is not archived")
+	}
+
+	if err := uc.setArchived(ctx, task, nil); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// setArchived сохраняет время архивации задачи; nil возвращает её из архива
+func (uc *TaskUseCase) setArchived(ctx context.Context, task *entity.Task, at *time.Time) error {
+	eventType := entity.TaskArchivedEvent
+	if at == nil {
+		eventType = entity.TaskUnarchivedEvent
+	}
+
+	task.ArchivedAt = at
+	_, err := uc.persistChange(ctx, eventType, task.ID, func(ctx context.Context) error {
+		return uc.repo.Update(ctx, task)
+	})
+	return err
+}
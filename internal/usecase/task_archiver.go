@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+// ArchiveConfig настройки автоматической архивации
+type ArchiveConfig struct {
+	// After сколько задача должна пробыть завершенной до архивации;
+	// 0 отключает автоматическую архивацию
+	After    time.Duration
+	Interval time.Duration
+}
+
+// TaskArchiver фоновая архивация задач, завершенных дольше заданного срока
+type TaskArchiver struct {
+	taskUseCase *TaskUseCase
+	taskRepo    repository.TaskRepository
+	logger      *logger.Logger
+	config      ArchiveConfig
+}
+
+func NewTaskArchiver(taskUseCase *TaskUseCase, taskRepo repository.TaskRepository, logger *logger.Logger, config ArchiveConfig) *TaskArchiver {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &TaskArchiver{
+		taskUseCase: taskUseCase,
+		taskRepo:    taskRepo,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+// Run архивирует задачи сразу и затем с заданным интервалом,
+// пока не отменен контекст
+func (a *TaskArchiver) Run(ctx context.Context) {
+	if a.config.After <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick архивирует задачи, завершенные раньше now минус срок After
+func (a *TaskArchiver) Tick(ctx context.Context, now time.Time) {
+	tasks, err := a.taskRepo.GetCompletedBefore(ctx, now.Add(-a.config.After))
+	if err != nil {
+		a.logger.Error("Failed to load completed tasks", err, nil)
+		return
+	}
+
+	for _, task := range tasks {
+		// Действия выполняются от имени владельца задачи в её пространстве
+		ownerCtx := context.WithValue(ctx, "user_id", task.UserID)
+		ownerCtx = context.WithValue(ownerCtx, "workspace_id", task.WorkspaceID)
+
+		if _, err := a.taskUseCase.ArchiveTask(ownerCtx, task.ID); err != nil {
+			a.logger.Error("Failed to archive completed task", err, map[string]interface{}{"id": task.ID})
+			continue
+		}
+
+		a.logger.Info("Completed task archived", map[string]interface{}{"id": task.ID})
+	}
+}
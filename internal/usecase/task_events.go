@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// Все изменения задач проходят через persist*: запись в хранилище и событие
+// в outbox фиксируются в одной транзакции, поэтому подписчики узнают о
+// каждом изменении и только о зафиксированных.
+
+// persistCreate сохраняет новую задачу и событие task.created
+func (uc *TaskUseCase) persistCreate(ctx context.Context, task *entity.Task) error {
+	return uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, task); err != nil {
+			return err
+		}
+
+		// Серия повторений начинается с первого экземпляра
+		if task.Recurrence != nil && task.SeriesID == "" {
+			task.SeriesID = task.ID
+			if err := uc.repo.Update(ctx, task); err != nil {
+				return err
+			}
+		}
+
+		return uc.outboxRepo.Append(ctx, uc.newTaskEvent(ctx, entity.TaskCreatedEvent, task, nil))
+	})
+}
+
+// persistUpdate выполняет изменение задачи write и сохраняет событие
+// task.updated с состояниями до и после; возвращает состояние до изменения
+func (uc *TaskUseCase) persistUpdate(ctx context.Context, id string, write func(ctx context.Context) error) (*entity.Task, error) {
+	return uc.persistChange(ctx, entity.TaskUpdatedEvent, id, write)
+}
+
+// persistChange как persistUpdate, но с событием типа eventType
+// (например, перенос в корзину)
+func (uc *TaskUseCase) persistChange(ctx context.Context, eventType entity.DomainEventType, id string, write func(ctx context.Context) error) (*entity.Task, error) {
+	var previous *entity.Task
+
+	err := uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if previous, err = uc.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := write(ctx); err != nil {
+			return err
+		}
+
+		current, err := uc.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return uc.outboxRepo.Append(ctx, uc.newTaskEvent(ctx, eventType, current, previous))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// persistDelete окончательно удаляет задачу и сохраняет событие task.deleted
+func (uc *TaskUseCase) persistDelete(ctx context.Context, task *entity.Task) error {
+	return uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Delete(ctx, task.ID); err != nil {
+			return err
+		}
+
+		return uc.outboxRepo.Append(ctx, uc.newTaskEvent(ctx, entity.TaskDeletedEvent, task, nil))
+	})
+}
+
+// restoredFromKey помечает в контексте изменение, возвращающее задачу к ревизии
+type restoredFromKey struct{}
+
+type restoredFrom struct {
+	taskID   string
+	revision int64
+}
+
+// withRestoredFrom помечает изменение задачи как восстановление ревизии;
+// изменения других задач в том же контексте остаются обычными
+func withRestoredFrom(ctx context.Context, taskID string, revision int64) context.Context {
+	return context.WithValue(ctx, restoredFromKey{}, restoredFrom{taskID: taskID, revision: revision})
+}
+
+// fromBackupKey помечает в контексте изменения, сделанные восстановлением
+// резервной копии
+type fromBackupKey struct{}
+
+// withFromBackup помечает события всех изменений в контексте как
+// восстановление резервной копии
+func withFromBackup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fromBackupKey{}, true)
+}
+
+// recategorizedKey помечает в контексте перенос категорий статусов после
+// изменения рабочего процесса
+type recategorizedKey struct{}
+
+// withRecategorized помечает события всех изменений в контексте как перенос
+// категорий статусов
+func withRecategorized(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recategorizedKey{}, true)
+}
+
+func (uc *TaskUseCase) newTaskEvent(ctx context.Context, eventType entity.DomainEventType, task, previous *entity.Task) *entity.DomainEvent {
+	actorID, _ := ctx.Value("user_id").(string)
+
+	workspaceID := task.WorkspaceID
+	if workspaceID == "" {
+		workspaceID = workspaceFromContext(ctx)
+	}
+
+	event := &entity.DomainEvent{
+		Type:        eventType,
+		TaskID:      task.ID,
+		WorkspaceID: workspaceID,
+		ActorID:     actorID,
+		OccurredAt:  time.Now(),
+		Task:        task,
+		Previous:    previous,
+	}
+
+	if restored, ok := ctx.Value(restoredFromKey{}).(restoredFrom); ok && restored.taskID == task.ID && eventType == entity.TaskUpdatedEvent {
+		event.RestoredFrom = restored.revision
+	}
+	event.FromBackup, _ = ctx.Value(fromBackupKey{}).(bool)
+	event.Recategorized, _ = ctx.Value(recategorizedKey{}).(bool)
+
+	return event
+}
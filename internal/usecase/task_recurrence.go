@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// maxOccurrencesPreview ограничивает число вхождений в предпросмотре расписания
+const maxOccurrencesPreview = 100
+
+// OccurrencePreview запланированное вхождение повторяющейся задачи
+type OccurrencePreview struct {
+	Index int
+	At    time.Time
+}
+
+// prepareRecurrence дополняет правило повторения значениями по умолчанию:
+// начало серии берется из срока задачи или текущего времени, а срок —
+// из ближайшего вхождения, если он не задан явно
+func (uc *TaskUseCase) prepareRecurrence(task *entity.Task) error {
+	if task.Recurrence == nil {
+		return nil
+	}
+
+	if task.Recurrence.Start.IsZero() {
+		if task.DueAt != nil {
+			task.Recurrence.Start = *task.DueAt
+		} else {
+			task.Recurrence.Start = time.Now().Truncate(time.Minute)
+		}
+	}
+
+	if err := task.Recurrence.Validate(); err != nil {
+		return errors.New("validation failed: invalid recurrence: " + err.Error())
+	}
+
+	if task.DueAt == nil {
+		from := task.Recurrence.Start
+		if now := time.Now(); now.After(from) {
+			from = now
+		}
+
+		at, index, err := task.Recurrence.Next(from.Add(-time.Nanosecond))
+		if err != nil {
+			return errors.New("validation failed: invalid recurrence: " + err.Error())
+		}
+		if index == 0 {
+			return errors.New("validation failed: recurrence has no upcoming occurrences")
+		}
+
+		task.DueAt = &at
+		task.Occurrence = index
+	}
+
+	if task.Occurrence == 0 {
+		task.Occurrence = 1
+	}
+
+	// Серия идентифицируется первым экземпляром; у новой задачи ID
+	// появляется только после сохранения, см. CreateTask
+	if task.SeriesID == "" {
+		task.SeriesID = task.ID
+	}
+
+	return nil
+}
+
+// GetOccurrences возвращает ближайшие вхождения повторяющейся задачи,
+// начиная с её текущего срока
+func (uc *TaskUseCase) GetOccurrences(ctx context.Context, id string, limit int) ([]OccurrencePreview, error) {
+	uc.logger.Info("Getting task occurrences", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Recurrence == nil || task.DueAt == nil {
+		return []OccurrencePreview{}, nil
+	}
+
+	if limit <= 0 || limit > maxOccurrencesPreview {
+		limit = maxOccurrencesPreview
+	}
+
+	result := []OccurrencePreview{}
+	after := task.DueAt.Add(-time.Nanosecond)
+	known, knownIndex := *task.DueAt, task.Occurrence
+	for len(result) < limit {
+		at, index, err := task.Recurrence.NextFrom(after, known, knownIndex)
+		if err != nil {
+			return nil, err
+		}
+		if index == 0 {
+			break
+		}
+
+		result = append(result, OccurrencePreview{Index: index, At: at})
+		after, known, knownIndex = at, at, index
+	}
+
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// GetTrash возвращает задачи пользователя в корзине, недавно удаленные первыми
+func (uc *TaskUseCase) GetTrash(ctx context.Context) ([]*entity.Task, error) {
+	uc.logger.Info("Getting trash", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.repo.GetTrashed(ctx, userID)
+}
+
+// GetTrashedTask возвращает задачу из корзины
+func (uc *TaskUseCase) GetTrashedTask(ctx context.Context, id string) (*entity.Task, error) {
+	uc.logger.Info("Getting trashed task", map[string]interface{}{"id": id})
+
+	task, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	if task.UserID != userID {
+		return nil, errors.New("access denied")
+	}
+
+	if !task.IsTrashed() {
+		return nil, errors.New("task not found in trash")
+	}
+
+	return task, nil
+}
+
+// RestoreTask возвращает задачу из корзины вместе с подзадачами, удаленными
+// вместе с ней. Если родитель задачи остался в корзине или удален
+// окончательно, задача восстанавливается корневой.
+func (uc *TaskUseCase) RestoreTask(ctx context.Context, id string) (*entity.Task, error) {
+	uc.logger.Info("Restoring task from trash", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTrashedTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := uc.repo.GetTrashed(ctx, task.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := *task.DeletedAt
+	err = uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.untrashTask(ctx, task); err != nil {
+			return err
+		}
+
+		return uc.untrashSubtasks(ctx, task.ID, deletedAt, trashed)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.repo.GetByID(ctx, id)
+}
+
+// PurgeTask окончательно удаляет задачу из корзины вместе с её подзадачами,
+// которые тоже находятся в корзине
+func (uc *TaskUseCase) PurgeTask(ctx context.Context, id string) error {
+	uc.logger.Info("Purging task", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTrashedTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	trashed, err := uc.repo.GetTrashed(ctx, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	return uc.purgeTask(ctx, task, trashed)
+}
+
+// EmptyTrash окончательно удаляет все задачи пользователя из корзины
+func (uc *TaskUseCase) EmptyTrash(ctx context.Context) error {
+	uc.logger.Info("Emptying trash", nil)
+
+	trashed, err := uc.GetTrash(ctx)
+	if err != nil {
+		return err
+	}
+
+	inTrash := make(map[string]bool)
+	for _, task := range trashed {
+		inTrash[task.ID] = true
+	}
+
+	// Подзадачи удаляются вместе с родителем из корзины
+	for _, task := range trashed {
+		if inTrash[task.ParentID] {
+			continue
+		}
+		if err := uc.purgeTask(ctx, task, trashed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// untrashTask возвращает задачу из корзины
+func (uc *TaskUseCase) untrashTask(ctx context.Context, task *entity.Task) error {
+	if task.ParentID != "" {
+		parent, err := uc.repo.GetByID(ctx, task.ParentID)
+		if err != nil || parent.IsTrashed() {
+			task.ParentID = ""
+		}
+	}
+
+	task.DeletedAt = nil
+	task.DeletedBy = ""
+
+	_, err := uc.persistChange(ctx, entity.TaskUntrashedEvent, task.ID, func(ctx context.Context) error {
+		return uc.repo.Update(ctx, task)
+	})
+	return err
+}
+
+// untrashSubtasks возвращает из корзины поддерево задачи parentID,
+// перенесенное в корзину в момент at
+func (uc *TaskUseCase) untrashSubtasks(ctx context.Context, parentID string, at time.Time, trashed []*entity.Task) error {
+	for _, child := range trashed {
+		if child.ParentID != parentID || !child.DeletedAt.Equal(at) {
+			continue
+		}
+
+		if err := uc.untrashTask(ctx, child); err != nil {
+			return err
+		}
+		if err := uc.untrashSubtasks(ctx, child.ID, at, trashed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// purgeTask окончательно удаляет задачу и её подзадачи из trashed
+func (uc *TaskUseCase) purgeTask(ctx context.Context, task *entity.Task, trashed []*entity.Task) error {
+	for _, child := range trashed {
+		if child.ParentID != task.ID {
+			continue
+		}
+
+		if err := uc.purgeTask(ctx, child, trashed); err != nil {
+			return err
+		}
+	}
+
+	return uc.deleteTask(ctx, task)
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"testing"
+	"time"
+)
+
+func TestRestoreTaskReturnsSubtreeTrashedWithIt(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	root := env.createTask(t, ctx, &entity.Task{Title: "Release"})
+	docs := env.createTask(t, ctx, &entity.Task{Title: "Docs", ParentID: root.ID})
+	blog := env.createTask(t, ctx, &entity.Task{Title: "Blog post", ParentID: root.ID})
+
+	// Блог удален отдельно и раньше, остальное дерево — вместе с корнем
+	if err := env.tasks.DeleteTask(ctx, blog.ID); err != nil {
+		t.Fatalf("delete blog: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := env.tasks.DeleteTask(ctx, root.ID); err != nil {
+		t.Fatalf("delete root: %v", err)
+	}
+
+	if trash, err := env.tasks.GetTrash(ctx); err != nil || len(trash) != 3 {
+		t.Fatalf("trash = %d tasks, %v; want 3", len(trash), err)
+	}
+
+	// Родитель в корзине, поэтому подзадача восстанавливается корневой
+	restored, err := env.tasks.RestoreTask(ctx, blog.ID)
+	if err != nil {
+		t.Fatalf("restore blog: %v", err)
+	}
+	if restored.IsTrashed() || restored.ParentID != "" {
+		t.Fatalf("restored blog: trashed %v, parent %q; want active root task", restored.IsTrashed(), restored.ParentID)
+	}
+
+	if _, err := env.tasks.RestoreTask(ctx, root.ID); err != nil {
+		t.Fatalf("restore root: %v", err)
+	}
+	current, err := env.tasks.GetTask(ctx, docs.ID)
+	if err != nil {
+		t.Fatalf("subtask trashed with root is not restored: %v", err)
+	}
+	if current.ParentID != root.ID {
+		t.Fatalf("docs parent = %q, want %q", current.ParentID, root.ID)
+	}
+
+	if trash, err := env.tasks.GetTrash(ctx); err != nil || len(trash) != 0 {
+		t.Fatalf("trash = %d tasks, %v; want empty", len(trash), err)
+	}
+
+	// Восстановить можно только задачу из корзины
+	if _, err := env.tasks.RestoreTask(ctx, root.ID); err == nil {
+		t.Fatalf("restored an active task")
+	}
+}
+
+func TestPurgeTaskDeletesSubtreeAndRunsHooks(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+
+	var purged []string
+	env.tasks.RegisterDeleteHook(func(ctx context.Context, task *entity.Task) {
+		purged = append(purged, task.Title)
+	})
+
+	parent := env.createTask(t, ctx, &entity.Task{Title: "Parent"})
+	child := env.createTask(t, ctx, &entity.Task{Title: "Child", ParentID: parent.ID})
+	kept := env.createTask(t, ctx, &entity.Task{Title: "Kept"})
+
+	if err := env.tasks.DeleteTask(ctx, parent.ID); err != nil {
+		t.Fatalf("delete parent: %v", err)
+	}
+
+	// Окончательно удалить можно только задачу из корзины
+	if err := env.tasks.PurgeTask(ctx, kept.ID); err == nil {
+		t.Fatalf("purged an active task")
+	}
+
+	if err := env.tasks.PurgeTask(ctx, parent.ID); err != nil {
+		t.Fatalf("purge parent: %v", err)
+	}
+
+	for _, id := range []string{parent.ID, child.ID} {
+		if _, err := env.taskRepo.GetByID(ctx, id); err == nil {
+			t.Fatalf("task %s still exists after purge", id)
+		}
+	}
+	if len(purged) != 2 {
+		t.Fatalf("delete hooks ran for %v, want parent and child", purged)
+	}
+}
+
+func TestTrashPurgerRemovesExpiredTasks(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := userContext("user-123")
+	purger := NewTrashPurger(env.tasks, env.taskRepo, env.logger, TrashConfig{Retention: 24 * time.Hour})
+
+	task := env.createTask(t, ctx, &entity.Task{Title: "Old idea"})
+	if err := env.tasks.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+
+	// Срок хранения не истек
+	now := time.Now()
+	purger.Tick(context.Background(), now.Add(23*time.Hour))
+	if _, err := env.tasks.GetTrashedTask(ctx, task.ID); err != nil {
+		t.Fatalf("task purged before retention expired: %v", err)
+	}
+
+	purger.Tick(context.Background(), now.Add(25*time.Hour))
+	if _, err := env.taskRepo.GetByID(ctx, task.ID); err == nil {
+		t.Fatalf("task still exists after retention expired")
+	}
+}
@@ -6,23 +6,98 @@ import (
 	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
 	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
 	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
 )
 
+// SubtaskDeletePolicy определяет, что происходит с подзадачами при удалении родителя
+type SubtaskDeletePolicy string
+
+const (
+	// DeletePolicyCascade переносит в корзину все поддерево вместе с родителем
+	DeletePolicyCascade SubtaskDeletePolicy = "cascade"
+	// DeletePolicyReparent переносит подзадачи к родителю удаляемой задачи
+	DeletePolicyReparent SubtaskDeletePolicy = "reparent"
+	// DeletePolicyOrphan делает подзадачи корневыми задачами
+	DeletePolicyOrphan SubtaskDeletePolicy = "orphan"
+	// DeletePolicyRestrict запрещает удаление задачи, у которой есть подзадачи
+	DeletePolicyRestrict SubtaskDeletePolicy = "restrict"
+)
+
+// TaskUseCaseConfig содержит настройки бизнес-логики задач
+type TaskUseCaseConfig struct {
+	SubtaskDeletePolicy SubtaskDeletePolicy
+	// BlockDoneByDependencies запрещает переводить задачу в DONE,
+	// пока не завершены все блокирующие её задачи
+	BlockDoneByDependencies bool
+	// DoneGuards условия перехода, проверяемые при любом переходе в статус
+	// категории done независимо от рабочего процесса пространства
+	DoneGuards []string
+}
+
+// TaskDeleteHook действие, выполняемое после окончательного удаления задачи;
+// через него связанные с задачей данные (комментарии, вложения) удаляются
+// вместе с ней. Пока задача в корзине, её данные сохраняются.
+type TaskDeleteHook func(ctx context.Context, task *entity.Task)
+
 type TaskUseCase struct {
-	repo   repository.TaskRepository
-	logger *logger.Logger
+	repo         repository.TaskRepository
+	depsRepo     repository.DependencyRepository
+	workflowRepo repository.WorkflowRepository
+	userRepo     repository.UserRepository
+	outboxRepo   repository.OutboxRepository
+	logger       *logger.Logger
+	config       TaskUseCaseConfig
+	guards       map[string]TransitionGuard
+	hooks        map[string]TransitionHook
+	deleteHooks  []TaskDeleteHook
 }
 
-func NewTaskUseCase(repo repository.TaskRepository, logger *logger.Logger) *TaskUseCase {
-	return &TaskUseCase{
-		repo:   repo,
-		logger: logger,
+func NewTaskUseCase(repo repository.TaskRepository, depsRepo repository.DependencyRepository, workflowRepo repository.WorkflowRepository, userRepo repository.UserRepository, outboxRepo repository.OutboxRepository, logger *logger.Logger, config TaskUseCaseConfig) *TaskUseCase {
+	if config.SubtaskDeletePolicy == "" {
+		config.SubtaskDeletePolicy = DeletePolicyCascade
 	}
+
+	uc := &TaskUseCase{
+		repo:         repo,
+		depsRepo:     depsRepo,
+		workflowRepo: workflowRepo,
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		logger:       logger,
+		config:       config,
+		guards:       make(map[string]TransitionGuard),
+		hooks:        make(map[string]TransitionHook),
+	}
+	uc.registerBuiltinTransitionRules()
+
+	return uc
 }
 
 func (uc *TaskUseCase) CreateTask(ctx context.Context, task *entity.Task) error {
 	uc.logger.Info("Creating task", map[string]interface{}{"title": task.Title})
 
+	if err := uc.ValidateNewTask(ctx, task); err != nil {
+		return err
+	}
+
+	return uc.persistCreate(ctx, task)
+}
+
+// ValidateNewTask проверяет новую задачу по тем же правилам, что и
+// CreateTask, и так же дополняет её (владелец, статус по умолчанию), но не
+// сохраняет. Нужна для предварительной проверки, например при импорте.
+func (uc *TaskUseCase) ValidateNewTask(ctx context.Context, task *entity.Task) error {
+	// Статус по умолчанию берется из рабочего процесса, поэтому
+	// определяется до валидации
+	task.WorkspaceID = workspaceFromContext(ctx)
+	if err := uc.applyInitialStatus(ctx, task); err != nil {
+		return err
+	}
+
+	if err := uc.prepareRecurrence(task); err != nil {
+		return err
+	}
+
 	if errMsgs := task.Validate(); len(errMsgs) > 0 {
 		return errors.New("validation failed: " + errMsgs[0])
 	}
@@ -34,8 +109,67 @@ func (uc *TaskUseCase) CreateTask(ctx context.Context, task *entity.Task) error
 	}
 
 	task.UserID = userID
+	// В архив и корзину задача попадает только через ArchiveTask и DeleteTask
+	task.ArchivedAt = nil
+	task.DeletedAt = nil
+	task.DeletedBy = ""
+
+	if err := uc.checkParent(ctx, task); err != nil {
+		return err
+	}
+
+	return uc.checkAssignee(ctx, task)
+}
+
+// ImportTask сохраняет задачу из резервной копии в прежнем состоянии: со
+// статусом, временем завершения, архивации и удаления и исходным временем
+// создания. Статус проверяется по рабочему процессу пространства задачи,
+// но переходы и их условия не применяются. Владельцем становится текущий
+// пользователь; пустое пространство означает текущее.
+func (uc *TaskUseCase) ImportTask(ctx context.Context, task *entity.Task) error {
+	uc.logger.Info("Importing task", map[string]interface{}{"id": task.ID, "title": task.Title})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	task.UserID = userID
+	if task.WorkspaceID == "" {
+		task.WorkspaceID = workspaceFromContext(ctx)
+	}
+
+	if errMsgs := task.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	workflow, err := uc.GetWorkflow(ctx, task.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	status, ok := workflow.Status(task.Status)
+	if !ok {
+		return errors.New("validation failed: status " + string(task.Status) + " is not defined in workflow")
+	}
+	task.StatusCategory = status.Category
 
-	return uc.repo.Create(ctx, task)
+	createdAt := task.CreatedAt
+	return uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, task); err != nil {
+			return err
+		}
+
+		// Хранилище назначает время создания само; исходное возвращается
+		// изменением в той же транзакции
+		if !createdAt.IsZero() {
+			task.CreatedAt = createdAt
+			if err := uc.repo.Update(ctx, task); err != nil {
+				return err
+			}
+		}
+
+		return uc.outboxRepo.Append(ctx, uc.newTaskEvent(ctx, entity.TaskCreatedEvent, task, nil))
+	})
 }
 
 func (uc *TaskUseCase) GetTask(ctx context.Context, id string) (*entity.Task, error) {
@@ -46,6 +180,11 @@ func (uc *TaskUseCase) GetTask(ctx context.Context, id string) (*entity.Task, er
 		return nil, err
 	}
 
+	// Задача в корзине доступна только через корзину
+	if task.IsTrashed() {
+		return nil, errors.New("task not found")
+	}
+
 	// Проверка прав доступа
 	userID, ok := ctx.Value("user_id").(string)
 	if !ok || userID == "" {
@@ -70,6 +209,75 @@ func (uc *TaskUseCase) GetAllTasks(ctx context.Context) ([]*entity.Task, error)
 	return uc.repo.GetAll(ctx, userID)
 }
 
+// EachTask вызывает fn для каждой активной задачи пользователя, читая их из
+// хранилища страницами по pageSize, чтобы не держать в памяти все задачи
+// сразу. Прерывается ошибкой fn или отменой контекста.
+func (uc *TaskUseCase) EachTask(ctx context.Context, pageSize int, fn func(task *entity.Task) error) error {
+	uc.logger.Info("Iterating tasks", map[string]interface{}{"page_size": pageSize})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	if pageSize <= 0 {
+		pageSize = 500
+	}
+
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		tasks, err := uc.repo.GetPage(ctx, userID, after, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+
+		if len(tasks) < pageSize {
+			return nil
+		}
+		after = tasks[len(tasks)-1].ID
+	}
+}
+
+// GetSubtasks возвращает непосредственные подзадачи задачи
+func (uc *TaskUseCase) GetSubtasks(ctx context.Context, id string) ([]*entity.Task, error) {
+	uc.logger.Info("Getting subtasks", map[string]interface{}{"id": id})
+
+	if _, err := uc.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.GetChildren(ctx, id)
+}
+
+// GetTaskTree возвращает задачу со всем поддеревом и рассчитанным прогрессом
+func (uc *TaskUseCase) GetTaskTree(ctx context.Context, id string) (*entity.TaskNode, error) {
+	uc.logger.Info("Getting task tree", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := uc.buildTree(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	root.CalculateProgress()
+
+	return root, nil
+}
+
 func (uc *TaskUseCase) UpdateTask(ctx context.Context, task *entity.Task) error {
 	uc.logger.Info("Updating task", map[string]interface{}{"id": task.ID})
 
@@ -88,15 +296,59 @@ func (uc *TaskUseCase) UpdateTask(ctx context.Context, task *entity.Task) error
 		return err
 	}
 
+	if existingTask.IsTrashed() {
+		return errors.New("task not found")
+	}
+
 	if existingTask.UserID != userID {
 		return errors.New("access denied")
 	}
 
 	task.UserID = userID // Сохраняем оригинального владельца
+	task.WorkspaceID = existingTask.WorkspaceID
+	// Счетчики чек-листа меняются только через чек-лист
+	task.ChecklistDone = existingTask.ChecklistDone
+	task.ChecklistTotal = existingTask.ChecklistTotal
+	// Принадлежность к серии повторений ведет планировщик
+	task.SeriesID = existingTask.SeriesID
+	task.Occurrence = existingTask.Occurrence
+	// Архивация меняется только через ArchiveTask и UnarchiveTask
+	task.ArchivedAt = existingTask.ArchivedAt
+	task.DeletedAt = nil
+	task.DeletedBy = ""
 
-	return uc.repo.Update(ctx, task)
+	if err := uc.prepareRecurrence(task); err != nil {
+		return err
+	}
+
+	if err := uc.checkParent(ctx, task); err != nil {
+		return err
+	}
+
+	if err := uc.checkAssignee(ctx, task); err != nil {
+		return err
+	}
+
+	// Смена статуса подчиняется рабочему процессу пространства
+	transition, err := uc.applyTransition(ctx, existingTask, task)
+	if err != nil {
+		return err
+	}
+
+	_, err = uc.persistUpdate(ctx, task.ID, func(ctx context.Context) error {
+		return uc.repo.Update(ctx, task)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.runTransitionHooks(ctx, transition, task, existingTask.Status)
+
+	return nil
 }
 
+// DeleteTask переносит задачу в корзину; подзадачи обрабатываются по
+// политике удаления. Окончательно задача удаляется из корзины.
 func (uc *TaskUseCase) DeleteTask(ctx context.Context, id string) error {
 	uc.logger.Info("Deleting task", map[string]interface{}{"id": id})
 
@@ -111,9 +363,208 @@ func (uc *TaskUseCase) DeleteTask(ctx context.Context, id string) error {
 		return err
 	}
 
+	if existingTask.IsTrashed() {
+		return errors.New("task not found")
+	}
+
 	if existingTask.UserID != userID {
 		return errors.New("access denied")
 	}
 
-	return uc.repo.Delete(ctx, id)
+	// Поддерево переносится в корзину целиком и с одним временем удаления,
+	// по которому потом восстанавливается вместе с задачей
+	now := time.Now()
+	return uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.handleSubtasksOnDelete(ctx, existingTask, now); err != nil {
+			return err
+		}
+
+		return uc.trashTask(ctx, existingTask, now)
+	})
+}
+
+// RegisterDeleteHook регистрирует действие после окончательного удаления задачи.
+// Вызывается при старте приложения.
+func (uc *TaskUseCase) RegisterDeleteHook(hook TaskDeleteHook) {
+	uc.deleteHooks = append(uc.deleteHooks, hook)
+}
+
+// trashTask переносит задачу в корзину
+func (uc *TaskUseCase) trashTask(ctx context.Context, task *entity.Task, at time.Time) error {
+	userID, _ := ctx.Value("user_id").(string)
+	task.DeletedAt = &at
+	task.DeletedBy = userID
+
+	_, err := uc.persistChange(ctx, entity.TaskTrashedEvent, task.ID, func(ctx context.Context) error {
+		return uc.repo.Update(ctx, task)
+	})
+	return err
+}
+
+// deleteTask окончательно удаляет задачу вместе с её связями-зависимостями
+func (uc *TaskUseCase) deleteTask(ctx context.Context, task *entity.Task) error {
+	if err := uc.persistDelete(ctx, task); err != nil {
+		return err
+	}
+
+	if err := uc.depsRepo.RemoveAllForTask(ctx, task.ID); err != nil {
+		return err
+	}
+
+	for _, hook := range uc.deleteHooks {
+		hook(ctx, task)
+	}
+
+	return nil
+}
+
+// checkAssignee проверяет, что исполнитель задачи существует
+func (uc *TaskUseCase) checkAssignee(ctx context.Context, task *entity.Task) error {
+	if task.AssigneeID == "" {
+		return nil
+	}
+
+	if _, err := uc.userRepo.GetByID(ctx, task.AssigneeID); err != nil {
+		return errors.New("validation failed: assignee not found")
+	}
+
+	return nil
+}
+
+// checkBlockers применяет глобальное правило BlockDoneByDependencies
+func (uc *TaskUseCase) checkBlockers(ctx context.Context, id string) error {
+	if !uc.config.BlockDoneByDependencies {
+		return nil
+	}
+
+	return uc.checkUnfinishedBlockers(ctx, id)
+}
+
+// checkUnfinishedBlockers проверяет, что все блокирующие задачи завершены
+func (uc *TaskUseCase) checkUnfinishedBlockers(ctx context.Context, id string) error {
+	blockers, err := uc.depsRepo.GetBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, dep := range blockers {
+		blocker, err := uc.repo.GetByID(ctx, dep.BlockerID)
+		if err != nil || blocker.IsTrashed() {
+			continue
+		}
+
+		if !blocker.IsDone() {
+			return errors.New("task is blocked by unfinished task " + blocker.ID)
+		}
+	}
+
+	return nil
+}
+
+// checkParent проверяет, что родитель существует, принадлежит тому же
+// пользователю и что новая связь не образует цикл в иерархии
+func (uc *TaskUseCase) checkParent(ctx context.Context, task *entity.Task) error {
+	if task.ParentID == "" {
+		return nil
+	}
+
+	parent, err := uc.repo.GetByID(ctx, task.ParentID)
+	if err != nil || parent.IsTrashed() {
+		return errors.New("parent task not found")
+	}
+
+	if parent.UserID != task.UserID {
+		return errors.New("access denied")
+	}
+
+	// У новой задачи ещё нет ID, значит и потомков, с которыми возможен цикл
+	if task.ID == "" {
+		return nil
+	}
+
+	// Поднимаемся от нового родителя к корню: если встретим саму задачу,
+	// она окажется собственным предком
+	visited := map[string]bool{}
+	for current := parent; current != nil; {
+		if current.ID == task.ID {
+			return errors.New("cycle detected: task cannot be moved under its own subtask")
+		}
+
+		if visited[current.ID] || current.ParentID == "" {
+			break
+		}
+		visited[current.ID] = true
+
+		next, err := uc.repo.GetByID(ctx, current.ParentID)
+		if err != nil {
+			break
+		}
+		current = next
+	}
+
+	return nil
+}
+
+// buildTree рекурсивно собирает поддерево задачи
+func (uc *TaskUseCase) buildTree(ctx context.Context, task *entity.Task) (*entity.TaskNode, error) {
+	node := entity.NewTaskNode(task)
+
+	children, err := uc.repo.GetChildren(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		childNode, err := uc.buildTree(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+
+	return node, nil
+}
+
+// handleSubtasksOnDelete применяет политику удаления к подзадачам задачи,
+// переносимой в корзину в момент at
+func (uc *TaskUseCase) handleSubtasksOnDelete(ctx context.Context, task *entity.Task, at time.Time) error {
+	children, err := uc.repo.GetChildren(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(children) == 0 {
+		return nil
+	}
+
+	switch uc.config.SubtaskDeletePolicy {
+	case DeletePolicyRestrict:
+		return errors.New("task has subtasks")
+	case DeletePolicyOrphan, DeletePolicyReparent:
+		newParentID := ""
+		if uc.config.SubtaskDeletePolicy == DeletePolicyReparent {
+			newParentID = task.ParentID
+		}
+
+		for _, child := range children {
+			child.ParentID = newParentID
+			_, err := uc.persistUpdate(ctx, child.ID, func(ctx context.Context) error {
+				return uc.repo.Update(ctx, child)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range children {
+			if err := uc.handleSubtasksOnDelete(ctx, child, at); err != nil {
+				return err
+			}
+			if err := uc.trashTask(ctx, child, at); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"strings"
+	"testing"
+)
+
+func TestDeleteTaskSubtaskPolicies(t *testing.T) {
+	tests := []struct {
+		policy SubtaskDeletePolicy
+		// parent ожидаемый родитель подзадачи: "root", "deleted" или "" — корневая
+		parent  string
+		trashed bool
+		err     string
+	}{
+		{policy: DeletePolicyCascade, parent: "deleted", trashed: true},
+		{policy: DeletePolicyReparent, parent: "root"},
+		{policy: DeletePolicyOrphan, parent: ""},
+		{policy: DeletePolicyRestrict, parent: "deleted", err: "task has subtasks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			env := newTestEnv(t)
+			env.tasks.config.SubtaskDeletePolicy = tt.policy
+			ctx := userContext("user-123")
+
+			root := env.createTask(t, ctx, &entity.Task{Title: "Release"})
+			deleted := env.createTask(t, ctx, &entity.Task{Title: "Docs", ParentID: root.ID})
+			child := env.createTask(t, ctx, &entity.Task{Title: "API reference", ParentID: deleted.ID})
+			grandchild := env.createTask(t, ctx, &entity.Task{Title: "Examples", ParentID: child.ID})
+
+			err := env.tasks.DeleteTask(ctx, deleted.ID)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("delete error = %v, want %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatalf("delete task: %v", err)
+			}
+
+			want := map[string]string{"root": root.ID, "deleted": deleted.ID, "": ""}[tt.parent]
+			got, err := env.taskRepo.GetByID(ctx, child.ID)
+			if err != nil {
+				t.Fatalf("get subtask: %v", err)
+			}
+			if got.ParentID != want || got.IsTrashed() != tt.trashed {
+				t.Fatalf("subtask parent %q, trashed %v; want parent %q, trashed %v", got.ParentID, got.IsTrashed(), want, tt.trashed)
+			}
+
+			// Внуки остаются под своей подзадачей и разделяют её судьбу
+			got, err = env.taskRepo.GetByID(ctx, grandchild.ID)
+			if err != nil {
+				t.Fatalf("get grandchild: %v", err)
+			}
+			if got.ParentID != child.ID || got.IsTrashed() != tt.trashed {
+				t.Fatalf("grandchild parent %q, trashed %v", got.ParentID, got.IsTrashed())
+			}
+
+			if got, _ := env.taskRepo.GetByID(ctx, root.ID); got.IsTrashed() {
+				t.Fatalf("root task was trashed")
+			}
+		})
+	}
+}
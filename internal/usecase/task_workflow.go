@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"time"
+)
+
+// TransitionGuard условие перехода: ошибка запрещает смену статуса
+type TransitionGuard func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) error
+
+// TransitionHook действие, выполняемое после успешной смены статуса
+type TransitionHook func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus)
+
+// RegisterGuard регистрирует условие перехода, на которое можно сослаться
+// по имени в рабочем процессе. Вызывается при старте приложения.
+func (uc *TaskUseCase) RegisterGuard(name string, guard TransitionGuard) {
+	uc.guards[name] = guard
+}
+
+// RegisterTransitionHook регистрирует действие перехода, на которое можно
+// сослаться по имени в рабочем процессе. Вызывается при старте приложения.
+func (uc *TaskUseCase) RegisterTransitionHook(name string, hook TransitionHook) {
+	uc.hooks[name] = hook
+}
+
+// HasGuard сообщает, зарегистрировано ли условие перехода
+func (uc *TaskUseCase) HasGuard(name string) bool {
+	_, ok := uc.guards[name]
+	return ok
+}
+
+// HasTransitionHook сообщает, зарегистрировано ли действие перехода
+func (uc *TaskUseCase) HasTransitionHook(name string) bool {
+	_, ok := uc.hooks[name]
+	return ok
+}
+
+// GetWorkflow возвращает рабочий процесс пространства; если он не
+// настроен, используется процесс по умолчанию
+func (uc *TaskUseCase) GetWorkflow(ctx context.Context, workspaceID string) (*entity.Workflow, error) {
+	workflow, err := uc.workflowRepo.GetByWorkspace(ctx, workspaceID)
+	if err != nil {
+		if err.Error() == "workflow not found" {
+			return entity.DefaultWorkflow(workspaceID), nil
+		}
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// applyWorkflowCategories переносит категории статусов рабочего процесса на
+// задачи его пространства, включая архивные и задачи в корзине: категория
+// хранится в задаче, и без этого изменение процесса не сказалось бы на
+// выборках по категории (архивация, отчеты). Время завершения меняется так
+// же, как при смене статуса. Повторный вызов с тем же процессом ничего не
+// меняет.
+func (uc *TaskUseCase) applyWorkflowCategories(ctx context.Context, workflow *entity.Workflow) error {
+	tasks, err := uc.repo.GetByWorkspace(ctx, workflow.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	return uc.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, task := range tasks {
+			if status, ok := workflow.Status(task.Status); !ok || status.Category == task.StatusCategory {
+				continue
+			}
+
+			// Задача перечитывается в транзакции, чтобы не затереть
+			// изменения, сделанные после выборки. Событие помечается как
+			// перенос категорий: оно попадает в журнал изменений, но
+			// пользователям и вебхукам о нём не сообщается.
+			_, err := uc.persistUpdate(withRecategorized(ctx), task.ID, func(ctx context.Context) error {
+				current, err := uc.repo.GetByID(ctx, task.ID)
+				if err != nil {
+					return err
+				}
+				status, ok := workflow.Status(current.Status)
+				if !ok {
+					return nil
+				}
+
+				wasDone := current.IsDone()
+				current.StatusCategory = status.Category
+				switch {
+				case !current.IsDone():
+					current.CompletedAt = nil
+				case !wasDone:
+					now := time.Now()
+					current.CompletedAt = &now
+				}
+				return uc.repo.Update(ctx, current)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAvailableTransitions возвращает переходы, разрешенные рабочим
+// процессом из текущего статуса задачи
+func (uc *TaskUseCase) GetAvailableTransitions(ctx context.Context, id string) ([]entity.WorkflowTransition, error) {
+	uc.logger.Info("Getting available transitions", map[string]interface{}{"id": id})
+
+	task, err := uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err := uc.GetWorkflow(ctx, task.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return workflow.AvailableTransitions(task.Status), nil
+}
+
+// applyInitialStatus проверяет статус новой задачи по рабочему процессу
+// и проставляет категорию статуса
+func (uc *TaskUseCase) applyInitialStatus(ctx context.Context, task *entity.Task) error {
+	workflow, err := uc.GetWorkflow(ctx, task.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	if task.Status == "" {
+		task.Status = workflow.InitialStatus
+	}
+
+	status, ok := workflow.Status(task.Status)
+	if !ok {
+		return errors.New("validation failed: status " + string(task.Status) + " is not defined in workflow")
+	}
+
+	task.StatusCategory = status.Category
+
+	task.CompletedAt = nil
+	if task.IsDone() {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	return nil
+}
+
+// applyTransition проверяет смену статуса по рабочему процессу и условиям
+// перехода. Возвращает описание перехода, чтобы после сохранения выполнить
+// его действия; nil означает, что статус не менялся.
+func (uc *TaskUseCase) applyTransition(ctx context.Context, existing, task *entity.Task) (*entity.WorkflowTransition, error) {
+	workflow, err := uc.GetWorkflow(ctx, task.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, ok := workflow.Status(task.Status)
+	if !ok {
+		return nil, errors.New("validation failed: status " + string(task.Status) + " is not defined in workflow")
+	}
+	task.StatusCategory = status.Category
+
+	// Время завершения сохраняется, пока задача остается в категории done
+	switch {
+	case !task.IsDone():
+		task.CompletedAt = nil
+	case existing.IsDone():
+		task.CompletedAt = existing.CompletedAt
+	default:
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	if existing.Status == task.Status {
+		return nil, nil
+	}
+
+	transition, ok := workflow.FindTransition(existing.Status, task.Status)
+	if !ok {
+		// Задачи со статусом, удаленным из процесса, можно перевести в любой
+		// статус — иначе они застрянут навсегда
+		if _, known := workflow.Status(existing.Status); known {
+			return nil, errors.New("transition not allowed: " + string(existing.Status) + " -> " + string(task.Status))
+		}
+		transition = &entity.WorkflowTransition{From: existing.Status, To: task.Status}
+	}
+
+	if status.Category == entity.CategoryDone && !existing.IsDone() {
+		if err := uc.checkBlockers(ctx, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	guards := transition.Guards
+	if status.Category == entity.CategoryDone && !existing.IsDone() {
+		guards = append(append([]string(nil), uc.config.DoneGuards...), guards...)
+	}
+
+	for _, name := range guards {
+		guard, ok := uc.guards[name]
+		if !ok {
+			return nil, errors.New("unknown transition guard " + name)
+		}
+
+		if err := guard(ctx, task, existing.Status, task.Status); err != nil {
+			return nil, errors.New("transition guard " + name + " failed: " + err.Error())
+		}
+	}
+
+	return transition, nil
+}
+
+// runTransitionHooks выполняет действия перехода после сохранения задачи
+func (uc *TaskUseCase) runTransitionHooks(ctx context.Context, transition *entity.WorkflowTransition, task *entity.Task, from entity.TaskStatus) {
+	if transition == nil {
+		return
+	}
+
+	for _, name := range transition.Hooks {
+		hook, ok := uc.hooks[name]
+		if !ok {
+			uc.logger.Error("Unknown transition hook", errors.New("hook not registered"), map[string]interface{}{"hook": name})
+			continue
+		}
+
+		hook(ctx, task, from, task.Status)
+	}
+}
+
+// registerBuiltinTransitionRules регистрирует стандартные условия и действия переходов
+func (uc *TaskUseCase) registerBuiltinTransitionRules() {
+	uc.RegisterGuard("blockers_done", func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) error {
+		return uc.checkUnfinishedBlockers(ctx, task.ID)
+	})
+
+	uc.RegisterGuard("subtasks_done", func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) error {
+		children, err := uc.repo.GetChildren(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if !child.IsDone() {
+				return errors.New("subtask " + child.ID + " is not finished")
+			}
+		}
+
+		return nil
+	})
+
+	uc.RegisterGuard("has_description", func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) error {
+		if task.Description == "" {
+			return errors.New("description is required")
+		}
+		return nil
+	})
+
+	uc.RegisterTransitionHook("log", func(ctx context.Context, task *entity.Task, from, to entity.TaskStatus) {
+		uc.logger.Info("Task status changed", map[string]interface{}{
+			"id":   task.ID,
+			"from": from,
+			"to":   to,
+		})
+	})
+}
+
+// workspaceFromContext возвращает рабочее пространство запроса
+func workspaceFromContext(ctx context.Context) string {
+	workspaceID, ok := ctx.Value("workspace_id").(string)
+	if !ok || workspaceID == "" {
+		return entity.DefaultWorkspaceID
+	}
+	return workspaceID
+}
+
+// checkWorkspaceRole проверяет, что пользователь запроса состоит в
+// пространстве workspaceID, а для роли администратора — что она у него есть
+func (uc *TaskUseCase) checkWorkspaceRole(ctx context.Context, workspaceID string, required entity.WorkspaceRole) error {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return errors.New("access denied")
+		}
+		return err
+	}
+
+	role, ok := user.RoleIn(workspaceID)
+	if !ok || required == entity.WorkspaceAdmin && role != entity.WorkspaceAdmin {
+		return errors.New("access denied")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"time"
+)
+
+// TrashConfig настройки очистки корзины
+type TrashConfig struct {
+	// Retention сколько задача хранится в корзине до окончательного удаления
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// TrashPurger фоновая очистка корзины: задачи, пролежавшие в ней дольше
+// срока хранения, удаляются окончательно
+type TrashPurger struct {
+	taskUseCase *TaskUseCase
+	taskRepo    repository.TaskRepository
+	logger      *logger.Logger
+	config      TrashConfig
+}
+
+func NewTrashPurger(taskUseCase *TaskUseCase, taskRepo repository.TaskRepository, logger *logger.Logger, config TrashConfig) *TrashPurger {
+	if config.Retention <= 0 {
+		config.Retention = 30 * 24 * time.Hour
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &TrashPurger{
+		taskUseCase: taskUseCase,
+		taskRepo:    taskRepo,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+// Run очищает корзину сразу и затем с заданным интервалом,
+// пока не отменен контекст
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick окончательно удаляет задачи, перенесенные в корзину раньше now минус
+// срок хранения
+func (p *TrashPurger) Tick(ctx context.Context, now time.Time) {
+	tasks, err := p.taskRepo.GetTrashedBefore(ctx, now.Add(-p.config.Retention))
+	if err != nil {
+		p.logger.Error("Failed to load expired trash", err, nil)
+		return
+	}
+
+	for _, task := range tasks {
+		// Действия выполняются от имени владельца задачи в её пространстве
+		ownerCtx := context.WithValue(ctx, "user_id", task.UserID)
+		ownerCtx = context.WithValue(ownerCtx, "workspace_id", task.WorkspaceID)
+
+		// Подзадача могла быть удалена вместе с родителем раньше в этом же
+		// проходе, а задача — восстановлена из корзины после чтения списка
+		err := p.taskUseCase.PurgeTask(ownerCtx, task.ID)
+		if err != nil && err.Error() != "task not found" && err.Error() != "task not found in trash" {
+			p.logger.Error("Failed to purge trashed task", err, map[string]interface{}{"id": task.ID})
+			continue
+		}
+
+		if err == nil {
+			p.logger.Info("Trashed task purged", map[string]interface{}{"id": task.ID, "deleted_at": task.DeletedAt})
+		}
+	}
+}
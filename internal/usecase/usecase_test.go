@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"testing"
+)
+
+// testEnv use case задач на хранилищах в памяти
+type testEnv struct {
+	logger    *logger.Logger
+	taskRepo  *db.TaskRepository
+	outbox    *db.OutboxRepository
+	users     *db.UserRepository
+	workflows *db.WorkflowRepository
+	deps      *db.DependencyRepository
+	tasks     *TaskUseCase
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := &testEnv{
+		logger:    logger.NewLogger(),
+		taskRepo:  db.NewTaskRepository(),
+		users:     db.NewUserRepository(),
+		workflows: db.NewWorkflowRepository(),
+		deps:      db.NewDependencyRepository(),
+	}
+	env.outbox = db.NewOutboxRepository(env.taskRepo)
+	env.tasks = NewTaskUseCase(env.taskRepo, env.deps, env.workflows, env.users, env.outbox, env.logger, TaskUseCaseConfig{})
+
+	for _, user := range []*entity.User{
+		{ID: "user-123", Username: "demo", Name: "Demo User", Email: "demo@example.com", Workspaces: map[string]entity.WorkspaceRole{entity.DefaultWorkspaceID: entity.WorkspaceAdmin}},
+		{ID: "user-456", Username: "alice", Name: "Alice", Email: "alice@example.com"},
+	} {
+		if err := env.users.Create(context.Background(), user); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	return env
+}
+
+// userContext контекст запроса пользователя, как его готовит AuthMiddleware
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), "user_id", userID)
+}
+
+// createTask создает задачу от имени пользователя контекста
+func (env *testEnv) createTask(t *testing.T, ctx context.Context, task *entity.Task) *entity.Task {
+	t.Helper()
+
+	if err := env.tasks.CreateTask(ctx, task); err != nil {
+		t.Fatalf("create task %q: %v", task.Title, err)
+	}
+	return task
+}
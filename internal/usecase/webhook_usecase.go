@@ -0,0 +1,402 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/webhook"
+	"strings"
+	"time"
+)
+
+// WebhookConfig настройки доставки вебхуков
+type WebhookConfig struct {
+	Interval     time.Duration // период опроса готовых доставок
+	Timeout      time.Duration // таймаут одного запроса к подписчику
+	MaxAttempts  int           // после стольких неудачных попыток доставка попадает в список недоставленных
+	RetryBackoff time.Duration // задержка перед первым повтором, далее удваивается
+	MaxBackoff   time.Duration
+	// AllowPrivateNetworks разрешает доставку на адреса внутренней сети,
+	// например получателю на той же машине при локальной разработке
+	AllowPrivateNetworks bool
+}
+
+// WebhookUseCase ведет подписки на события задач и доставляет события
+// подписчикам подписанными HMAC-SHA256 запросами с повторами
+type WebhookUseCase struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	client       *webhook.Client
+	logger       *logger.Logger
+	config       WebhookConfig
+}
+
+// webhookPayload тело запроса к подписчику
+type webhookPayload struct {
+	ID          string              `json:"id"`
+	Event       entity.WebhookEvent `json:"event"`
+	OccurredAt  time.Time           `json:"occurred_at"`
+	WorkspaceID string              `json:"workspace_id"`
+	Data        webhookPayloadData  `json:"data"`
+}
+
+type webhookPayloadData struct {
+	Task     *entity.Task `json:"task"`
+	Previous *entity.Task `json:"previous,omitempty"`
+}
+
+func NewWebhookUseCase(webhookRepo repository.WebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, logger *logger.Logger, config WebhookConfig) *WebhookUseCase {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 6
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 10 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	return &WebhookUseCase{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		client:       webhook.NewClient(config.Timeout, config.AllowPrivateNetworks),
+		logger:       logger,
+		config:       config,
+	}
+}
+
+// CreateSubscription создает подписку в текущем пространстве; ключ подписи
+// генерируется и возвращается в subscription.Secret
+func (uc *WebhookUseCase) CreateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	uc.logger.Info("Creating webhook", map[string]interface{}{"url": subscription.URL})
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return errors.New("unauthorized")
+	}
+
+	if errMsgs := subscription.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	subscription.UserID = userID
+	subscription.WorkspaceID = workspaceFromContext(ctx)
+	subscription.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	return uc.webhookRepo.Create(ctx, subscription)
+}
+
+// GetSubscriptions возвращает подписки текущего пространства
+func (uc *WebhookUseCase) GetSubscriptions(ctx context.Context) ([]*entity.WebhookSubscription, error) {
+	if _, ok := ctx.Value("user_id").(string); !ok {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.webhookRepo.GetByWorkspace(ctx, workspaceFromContext(ctx))
+}
+
+// GetSubscription возвращает подписку текущего пространства
+func (uc *WebhookUseCase) GetSubscription(ctx context.Context, id string) (*entity.WebhookSubscription, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	subscription, err := uc.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подписки других пространств не раскрываются
+	if subscription.WorkspaceID != workspaceFromContext(ctx) {
+		return nil, errors.New("webhook not found")
+	}
+
+	return subscription, nil
+}
+
+// UpdateSubscription меняет адрес, фильтр событий и активность подписки
+func (uc *WebhookUseCase) UpdateSubscription(ctx context.Context, id, url string, events []entity.WebhookEvent, active bool) (*entity.WebhookSubscription, error) {
+	uc.logger.Info("Updating webhook", map[string]interface{}{"id": id})
+
+	subscription, err := uc.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.URL = url
+	subscription.Events = events
+	subscription.Active = active
+
+	if errMsgs := subscription.Validate(); len(errMsgs) > 0 {
+		return nil, errors.New("validation failed: " + errMsgs[0])
+	}
+
+	if err := uc.webhookRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом доставок
+func (uc *WebhookUseCase) DeleteSubscription(ctx context.Context, id string) error {
+	uc.logger.Info("Deleting webhook", map[string]interface{}{"id": id})
+
+	if _, err := uc.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	if err := uc.webhookRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.deliveryRepo.DeleteBySubscription(ctx, id)
+}
+
+// GetDeliveries возвращает журнал доставок подписки; status "dead" —
+// список недоставленных событий
+func (uc *WebhookUseCase) GetDeliveries(ctx context.Context, id string, status entity.WebhookDeliveryStatus) ([]*entity.WebhookDelivery, error) {
+	if _, err := uc.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return uc.deliveryRepo.GetBySubscription(ctx, id, status)
+}
+
+// ReplayDelivery повторно отправляет событие подписчику. Создается новая
+// доставка с тем же телом, журнал исходной доставки не меняется.
+func (uc *WebhookUseCase) ReplayDelivery(ctx context.Context, id, deliveryID string) (*entity.WebhookDelivery, error) {
+	uc.logger.Info("Replaying webhook delivery", map[string]interface{}{"id": id, "delivery": deliveryID})
+
+	if _, err := uc.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	original, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.SubscriptionID != id {
+		return nil, errors.New("delivery not found")
+	}
+
+	replay := &entity.WebhookDelivery{
+		SubscriptionID: id,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         entity.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		ReplayOf:       original.ID,
+	}
+
+	if err := uc.deliveryRepo.Create(ctx, replay); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// HandleTaskEvent ставит в очередь доставки вебхуки доменного события задачи;
+// при смене статуса к task.updated добавляется task.status_changed
+func (uc *WebhookUseCase) HandleTaskEvent(ctx context.Context, event *entity.DomainEvent) error {
+	// Восстановленные из резервной копии задачи не новы для получателей, а
+	// перенос категорий не является изменением задачи
+	if event.Silent() {
+		return nil
+	}
+
+	if err := uc.publish(ctx, event, entity.WebhookEvent(event.Type)); err != nil {
+		return err
+	}
+
+	if event.StatusChanged() {
+		return uc.publish(ctx, event, entity.WebhookTaskStatusChanged)
+	}
+
+	return nil
+}
+
+// publish ставит событие в очередь доставки всем подходящим подпискам
+// пространства задачи. Повторно опубликованное событие не дублирует уже
+// созданные доставки.
+func (uc *WebhookUseCase) publish(ctx context.Context, event *entity.DomainEvent, webhookEvent entity.WebhookEvent) error {
+	subscriptions, err := uc.webhookRepo.GetByWorkspace(ctx, event.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	// Производное событие получает собственный ID, чтобы получатель мог
+	// отсеивать повторы по полю id
+	eventID := event.ID
+	if webhookEvent != entity.WebhookEvent(event.Type) {
+		eventID = event.ID + ":" + string(webhookEvent)
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:          eventID,
+		Event:       webhookEvent,
+		OccurredAt:  event.OccurredAt,
+		WorkspaceID: event.WorkspaceID,
+		Data:        webhookPayloadData{Task: event.Task, Previous: event.Previous},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(webhookEvent) {
+			continue
+		}
+
+		exists, err := uc.deliveryRepo.HasEvent(ctx, subscription.ID, eventID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		delivery := &entity.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			Event:          webhookEvent,
+			Payload:        payload,
+			Status:         entity.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		}
+
+		if err := uc.deliveryRepo.Create(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run отправляет готовые доставки сразу и затем с заданным интервалом,
+// пока не отменен контекст
+func (uc *WebhookUseCase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		uc.Tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick отправляет все готовые доставки
+func (uc *WebhookUseCase) Tick(ctx context.Context, now time.Time) {
+	deliveries, err := uc.deliveryRepo.ClaimDue(ctx, now, now.Add(2*uc.config.Timeout))
+	if err != nil {
+		uc.logger.Error("Failed to claim webhook deliveries", err, nil)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		uc.deliver(ctx, delivery)
+	}
+}
+
+// deliver выполняет одну попытку доставки и записывает её в журнал; при
+// ошибке следующая попытка откладывается экспоненциально, а после
+// MaxAttempts доставка попадает в список недоставленных. Доставка удаленной
+// подписки сразу попадает в список недоставленных.
+func (uc *WebhookUseCase) deliver(ctx context.Context, delivery *entity.WebhookDelivery) {
+	subscription, err := uc.webhookRepo.GetByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			// Повтор после истечения аренды
+			uc.logger.Error("Failed to load webhook", err, map[string]interface{}{"delivery": delivery.ID})
+			return
+		}
+
+		delivery.Status = entity.WebhookDeliveryDead
+		delivery.LeaseUntil = time.Time{}
+		delivery.Attempts = append(delivery.Attempts, entity.WebhookAttempt{At: time.Now(), Error: "subscription deleted"})
+		if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+			uc.logger.Error("Failed to save webhook delivery", err, map[string]interface{}{"delivery": delivery.ID})
+		}
+		return
+	}
+
+	start := time.Now()
+	resp, err := uc.client.Send(ctx, webhook.Request{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		Event:      string(delivery.Event),
+		DeliveryID: delivery.ID,
+		Body:       delivery.Payload,
+	})
+
+	attempt := entity.WebhookAttempt{At: start, Duration: time.Since(start)}
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+		attempt.Response = resp.Body
+	}
+
+	now := time.Now()
+	delivery.LeaseUntil = time.Time{}
+
+	if err == nil {
+		delivery.Status = entity.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	} else {
+		attempt.Error = err.Error()
+
+		if len(delivery.Attempts)+1 >= uc.config.MaxAttempts {
+			delivery.Status = entity.WebhookDeliveryDead
+		} else {
+			delivery.Status = entity.WebhookDeliveryPending
+			delivery.NextAttemptAt = now.Add(uc.backoff(len(delivery.Attempts) + 1))
+		}
+
+		uc.logger.Error("Webhook delivery failed", err, map[string]interface{}{
+			"webhook": subscription.ID, "delivery": delivery.ID, "attempt": len(delivery.Attempts) + 1,
+		})
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+		uc.logger.Error("Failed to save webhook delivery", err, map[string]interface{}{"delivery": delivery.ID})
+	}
+}
+
+// backoff задержка перед повтором после attempt неудачных попыток
+func (uc *WebhookUseCase) backoff(attempt int) time.Duration {
+	delay := uc.config.RetryBackoff
+	for i := 1; i < attempt && delay < uc.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > uc.config.MaxBackoff {
+		delay = uc.config.MaxBackoff
+	}
+
+	return delay
+}
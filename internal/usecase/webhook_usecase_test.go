@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository/db"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// webhookTestEnv вебхуки пространства по умолчанию с одним получателем
+type webhookTestEnv struct {
+	webhookRepo  *db.WebhookRepository
+	deliveryRepo *db.WebhookDeliveryRepository
+	webhooks     *WebhookUseCase
+	subscription *entity.WebhookSubscription
+	received     atomic.Int32
+}
+
+func newWebhookTestEnv(t *testing.T, config WebhookConfig) *webhookTestEnv {
+	t.Helper()
+
+	env := &webhookTestEnv{}
+	env.open(t, t.TempDir(), config)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.received.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	env.subscription = &entity.WebhookSubscription{URL: server.URL, Active: true}
+	if err := env.webhooks.CreateSubscription(userContext("user-123"), env.subscription); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	return env
+}
+
+// open открывает подписки и доставки, сохраненные в каталоге dir, как при
+// запуске сервиса
+func (env *webhookTestEnv) open(t *testing.T, dir string, config WebhookConfig) {
+	t.Helper()
+
+	var err error
+	if env.webhookRepo, err = db.NewWebhookRepository(filepath.Join(dir, "webhooks.json")); err != nil {
+		t.Fatalf("load webhooks: %v", err)
+	}
+	if env.deliveryRepo, err = db.NewWebhookDeliveryRepository(filepath.Join(dir, "webhook_deliveries.json")); err != nil {
+		t.Fatalf("load webhook deliveries: %v", err)
+	}
+	env.webhooks = NewWebhookUseCase(env.webhookRepo, env.deliveryRepo, logger.NewLogger(), config)
+}
+
+// publish передает вебхукам событие создания задачи
+func (env *webhookTestEnv) publish(t *testing.T) {
+	t.Helper()
+
+	task := &entity.Task{ID: "task-1", Title: "Ship", WorkspaceID: entity.DefaultWorkspaceID}
+	event := &entity.DomainEvent{ID: "event-1", Type: entity.TaskCreatedEvent, TaskID: task.ID, WorkspaceID: task.WorkspaceID, OccurredAt: time.Now(), Task: task}
+	if err := env.webhooks.HandleTaskEvent(context.Background(), event); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+}
+
+func (env *webhookTestEnv) delivery(t *testing.T) *entity.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := env.deliveryRepo.GetBySubscription(context.Background(), env.subscription.ID, "")
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("deliveries = %d, %v; want one", len(deliveries), err)
+	}
+	return deliveries[0]
+}
+
+func TestWebhookDeliveryRefusesPrivateAddress(t *testing.T) {
+	env := newWebhookTestEnv(t, WebhookConfig{})
+	env.publish(t)
+
+	env.webhooks.Tick(context.Background(), time.Now())
+
+	delivery := env.delivery(t)
+	if env.received.Load() != 0 {
+		t.Fatal("webhook delivered to a loopback address")
+	}
+	if delivery.Status != entity.WebhookDeliveryPending || len(delivery.Attempts) != 1 || !strings.Contains(delivery.Attempts[0].Error, "not public") {
+		t.Fatalf("delivery = %+v, want failed attempt", delivery)
+	}
+}
+
+func TestWebhookDeliveryToPrivateAddressWhenAllowed(t *testing.T) {
+	env := newWebhookTestEnv(t, WebhookConfig{AllowPrivateNetworks: true})
+	env.publish(t)
+
+	env.webhooks.Tick(context.Background(), time.Now())
+
+	if delivery := env.delivery(t); delivery.Status != entity.WebhookDeliveryDelivered || env.received.Load() != 1 {
+		t.Fatalf("delivery = %+v, received %d", delivery, env.received.Load())
+	}
+}
+
+func TestWebhookDeliveryOfDeletedSubscriptionIsDead(t *testing.T) {
+	env := newWebhookTestEnv(t, WebhookConfig{AllowPrivateNetworks: true})
+	env.publish(t)
+
+	// Подписка удалена после того, как доставка встала в очередь
+	if err := env.webhookRepo.Delete(context.Background(), env.subscription.ID); err != nil {
+		t.Fatalf("delete subscription: %v", err)
+	}
+
+	now := time.Now()
+	env.webhooks.Tick(context.Background(), now)
+
+	delivery := env.delivery(t)
+	if delivery.Status != entity.WebhookDeliveryDead || len(delivery.Attempts) != 1 || delivery.Attempts[0].Error != "subscription deleted" {
+		t.Fatalf("delivery = %+v, want dead letter", delivery)
+	}
+
+	// Недоставленная доставка больше не выбирается
+	due, err := env.deliveryRepo.ClaimDue(context.Background(), now.Add(time.Hour), now.Add(2*time.Hour))
+	if err != nil || len(due) != 0 {
+		t.Fatalf("due deliveries = %d, %v", len(due), err)
+	}
+	if env.received.Load() != 0 {
+		t.Fatal("delivery of deleted subscription was sent")
+	}
+}
+
+func TestWebhookRetryIsSentAfterRestart(t *testing.T) {
+	config := WebhookConfig{AllowPrivateNetworks: true}
+	dir := t.TempDir()
+	env := &webhookTestEnv{}
+	env.open(t, dir, config)
+
+	// Получатель сначала недоступен, затем принимает запрос с верной подписью
+	var available atomic.Bool
+	var verified atomic.Int32
+	var secret atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(secret.Load().(string), r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, time.Minute); err == nil {
+			verified.Add(1)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	env.subscription = &entity.WebhookSubscription{URL: server.URL, Active: true}
+	if err := env.webhooks.CreateSubscription(userContext("user-123"), env.subscription); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	secret.Store(env.subscription.Secret)
+
+	env.publish(t)
+	env.webhooks.Tick(context.Background(), time.Now())
+	if delivery := env.delivery(t); delivery.Status != entity.WebhookDeliveryPending || len(delivery.Attempts) != 1 {
+		t.Fatalf("delivery after failed attempt = %+v", delivery)
+	}
+
+	// После перезапуска повтор берется из файла и подписывается сохраненным ключом
+	env.open(t, dir, config)
+	available.Store(true)
+	env.webhooks.Tick(context.Background(), time.Now().Add(time.Hour))
+
+	delivery := env.delivery(t)
+	if delivery.Status != entity.WebhookDeliveryDelivered || len(delivery.Attempts) != 2 || verified.Load() != 1 {
+		t.Fatalf("delivery after restart = %+v, verified %d", delivery, verified.Load())
+	}
+}
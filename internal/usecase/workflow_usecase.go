@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/repository"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+)
+
+type WorkflowUseCase struct {
+	taskUseCase  *TaskUseCase
+	workflowRepo repository.WorkflowRepository
+	logger       *logger.Logger
+}
+
+func NewWorkflowUseCase(taskUseCase *TaskUseCase, workflowRepo repository.WorkflowRepository, logger *logger.Logger) *WorkflowUseCase {
+	return &WorkflowUseCase{
+		taskUseCase:  taskUseCase,
+		workflowRepo: workflowRepo,
+		logger:       logger,
+	}
+}
+
+// GetWorkflow возвращает рабочий процесс текущего пространства
+func (uc *WorkflowUseCase) GetWorkflow(ctx context.Context) (*entity.Workflow, error) {
+	uc.logger.Info("Getting workflow", nil)
+
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, errors.New("unauthorized")
+	}
+
+	return uc.taskUseCase.GetWorkflow(ctx, workspaceFromContext(ctx))
+}
+
+// SaveWorkflow заменяет рабочий процесс текущего пространства и обновляет
+// категории статусов его задач. Процесс меняет только администратор
+// пространства: он действует на задачи всех участников.
+func (uc *WorkflowUseCase) SaveWorkflow(ctx context.Context, workflow *entity.Workflow) error {
+	uc.logger.Info("Saving workflow", map[string]interface{}{"name": workflow.Name})
+
+	workflow.WorkspaceID = workspaceFromContext(ctx)
+
+	if err := uc.taskUseCase.checkWorkspaceRole(ctx, workflow.WorkspaceID, entity.WorkspaceAdmin); err != nil {
+		return err
+	}
+
+	if errMsgs := workflow.Validate(); len(errMsgs) > 0 {
+		return errors.New("validation failed: " + errMsgs[0])
+	}
+
+	// Процесс может ссылаться только на зарегистрированные условия и действия
+	for _, tr := range workflow.Transitions {
+		for _, name := range tr.Guards {
+			if !uc.taskUseCase.HasGuard(name) {
+				return errors.New("validation failed: unknown transition guard " + name)
+			}
+		}
+		for _, name := range tr.Hooks {
+			if !uc.taskUseCase.HasTransitionHook(name) {
+				return errors.New("validation failed: unknown transition hook " + name)
+			}
+		}
+	}
+
+	if err := uc.workflowRepo.Save(ctx, workflow); err != nil {
+		return err
+	}
+
+	// Процесс сохраняется первым: если перенос категорий не удался,
+	// повторное сохранение того же процесса его завершит
+	return uc.taskUseCase.applyWorkflowCategories(ctx, workflow)
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/internal/domain/entity"
+	"testing"
+	"time"
+)
+
+func TestSaveWorkflowRecategorizesTasks(t *testing.T) {
+	env := newTestEnv(t)
+	workflows := NewWorkflowUseCase(env.tasks, env.workflows, env.logger)
+	ctx := userContext("user-123")
+
+	review := env.createTask(t, ctx, &entity.Task{Title: "Review design", Status: entity.StatusInProgress})
+	backlog := env.createTask(t, ctx, &entity.Task{Title: "Backlog idea"})
+	if err := env.tasks.DeleteTask(ctx, backlog.ID); err != nil {
+		t.Fatalf("trash task: %v", err)
+	}
+	other := env.createTask(t, userContext("user-456"), &entity.Task{Title: "Alice's task", Status: entity.StatusInProgress})
+
+	// "В работе" теперь считается завершением, а "к выполнению" — работой
+	workflow := entity.DefaultWorkflow(entity.DefaultWorkspaceID)
+	for i := range workflow.Statuses {
+		switch workflow.Statuses[i].Key {
+		case entity.StatusInProgress:
+			workflow.Statuses[i].Category = entity.CategoryDone
+		case entity.StatusTodo:
+			workflow.Statuses[i].Category = entity.CategoryActive
+		}
+	}
+	if err := workflows.SaveWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("save workflow: %v", err)
+	}
+
+	// Задачи всех пользователей пространства получают новую категорию
+	for _, id := range []string{review.ID, other.ID} {
+		task, err := env.taskRepo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		if task.StatusCategory != entity.CategoryDone || task.CompletedAt == nil {
+			t.Fatalf("task %q after workflow change: category %s, completed at %v", task.Title, task.StatusCategory, task.CompletedAt)
+		}
+	}
+	// Перенос категорий попадает в outbox, но помечен как неслышимый для
+	// пользователей и вебхуков. Записи задачи выдаются по одной, поэтому
+	// outbox вычитывается до конца.
+	recategorized := 0
+	for {
+		records, err := env.outbox.ClaimPending(context.Background(), time.Now(), time.Now().Add(time.Minute), 0)
+		if err != nil {
+			t.Fatalf("claim outbox: %v", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			if record.Event.Type == entity.TaskUpdatedEvent && !record.Event.Silent() {
+				t.Fatalf("recategorization of %s is published to users: %+v", record.Event.TaskID, record.Event)
+			}
+			if record.Event.Recategorized {
+				recategorized++
+			}
+			record.Status = entity.OutboxPublished
+			if err := env.outbox.Update(context.Background(), record); err != nil {
+				t.Fatalf("finish outbox record: %v", err)
+			}
+		}
+	}
+	if recategorized != 3 {
+		t.Fatalf("%d recategorization events, want one per task", recategorized)
+	}
+
+	completed, err := env.taskRepo.GetCompletedBefore(ctx, time.Now().Add(time.Second))
+	if err != nil || len(completed) != 2 {
+		t.Fatalf("completed tasks = %d, %v; want both in-progress tasks", len(completed), err)
+	}
+
+	trashed, err := env.tasks.GetTrashedTask(ctx, backlog.ID)
+	if err != nil || trashed.StatusCategory != entity.CategoryActive {
+		t.Fatalf("trashed task = %+v, %v", trashed, err)
+	}
+
+	// Повторное сохранение того же процесса задачи не меняет
+	before, _ := env.taskRepo.GetByID(ctx, review.ID)
+	if err := workflows.SaveWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("save workflow again: %v", err)
+	}
+	after, _ := env.taskRepo.GetByID(ctx, review.ID)
+	if !after.UpdatedAt.Equal(before.UpdatedAt) || !after.CompletedAt.Equal(*before.CompletedAt) {
+		t.Fatalf("second save changed the task: %+v -> %+v", before, after)
+	}
+}
+
+func TestSaveWorkflowRequiresWorkspaceAdmin(t *testing.T) {
+	env := newTestEnv(t)
+	workflows := NewWorkflowUseCase(env.tasks, env.workflows, env.logger)
+
+	workflow := entity.DefaultWorkflow(entity.DefaultWorkspaceID)
+	workflow.Name = "Hijacked"
+
+	// Участник общего пространства не администратор
+	if err := workflows.SaveWorkflow(userContext("user-456"), workflow); err == nil || err.Error() != "access denied" {
+		t.Fatalf("save by member: error = %v, want access denied", err)
+	}
+
+	// Пространство из заголовка, в котором пользователь не состоит
+	foreign := context.WithValue(userContext("user-123"), "workspace_id", "acme")
+	if err := workflows.SaveWorkflow(foreign, entity.DefaultWorkflow("acme")); err == nil || err.Error() != "access denied" {
+		t.Fatalf("save in foreign workspace: error = %v, want access denied", err)
+	}
+
+	if _, err := env.workflows.GetByWorkspace(context.Background(), entity.DefaultWorkspaceID); err == nil {
+		t.Fatal("workflow is saved despite denied access")
+	}
+	if _, err := env.workflows.GetByWorkspace(context.Background(), "acme"); err == nil {
+		t.Fatal("foreign workflow is saved despite denied access")
+	}
+}
@@ -0,0 +1,185 @@
+// Package backup читает и пишет архивы резервных копий: zip с манифестом,
+// документами JSON и содержимым файлов в каталоге blobs/.
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// Format отличает архивы резервных копий от произвольных zip
+	Format = "taskmanager-backup"
+	// Version версия формата; архивы более новых версий не читаются
+	Version = 1
+
+	manifestName = "manifest.json"
+	blobsDir     = "blobs/"
+)
+
+// Manifest описание архива. Documents — число записей в каждом документе,
+// по нему при чтении проверяется, что архив не обрезан.
+type Manifest struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	UserID    string         `json:"user_id"`
+	Documents map[string]int `json:"documents"`
+	Blobs     int            `json:"blobs"`
+}
+
+// Writer пишет архив потоком; манифест записывается при Close
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+func NewWriter(w io.Writer, userID string) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+		manifest: Manifest{
+			Format:    Format,
+			Version:   Version,
+			CreatedAt: time.Now().UTC(),
+			UserID:    userID,
+			Documents: make(map[string]int),
+		},
+	}
+}
+
+// WriteDocument записывает документ name.json со списком записей items
+func WriteDocument[T any](w *Writer, name string, items []T) error {
+	if items == nil {
+		items = []T{}
+	}
+
+	file, err := w.zw.Create(name + ".json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(items); err != nil {
+		return err
+	}
+
+	w.manifest.Documents[name] = len(items)
+	return nil
+}
+
+// WriteBlob записывает содержимое файла под ключом key
+func (w *Writer) WriteBlob(key string, r io.Reader) error {
+	file, err := w.zw.Create(blobsDir + key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		return err
+	}
+
+	w.manifest.Blobs++
+	return nil
+}
+
+// Close записывает манифест и завершает архив
+func (w *Writer) Close() error {
+	file, err := w.zw.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(w.manifest); err != nil {
+		return err
+	}
+
+	return w.zw.Close()
+}
+
+// Reader читает архив, проверенный по манифесту
+type Reader struct {
+	Manifest Manifest
+	files    map[string]*zip.File
+}
+
+// NewReader открывает архив и проверяет формат и версию манифеста
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %v", err)
+	}
+
+	reader := &Reader{files: make(map[string]*zip.File)}
+	for _, file := range zr.File {
+		reader.files[file.Name] = file
+	}
+
+	if err := reader.decode(manifestName, &reader.Manifest); err != nil {
+		return nil, err
+	}
+	if reader.Manifest.Format != Format {
+		return nil, errors.New("invalid backup archive: unknown format " + reader.Manifest.Format)
+	}
+	if reader.Manifest.Version < 1 || reader.Manifest.Version > Version {
+		return nil, fmt.Errorf("invalid backup archive: unsupported version %d", reader.Manifest.Version)
+	}
+
+	return reader, nil
+}
+
+// ReadDocument читает документ, указанный в манифесте, и сверяет число записей
+func ReadDocument[T any](r *Reader, name string) ([]T, error) {
+	count, ok := r.Manifest.Documents[name]
+	if !ok {
+		return nil, errors.New("invalid backup archive: missing document " + name)
+	}
+
+	var items []T
+	if err := r.decode(name+".json", &items); err != nil {
+		return nil, err
+	}
+	if len(items) != count {
+		return nil, fmt.Errorf("invalid backup archive: document %s has %d records, manifest says %d", name, len(items), count)
+	}
+
+	return items, nil
+}
+
+// HasBlob сообщает, есть ли в архиве содержимое под ключом key
+func (r *Reader) HasBlob(key string) bool {
+	_, ok := r.files[blobsDir+key]
+	return ok
+}
+
+// OpenBlob открывает содержимое файла под ключом key
+func (r *Reader) OpenBlob(key string) (io.ReadCloser, int64, error) {
+	file, ok := r.files[blobsDir+key]
+	if !ok || strings.Contains(key, "/") {
+		return nil, 0, errors.New("invalid backup archive: missing blob " + key)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid backup archive: %v", err)
+	}
+	return rc, int64(file.UncompressedSize64), nil
+}
+
+func (r *Reader) decode(name string, v interface{}) error {
+	file, ok := r.files[name]
+	if !ok {
+		return errors.New("invalid backup archive: missing " + name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("invalid backup archive: %v", err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("invalid backup archive: %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound возвращается, если объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore представляет интерфейс хранилища двоичных объектов
+type BlobStore interface {
+	// Put сохраняет объект; size равен -1, если размер неизвестен
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// validateKey запрещает ключи, которые могут выйти за пределы хранилища
+func validateKey(key string) error {
+	if key == "" {
+		return errors.New("blob key is required")
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errors.New("invalid blob key")
+		}
+	}
+
+	if strings.ContainsAny(key, "\\\x00") {
+		return errors.New("invalid blob key")
+	}
+
+	return nil
+}
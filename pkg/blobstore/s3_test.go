@@ -0,0 +1,149 @@
+package blobstore_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/blobstore/s3test"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newS3Store(t *testing.T) (*blobstore.S3Store, *s3test.Server) {
+	t.Helper()
+
+	server := s3test.NewServer("test-access", "test-secret")
+	t.Cleanup(server.Close)
+
+	store := blobstore.NewS3Store(blobstore.S3Config{
+		Endpoint:  server.URL + "/",
+		Bucket:    "attachments",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+	})
+	return store, server
+}
+
+func readBlob(t *testing.T, store blobstore.BlobStore, key string) string {
+	t.Helper()
+
+	r, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %q: %v", key, err)
+	}
+	return string(data)
+}
+
+func TestS3StorePutGet(t *testing.T) {
+	store, server := newS3Store(t)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "ab/abcdef", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	data, contentType, ok := server.Object("attachments", "ab/abcdef")
+	if !ok || string(data) != "hello" || contentType != "text/plain" {
+		t.Fatalf("stored object = %q, %q, %v", data, contentType, ok)
+	}
+
+	if got := readBlob(t, store, "ab/abcdef"); got != "hello" {
+		t.Fatalf("Get = %q, want hello", got)
+	}
+}
+
+func TestS3StorePutUnknownSize(t *testing.T) {
+	store, server := newS3Store(t)
+
+	if err := store.Put(context.Background(), "stream", io.MultiReader(strings.NewReader("a"), strings.NewReader("b")), -1, ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if data, _, _ := server.Object("attachments", "stream"); string(data) != "ab" {
+		t.Fatalf("stored %q, want ab", data)
+	}
+}
+
+func TestS3StoreExistsAndDelete(t *testing.T) {
+	store, server := newS3Store(t)
+	ctx := context.Background()
+
+	if ok, err := store.Exists(ctx, "key"); err != nil || ok {
+		t.Fatalf("Exists before Put = %v, %v", ok, err)
+	}
+
+	if err := store.Put(ctx, "key", strings.NewReader("data"), 4, ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if ok, err := store.Exists(ctx, "key"); err != nil || !ok {
+		t.Fatalf("Exists after Put = %v, %v", ok, err)
+	}
+
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, ok := server.Object("attachments", "key"); ok {
+		t.Fatal("object still stored after Delete")
+	}
+	if _, err := store.Get(ctx, "key"); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+
+	// Повторное удаление не ошибка
+	if err := store.Delete(ctx, "key"); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestS3StoreEscapesKeys(t *testing.T) {
+	store, server := newS3Store(t)
+	key := "imports/отчет за май+итог (1).json"
+
+	if err := store.Put(context.Background(), key, strings.NewReader("{}"), 2, "application/json"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if _, _, ok := server.Object("attachments", key); !ok {
+		t.Fatalf("object not stored under %q: %v", key, server.Keys("attachments"))
+	}
+	if got := readBlob(t, store, key); got != "{}" {
+		t.Fatalf("Get = %q", got)
+	}
+}
+
+func TestS3StoreRejectsInvalidKeys(t *testing.T) {
+	store, server := newS3Store(t)
+
+	for _, key := range []string{"", "../etc/passwd", "a//b", "a/./b"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Fatalf("invalid keys reached the server: %v", requests)
+	}
+}
+
+func TestS3StoreWrongSecret(t *testing.T) {
+	server := s3test.NewServer("test-access", "test-secret")
+	defer server.Close()
+
+	store := blobstore.NewS3Store(blobstore.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "attachments",
+		AccessKey: "test-access",
+		SecretKey: "wrong",
+	})
+
+	err := store.Put(context.Background(), "key", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put with wrong secret = %v, want 403 error", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package s3test поднимает локальный заменитель S3-совместимого хранилища
+// для тестов: объекты хранятся в памяти, подпись AWS Signature V4 каждого
+// запроса проверяется ключами сервера.
+package s3test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Server заменитель S3 с адресацией бакета в пути (/bucket/key)
+type Server struct {
+	*httptest.Server
+
+	AccessKey string
+	SecretKey string
+
+	mutex    sync.Mutex
+	objects  map[string]object // "bucket/key" -> объект
+	requests []string
+}
+
+// NewServer запускает сервер, принимающий запросы, подписанные ключами
+// accessKey и secretKey. Сервер нужно закрыть через Close.
+func NewServer(accessKey, secretKey string) *Server {
+	s := &Server{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		objects:   make(map[string]object),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Object возвращает содержимое и тип объекта
+func (s *Server) Object(bucket, key string) ([]byte, string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	obj, ok := s.objects[bucket+"/"+key]
+	return obj.data, obj.contentType, ok
+}
+
+// Keys возвращает ключи всех объектов бакета по порядку
+func (s *Server) Keys(bucket string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for name := range s.objects {
+		if key, ok := strings.CutPrefix(name, bucket+"/"); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Requests возвращает принятые запросы в виде "METHOD bucket/key"
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if err := s.verify(r); err != nil {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if bucket, key, _ := strings.Cut(name, "/"); bucket == "" || key == "" {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "bucket and key are required")
+		return
+	}
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, r.Method+" "+name)
+	s.mutex.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		s.mutex.Lock()
+		s.objects[name] = object{data: data, contentType: r.Header.Get("Content-Type")}
+		s.mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet, http.MethodHead:
+		s.mutex.Lock()
+		obj, ok := s.objects[name]
+		s.mutex.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.data)))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+
+	case http.MethodDelete:
+		// Как и S3, удаление отсутствующего объекта не считается ошибкой
+		s.mutex.Lock()
+		delete(s.objects, name)
+		s.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// verify проверяет подпись Signature V4 запроса, вычисляя её заново по
+// заголовкам, перечисленным в SignedHeaders
+func (s *Server) verify(r *http.Request) error {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return fmt.Errorf("missing AWS4-HMAC-SHA256 authorization")
+	}
+
+	params := make(map[string]string)
+	for _, part := range strings.Split(auth, ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[key] = value
+		}
+	}
+
+	credential := strings.Split(params["Credential"], "/")
+	if len(credential) != 5 || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return fmt.Errorf("malformed credential %q", params["Credential"])
+	}
+	if credential[0] != s.AccessKey {
+		return fmt.Errorf("unknown access key %q", credential[0])
+	}
+	date, region := credential[1], credential[2]
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, date) {
+		return fmt.Errorf("x-amz-date %q does not match credential date", amzDate)
+	}
+
+	var canonicalHeaders strings.Builder
+	signedHeaders := strings.Split(params["SignedHeaders"], ";")
+	for _, header := range signedHeaders {
+		value := r.Header.Get(header)
+		if header == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		params["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := sum([]byte("AWS4"+s.SecretKey), date)
+	key = sum(key, region)
+	key = sum(key, "s3")
+	key = sum(key, "aws4_request")
+	expected := hex.EncodeToString(sum(key, stringToSign))
+
+	if !hmac.Equal([]byte(expected), []byte(params["Signature"])) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+func sum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
@@ -0,0 +1,104 @@
+// Package ical формирует данные iCalendar (RFC 5545): компоненты,
+// свойства с экранированием текста и перенос длинных строк.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets предельная длина строки без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Writer пишет календарь построчно. Ошибка записи запоминается, и
+// последующие вызовы ничего не делают; её возвращает Flush.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Begin открывает компонент (VCALENDAR, VTODO, VEVENT, ...)
+func (w *Writer) Begin(component string) {
+	w.line("BEGIN:" + component)
+}
+
+// End закрывает компонент
+func (w *Writer) End(component string) {
+	w.line("END:" + component)
+}
+
+// Raw пишет свойство со значением как есть; значение должно быть уже
+// в формате своего типа (например, STATUS:COMPLETED)
+func (w *Writer) Raw(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text пишет текстовое свойство, экранируя значение
+func (w *Writer) Text(name, value string) {
+	w.line(name + ":" + EscapeText(value))
+}
+
+// Time пишет свойство с датой и временем в UTC (DTSTAMP:20240102T150405Z)
+func (w *Writer) Time(name string, t time.Time) {
+	w.line(name + ":" + t.UTC().Format("20060102T150405Z"))
+}
+
+// Flush дописывает буфер и возвращает первую ошибку записи
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// line пишет строку содержимого, перенося её на строки-продолжения,
+// начинающиеся с пробела, так, чтобы ни одна не превышала 75 октетов и
+// многобайтовые символы UTF-8 не разрывались
+func (w *Writer) line(content string) {
+	if w.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.write(content[:cut])
+		w.write("\r\n ")
+		content = content[cut:]
+		// Пробел в начале строки-продолжения входит в её длину
+		limit = maxLineOctets - 1
+	}
+	w.write(content)
+	w.write("\r\n")
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+// textEscaper экранирует символы, особые для значений типа TEXT
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// EscapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func EscapeText(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -0,0 +1,111 @@
+// Package importer разбирает экспорты других трекеров задач (Trello, Jira)
+// в общий список записей, не зависящий от модели сервиса.
+package importer
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	FormatTrello = "trello"
+	FormatJira   = "jira"
+)
+
+// Категории статуса, которые угадываются по данным источника
+const (
+	CategoryTodo   = "todo"
+	CategoryActive = "active"
+	CategoryDone   = "done"
+)
+
+// Board разобранный экспорт: доска Trello или выборка задач Jira
+type Board struct {
+	Name  string
+	Items []Item
+	// Skipped записи, которые не импортируются (например, архивные карточки)
+	Skipped int
+}
+
+// Item задача источника. Записи идут так, что родитель всегда раньше
+// своих подзадач; порядок внутри колонки сохраняется.
+type Item struct {
+	ExternalID  string
+	ParentID    string // внешний ID родителя
+	Title       string
+	Description string
+	// State колонка Trello или статус Jira; по нему настраивается
+	// соответствие статусам
+	State string
+	// Category категория статуса, угаданная по источнику
+	Category    string
+	Labels      []string
+	DueAt       *time.Time
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	Comments    []Comment
+	Checklist   []CheckItem
+}
+
+// Comment комментарий источника; автор — отображаемое имя
+type Comment struct {
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// CheckItem пункт чек-листа
+type CheckItem struct {
+	Text string
+	Done bool
+}
+
+// Parse разбирает экспорт в формате format
+func Parse(format string, r io.Reader) (*Board, error) {
+	switch format {
+	case FormatTrello:
+		return ParseTrello(r)
+	case FormatJira:
+		return ParseJira(r)
+	}
+	return nil, errors.New("unknown import format " + format)
+}
+
+// orderParentsFirst переставляет записи так, чтобы родитель шел раньше
+// подзадач, сохраняя исходный порядок в остальном. Ссылки на родителя,
+// которого нет в выборке или который образует цикл, убираются.
+func orderParentsFirst(items []Item) []Item {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.ExternalID] = i
+	}
+
+	result := make([]Item, 0, len(items))
+	state := make([]int, len(items)) // 0 — не посещена, 1 — в обходе, 2 — добавлена
+
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+		state[i] = 1
+
+		if parent, ok := index[items[i].ParentID]; ok && items[i].ParentID != "" {
+			visit(parent)
+			if state[parent] != 2 {
+				items[i].ParentID = ""
+			}
+		} else {
+			items[i].ParentID = ""
+		}
+
+		state[i] = 2
+		result = append(result, items[i])
+	}
+
+	for i := range items {
+		visit(i)
+	}
+	return result
+}
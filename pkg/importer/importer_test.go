@@ -0,0 +1,151 @@
+package importer_test
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/importer"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, format, name string) *importer.Board {
+	t.Helper()
+
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer file.Close()
+
+	board, err := importer.Parse(format, file)
+	if err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+	return board
+}
+
+// externalIDs возвращает внешние ID записей и их родителей в порядке доски
+func externalIDs(board *importer.Board) []string {
+	var ids []string
+	for _, item := range board.Items {
+		id := item.ExternalID
+		if item.ParentID != "" {
+			id += "<" + item.ParentID
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestParseJira(t *testing.T) {
+	board := parseFixture(t, importer.FormatJira, "jira.json")
+
+	if board.Name != "APP" || board.Skipped != 1 {
+		t.Fatalf("board %q skipped %d", board.Name, board.Skipped)
+	}
+
+	// Родитель идет раньше подзадачи; ссылки на отсутствующего родителя и
+	// замыкающие цикл убираются
+	want := []string{"APP-1", "APP-2<APP-1", "APP-3", "APP-5", "APP-4<APP-5"}
+	if got := externalIDs(board); !reflect.DeepEqual(got, want) {
+		t.Fatalf("items = %v, want %v", got, want)
+	}
+
+	epic, bug := board.Items[0], board.Items[1]
+
+	if epic.Description != "Plain text from Jira Server" || epic.Category != importer.CategoryDone {
+		t.Fatalf("epic = %+v", epic)
+	}
+	if epic.DueAt == nil || !epic.DueAt.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("epic due = %v", epic.DueAt)
+	}
+	if epic.CompletedAt == nil || !epic.CompletedAt.Equal(time.Date(2024, time.March, 20, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("epic completed = %v", epic.CompletedAt)
+	}
+
+	description := strings.Join([]string{
+		"Steps:",
+		"- Open the app",
+		"- Tap Login",
+		"  - nothing happens",
+		"Seen on 14",
+		"and 15",
+	}, "\n")
+	if bug.Description != description {
+		t.Fatalf("ADF description = %q, want %q", bug.Description, description)
+	}
+	if bug.State != "In Review" || bug.Category != importer.CategoryActive || bug.CompletedAt != nil {
+		t.Fatalf("bug = %+v", bug)
+	}
+	if !bug.CreatedAt.Equal(time.Date(2024, time.March, 2, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("bug created = %v", bug.CreatedAt)
+	}
+	if len(bug.Comments) != 1 || bug.Comments[0].Author != "Ann Lee" || bug.Comments[0].Body != "Reproduced" {
+		t.Fatalf("bug comments = %+v", bug.Comments)
+	}
+
+	if board.Items[2].Description != "" || board.Items[2].Category != importer.CategoryTodo {
+		t.Fatalf("issue with null description = %+v", board.Items[2])
+	}
+}
+
+func TestParseTrello(t *testing.T) {
+	board := parseFixture(t, importer.FormatTrello, "trello.json")
+
+	// Архивная карточка и карточка архивного списка пропускаются; порядок —
+	// по спискам, затем по позиции карточки
+	if board.Name != "Release board" || board.Skipped != 2 {
+		t.Fatalf("board %q skipped %d", board.Name, board.Skipped)
+	}
+	want := []string{"65a0000000000000000000t1", "65a0000000000000000000t2", "65a0000000000000000000p1", "65a0000000000000000000d1"}
+	if got := externalIDs(board); !reflect.DeepEqual(got, want) {
+		t.Fatalf("items = %v, want %v", got, want)
+	}
+
+	readme, changelog, flaky, ship := board.Items[0], board.Items[1], board.Items[2], board.Items[3]
+
+	if readme.State != "To Do" || readme.Category != importer.CategoryTodo || readme.Description != "Mention the new flags" || readme.DueAt == nil {
+		t.Fatalf("readme = %+v", readme)
+	}
+	if !readme.CreatedAt.Equal(time.Unix(0x65a00000, 0)) {
+		t.Fatalf("readme created = %v", readme.CreatedAt)
+	}
+
+	checklist := []importer.CheckItem{
+		{Text: "Sections: Install", Done: true},
+		{Text: "Sections: Flags"},
+		{Text: "Review: Proofread"},
+	}
+	if !reflect.DeepEqual(readme.Checklist, checklist) {
+		t.Fatalf("merged checklist = %+v, want %+v", readme.Checklist, checklist)
+	}
+	if len(readme.Comments) != 2 || readme.Comments[0].Body != "First" || readme.Comments[0].Author != "Ann Lee" || readme.Comments[1].Author != "bob" {
+		t.Fatalf("comments = %+v, want oldest first", readme.Comments)
+	}
+
+	if !reflect.DeepEqual(changelog.Labels, []string{"docs", "red"}) {
+		t.Fatalf("labels = %v", changelog.Labels)
+	}
+	if flaky.Category != importer.CategoryActive || !reflect.DeepEqual(flaky.Checklist, []importer.CheckItem{{Text: "Find the seed", Done: true}}) {
+		t.Fatalf("flaky = %+v", flaky)
+	}
+
+	// Завершение — последнее перемещение в завершающий список
+	if ship.Category != importer.CategoryDone || ship.CompletedAt == nil || !ship.CompletedAt.Equal(time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC)) {
+		t.Fatalf("shipped card = %+v", ship)
+	}
+}
+
+func TestParseRejectsForeignFiles(t *testing.T) {
+	for _, tc := range []struct{ format, input string }{
+		{importer.FormatJira, `{"name": "Release board", "cards": []}`},
+		{importer.FormatTrello, `{"issues": []}`},
+		{importer.FormatTrello, `not json`},
+		{"asana", `{}`},
+	} {
+		if _, err := importer.Parse(tc.format, strings.NewReader(tc.input)); err == nil {
+			t.Fatalf("Parse(%s, %s) succeeded", tc.format, tc.input)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"`
+		Status      struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Labels         []string `json:"labels"`
+		DueDate        string   `json:"duedate"`
+		Created        string   `json:"created"`
+		ResolutionDate string   `json:"resolutiondate"`
+		Parent         *struct {
+			Key string `json:"key"`
+		} `json:"parent"`
+		Comment struct {
+			Comments []struct {
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Body    json.RawMessage `json:"body"`
+				Created string          `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// adfNode узел Atlassian Document Format, в котором Jira Cloud отдает
+// описания и комментарии
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+}
+
+// jiraTimeLayout формат времени REST API Jira
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// ParseJira разбирает JSON с задачами Jira: ответ поиска REST API
+// ({"issues": [...]}) или массив задач. Статус Jira становится State,
+// его категория — Category; подзадачи связываются с родителем по ключу.
+// Описания в Atlassian Document Format переводятся в текст.
+func ParseJira(r io.Reader) (*Board, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []jiraIssue
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &issues)
+	} else {
+		var search struct {
+			Issues []jiraIssue `json:"issues"`
+		}
+		err = json.Unmarshal(data, &search)
+		if err == nil && search.Issues == nil {
+			err = fmt.Errorf("not a Jira issues export")
+		}
+		issues = search.Issues
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid import file: %v", err)
+	}
+
+	board := &Board{Name: "Jira import"}
+	for _, issue := range issues {
+		fields := issue.Fields
+		if issue.Key == "" {
+			board.Skipped++
+			continue
+		}
+
+		item := Item{
+			ExternalID:  issue.Key,
+			Title:       fields.Summary,
+			Description: jiraText(fields.Description),
+			State:       fields.Status.Name,
+			Category:    jiraCategory(fields.Status.StatusCategory.Key),
+			Labels:      fields.Labels,
+			CreatedAt:   parseJiraTime(fields.Created),
+		}
+		if fields.Parent != nil {
+			item.ParentID = fields.Parent.Key
+		}
+
+		if due, err := time.Parse("2006-01-02", fields.DueDate); err == nil {
+			item.DueAt = &due
+		}
+		if resolved := parseJiraTime(fields.ResolutionDate); !resolved.IsZero() && item.Category == CategoryDone {
+			item.CompletedAt = &resolved
+		}
+
+		for _, comment := range fields.Comment.Comments {
+			item.Comments = append(item.Comments, Comment{
+				Author:    comment.Author.DisplayName,
+				Body:      jiraText(comment.Body),
+				CreatedAt: parseJiraTime(comment.Created),
+			})
+		}
+
+		board.Items = append(board.Items, item)
+	}
+
+	// Ключ проекта первой задачи дает выборке имя
+	if len(board.Items) > 0 {
+		if project, _, ok := strings.Cut(board.Items[0].ExternalID, "-"); ok {
+			board.Name = project
+		}
+	}
+
+	board.Items = orderParentsFirst(board.Items)
+	return board, nil
+}
+
+// jiraCategory переводит ключ категории статуса Jira
+func jiraCategory(key string) string {
+	switch key {
+	case "done":
+		return CategoryDone
+	case "indeterminate":
+		return CategoryActive
+	}
+	return CategoryTodo
+}
+
+func parseJiraTime(value string) time.Time {
+	for _, layout := range []string{jiraTimeLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// jiraText возвращает текст поля: строку Jira Server или документ ADF Jira Cloud
+func jiraText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var doc adfNode
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	writeADF(&b, doc, 0)
+	return strings.TrimSpace(b.String())
+}
+
+// writeADF выводит узел ADF как текст: блоки с новой строки, пункты
+// списков с маркером и отступом по глубине вложенности
+func writeADF(b *strings.Builder, node adfNode, depth int) {
+	switch node.Type {
+	case "text":
+		b.WriteString(node.Text)
+		return
+	case "hardBreak":
+		b.WriteString("\n")
+		return
+	case "listItem":
+		b.WriteString(strings.Repeat("  ", max(depth-1, 0)) + "- ")
+	case "bulletList", "orderedList":
+		depth++
+	}
+
+	for _, child := range node.Content {
+		writeADF(b, child, depth)
+	}
+
+	switch node.Type {
+	case "paragraph", "heading", "codeBlock", "blockquote", "rule":
+		b.WriteString("\n")
+	}
+}
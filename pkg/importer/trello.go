@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type trelloBoard struct {
+	Name       string            `json:"name"`
+	Lists      []trelloList      `json:"lists"`
+	Cards      []trelloCard      `json:"cards"`
+	Checklists []trelloChecklist `json:"checklists"`
+	Actions    []trelloAction    `json:"actions"`
+}
+
+type trelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type trelloCard struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Desc   string        `json:"desc"`
+	IDList string        `json:"idList"`
+	Closed bool          `json:"closed"`
+	Pos    float64       `json:"pos"`
+	Due    *time.Time    `json:"due"`
+	Labels []trelloLabel `json:"labels"`
+}
+
+type trelloLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type trelloChecklist struct {
+	IDCard     string            `json:"idCard"`
+	Name       string            `json:"name"`
+	Pos        float64           `json:"pos"`
+	CheckItems []trelloCheckItem `json:"checkItems"`
+}
+
+type trelloCheckItem struct {
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+type trelloAction struct {
+	Type string    `json:"type"`
+	Date time.Time `json:"date"`
+	Data struct {
+		Text string `json:"text"`
+		Card struct {
+			ID string `json:"id"`
+		} `json:"card"`
+		ListAfter *struct {
+			ID string `json:"id"`
+		} `json:"listAfter"`
+	} `json:"data"`
+	MemberCreator struct {
+		FullName string `json:"fullName"`
+		Username string `json:"username"`
+	} `json:"memberCreator"`
+}
+
+// ParseTrello разбирает JSON-экспорт доски Trello. Списки становятся
+// колонками (State), карточки — задачами, метки — метками, чек-листы
+// сливаются в один; если их несколько, пункт начинается с имени чек-листа.
+// Архивные карточки и карточки архивных списков пропускаются.
+func ParseTrello(r io.Reader) (*Board, error) {
+	var export trelloBoard
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid import file: %v", err)
+	}
+	if export.Cards == nil && export.Lists == nil {
+		return nil, fmt.Errorf("invalid import file: not a Trello board export")
+	}
+
+	lists := make(map[string]trelloList, len(export.Lists))
+	for _, list := range export.Lists {
+		lists[list.ID] = list
+	}
+
+	checklists := make(map[string][]trelloChecklist)
+	for _, checklist := range export.Checklists {
+		checklists[checklist.IDCard] = append(checklists[checklist.IDCard], checklist)
+	}
+
+	// Действия в экспорте идут от новых к старым
+	comments := make(map[string][]Comment)
+	completed := make(map[string]time.Time)
+	for _, action := range export.Actions {
+		cardID := action.Data.Card.ID
+		switch action.Type {
+		case "commentCard":
+			author := action.MemberCreator.FullName
+			if author == "" {
+				author = action.MemberCreator.Username
+			}
+			comments[cardID] = append(comments[cardID], Comment{Author: author, Body: action.Data.Text, CreatedAt: action.Date})
+		case "updateCard":
+			// Последнее перемещение в завершающий список считается
+			// моментом завершения
+			if action.Data.ListAfter != nil {
+				if _, seen := completed[cardID]; !seen && trelloCategory(lists[action.Data.ListAfter.ID].Name) == CategoryDone {
+					completed[cardID] = action.Date
+				}
+			}
+		}
+	}
+
+	cards := export.Cards
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := lists[cards[i].IDList], lists[cards[j].IDList]
+		if a.Pos != b.Pos {
+			return a.Pos < b.Pos
+		}
+		return cards[i].Pos < cards[j].Pos
+	})
+
+	board := &Board{Name: export.Name}
+	for _, card := range cards {
+		list, ok := lists[card.IDList]
+		if card.Closed || !ok || list.Closed {
+			board.Skipped++
+			continue
+		}
+
+		item := Item{
+			ExternalID:  card.ID,
+			Title:       card.Name,
+			Description: card.Desc,
+			State:       list.Name,
+			Category:    trelloCategory(list.Name),
+			DueAt:       card.Due,
+			CreatedAt:   trelloCreatedAt(card.ID),
+		}
+
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			item.Labels = append(item.Labels, name)
+		}
+
+		cardComments := comments[card.ID]
+		for i := len(cardComments) - 1; i >= 0; i-- {
+			item.Comments = append(item.Comments, cardComments[i])
+		}
+
+		cardChecklists := checklists[card.ID]
+		sort.SliceStable(cardChecklists, func(i, j int) bool { return cardChecklists[i].Pos < cardChecklists[j].Pos })
+		for _, checklist := range cardChecklists {
+			sort.SliceStable(checklist.CheckItems, func(i, j int) bool { return checklist.CheckItems[i].Pos < checklist.CheckItems[j].Pos })
+			for _, checkItem := range checklist.CheckItems {
+				text := checkItem.Name
+				if len(cardChecklists) > 1 {
+					text = checklist.Name + ": " + text
+				}
+				item.Checklist = append(item.Checklist, CheckItem{Text: text, Done: checkItem.State == "complete"})
+			}
+		}
+
+		if item.Category == CategoryDone {
+			if at, ok := completed[card.ID]; ok {
+				item.CompletedAt = &at
+			}
+		}
+
+		board.Items = append(board.Items, item)
+	}
+
+	return board, nil
+}
+
+// trelloCategory угадывает категорию статуса по названию списка
+func trelloCategory(name string) string {
+	name = strings.ToLower(name)
+	for _, word := range []string{"done", "complete", "closed", "finished", "shipped", "готово", "сделано"} {
+		if strings.Contains(name, word) {
+			return CategoryDone
+		}
+	}
+	for _, word := range []string{"doing", "progress", "review", "testing", "active", "в работе"} {
+		if strings.Contains(name, word) {
+			return CategoryActive
+		}
+	}
+	return CategoryTodo
+}
+
+// trelloCreatedAt извлекает время создания из ID: первые 8 шестнадцатеричных
+// цифр — Unix-время в секундах
+func trelloCreatedAt(id string) time.Time {
+	if len(id) < 8 {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(id[:8], 16, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig параметры SMTP-сервера
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// Timeout ограничивает соединение и весь обмен с сервером
+	Timeout time.Duration
+}
+
+// EmailNotifier отправляет уведомления письмом через SMTP. Message-ID
+// строится из ключа идемпотентности, поэтому повторно доставленное письмо
+// почтовые клиенты распознают как дубль.
+type EmailNotifier struct {
+	config SMTPConfig
+}
+
+func NewEmailNotifier(config SMTPConfig) *EmailNotifier {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &EmailNotifier{config: config}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.To == "" {
+		return errors.New("recipient email is required")
+	}
+
+	dialer := &net.Dialer{Timeout: n.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Зависший сервер не должен останавливать отправку остальных писем:
+	// весь обмен ограничен таймаутом и прерывается отменой контекста
+	deadline := time.Now().Add(n.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := n.send(conn, notification); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// send передает письмо по уже открытому соединению так же, как
+// smtp.SendMail: STARTTLS, если сервер его предлагает, и вход, если задан
+// пользователь
+func (n *EmailNotifier) send(conn net.Conn, notification Notification) error {
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if n.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.config.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(notification.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(notification)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (n *EmailNotifier) message(notification Notification) []byte {
+	domain := n.config.Host
+	if at := strings.LastIndex(n.config.From, "@"); at >= 0 {
+		domain = n.config.From[at+1:]
+	}
+
+	sentAt := notification.SentAt
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", notification.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(notification.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", sentAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", notification.ID, domain)
+	if notification.UnsubscribeURL != "" {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\n", headerValue(notification.UnsubscribeURL))
+		b.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if notification.HTML == "" {
+		writePart(&b, "text/plain", notification.Body)
+		return []byte(b.String())
+	}
+
+	// Клиенты без поддержки HTML показывают текстовую часть
+	boundary := "alt-" + strconv.FormatInt(sentAt.UnixNano(), 36)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	writePart(&b, "text/plain", notification.Body)
+	fmt.Fprintf(&b, "\r\n--%s\r\n", boundary)
+	writePart(&b, "text/html", notification.HTML)
+	fmt.Fprintf(&b, "\r\n--%s--\r\n", boundary)
+
+	return []byte(b.String())
+}
+
+// writePart записывает заголовки и тело части письма в кодировке
+// quoted-printable, чтобы длинные строки и не-ASCII текст доходили без искажений
+func writePart(b *strings.Builder, contentType, body string) {
+	fmt.Fprintf(b, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(b)
+	w.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")))
+	w.Close()
+}
+
+// headerValue убирает переводы строк, чтобы значение не могло добавить заголовки
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,82 @@
+package notifier_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/notifier/smtptest"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// silentServer принимает соединения и ничего не отвечает, как зависший
+// SMTP-сервер
+func silentServer(t *testing.T) (string, int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestEmailNotifierTimesOut(t *testing.T) {
+	host, port := silentServer(t)
+	mailer := notifier.NewEmailNotifier(notifier.SMTPConfig{Host: host, Port: port, From: "tasks@example.com", Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	err := mailer.Notify(context.Background(), notifier.Notification{ID: "1", To: "alice@example.com", Subject: "Hi", Body: "Hello"})
+	if err == nil {
+		t.Fatal("Notify to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Notify returned after %v, want the timeout", elapsed)
+	}
+}
+
+func TestEmailNotifierStopsOnContextCancel(t *testing.T) {
+	host, port := silentServer(t)
+	mailer := notifier.NewEmailNotifier(notifier.SMTPConfig{Host: host, Port: port, From: "tasks@example.com", Timeout: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err := mailer.Notify(ctx, notifier.Notification{ID: "1", To: "alice@example.com", Subject: "Hi", Body: "Hello"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Notify error = %v, want context.Canceled", err)
+	}
+}
+
+func TestEmailNotifierSends(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+
+	mailer := notifier.NewEmailNotifier(notifier.SMTPConfig{Host: server.Host(), Port: server.Port(), From: "tasks@example.com"})
+	if err := mailer.Notify(context.Background(), notifier.Notification{ID: "n-1", To: "alice@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 || !strings.HasPrefix(messages[0].From, "<tasks@example.com>") || len(messages[0].To) != 1 || messages[0].To[0] != "<alice@example.com>" {
+		t.Fatalf("messages = %+v", messages)
+	}
+	if !strings.Contains(messages[0].Data, "Message-ID: <n-1@example.com>") {
+		t.Fatalf("message does not carry the idempotency key:\n%s", messages[0].Data)
+	}
+}
@@ -0,0 +1,26 @@
+package notifier
+
+import (
+	"context"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/logger"
+)
+
+// LogNotifier записывает уведомления в журнал приложения
+type LogNotifier struct {
+	logger *logger.Logger
+}
+
+func NewLogNotifier(logger *logger.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.logger.Info("Notification", map[string]interface{}{
+		"id":      notification.ID,
+		"user":    notification.UserID,
+		"task":    notification.TaskID,
+		"subject": notification.Subject,
+		"body":    notification.Body,
+	})
+	return nil
+}
@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"context"
+	"time"
+)
+
+// Notification уведомление, отправляемое пользователю
+type Notification struct {
+	// ID ключ идемпотентности: повторная отправка того же уведомления
+	// после сбоя приходит с тем же ID, и получатель может отбросить дубль
+	ID      string
+	UserID  string
+	To      string // адрес получателя для каналов, которым он нужен (email)
+	Subject string
+	Body    string
+	HTML    string // HTML-версия тела; каналы без поддержки HTML используют Body
+	TaskID  string
+	SentAt  time.Time
+	// UnsubscribeURL ссылка для отписки в один клик (RFC 8058)
+	UnsubscribeURL string
+}
+
+// Notifier представляет канал доставки уведомлений
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
@@ -0,0 +1,186 @@
+// Package smtptest поднимает минимальный SMTP-сервер, который принимает
+// любые письма и запоминает их: для тестов почтовых уведомлений и локальной
+// проверки через cmd/fakesmtp.
+package smtptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message принятое письмо; Data — текст письма с заголовками без
+// завершающей точки
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Server SMTP-сервер без авторизации и TLS
+type Server struct {
+	// Addr адрес, на котором слушает сервер, в виде host:port
+	Addr string
+
+	listener net.Listener
+	handler  func(Message)
+
+	mutex    sync.Mutex
+	messages []Message
+	reject   func(Message) bool
+}
+
+// NewServer запускает сервер на свободном порту localhost. Сервер нужно
+// закрыть через Close.
+func NewServer() *Server {
+	s, err := Start("127.0.0.1:0", nil)
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to listen: %v", err))
+	}
+	return s
+}
+
+// Start запускает сервер на адресе addr; handler, если задан, вызывается
+// для каждого принятого письма
+func Start(addr string, handler func(Message)) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{Addr: listener.Addr().String(), listener: listener, handler: handler}
+	go s.accept()
+	return s, nil
+}
+
+// Host возвращает хост сервера
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port возвращает порт сервера
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Close останавливает прием соединений
+func (s *Server) Close() {
+	s.listener.Close()
+}
+
+// Messages возвращает принятые письма по порядку
+func (s *Server) Messages() []Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Reject отклоняет временной ошибкой 451 письма, для которых reject
+// возвращает true; nil снова принимает все письма
+func (s *Server) Reject(reject func(Message) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reject = reject
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve ведет SMTP-диалог с одним клиентом
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	var from string
+	var to []string
+
+	reply("220 smtptest ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-smtptest")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "HELO"):
+			reply("250 smtptest")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			from = strings.TrimSpace(line[len("MAIL FROM:"):])
+			to = nil
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			to = append(to, strings.TrimSpace(line[len("RCPT TO:"):]))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" || dataLine == ".\n" {
+					break
+				}
+				// Снимаем точку, удвоенную клиентом (RFC 5321, 4.5.2)
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+
+			if s.record(Message{From: from, To: to, Data: data.String()}) {
+				reply("250 OK: queued")
+			} else {
+				reply("451 Temporary failure")
+			}
+		case cmd == "RSET":
+			from, to = "", nil
+			reply("250 OK")
+		case cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// record запоминает письмо или отклоняет его, если так решил фильтр Reject
+func (s *Server) record(message Message) bool {
+	s.mutex.Lock()
+	if s.reject != nil && s.reject(message) {
+		s.mutex.Unlock()
+		return false
+	}
+	s.messages = append(s.messages, message)
+	s.mutex.Unlock()
+
+	if s.handler != nil {
+		s.handler(message)
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+// Package todotxt читает и пишет задачи в формате todo.txt
+// (https://github.com/todotxt/todo.txt): одна задача на строку, отметка
+// выполнения, приоритет, даты, +проекты, @контексты и расширения key:value.
+//
+// Формат не предусматривает экранирования, поэтому пакет добавляет свое,
+// чтобы записанная задача читалась обратно без изменений. Слово текста,
+// которое было бы прочитано как разметка, пишется с обратной косой чертой
+// в начале (\+word, \10:30). В именах проектов, контекстов и значениях
+// расширений пробельные символы и знак процента кодируются как в URL
+// (%20, %25); другие последовательности с процентом остаются как есть.
+package todotxt
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// DateLayout формат дат todo.txt
+const DateLayout = "2006-01-02"
+
+// priorityKey расширение, в котором выполненная задача хранит приоритет:
+// у выполненных задач приоритет в начале строки не ставится
+const priorityKey = "pri"
+
+// Task строка todo.txt. Text — описание без +проектов, @контекстов и
+// расширений; при записи они добавляются после текста в этом порядке.
+// Все поля хранятся без экранирования.
+type Task struct {
+	Done bool
+	// Priority заглавная буква от A до Z или пустая строка
+	Priority    string
+	CompletedAt *time.Time
+	CreatedAt   *time.Time
+	Text        string
+	Projects    []string
+	Contexts    []string
+	Extensions  []Extension
+}
+
+// Extension расширение key:value; порядок расширений в строке сохраняется
+type Extension struct {
+	Key   string
+	Value string
+}
+
+// Extension возвращает значение расширения key
+func (t *Task) Extension(key string) (string, bool) {
+	for _, ext := range t.Extensions {
+		if ext.Key == key {
+			return ext.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse разбирает строку todo.txt. Приоритет выполненной задачи берется
+// из расширения pri:, как его сохраняет todo.sh.
+func Parse(line string) (*Task, error) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return nil, errors.New("empty line")
+	}
+
+	task := &Task{}
+	if words[0] == "x" {
+		task.Done = true
+		words = words[1:]
+	} else if isPriority(words[0]) {
+		task.Priority = words[0][1:2]
+		words = words[1:]
+	}
+
+	// Даты: у выполненной задачи первая — завершения, вторая — создания
+	var dates []time.Time
+	for len(words) > 0 && len(dates) < 2 {
+		date, err := time.Parse(DateLayout, words[0])
+		if err != nil {
+			break
+		}
+		dates = append(dates, date)
+		words = words[1:]
+	}
+	switch {
+	case task.Done && len(dates) == 2:
+		task.CompletedAt, task.CreatedAt = &dates[0], &dates[1]
+	case task.Done && len(dates) == 1:
+		task.CompletedAt = &dates[0]
+	case len(dates) == 2:
+		// Вторая дата у невыполненной задачи — уже часть текста
+		task.CreatedAt = &dates[0]
+		words = append([]string{dates[1].Format(DateLayout)}, words...)
+	case len(dates) == 1:
+		task.CreatedAt = &dates[0]
+	}
+
+	var text []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '\\':
+			text = append(text, word[1:])
+		case len(word) > 1 && word[0] == '+':
+			task.Projects = append(task.Projects, tokenDecoder.Replace(word[1:]))
+		case len(word) > 1 && word[0] == '@':
+			task.Contexts = append(task.Contexts, tokenDecoder.Replace(word[1:]))
+		default:
+			key, value, ok := splitExtension(word)
+			if !ok {
+				text = append(text, word)
+				continue
+			}
+			if task.Done && key == priorityKey && isPriority("("+value+")") {
+				task.Priority = value
+				continue
+			}
+			task.Extensions = append(task.Extensions, Extension{Key: key, Value: tokenDecoder.Replace(value)})
+		}
+	}
+	task.Text = strings.Join(text, " ")
+
+	return task, nil
+}
+
+// String возвращает задачу строкой todo.txt без перевода строки
+func (t *Task) String() string {
+	var words []string
+	if t.Done {
+		words = append(words, "x")
+		if t.CompletedAt != nil {
+			words = append(words, t.CompletedAt.Format(DateLayout))
+		}
+	} else if t.Priority != "" {
+		words = append(words, "("+t.Priority+")")
+	}
+
+	// Дата создания выполненной задачи пишется только после даты завершения
+	if t.CreatedAt != nil && (!t.Done || t.CompletedAt != nil) {
+		words = append(words, t.CreatedAt.Format(DateLayout))
+	}
+
+	for i, word := range strings.Fields(t.Text) {
+		words = append(words, escapeWord(word, i == 0))
+	}
+	for _, project := range t.Projects {
+		words = append(words, "+"+tokenEncoder.Replace(project))
+	}
+	for _, context := range t.Contexts {
+		words = append(words, "@"+tokenEncoder.Replace(context))
+	}
+	for _, ext := range t.Extensions {
+		words = append(words, ext.Key+":"+tokenEncoder.Replace(ext.Value))
+	}
+	if t.Done && t.Priority != "" {
+		words = append(words, priorityKey+":"+t.Priority)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Token приводит имя к привычному виду +проекта: пробелы заменяются на
+// подчеркивание. В отличие от кодирования при записи, такое имя не
+// восстанавливается при чтении.
+func Token(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+var (
+	tokenEncoder = strings.NewReplacer("%", "%25", " ", "%20", "\t", "%09", "\n", "%0A", "\r", "%0D")
+	tokenDecoder = strings.NewReplacer("%25", "%", "%20", " ", "%09", "\t", "%0A", "\n", "%0D", "\r")
+)
+
+// escapeWord ставит обратную косую черту перед словом текста, которое при
+// чтении стало бы +проектом, @контекстом или расширением, а в начале
+// текста — еще и отметкой выполнения, приоритетом или датой. Слово, которое
+// само начинается с обратной косой черты, тоже экранируется.
+func escapeWord(word string, first bool) string {
+	markup := word[0] == '\\' ||
+		len(word) > 1 && (word[0] == '+' || word[0] == '@')
+	if _, _, ok := splitExtension(word); ok {
+		markup = true
+	}
+	if first && (word == "x" || isPriority(word) || isDate(word)) {
+		markup = true
+	}
+
+	if markup {
+		return "\\" + word
+	}
+	return word
+}
+
+func isDate(word string) bool {
+	_, err := time.Parse(DateLayout, word)
+	return err == nil
+}
+
+// Reader читает файл todo.txt построчно, пропуская пустые строки
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	return &Reader{scanner: scanner}
+}
+
+// Read возвращает следующую задачу и номер её строки в файле (с 1). В
+// конце файла возвращается io.EOF.
+func (r *Reader) Read() (*Task, int, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimPrefix(r.scanner.Text(), "\ufeff")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		task, err := Parse(line)
+		return task, r.line, err
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, r.line, err
+	}
+	return nil, r.line, io.EOF
+}
+
+// isPriority проверяет слово вида (A)
+func isPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[2] == ')' && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+// splitExtension выделяет key:value; ссылки вида https://... расширением
+// не считаются
+func splitExtension(word string) (string, string, bool) {
+	key, value, ok := strings.Cut(word, ":")
+	if !ok || key == "" || value == "" || strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+		return "", "", false
+	}
+	return key, value, true
+}
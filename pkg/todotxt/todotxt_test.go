@@ -0,0 +1,113 @@
+package todotxt_test
+
+import (
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/todotxt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse(todotxt.DateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want todotxt.Task
+	}{
+		{
+			line: "(A) 2026-01-02 Call mom +Family @phone due:2026-01-05",
+			want: todotxt.Task{
+				Priority:   "A",
+				CreatedAt:  date("2026-01-02"),
+				Text:       "Call mom",
+				Projects:   []string{"Family"},
+				Contexts:   []string{"phone"},
+				Extensions: []todotxt.Extension{{Key: "due", Value: "2026-01-05"}},
+			},
+		},
+		{
+			line: "x 2026-01-03 2026-01-01 Pay rent pri:B",
+			want: todotxt.Task{
+				Done:        true,
+				Priority:    "B",
+				CompletedAt: date("2026-01-03"),
+				CreatedAt:   date("2026-01-01"),
+				Text:        "Pay rent",
+			},
+		},
+		{
+			// Ссылки и время без ключа не считаются расширениями
+			line: "Read https://example.com at 10:30:00",
+			want: todotxt.Task{Text: "Read https://example.com at 10:30:00"},
+		},
+		{
+			line: `Escaped \+word \@word \10:30 \\back @home%20office`,
+			want: todotxt.Task{Text: `Escaped +word @word 10:30 \back`, Contexts: []string{"home office"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := todotxt.Parse(tt.line)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.line, err)
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.line, *got, tt.want)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tasks := []todotxt.Task{
+		{Text: "Plan +launch with @team at 10:30 key:value"},
+		{Text: "x marks the spot"},
+		{Text: "(B) is not a priority"},
+		{Text: "2026-01-01 is not a date"},
+		{Text: `\ and \+escaped words`},
+		{Text: "100% done", Contexts: []string{"home office", "50%off", "100%25"}},
+		{Done: true, CompletedAt: date("2026-02-01"), CreatedAt: date("2026-01-01"), Priority: "C", Text: "Archive pri:A"},
+		{CreatedAt: date("2026-01-01"), Text: "2026-01-02 second date", Projects: []string{"My Project"}},
+		{Text: "Ship", Extensions: []todotxt.Extension{{Key: "owner", Value: "Jane Doe"}}},
+	}
+
+	for _, task := range tasks {
+		line := task.String()
+		got, err := todotxt.Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", line, err)
+		}
+		if !reflect.DeepEqual(*got, task) {
+			t.Errorf("round trip through %q = %+v, want %+v", line, *got, task)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	reader := todotxt.NewReader(strings.NewReader("\ufeffFirst\n\n  \nSecond +p\n"))
+
+	var lines []int
+	var texts []string
+	for {
+		task, line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		lines = append(lines, line)
+		texts = append(texts, task.Text)
+	}
+
+	if !reflect.DeepEqual(lines, []int{1, 4}) || !reflect.DeepEqual(texts, []string{"First", "Second"}) {
+		t.Fatalf("read lines %v with texts %q", lines, texts)
+	}
+}
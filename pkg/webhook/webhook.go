@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Заголовки исходящего запроса
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// maxResponseSnippet сколько байт ответа получателя сохраняется в журнале
+const maxResponseSnippet = 512
+
+// Sign вычисляет подпись тела: HMAC-SHA256 от "<timestamp>.<body>".
+// Метка времени входит в подпись, чтобы перехваченный запрос нельзя было
+// повторить позже.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись входящего запроса на стороне получателя;
+// tolerance ограничивает возраст метки времени
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return errors.New("timestamp outside tolerance")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+// Request исходящий запрос вебхука
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Response результат попытки доставки
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+// ErrForbiddenAddress возвращается, если адрес получателя находится во
+// внутренней сети
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// forbiddenPrefixes адреса, не покрытые проверками netip.Addr, но тоже не
+// ведущие в интернет
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 может вести во внутреннюю сеть IPv4
+}
+
+// Client отправляет подписанные запросы вебхуков
+type Client struct {
+	http *http.Client
+}
+
+// NewClient создает клиент. Без allowPrivate соединения с адресами
+// внутренней сети, loopback и link-local запрещены: адрес проверяется при
+// каждом соединении, уже после разрешения имени, поэтому его не обойти
+// ни DNS-записью, ни перенаправлением.
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	if !allowPrivate {
+		// Через прокси проверялся бы адрес прокси, а не получателя
+		transport.Proxy = nil
+	}
+
+	return &Client{http: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// refusePrivate запрещает соединение с адресом, не доступным из интернета
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+		}
+	}
+
+	return nil
+}
+
+// Send отправляет запрос; ответ со статусом вне 2xx возвращается вместе с ошибкой
+func (c *Client) Send(ctx context.Context, r Request) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-manager-webhooks/1.0")
+	req.Header.Set(HeaderEvent, r.Event)
+	req.Header.Set(HeaderDelivery, r.DeliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(r.Secret, timestamp, r.Body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSnippet))
+	io.Copy(io.Discard, resp.Body)
+
+	result := &Response{StatusCode: resp.StatusCode, Body: strings.ToValidUTF8(string(snippet), "")}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaveljevRoman/go-layout-project-2/pkg/webhook"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to private address reached the server: %s %s", r.Method, r.URL)
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	// Соединение отклоняется до отправки пакетов, поэтому адреса не обязаны существовать
+	client := webhook.NewClient(time.Second, false)
+	for _, url := range []string{
+		server.URL,
+		fmt.Sprintf("http://localhost:%d/", port),
+		"http://[::1]:8080/",
+		"http://[::ffff:127.0.0.1]:8080/",
+		"http://10.1.2.3/",
+		"http://192.168.0.10/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.64.0.1/",
+		"http://0.0.0.0/",
+	} {
+		_, err := client.Send(context.Background(), webhook.Request{URL: url, Secret: "secret", Body: []byte("{}")})
+		if !errors.Is(err, webhook.ErrForbiddenAddress) {
+			t.Errorf("Send to %s: error = %v, want ErrForbiddenAddress", url, err)
+		}
+	}
+}
+
+func TestClientSignsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify("secret", r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, time.Minute); err != nil {
+			t.Errorf("verify: %v", err)
+		}
+		if r.Header.Get(webhook.HeaderEvent) != "task.created" || r.Header.Get(webhook.HeaderDelivery) != "delivery-1" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := webhook.NewClient(time.Second, true)
+	resp, err := client.Send(context.Background(), webhook.Request{
+		URL:        server.URL,
+		Secret:     "secret",
+		Event:      "task.created",
+		DeliveryID: "delivery-1",
+		Body:       []byte(`{"id":"1"}`),
+	})
+	if err != nil || resp.StatusCode != http.StatusOK || resp.Body != "ok" {
+		t.Fatalf("Send = %+v, %v", resp, err)
+	}
+}